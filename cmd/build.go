@@ -5,11 +5,18 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/joelhelbling/glovebox/internal/digest"
+	"github.com/joelhelbling/glovebox/internal/dotfiles"
 	"github.com/joelhelbling/glovebox/internal/generator"
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/mod"
 	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/joelhelbling/glovebox/internal/runtime"
 	"github.com/spf13/cobra"
 )
 
@@ -17,6 +24,12 @@ var (
 	buildForce    bool
 	buildGenerate bool
 	buildBase     bool
+	buildProfile  string
+	buildName     string
+	buildVerbose  bool
+	buildExplain  bool
+	buildPlatform string
+	buildUpdate   bool
 )
 
 var buildCmd = &cobra.Command{
@@ -26,9 +39,29 @@ var buildCmd = &cobra.Command{
 
 For the global profile (~/.glovebox/profile.yaml), this builds glovebox:base.
 For project profiles (.glovebox/profile.yaml), this builds a project-specific
-image that extends glovebox:base.
+image that extends glovebox:base, or the named profile given by 'extends:'.
+Images for the profiles it extends are built first when they are missing or
+out of date.
 
-Use --base to explicitly build only the base image.
+Use --base to explicitly build only the base image (--base --name <name> for
+a named base, glovebox:base-<name>), or --profile <name> to build a named
+profile (~/.glovebox/profiles/<name>/profile.yaml).
+
+Builds show one line per mod step with how long it took; --verbose shows the
+full build output instead. When a build fails, the mod it failed in is named
+along with the end of its output.
+
+Base images are pinned to the digest their OS image's tag pointed to when
+first built, so rebuilds and other machines use the same image. --update
+moves the pin to the tag's current digest; 'glovebox outdated' shows when
+it has moved.
+
+--platform builds for other platforms than the runtime's own, such as
+linux/amd64 on an Apple silicon Mac; list several to build a multi-platform
+image. It overrides the profile's platform setting.
+
+--explain-cache builds nothing; it reports which steps of the image a pending
+profile change would rebuild, and which the build cache would provide.
 
 If the Dockerfile has been modified since last generation, you'll be prompted
 to choose how to proceed.`,
@@ -39,19 +72,42 @@ func init() {
 	buildCmd.Flags().BoolVarP(&buildForce, "force", "f", false, "Force regeneration without prompts")
 	buildCmd.Flags().BoolVar(&buildGenerate, "generate-only", false, "Only generate Dockerfile, don't build image")
 	buildCmd.Flags().BoolVar(&buildBase, "base", false, "Build only the base image (from global profile)")
+	buildCmd.Flags().StringVar(&buildProfile, "profile", "", "Build a named profile (e.g. team-web)")
+	buildCmd.Flags().StringVar(&buildName, "name", "", "With --base, build a named base (e.g. py)")
+	buildCmd.Flags().BoolVarP(&buildVerbose, "verbose", "v", false, "Show the full build output")
+	buildCmd.Flags().BoolVar(&buildUpdate, "update", false, "Pin the base image's OS image to its tag's current digest")
+	buildCmd.Flags().StringVar(&buildPlatform, "platform", "", "Platforms to build for, comma-separated (e.g. linux/amd64,linux/arm64)")
+	buildCmd.Flags().BoolVar(&buildExplain, "explain-cache", false, "Report which layers a pending change would rebuild, without building")
 	rootCmd.AddCommand(buildCmd)
 }
 
 func runBuild(cmd *cobra.Command, args []string) error {
+	rt := runtimeOf(cmd)
+
 	cwd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("getting current directory: %w", err)
 	}
 
+	if buildName != "" && !buildBase {
+		return fmt.Errorf("--name can only be used with --base")
+	}
+
 	// Determine what to build
 	if buildBase {
 		// Explicitly building base image
-		return buildBaseImage()
+		return buildBaseImage(rt, buildName)
+	}
+
+	if buildProfile != "" {
+		named, err := profile.LoadNamed(buildProfile)
+		if err != nil {
+			return fmt.Errorf("loading profile %q: %w", buildProfile, err)
+		}
+		if named == nil {
+			return fmt.Errorf("profile %q not found. Run 'glovebox init --profile %s' first", buildProfile, buildProfile)
+		}
+		return buildProjectImage(rt, named)
 	}
 
 	// Check for project profile first
@@ -62,7 +118,7 @@ func runBuild(cmd *cobra.Command, args []string) error {
 
 	if projectProfile != nil {
 		// Project profile exists - build project image (which requires base)
-		return buildProjectImage(projectProfile)
+		return buildProjectImage(rt, projectProfile)
 	}
 
 	// No project profile - check for global profile and build base
@@ -72,89 +128,308 @@ func runBuild(cmd *cobra.Command, args []string) error {
 	}
 
 	if globalProfile != nil {
-		return buildBaseImage()
+		return buildBaseImage(rt, "")
 	}
 
 	return fmt.Errorf("no profile found. Run 'glovebox init' or 'glovebox init --global' first")
 }
 
-func buildBaseImage() error {
-	globalProfile, err := profile.LoadGlobal()
+// buildBaseImage builds a base image: glovebox:base from the global profile
+// when name is empty, otherwise the named base glovebox:base-<name>.
+func buildBaseImage(rt runtime.Runtime, name string) error {
+	baseProfile, err := profile.LoadBase(name)
 	if err != nil {
-		return fmt.Errorf("loading global profile: %w", err)
+		return fmt.Errorf("loading base profile: %w", err)
 	}
-	if globalProfile == nil {
+	if baseProfile == nil {
+		if name != "" {
+			return fmt.Errorf("no base named %q found. Run 'glovebox init --base --name %s' first", name, name)
+		}
 		return fmt.Errorf("no global profile found. Run 'glovebox init --global' first")
 	}
 
-	dockerfilePath := globalProfile.DockerfilePath()
-	imageName := "glovebox:base"
+	dockerfilePath := baseProfile.DockerfilePath()
+	imageName := profile.BaseImageFor(name)
+
+	opts, dotfilesFiles, err := dotfilesInputs(baseProfile)
+	if err != nil {
+		return err
+	}
+	opts.Platforms, err = targetPlatforms([]*profile.Profile{baseProfile})
+	if err != nil {
+		return err
+	}
+	from, err := generator.BaseImage(baseProfile.Mods)
+	if err != nil {
+		return fmt.Errorf("generating Dockerfile: %w", err)
+	}
+	opts.From = from
+	opts.FromDigest = pinBaseImage(rt, baseProfile, from)
 
 	// Generate new Dockerfile content
-	newContent, err := generator.GenerateBase(globalProfile.Mods)
+	newContent, err := generateDockerfile(baseProfile, nil, opts)
 	if err != nil {
 		return fmt.Errorf("generating Dockerfile: %w", err)
 	}
+	files, err := generator.BaseContextFiles(baseProfile.Mods)
+	if err != nil {
+		return fmt.Errorf("collecting mod files: %w", err)
+	}
+	secrets, err := generator.BaseBuildSecrets(baseProfile.Mods)
+	if err != nil {
+		return fmt.Errorf("collecting build secrets: %w", err)
+	}
+	in := buildInputs{
+		files:     append(files, dotfilesFiles...),
+		secrets:   secrets,
+		chain:     []*profile.Profile{baseProfile},
+		platforms: opts.Platforms,
+	}
 
-	return buildImage(globalProfile, dockerfilePath, imageName, newContent)
+	if buildExplain {
+		return explainCache(rt, baseProfile, dockerfilePath, imageName, newContent, false, in.chain)
+	}
+	return buildImage(rt, baseProfile, dockerfilePath, imageName, newContent, in)
 }
 
-func buildProjectImage(p *profile.Profile) error {
-	// When building (not just generating), ensure base image exists
-	if !buildGenerate && !rt.ImageExists("glovebox:base") {
-		fmt.Println("Base image not found. Building glovebox:base first...")
-		if err := buildBaseImage(); err != nil {
-			return fmt.Errorf("building base image: %w", err)
+// buildProjectImage builds the image for a project or named profile. The
+// images of the profiles it extends are built first when missing or stale.
+func buildProjectImage(rt runtime.Runtime, p *profile.Profile) error {
+	ancestors, err := p.Ancestors()
+	if err != nil {
+		return err
+	}
+	chain := append(slices.Clone(ancestors), p)
+	platforms, err := targetPlatforms(chain)
+	if err != nil {
+		return err
+	}
+
+	// When building (not just generating), ensure parent images are current
+	building := !buildGenerate && !buildExplain
+	if building {
+		if err := ensureAncestorImages(rt, ancestors); err != nil {
+			return err
 		}
-		fmt.Println()
 	}
 
-	// Check if base image has changed since last project build
-	var baseDigest string
-	if rt.ImageExists("glovebox:base") {
-		var err error
-		baseDigest, err = rt.GetImageDigest("glovebox:base")
+	// Check if the parent image has changed since last build
+	parentImage := p.ParentImageName()
+	var parentDigest string
+	parentExists, err := rt.ImageExists(parentImage)
+	if err != nil && building {
+		return fmt.Errorf("checking parent image: %w", err)
+	}
+	if parentExists {
+		parentDigest, err = rt.GetImageDigest(parentImage)
 		if err != nil {
-			return fmt.Errorf("getting base image digest: %w", err)
+			return fmt.Errorf("getting parent image digest: %w", err)
 		}
 
-		if p.Build.BaseDigest != "" && p.Build.BaseDigest != baseDigest {
-			colorYellow.Println("⚠ Base image has changed since last build")
-			fmt.Println("Project image will be rebuilt with new base.")
+		if p.Build.BaseDigest != "" && p.Build.BaseDigest != parentDigest && !buildExplain {
+			colorYellow.Printf("⚠ %s has changed since last build\n", parentImage)
+			fmt.Println("Image will be rebuilt with the new parent.")
 			fmt.Println()
 		}
-	} else if !buildGenerate {
-		return fmt.Errorf("base image glovebox:base not found. Run 'glovebox build --base' first")
+
+		if building {
+			mismatch, err := platformMismatch(rt, parentImage, platforms)
+			if err != nil {
+				return err
+			}
+			if mismatch != "" {
+				return fmt.Errorf("%s: set the same platform in the profiles it extends, or build with --platform %s", mismatch, strings.Join(platforms, ","))
+			}
+		}
+	} else if building {
+		if p.Extends != "" {
+			return fmt.Errorf("parent image %s not found. Run 'glovebox build --profile %s' first", parentImage, p.Extends)
+		}
+		if p.Base != "" {
+			return fmt.Errorf("parent image %s not found. Run 'glovebox build --base --name %s' first", parentImage, p.Base)
+		}
+		return fmt.Errorf("parent image %s not found. Run 'glovebox build --base' first", parentImage)
 	}
 
 	dockerfilePath := p.DockerfilePath()
 	imageName := p.ImageName()
 
-	// Load global profile to get base mods for filtering
-	globalProfile, err := profile.LoadGlobal()
+	opts, dotfilesFiles, err := dotfilesInputs(p)
 	if err != nil {
-		return fmt.Errorf("loading global profile: %w", err)
-	}
-	var baseMods []string
-	if globalProfile != nil {
-		baseMods = globalProfile.Mods
+		return err
 	}
+	opts.Platforms = platforms
 
-	// Generate new Dockerfile content, excluding mods already in base
-	newContent, err := generator.GenerateProject(p.Mods, baseMods)
+	// Generate new Dockerfile content, excluding mods already in the parent images
+	newContent, err := generateDockerfile(p, ancestors, opts)
 	if err != nil {
 		return fmt.Errorf("generating Dockerfile: %w", err)
 	}
+	files, err := generator.ProjectContextFiles(p.Mods, profile.ChainMods(ancestors))
+	if err != nil {
+		return fmt.Errorf("collecting mod files: %w", err)
+	}
+	secrets, err := generator.ProjectBuildSecrets(p.Mods, profile.ChainMods(ancestors))
+	if err != nil {
+		return fmt.Errorf("collecting build secrets: %w", err)
+	}
+	in := buildInputs{
+		files:     append(files, dotfilesFiles...),
+		secrets:   secrets,
+		chain:     chain,
+		platforms: opts.Platforms,
+	}
+
+	if buildExplain {
+		parentChanged := parentDigest != "" && p.Build.BaseDigest != "" && p.Build.BaseDigest != parentDigest
+		return explainCache(rt, p, dockerfilePath, imageName, newContent, parentChanged, in.chain)
+	}
 
-	// Store base digest for future comparison (if available)
-	if baseDigest != "" {
-		p.Build.BaseDigest = baseDigest
+	// Store parent digest for future comparison (if available)
+	if parentDigest != "" {
+		p.Build.BaseDigest = parentDigest
 	}
 
-	return buildImage(p, dockerfilePath, imageName, newContent)
+	return buildImage(rt, p, dockerfilePath, imageName, newContent, in)
 }
 
-func buildImage(p *profile.Profile, dockerfilePath, imageName, newContent string) error {
+// ensureAncestorImages builds the images of an extends chain, root first,
+// so each layer is built on a current parent. The base image is only built
+// when missing; named profile images are also rebuilt when stale, which
+// cascades a base rebuild down the chain.
+func ensureAncestorImages(rt runtime.Runtime, ancestors []*profile.Profile) error {
+	for i, a := range ancestors {
+		imageName := a.ImageName()
+		if a.IsBase() {
+			exists, err := rt.ImageExists(imageName)
+			if err != nil {
+				return fmt.Errorf("checking base image: %w", err)
+			}
+			reason := "Base image not found"
+			if exists {
+				platforms, err := targetPlatforms(ancestors[:i+1])
+				if err != nil {
+					return err
+				}
+				if reason, err = platformMismatch(rt, imageName, platforms); err != nil {
+					return err
+				}
+				if reason == "" {
+					continue
+				}
+			}
+			fmt.Printf("%s. Building %s first...\n", reason, imageName)
+			if err := buildBaseImage(rt, a.BaseName); err != nil {
+				return fmt.Errorf("building base image: %w", err)
+			}
+			fmt.Println()
+			continue
+		}
+
+		reason, err := imageStaleness(rt, a, ancestors[:i])
+		if err != nil {
+			return err
+		}
+		if reason == "" {
+			continue
+		}
+		fmt.Printf("%s. Building %s first...\n", reason, imageName)
+		if err := buildProjectImage(rt, a); err != nil {
+			return fmt.Errorf("building %s: %w", imageName, err)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// imageStaleness explains why a profile's image needs rebuilding, or returns
+// "" when it is current: the image is missing, the profile changed since the
+// image was built, or the parent image was rebuilt since.
+func imageStaleness(rt runtime.Runtime, p *profile.Profile, ancestors []*profile.Profile) (string, error) {
+	imageName := p.ImageName()
+	exists, err := rt.ImageExists(imageName)
+	if err != nil {
+		return "", fmt.Errorf("checking image %s: %w", imageName, err)
+	}
+	if !exists {
+		return fmt.Sprintf("Image %s not found", imageName), nil
+	}
+
+	expected, err := generateDockerfile(p, ancestors, recordedOptions(p))
+	if err == nil && digest.Calculate(expected) != p.Build.DockerfileDigest {
+		return fmt.Sprintf("Profile for %s has changed since it was built", imageName), nil
+	}
+
+	platforms, err := targetPlatforms(append(slices.Clone(ancestors), p))
+	if err != nil {
+		return "", err
+	}
+	if mismatch, err := platformMismatch(rt, imageName, platforms); err != nil || mismatch != "" {
+		return mismatch, err
+	}
+
+	if !p.IsBase() && p.Build.BaseDigest != "" {
+		parentImage := p.ParentImageName()
+		if parentDigest, err := rt.GetImageDigest(parentImage); err == nil && parentDigest != p.Build.BaseDigest {
+			return fmt.Sprintf("%s has changed since %s was built", parentImage, imageName), nil
+		}
+	}
+	return "", nil
+}
+
+// generateDockerfile renders the Dockerfile for a profile on top of the
+// chain of profiles it extends.
+func generateDockerfile(p *profile.Profile, ancestors []*profile.Profile, opts generator.Options) (string, error) {
+	opts.Strategy = p.BuildStrategy
+	if p.IsBase() {
+		return generator.GenerateBaseWithOptions(p.Mods, opts)
+	}
+	opts.ParentImage = p.ParentImageName()
+	opts.Layer = len(ancestors)
+	return generator.GenerateProjectWithOptions(p.Mods, profile.ChainMods(ancestors), opts)
+}
+
+// dotfilesInputs resolves a profile's dotfiles for a build: the generator
+// options that install them and the directory to stage in the build context.
+// Git sources are fetched so the image picks up the latest commit.
+func dotfilesInputs(p *profile.Profile) (generator.Options, []generator.ContextFile, error) {
+	var opts generator.Options
+	if p.Dotfiles == nil {
+		return opts, nil, nil
+	}
+	if err := p.Dotfiles.Validate(); err != nil {
+		return opts, nil, err
+	}
+
+	opts.Dotfiles = p.Dotfiles
+	if p.Dotfiles.EffectiveApply() != dotfiles.ApplyBuild {
+		// Installed at container creation; nothing to bake into the image
+		return opts, nil, nil
+	}
+
+	dir, err := dotfiles.Resolve(p.Dotfiles)
+	if err != nil {
+		return opts, nil, err
+	}
+	revision, err := dotfiles.LocalRevision(p.Dotfiles, dir)
+	if err != nil {
+		return opts, nil, err
+	}
+	opts.DotfilesRevision = revision
+	p.Build.DotfilesRevision = revision
+
+	return opts, generator.DotfilesContextFile(p.Dotfiles, dir), nil
+}
+
+// buildInputs is what an image build needs besides its Dockerfile
+type buildInputs struct {
+	files     []generator.ContextFile // host files staged into the build context
+	secrets   []mod.BuildSecret       // build secrets the image's mods mount
+	chain     []*profile.Profile      // profiles supplying build args and secret sources
+	platforms []string                // target platforms; none for the runtime's default
+}
+
+func buildImage(rt runtime.Runtime, p *profile.Profile, dockerfilePath, imageName, newContent string, in buildInputs) error {
 	newDigest := digest.Calculate(newContent)
 
 	// Check if Dockerfile exists and has been modified
@@ -176,7 +451,7 @@ func buildImage(p *profile.Profile, dockerfilePath, imageName, newContent string
 			}
 			colorGreen.Printf("✓ Dockerfile is already up to date (%s)\n", dockerfilePath)
 			if !buildGenerate {
-				return runImageBuild(dockerfilePath, imageName)
+				return runImageBuild(rt, dockerfilePath, imageName, in, imageLabels(p))
 			}
 			return nil
 		}
@@ -223,7 +498,7 @@ func buildImage(p *profile.Profile, dockerfilePath, imageName, newContent string
 				}
 				colorGreen.Println("✓ Keeping current Dockerfile and updating digest")
 				if !buildGenerate {
-					return runImageBuild(dockerfilePath, imageName)
+					return runImageBuild(rt, dockerfilePath, imageName, in, imageLabels(p))
 				}
 				return nil
 			case "regenerate":
@@ -259,7 +534,7 @@ func buildImage(p *profile.Profile, dockerfilePath, imageName, newContent string
 		return nil
 	}
 
-	return runImageBuild(dockerfilePath, imageName)
+	return runImageBuild(rt, dockerfilePath, imageName, in, imageLabels(p))
 }
 
 func promptBuildAction() (string, error) {
@@ -321,7 +596,48 @@ func showDiff(existing, new string) error {
 	return nil
 }
 
-func runImageBuild(dockerfilePath, imageName string) error {
+// imageLabels describes the image built from a profile. Its creation time is
+// when the Dockerfile was generated, so rebuilding an unchanged Dockerfile
+// still reuses the cached image.
+// The profile's content hash and Dockerfile digest let an image pulled from
+// a registry be matched to the profile of whoever pulls it.
+func imageLabels(p *profile.Profile) map[string]string {
+	role := labels.RoleProject
+	switch {
+	case p.IsBase():
+		role = labels.RoleBase
+	case p.Name != "":
+		role = labels.RoleProfile
+	}
+
+	l := labels.New(Version, role, p.Build.LastBuiltAt)
+	l[labels.Profile] = p.Path
+	if role == labels.RoleProject {
+		l[labels.Project] = filepath.Dir(filepath.Dir(p.Path))
+	}
+	if !p.IsBase() && p.Build.BaseDigest != "" {
+		l[labels.Parent] = p.ParentImageName()
+		l[labels.ParentID] = p.Build.BaseDigest
+	}
+	l[labels.ContentHash] = p.ComputeContentHash()
+	if p.Build.DockerfileDigest != "" {
+		l[labels.DockerfileDigest] = p.Build.DockerfileDigest
+	}
+	if p.Build.From != "" {
+		l[labels.From] = p.Build.From
+		if p.Build.FromDigest != "" {
+			l[labels.From] += "@" + p.Build.FromDigest
+		}
+	}
+	return l
+}
+
+func runImageBuild(rt runtime.Runtime, dockerfilePath, imageName string, in buildInputs, buildLabels map[string]string) error {
+	secrets, err := resolveBuildSecrets(in)
+	if err != nil {
+		return err
+	}
+
 	fmt.Printf("\nBuilding image %s...\n", imageName)
 
 	dockerfileDir := dockerfilePath[:len(dockerfilePath)-len("Dockerfile")]
@@ -329,10 +645,79 @@ func runImageBuild(dockerfilePath, imageName string) error {
 		dockerfileDir = "."
 	}
 
-	if err := rt.BuildImage(dockerfilePath, dockerfileDir, imageName); err != nil {
-		return fmt.Errorf("image build failed: %w", err)
+	// Stage files referenced by mods next to the Dockerfile so COPY can find them
+	if err := generator.StageContextFiles(filepath.Clean(dockerfileDir), in.files); err != nil {
+		return fmt.Errorf("staging build context: %w", err)
+	}
+
+	cfg := runtime.BuildConfig{
+		DockerfilePath: dockerfilePath,
+		ContextDir:     dockerfileDir,
+		ImageName:      imageName,
+		Labels:         buildLabels,
+		BuildArgs:      profile.ChainBuildArgs(in.chain),
+		Secrets:        secrets,
+		Platforms:      in.platforms,
+	}
+	if len(in.platforms) > 0 {
+		fmt.Printf("Platforms: %s\n", strings.Join(in.platforms, ", "))
+	}
+	if buildVerbose {
+		if err := rt.BuildImage(cfg); err != nil {
+			return fmt.Errorf("image build failed: %w", err)
+		}
+		colorGreen.Printf("\n✓ Image %s built successfully\n", imageName)
+		return nil
+	}
+
+	dockerfile, err := os.ReadFile(dockerfilePath)
+	if err != nil {
+		return fmt.Errorf("reading Dockerfile: %w", err)
 	}
+	progress := newBuildProgress(string(dockerfile), modIDs(in.chain))
+	cfg.Progress = progress
+	if err := rt.BuildImage(cfg); err != nil {
+		return fmt.Errorf("image build failed: %w", progress.explain(err))
+	}
+	progress.Flush()
 
-	colorGreen.Printf("\n✓ Image %s built successfully\n", imageName)
+	colorGreen.Printf("\n✓ Image %s built successfully in %s\n", imageName, formatStepDuration(time.Since(progress.start)))
+	progress.printSlowest()
 	return nil
 }
+
+// resolveBuildSecrets finds the host source of each build secret: the
+// profile chain's build_secrets, or else the environment variable the mod
+// exports it as. Optional secrets without a source are left out.
+func resolveBuildSecrets(in buildInputs) ([]runtime.BuildSecret, error) {
+	sources, err := profile.ChainBuildSecrets(in.chain)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []runtime.BuildSecret
+	for _, s := range in.secrets {
+		if src, ok := sources[s.ID]; ok {
+			secret := runtime.BuildSecret{ID: s.ID, Env: src.Env}
+			if src.File != "" {
+				if secret.File, err = profile.ResolveMountSource(src.File, ""); err != nil {
+					return nil, err
+				}
+			}
+			result = append(result, secret)
+			continue
+		}
+		if _, ok := os.LookupEnv(s.Env); s.Env != "" && ok {
+			result = append(result, runtime.BuildSecret{ID: s.ID, Env: s.Env})
+			continue
+		}
+		if s.Required {
+			hint := fmt.Sprintf("add %s to build_secrets in your profile", s.ID)
+			if s.Env != "" {
+				hint = fmt.Sprintf("set %s or %s", s.Env, hint)
+			}
+			return nil, fmt.Errorf("build secret %s is required: %s", s.ID, hint)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,311 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/joelhelbling/glovebox/internal/docker"
+	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+	"github.com/joelhelbling/glovebox/internal/ui"
+)
+
+// writeGlobalMod writes a mod under ~/.glovebox/mods
+func writeGlobalMod(t *testing.T, env *testEnv, id, content string) {
+	t.Helper()
+	path := filepath.Join(env.home, ".glovebox", "mods", id+".yaml")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const privateMod = `name: private
+description: installs from a private registry
+category: custom
+build_args:
+  TOOL_VERSION: "1.0"
+build_secrets:
+  - id: npm_token
+    env: NPM_TOKEN
+    required: true
+  - id: pip_conf
+run_as_root: echo installing
+`
+
+func TestBuildSecrets(t *testing.T) {
+	t.Run("passes build args and secret sources to the build", func(t *testing.T) {
+		env := newTestEnv(t)
+		writeGlobalMod(t, env, "custom/private", privateMod)
+		p := env.saveGlobal("os/ubuntu", "custom/private")
+		p.BuildArgs = map[string]string{"TOOL_VERSION": "2.0"}
+		p.BuildSecrets = map[string]profile.Secret{"pip_conf": {File: "~/.config/pip/pip.conf"}}
+		if err := p.SaveTo(p.Path); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("NPM_TOKEN", "s3cret")
+
+		env.mustRun("", "build", "--base")
+
+		if len(env.rt.Builds) != 1 {
+			t.Fatalf("builds = %d, want 1", len(env.rt.Builds))
+		}
+		build := env.rt.Builds[0]
+		if build.BuildArgs["TOOL_VERSION"] != "2.0" {
+			t.Errorf("build args = %v", build.BuildArgs)
+		}
+		want := []runtime.BuildSecret{
+			{ID: "npm_token", Env: "NPM_TOKEN"},
+			{ID: "pip_conf", File: filepath.Join(env.home, ".config", "pip", "pip.conf")},
+		}
+		if !slices.Equal(build.Secrets, want) {
+			t.Errorf("secrets = %+v, want %+v", build.Secrets, want)
+		}
+	})
+
+	t.Run("leaves out optional secrets without a source", func(t *testing.T) {
+		env := newTestEnv(t)
+		writeGlobalMod(t, env, "custom/private", privateMod)
+		env.saveGlobal("os/ubuntu", "custom/private")
+		t.Setenv("NPM_TOKEN", "s3cret")
+
+		env.mustRun("", "build", "--base")
+
+		if secrets := env.rt.Builds[0].Secrets; len(secrets) != 1 || secrets[0].ID != "npm_token" {
+			t.Errorf("secrets = %+v, want only npm_token", secrets)
+		}
+	})
+
+	t.Run("fails before building without a required secret", func(t *testing.T) {
+		env := newTestEnv(t)
+		writeGlobalMod(t, env, "custom/private", privateMod)
+		env.saveGlobal("os/ubuntu", "custom/private")
+		t.Setenv("NPM_TOKEN", "") // restored after the test
+		os.Unsetenv("NPM_TOKEN")
+
+		_, err := env.run("", "build", "--base")
+		if err == nil || !strings.Contains(err.Error(), "build secret npm_token is required: set NPM_TOKEN") {
+			t.Errorf("error = %v", err)
+		}
+		if len(env.rt.Builds) != 0 {
+			t.Error("nothing should be built")
+		}
+	})
+}
+
+func TestBuildProgress(t *testing.T) {
+	const output = `#4 [1/8] FROM docker.io/library/ubuntu:24.04
+#4 CACHED
+#5 [2/8] RUN <<'EOF' (set -e...)
+#5 0.251 Get:1 http://archive.ubuntu.com/ubuntu noble InRelease [256 kB]
+#5 DONE 12.5s
+#10 [7/8] RUN <<'EOF' (set -e...)
+#10 1.204 ==> Downloading and installing Homebrew...
+#10 9.870 curl: (6) Could not resolve host: github.com
+#10 ERROR: process "/bin/sh -c set -e..." did not complete successfully: exit code: 1
+`
+
+	t.Run("shows durations per mod step", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu", "tools/homebrew-ubuntu")
+		env.rt.BuildOutput = "#5 [2/8] RUN <<'EOF' (set -e...)\n#5 DONE 12.5s\n"
+
+		out := env.mustRun("", "build", "--base")
+
+		if env.rt.Builds[0].Progress == nil {
+			t.Error("build should report progress")
+		}
+		for _, want := range []string{"✓ os/ubuntu (run_as_root)", "12.5s", "Slowest mods: os/ubuntu 12.5s"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("names the mod a build failed in", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu", "tools/homebrew-ubuntu")
+		env.rt.BuildOutput = output
+		env.rt.FailOn("BuildImage", errors.New("exit status 1"))
+
+		out, err := env.run("", "build", "--base")
+		if err == nil || !strings.Contains(err.Error(), "mod tools/homebrew-ubuntu failed in run_as_user") {
+			t.Errorf("error = %v", err)
+		}
+		for _, want := range []string{"✗ mod tools/homebrew-ubuntu failed in run_as_user:", "  curl: (6) Could not resolve host: github.com", "glovebox build --verbose"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+		if strings.Contains(out, "archive.ubuntu.com") {
+			t.Error("output of other steps should not be shown")
+		}
+	})
+
+	t.Run("verbose shows the build output as is", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+
+		env.mustRun("", "build", "--base", "--verbose")
+
+		if env.rt.Builds[0].Progress != nil {
+			t.Error("--verbose should leave the output to the runtime")
+		}
+	})
+}
+
+func TestExplainCache(t *testing.T) {
+	greet := func(greeting string) string {
+		return "name: greet\ndescription: greets\ncategory: custom\nrun_as_root: echo " + greeting + "\n"
+	}
+
+	t.Run("every step runs without an image", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+
+		out := env.mustRun("", "build", "--base", "--explain-cache")
+
+		if !strings.Contains(out, "Image glovebox:base not found, so every step will run.") {
+			t.Errorf("output = %q", out)
+		}
+		if len(env.rt.Builds) != 0 {
+			t.Error("--explain-cache should not build")
+		}
+	})
+
+	t.Run("names the changed mod and the steps it rebuilds", func(t *testing.T) {
+		env := newTestEnv(t)
+		writeGlobalMod(t, env, "custom/greet", greet("hello"))
+		env.saveGlobal("os/ubuntu", "tools/homebrew-ubuntu", "custom/greet")
+		env.mustRun("", "build", "--base")
+
+		out := env.mustRun("", "build", "--base", "--explain-cache")
+		if !strings.Contains(out, "All 9 steps are cached") {
+			t.Errorf("unchanged profile should be cached:\n%s", out)
+		}
+
+		writeGlobalMod(t, env, "custom/greet", greet("hi"))
+		out = env.mustRun("", "build", "--base", "--explain-cache")
+		for _, want := range []string{
+			"Mod custom/greet changed its run_as_root at step 3.",
+			"7 of 9 steps will be rebuilt:",
+			"✗ custom/greet (run_as_root)",
+			"✗ tools/homebrew-ubuntu (run_as_user)",
+			"build_strategy: stable",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+		if len(env.rt.Builds) != 1 {
+			t.Errorf("builds = %d, want 1", len(env.rt.Builds))
+		}
+	})
+
+	t.Run("stable strategy rebuilds only from the changed mod", func(t *testing.T) {
+		env := newTestEnv(t)
+		writeGlobalMod(t, env, "custom/greet", greet("hello"))
+		p := env.saveGlobal("os/ubuntu", "custom/greet", "tools/homebrew-ubuntu")
+		p.BuildStrategy = "stable"
+		if err := p.Save(); err != nil {
+			t.Fatal(err)
+		}
+		env.mustRun("", "build", "--base")
+
+		writeGlobalMod(t, env, "custom/greet", greet("hi"))
+		out := env.mustRun("", "build", "--base", "--explain-cache")
+		if !strings.Contains(out, "✗ custom/greet (run_as_root)") || strings.Contains(out, "✗ tools/homebrew-ubuntu") {
+			t.Errorf("only custom/greet and later steps should rebuild:\n%s", out)
+		}
+		if strings.Contains(out, "build_strategy: stable") {
+			t.Error("the stable strategy should not be suggested when in use")
+		}
+	})
+}
+
+func TestBuildPlatform(t *testing.T) {
+	const x86Mod = `name: x86-tool
+description: ships amd64 binaries only
+category: custom
+platforms: [linux/amd64]
+run_as_root: echo installing
+`
+
+	t.Run("builds for the given platforms", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+
+		env.mustRun("", "build", "--base", "--platform", "linux/amd64,linux/arm64")
+
+		if got := env.rt.Builds[0].Platforms; !slices.Equal(got, []string{"linux/amd64", "linux/arm64"}) {
+			t.Errorf("platforms = %v", got)
+		}
+	})
+
+	t.Run("builds and runs for the profile's platform", func(t *testing.T) {
+		env := newTestEnv(t)
+		p := env.saveGlobal("os/ubuntu")
+		p.Platform = "linux/amd64"
+		if err := p.Save(); err != nil {
+			t.Fatal(err)
+		}
+
+		env.mustRun("", "run")
+
+		if got := env.rt.Builds[0].Platforms; !slices.Equal(got, []string{"linux/amd64"}) {
+			t.Errorf("platforms = %v", got)
+		}
+		if c := env.rt.Container(docker.ContainerName(env.project)); c == nil || c.Run.Platform != "linux/amd64" {
+			t.Errorf("container = %+v, want it run as linux/amd64", c)
+		}
+		out := env.mustRun("", "status", "-o", "json")
+		if item := statusItem(t, out, "Base Image", "Platform"); item == nil || item.Value != "linux/amd64" || item.Status == ui.StatusWarning {
+			t.Errorf("platform status = %+v", item)
+		}
+	})
+
+	t.Run("refuses mods that don't support the platform", func(t *testing.T) {
+		env := newTestEnv(t)
+		writeGlobalMod(t, env, "custom/x86-tool", x86Mod)
+		env.saveGlobal("os/ubuntu", "custom/x86-tool")
+
+		_, err := env.run("", "build", "--base", "--platform", "linux/arm64")
+		if err == nil || !strings.Contains(err.Error(), `mod "x86-tool" doesn't support linux/arm64`) {
+			t.Errorf("error = %v", err)
+		}
+		if len(env.rt.Builds) != 0 {
+			t.Error("nothing should be built")
+		}
+	})
+
+	t.Run("refuses a parent built for another platform", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.mustRun("", "build", "--base", "--platform", "linux/arm64")
+		p := env.saveProject("tools/mise")
+		p.Platform = "linux/amd64"
+		if err := p.Save(); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := env.run("", "build")
+		if err == nil || !strings.Contains(err.Error(), "glovebox:base is built for linux/arm64, not linux/amd64") {
+			t.Errorf("error = %v", err)
+		}
+
+		// --platform applies to the whole chain, rebuilding the base for it
+		out := env.mustRun("", "build", "--platform", "linux/amd64")
+		if !strings.Contains(out, "glovebox:base is built for linux/arm64, not linux/amd64. Building glovebox:base first") {
+			t.Errorf("output = %q", out)
+		}
+		if len(env.rt.Builds) != 3 || !slices.Equal(env.rt.Builds[1].Platforms, []string{"linux/amd64"}) {
+			t.Errorf("builds = %+v", env.rt.Builds)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/joelhelbling/glovebox/internal/buildlog"
+	"github.com/joelhelbling/glovebox/internal/mod"
+	"github.com/joelhelbling/glovebox/internal/profile"
+)
+
+// slowestShown is how many mods the build summary lists as the slowest
+const slowestShown = 3
+
+// buildProgress shows an image build as one line per finished mod step in
+// place of BuildKit's output, and explains a failure by the mod it
+// happened in
+type buildProgress struct {
+	*buildlog.Progress
+	ids   map[string]string // mod name → ID
+	start time.Time
+}
+
+func newBuildProgress(dockerfile string, ids map[string]string) *buildProgress {
+	bp := &buildProgress{Progress: buildlog.NewProgress(dockerfile), ids: ids, start: time.Now()}
+	bp.OnStep = bp.printStep
+	return bp
+}
+
+// modIDs maps the names of the mods in a profile chain to their IDs, which
+// is how people refer to them. Mods that fail to load keep their names.
+func modIDs(chain []*profile.Profile) map[string]string {
+	ids := make(map[string]string)
+	mods, err := mod.LoadMultiple(profile.ChainMods(chain))
+	if err != nil {
+		return ids
+	}
+	for _, m := range mods {
+		ids[m.Name] = m.ID
+	}
+	return ids
+}
+
+// modID returns how to refer to the mod a step belongs to
+func (bp *buildProgress) modID(name string) string {
+	if id, ok := bp.ids[name]; ok {
+		return id
+	}
+	return name
+}
+
+func (bp *buildProgress) printStep(r buildlog.Result) {
+	if r.Mod == "" {
+		return
+	}
+	label := fmt.Sprintf("%s (%s)", bp.modID(r.Mod), r.Section)
+	if r.Cached {
+		colorDim.Printf("  ✓ %-48s cached\n", label)
+		return
+	}
+	fmt.Printf("  ✓ %-48s %s\n", label, formatStepDuration(r.Duration))
+}
+
+// printSlowest lists the mods the build spent the most time on
+func (bp *buildProgress) printSlowest() {
+	var slowest []string
+	for _, t := range bp.Timings() {
+		if t.Mod == "" || t.Duration < time.Second {
+			continue
+		}
+		slowest = append(slowest, fmt.Sprintf("%s %s", bp.modID(t.Mod), formatStepDuration(t.Duration)))
+		if len(slowest) == slowestShown {
+			break
+		}
+	}
+	if len(slowest) > 0 {
+		colorDim.Printf("  Slowest mods: %s\n", strings.Join(slowest, ", "))
+	}
+}
+
+// explain prints where a failed build failed and the end of its output,
+// and returns err naming the failed mod
+func (bp *buildProgress) explain(err error) error {
+	bp.Flush()
+	f := bp.Failure()
+
+	fmt.Println()
+	tail := bp.Tail()
+	switch {
+	case f != nil && f.Mod != "":
+		colorRed.Printf("✗ mod %s failed in %s:\n", bp.modID(f.Mod), f.Section)
+		tail = f.Tail
+		err = fmt.Errorf("mod %s failed in %s: %w", bp.modID(f.Mod), f.Section, err)
+	case f != nil && f.Index > 0:
+		colorRed.Printf("✗ step %d failed: %s\n", f.Index, f.Instruction)
+		tail = f.Tail
+	default:
+		colorRed.Println("✗ build failed:")
+	}
+	for _, line := range tail {
+		fmt.Printf("  %s\n", line)
+	}
+	if f != nil && f.Error != "" {
+		colorDim.Printf("  %s\n", f.Error)
+	}
+	colorDim.Println("Run 'glovebox build --verbose' to see the full build output.")
+	return err
+}
+
+// formatStepDuration shows a duration to a tenth of a second, or whole
+// seconds past a minute
+func formatStepDuration(d time.Duration) string {
+	if d >= time.Minute {
+		return d.Round(time.Second).String()
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
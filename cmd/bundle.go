@@ -0,0 +1,378 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/joelhelbling/glovebox/internal/bundle"
+	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportImageBase    bool
+	exportImageName    string
+	exportImageProject bool
+	exportImageFile    string
+	importImageForce   bool
+)
+
+var exportImageCmd = &cobra.Command{
+	Use:   "export-image",
+	Short: "Save a built image and its profile to a bundle file",
+	Long: `Save a glovebox image, together with the profile and Dockerfile it was
+built from, to a single bundle file that 'glovebox import-image' loads on
+another machine. Importing needs no network access, so a fully built
+environment can be handed to someone offline or on an air-gapped machine.
+
+--base exports the base image (--base --name <name> for a named base) and
+--project the current project's image. Without either, the project's image
+is exported when there is a project profile, otherwise the base image.
+
+A project image holds everything it was built on, so it runs on its own.`,
+	Args: cobra.NoArgs,
+	RunE: runExportImage,
+}
+
+var importImageCmd = &cobra.Command{
+	Use:   "import-image <file> [directory]",
+	Short: "Load an image and its profile from a bundle file",
+	Long: `Load an image bundle written by 'glovebox export-image'.
+
+A base bundle becomes this machine's base image and profile (or named base).
+A project bundle becomes the image and profile of the project in the given
+directory, the current one by default, under the image name glovebox uses
+for it here.
+
+Existing profiles and images are only replaced with --force.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runImportImage,
+}
+
+func init() {
+	exportImageCmd.Flags().BoolVar(&exportImageBase, "base", false, "Export the base image")
+	exportImageCmd.Flags().StringVar(&exportImageName, "name", "", "With --base, export a named base (e.g. py)")
+	exportImageCmd.Flags().BoolVar(&exportImageProject, "project", false, "Export the current project's image")
+	exportImageCmd.Flags().StringVarP(&exportImageFile, "output", "o", "", "Bundle file to write (e.g. app.tar)")
+	_ = exportImageCmd.MarkFlagRequired("output")
+	exportImageCmd.MarkFlagsMutuallyExclusive("base", "project")
+	importImageCmd.Flags().BoolVarP(&importImageForce, "force", "f", false, "Replace an existing profile and image")
+	rootCmd.AddCommand(exportImageCmd)
+	rootCmd.AddCommand(importImageCmd)
+}
+
+func runExportImage(cmd *cobra.Command, args []string) error {
+	rt := runtimeOf(cmd)
+	if !rt.Capabilities().SupportsExport {
+		return fmt.Errorf("%s can't export images", rt.Name())
+	}
+	if exportImageName != "" && !exportImageBase {
+		return fmt.Errorf("--name can only be used with --base")
+	}
+
+	p, err := exportedProfile()
+	if err != nil {
+		return err
+	}
+	ancestors, err := p.Ancestors()
+	if err != nil {
+		return err
+	}
+
+	imageName := p.ImageName()
+	info, err := rt.InspectImage(imageName)
+	if errors.Is(err, runtime.ErrNotFound) {
+		return fmt.Errorf("image %s not found. Run 'glovebox build' first", imageName)
+	} else if err != nil {
+		return fmt.Errorf("inspecting image %s: %w", imageName, err)
+	}
+	if reason, err := imageStaleness(rt, p, ancestors); err == nil && reason != "" {
+		colorYellow.Printf("⚠ %s. Exporting the image as it is.\n", reason)
+	}
+
+	b := bundle.Bundle{Manifest: bundle.Manifest{
+		Version:    bundle.Version,
+		Kind:       bundle.KindProject,
+		Image:      imageName,
+		Mods:       profile.ChainMods(append(slices.Clone(ancestors), p)),
+		Platforms:  info.Platforms,
+		Glovebox:   Version,
+		ExportedAt: time.Now().UTC(),
+	}}
+	if p.IsBase() {
+		b.Manifest.Kind = bundle.KindBase
+		b.Manifest.BaseName = p.BaseName
+	} else {
+		b.Manifest.Parent = p.ParentImageName()
+		b.Manifest.ParentID = p.Build.BaseDigest
+	}
+	if b.Profile, err = os.ReadFile(p.Path); err != nil {
+		return fmt.Errorf("reading profile: %w", err)
+	}
+	b.Dockerfile, err = os.ReadFile(p.DockerfilePath())
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("reading Dockerfile: %w", err)
+	}
+
+	fmt.Printf("Saving %s...\n", imageName)
+	image, size, err := saveImage(rt, imageName)
+	if err != nil {
+		return err
+	}
+	defer image.Close()
+
+	out, err := os.Create(exportImageFile)
+	if err != nil {
+		return fmt.Errorf("creating bundle: %w", err)
+	}
+	err = bundle.Write(out, b, image, size)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(exportImageFile)
+		return fmt.Errorf("writing bundle: %w", err)
+	}
+
+	bundleSize := size
+	if st, err := os.Stat(exportImageFile); err == nil {
+		bundleSize = st.Size()
+	}
+	colorGreen.Printf("✓ Exported %s to %s (%s)\n", imageName, exportImageFile, formatSize(bundleSize))
+	fmt.Println("Load it on another machine with 'glovebox import-image " + filepath.Base(exportImageFile) + "'.")
+	return nil
+}
+
+// exportedProfile loads the profile whose image export-image saves
+func exportedProfile() (*profile.Profile, error) {
+	if !exportImageBase {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("getting current directory: %w", err)
+		}
+		p, err := profile.LoadProject(cwd)
+		if err != nil {
+			return nil, fmt.Errorf("loading project profile: %w", err)
+		}
+		if p != nil {
+			return p, nil
+		}
+		if exportImageProject {
+			return nil, fmt.Errorf("no project profile found. Run 'glovebox init' first")
+		}
+	}
+
+	p, err := profile.LoadBase(exportImageName)
+	if err != nil {
+		return nil, fmt.Errorf("loading base profile: %w", err)
+	}
+	if p == nil {
+		if exportImageName != "" {
+			return nil, fmt.Errorf("no base named %q found. Run 'glovebox init --base --name %s' first", exportImageName, exportImageName)
+		}
+		return nil, fmt.Errorf("no profile found. Run 'glovebox init' or 'glovebox init --global' first")
+	}
+	return p, nil
+}
+
+// saveImage saves an image to a temporary file, as a bundle needs the
+// size of the archive before it. The file is removed when closed.
+func saveImage(rt runtime.Runtime, imageName string) (io.ReadCloser, int64, error) {
+	archive, err := rt.SaveImage(imageName)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer archive.Close()
+
+	tmp, err := os.CreateTemp("", "glovebox-export-*.tar")
+	if err != nil {
+		return nil, 0, fmt.Errorf("saving image %s: %w", imageName, err)
+	}
+	size, err := io.Copy(tmp, archive)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, 0, fmt.Errorf("saving image %s: %w", imageName, err)
+	}
+	return &removeOnClose{tmp}, size, nil
+}
+
+// removeOnClose is a temporary file removed once closed
+type removeOnClose struct {
+	*os.File
+}
+
+func (r *removeOnClose) Close() error {
+	err := r.File.Close()
+	os.Remove(r.File.Name())
+	return err
+}
+
+func runImportImage(cmd *cobra.Command, args []string) error {
+	rt := runtimeOf(cmd)
+	if !rt.Capabilities().SupportsExport {
+		return fmt.Errorf("%s can't import images", rt.Name())
+	}
+
+	targetDir := "."
+	if len(args) > 1 {
+		targetDir = args[1]
+	}
+	absPath, err := filepath.Abs(targetDir)
+	if err != nil {
+		return fmt.Errorf("resolving path: %w", err)
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("opening bundle: %w", err)
+	}
+	defer f.Close()
+
+	return bundle.Read(f, func(b bundle.Bundle, image io.Reader) error {
+		return importBundle(rt, b, image, absPath)
+	})
+}
+
+// importBundle loads a bundle's image and writes its profile. A project's
+// image is registered under the name of the project in dir.
+func importBundle(rt runtime.Runtime, b bundle.Bundle, image io.Reader, dir string) error {
+	m := b.Manifest
+
+	var profilePath, target string
+	if m.Kind == bundle.KindBase {
+		path, err := profile.BasePath(m.BaseName)
+		if err != nil {
+			return err
+		}
+		profilePath, target = path, profile.BaseImageFor(m.BaseName)
+	} else {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("directory not found: %s", dir)
+		}
+		profilePath, target = profile.ProjectPath(dir), profile.GenerateImageName(dir)
+	}
+
+	if !importImageForce {
+		if isFile(profilePath) {
+			return fmt.Errorf("%s already exists. Use --force to replace it and its image", collapsePath(profilePath))
+		}
+		if exists, err := rt.ImageExists(target); err != nil {
+			return fmt.Errorf("checking image %s: %w", target, err)
+		} else if exists {
+			return fmt.Errorf("image %s already exists. Use --force to replace it", target)
+		}
+	}
+	if m.Image != target {
+		// Loading would take the name of another project's image
+		if exists, err := rt.ImageExists(m.Image); err != nil {
+			return fmt.Errorf("checking image %s: %w", m.Image, err)
+		} else if exists {
+			return fmt.Errorf("the bundle's image is named %s, like an image already here; remove it first", m.Image)
+		}
+	}
+
+	fmt.Printf("Loading %s...\n", m.Image)
+	if err := rt.LoadImage(image); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(profilePath), 0755); err != nil {
+		return fmt.Errorf("creating profile directory: %w", err)
+	}
+	if err := os.WriteFile(profilePath, b.Profile, 0644); err != nil {
+		return fmt.Errorf("writing profile: %w", err)
+	}
+	p, err := profile.Load(profilePath)
+	if err != nil {
+		return err
+	}
+	if b.Dockerfile != nil {
+		if err := os.WriteFile(p.DockerfilePath(), b.Dockerfile, 0644); err != nil {
+			return fmt.Errorf("writing Dockerfile: %w", err)
+		}
+	}
+	p.Build.ImageName = target
+	if err := p.Save(); err != nil {
+		return fmt.Errorf("saving profile: %w", err)
+	}
+
+	// A project image's labels name the exporting machine's paths, which
+	// ls and prune go by, so it is relabeled for this one under its name
+	// here. Base images keep their ID, which the images built on them record.
+	if m.Kind == bundle.KindProject {
+		if err := relabelImage(rt, m.Image, target, imageLabels(p), m.Platforms); err != nil {
+			return err
+		}
+		if m.Image != target {
+			if err := rt.RemoveImage(m.Image); err != nil {
+				colorYellow.Printf("Warning: could not remove %s: %v\n", m.Image, err)
+			}
+		}
+	}
+
+	colorGreen.Printf("✓ Imported %s with profile %s\n", target, collapsePath(profilePath))
+	if m.Kind == bundle.KindProject {
+		warnImportedParent(rt, m.Parent, m.ParentID, target)
+		fmt.Printf("Run 'glovebox run %s' to start it.\n", collapsePath(dir))
+	}
+	return nil
+}
+
+// relabelImage gives an image new labels under a new name. Labels can't be
+// changed in place, so this builds a Dockerfile of just its FROM line, which
+// adds no layers.
+func relabelImage(rt runtime.Runtime, source, target string, l map[string]string, platforms []string) error {
+	dir, err := os.MkdirTemp("", "glovebox-relabel-*")
+	if err != nil {
+		return fmt.Errorf("relabeling %s: %w", target, err)
+	}
+	defer os.RemoveAll(dir)
+	dockerfile := filepath.Join(dir, "Dockerfile")
+	if err := os.WriteFile(dockerfile, []byte("FROM "+source+"\n"), 0644); err != nil {
+		return fmt.Errorf("relabeling %s: %w", target, err)
+	}
+
+	var progress bytes.Buffer
+	err = rt.BuildImage(runtime.BuildConfig{
+		DockerfilePath: dockerfile,
+		ContextDir:     dir,
+		ImageName:      target,
+		Labels:         l,
+		Platforms:      platforms,
+		Progress:       &progress,
+	})
+	if err != nil {
+		return fmt.Errorf("relabeling %s: %w\n%s", target, err, strings.TrimSpace(progress.String()))
+	}
+	return nil
+}
+
+// warnImportedParent explains when the parent image an imported or pulled
+// image was built on differs here. The image runs either way, as it holds
+// its parent's layers; the difference shows once it is rebuilt.
+func warnImportedParent(rt runtime.Runtime, parent, parentID, target string) {
+	exists, err := rt.ImageExists(parent)
+	if err != nil {
+		return
+	}
+	if !exists {
+		colorYellow.Printf("⚠ %s was built on %s, which isn't here. It runs without it, but rebuilding it needs %s.\n", target, parent, parent)
+		return
+	}
+	if id, err := rt.GetImageDigest(parent); err == nil && parentID != "" && id != parentID {
+		colorYellow.Printf("⚠ %s was built on another %s than the one here. It runs as it is, and is rebuilt on this one the next time it's built.\n", target, parent)
+	}
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+)
+
+// exportBundles builds a base and project image and exports both,
+// returning the bundle paths
+func exportBundles(t *testing.T, env *testEnv) (base, project string) {
+	t.Helper()
+	env.saveGlobal("os/ubuntu", "tools/homebrew-ubuntu")
+	env.saveProject("tools/mise")
+	env.mustRun("", "build")
+
+	dir := realTempDir(t)
+	base, project = filepath.Join(dir, "base.tar"), filepath.Join(dir, "app.tar")
+	out := env.mustRun("", "export-image", "--base", "-o", base)
+	if !strings.Contains(out, "✓ Exported glovebox:base to "+base) {
+		t.Errorf("output = %q", out)
+	}
+	env.mustRun("", "export-image", "-o", project)
+	return base, project
+}
+
+func TestExportImportImage(t *testing.T) {
+	t.Run("moves a built environment to another machine", func(t *testing.T) {
+		exporter := newTestEnv(t)
+		baseBundle, projectBundle := exportBundles(t, exporter)
+		baseID := exporter.rt.Image(profile.BaseImageName).ID
+
+		env := newTestEnv(t)
+		env.mustRun("", "import-image", baseBundle)
+		if img := env.rt.Image(profile.BaseImageName); img == nil || img.ID != baseID {
+			t.Fatalf("base image = %+v, want ID %s", img, baseID)
+		}
+		global, err := profile.LoadGlobal()
+		if err != nil || global == nil || strings.Join(global.Mods, " ") != "os/ubuntu tools/homebrew-ubuntu" {
+			t.Fatalf("global profile = %+v, %v", global, err)
+		}
+
+		out := env.mustRun("", "import-image", projectBundle)
+		if strings.Contains(out, "⚠") {
+			t.Errorf("importing onto the same base should not warn:\n%s", out)
+		}
+		p, err := profile.LoadProject(env.project)
+		if err != nil || p == nil {
+			t.Fatalf("project profile = %+v, %v", p, err)
+		}
+		target := profile.GenerateImageName(env.project)
+		if p.ImageName() != target {
+			t.Errorf("ImageName() = %s, want %s", p.ImageName(), target)
+		}
+		img := env.rt.Image(target)
+		if img == nil {
+			t.Fatalf("image %s not imported", target)
+		}
+		if img.Labels[labels.Project] != env.project || img.Labels[labels.ParentID] != baseID {
+			t.Errorf("labels = %v", img.Labels)
+		}
+		if exported := profile.GenerateImageName(exporter.project); env.rt.Image(exported) != nil {
+			t.Errorf("%s should only be known by its name here", exported)
+		}
+
+		out = env.mustRun("", "status")
+		if !strings.Contains(out, "Up to date") || strings.Contains(out, "has changed") {
+			t.Errorf("status after import:\n%s", out)
+		}
+		builds := len(env.rt.Builds)
+		env.mustRun("", "run")
+		if len(env.rt.Builds) != builds {
+			t.Error("run should use the imported image without building")
+		}
+	})
+
+	t.Run("warns when the base isn't here", func(t *testing.T) {
+		_, projectBundle := exportBundles(t, newTestEnv(t))
+		env := newTestEnv(t)
+		out := env.mustRun("", "import-image", projectBundle)
+		if !strings.Contains(out, "was built on glovebox:base, which isn't here") {
+			t.Errorf("output = %q", out)
+		}
+	})
+
+	t.Run("replaces existing profiles only with --force", func(t *testing.T) {
+		env := newTestEnv(t)
+		_, projectBundle := exportBundles(t, env)
+		_, err := env.run("", "import-image", projectBundle)
+		if err == nil || !strings.Contains(err.Error(), "Use --force") {
+			t.Errorf("error = %v, want a --force hint", err)
+		}
+		env.mustRun("", "import-image", "--force", projectBundle)
+	})
+
+	t.Run("imports into another directory", func(t *testing.T) {
+		_, projectBundle := exportBundles(t, newTestEnv(t))
+		env := newTestEnv(t)
+		dir := filepath.Join(env.home, "code", "app")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		env.mustRun("", "import-image", projectBundle, dir)
+		if env.rt.Image(profile.GenerateImageName(dir)) == nil || !isFile(profile.ProjectPath(dir)) {
+			t.Error("project not imported into the directory")
+		}
+	})
+
+	t.Run("needs a runtime that can export", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.mustRun("", "build")
+		env.rt.Caps = runtime.Capabilities{}
+		_, err := env.run("", "export-image", "-o", filepath.Join(env.home, "base.tar"))
+		if err == nil || !strings.Contains(err.Error(), "can't export images") {
+			t.Errorf("error = %v", err)
+		}
+	})
+
+	t.Run("rejects files that aren't bundles", func(t *testing.T) {
+		env := newTestEnv(t)
+		file := filepath.Join(env.home, "notes.tar")
+		if err := os.WriteFile(file, []byte("not a tar"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := env.run("", "import-image", file); err == nil {
+			t.Error("import-image should fail")
+		}
+	})
+}
@@ -4,17 +4,23 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/fatih/color"
 	"github.com/joelhelbling/glovebox/internal/docker"
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/joelhelbling/glovebox/internal/runtime"
 	"github.com/spf13/cobra"
 )
 
 var (
-	cleanImage bool
-	cleanAll   bool
-	cleanForce bool
+	cleanImage   bool
+	cleanAll     bool
+	cleanForce   bool
+	cleanVolumes bool
 )
 
 var cleanCmd = &cobra.Command{
@@ -27,14 +33,19 @@ By default, removes only the container (preserving the image):
   - Next run creates a fresh container from the existing image
   - Safe: committed changes in the image are preserved
 
+Sidecar services from the profile's services section are removed along
+with the container, as is the project's private network. Their data volumes
+are kept unless --volumes is given.
+
 With --image, also removes the project image:
   - Removes both container and image
   - Next run triggers a full image rebuild
   - Warning: any user-committed changes will be lost
 
 With --all, removes everything glovebox-related (requires confirmation):
-  - All glovebox:* images
-  - All glovebox-* containers
+  - All glovebox:* images, including every base (removed last)
+  - All glovebox-* containers, including services, and glovebox networks
+  - With --volumes, all service data volumes
 
 Use --force to skip confirmation prompts.`,
 	Args: cobra.MaximumNArgs(1),
@@ -45,16 +56,19 @@ func init() {
 	cleanCmd.Flags().BoolVar(&cleanImage, "image", false, "Also remove the project image (loses committed changes)")
 	cleanCmd.Flags().BoolVar(&cleanAll, "all", false, "Remove all glovebox images and containers (requires confirmation)")
 	cleanCmd.Flags().BoolVarP(&cleanForce, "force", "f", false, "Skip confirmation prompts")
+	cleanCmd.Flags().BoolVar(&cleanVolumes, "volumes", false, "Also remove service data volumes")
 	rootCmd.AddCommand(cleanCmd)
 }
 
 func runClean(cmd *cobra.Command, args []string) error {
+	rt := runtimeOf(cmd)
+
 	yellow := color.New(color.FgYellow)
 	green := color.New(color.FgGreen)
 	red := color.New(color.FgRed)
 
 	// Check for running containers first
-	runningContainers, err := findRunningGloveboxContainers()
+	runningContainers, err := findRunningGloveboxContainers(rt)
 	if err != nil {
 		return fmt.Errorf("checking for running containers: %w", err)
 	}
@@ -68,7 +82,7 @@ func runClean(cmd *cobra.Command, args []string) error {
 	}
 
 	if cleanAll {
-		return cleanAllGlovebox(yellow, green, red)
+		return cleanAllGlovebox(rt, yellow, green, red)
 	}
 
 	// Determine target directory
@@ -82,10 +96,26 @@ func runClean(cmd *cobra.Command, args []string) error {
 	containerName := docker.ContainerName(targetDir)
 
 	// Check if there's anything to clean
-	imageFound := rt.ImageExists(imageName)
-	containerFound := rt.ContainerExists(containerName)
+	imageFound, err := rt.ImageExists(imageName)
+	if err != nil {
+		return err
+	}
+	containerFound, err := rt.ContainerExists(containerName)
+	if err != nil {
+		return err
+	}
+	networkFound, err := rt.NetworkExists(profile.NetworkName(containerName))
+	if err != nil {
+		return err
+	}
+	services, _ := findServiceContainers(rt, containerName)
+	servicesFound := len(services) > 0 || networkFound
+	var volumes []string
+	if cleanVolumes {
+		volumes, _ = rt.ListVolumes(profile.ServiceContainerName(containerName, ""))
+	}
 
-	if !containerFound && (!cleanImage || !imageFound) {
+	if !containerFound && !servicesFound && len(volumes) == 0 && (!cleanImage || !imageFound) {
 		yellow.Printf("No glovebox container found for %s\n", collapsePath(targetDir))
 		return nil
 	}
@@ -95,14 +125,19 @@ func runClean(cmd *cobra.Command, args []string) error {
 
 	// Remove container first (must be done before image)
 	if containerFound {
-		if err := removeContainer(containerName, green); err != nil {
+		if err := removeContainer(rt, containerName, green); err != nil {
 			yellow.Printf("Warning: could not remove container %s: %v\n", containerName, err)
 		}
 	}
 
+	// Services depend on nothing else; remove them with the container
+	if servicesFound || len(volumes) > 0 {
+		removeServices(rt, containerName, cleanVolumes, green, yellow)
+	}
+
 	// Only remove image if --image flag is set
 	if cleanImage && imageFound {
-		if err := removeImage(imageName, green); err != nil {
+		if err := removeImage(rt, imageName, green); err != nil {
 			yellow.Printf("Warning: could not remove image %s: %v\n", imageName, err)
 		}
 	}
@@ -115,41 +150,75 @@ type containerInfo struct {
 	image string
 }
 
-func findRunningGloveboxContainers() ([]containerInfo, error) {
-	containers, err := rt.ListContainers("", false) // running only
+func findRunningGloveboxContainers(rt runtime.Runtime) ([]containerInfo, error) {
+	containers, err := listGloveboxContainers(rt, false) // running only
 	if err != nil {
 		return nil, err
 	}
 
 	var result []containerInfo
 	for _, c := range containers {
-		if strings.HasPrefix(c.Image, "glovebox:") {
-			result = append(result, containerInfo{name: c.Name, image: c.Image})
-		}
+		result = append(result, containerInfo{name: c.Name, image: c.Image})
 	}
 	return result, nil
 }
 
-func cleanAllGlovebox(yellow, green, red *color.Color) error {
+// listGloveboxContainers lists the containers glovebox created: those
+// labelled as its own, plus unlabelled ones from before labels that follow
+// glovebox naming and run a glovebox image
+func listGloveboxContainers(rt runtime.Runtime, all bool) ([]runtime.ContainerInfo, error) {
+	containers, err := rt.ListContainers(runtime.ListFilter{Labels: labels.Selector("")}, all)
+	if err != nil {
+		return nil, err
+	}
+
+	legacy, err := rt.ListContainers(runtime.ListFilter{Name: "glovebox-"}, all)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range legacy {
+		if _, labelled := c.Labels[labels.Managed]; labelled {
+			continue
+		}
+		if strings.HasPrefix(c.Name, "glovebox-") && strings.HasPrefix(c.Image, "glovebox:") {
+			containers = append(containers, c)
+		}
+	}
+	return containers, nil
+}
+
+func cleanAllGlovebox(rt runtime.Runtime, yellow, green, red *color.Color) error {
 	// Find all glovebox images
-	images, err := findGloveboxImages()
+	images, err := findGloveboxImages(rt)
 	if err != nil {
 		return fmt.Errorf("listing images: %w", err)
 	}
 
 	// Find all glovebox containers
-	containers, err := findGloveboxContainers()
+	containers, err := findGloveboxContainers(rt)
 	if err != nil {
 		return fmt.Errorf("listing containers: %w", err)
 	}
 
-	if len(images) == 0 && len(containers) == 0 {
+	networks, err := rt.ListNetworks("glovebox-")
+	if err != nil {
+		return fmt.Errorf("listing networks: %w", err)
+	}
+
+	var volumes []string
+	if cleanVolumes {
+		if volumes, err = rt.ListVolumes("glovebox-"); err != nil {
+			return fmt.Errorf("listing volumes: %w", err)
+		}
+	}
+
+	if len(images) == 0 && len(containers) == 0 && len(networks) == 0 && len(volumes) == 0 {
 		yellow.Println("No glovebox resources found.")
 		return nil
 	}
 
 	if !cleanForce {
-		red.Println("Warning: This will remove ALL glovebox images and containers:")
+		red.Println("Warning: This will remove ALL glovebox images, containers and networks:")
 		if len(containers) > 0 {
 			fmt.Println("\nContainers:")
 			for _, c := range containers {
@@ -162,6 +231,18 @@ func cleanAllGlovebox(yellow, green, red *color.Color) error {
 				fmt.Printf("  - %s\n", img)
 			}
 		}
+		if len(networks) > 0 {
+			fmt.Println("\nNetworks:")
+			for _, n := range networks {
+				fmt.Printf("  - %s\n", n)
+			}
+		}
+		if len(volumes) > 0 {
+			fmt.Println("\nVolumes (service data):")
+			for _, v := range volumes {
+				fmt.Printf("  - %s\n", v)
+			}
+		}
 		fmt.Print("\nContinue? [y/N] ")
 
 		if !confirmPrompt() {
@@ -172,14 +253,26 @@ func cleanAllGlovebox(yellow, green, red *color.Color) error {
 
 	// Remove all containers first (must be done before images)
 	for _, c := range containers {
-		if err := removeContainer(c, green); err != nil {
+		if err := removeContainer(rt, c, green); err != nil {
 			yellow.Printf("Warning: could not remove container %s: %v\n", c, err)
 		}
 	}
 
+	// Networks and volumes can only go once no container uses them
+	for _, n := range networks {
+		if err := rt.RemoveNetwork(n); err != nil {
+			yellow.Printf("Warning: could not remove network %s: %v\n", n, err)
+		} else {
+			green.Printf("Removed network: %s\n", n)
+		}
+	}
+	for _, v := range volumes {
+		removeVolume(rt, v, green, yellow)
+	}
+
 	// Remove all images
 	for _, img := range images {
-		if err := removeImage(img, green); err != nil {
+		if err := removeImage(rt, img, green); err != nil {
 			yellow.Printf("Warning: could not remove image %s: %v\n", img, err)
 		}
 	}
@@ -187,26 +280,55 @@ func cleanAllGlovebox(yellow, green, red *color.Color) error {
 	return nil
 }
 
-func findGloveboxImages() ([]string, error) {
-	return rt.ListImages("glovebox:*")
+// findGloveboxImages lists glovebox images in removal order: project images,
+// then named profile images, then bases, so children go before their parents.
+func findGloveboxImages(rt runtime.Runtime) ([]string, error) {
+	images, err := rt.ListImages(runtime.ListFilter{Labels: labels.Selector("")})
+	if err != nil {
+		return nil, err
+	}
+	// Images built before glovebox labelled them are found by name
+	legacy, err := rt.ListImages(runtime.ListFilter{Name: "glovebox:*"})
+	if err != nil {
+		return nil, err
+	}
+	for _, img := range legacy {
+		if !slices.Contains(images, img) {
+			images = append(images, img)
+		}
+	}
+
+	sort.SliceStable(images, func(i, j int) bool {
+		return imageRemovalRank(images[i]) < imageRemovalRank(images[j])
+	})
+	return images, nil
+}
+
+func imageRemovalRank(imageName string) int {
+	switch {
+	case profile.IsBaseImage(imageName):
+		return 2
+	case strings.HasPrefix(imageName, profile.NamedImageName("")):
+		return 1
+	default:
+		return 0
+	}
 }
 
-func findGloveboxContainers() ([]string, error) {
-	containers, err := rt.ListContainers("glovebox-", true) // all, including stopped
+func findGloveboxContainers(rt runtime.Runtime) ([]string, error) {
+	containers, err := listGloveboxContainers(rt, true) // all, including stopped
 	if err != nil {
 		return nil, err
 	}
 
 	var names []string
 	for _, c := range containers {
-		if strings.HasPrefix(c.Name, "glovebox-") {
-			names = append(names, c.Name)
-		}
+		names = append(names, c.Name)
 	}
 	return names, nil
 }
 
-func removeContainer(name string, green *color.Color) error {
+func removeContainer(rt runtime.Runtime, name string, green *color.Color) error {
 	if err := rt.ForceRemoveContainer(name); err != nil {
 		return err
 	}
@@ -214,7 +336,7 @@ func removeContainer(name string, green *color.Color) error {
 	return nil
 }
 
-func removeImage(name string, green *color.Color) error {
+func removeImage(rt runtime.Runtime, name string, green *color.Color) error {
 	if err := rt.RemoveImage(name); err != nil {
 		return err
 	}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/joelhelbling/glovebox/internal/docker"
+	"github.com/joelhelbling/glovebox/internal/profile"
+)
+
+func TestClean(t *testing.T) {
+	t.Run("removes the container and keeps the image", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		p := env.saveProject("shells/bash")
+		env.mustRun("", "run")
+
+		env.mustRun("", "clean")
+		if env.rt.Container(docker.ContainerName(env.project)) != nil {
+			t.Error("container not removed")
+		}
+		if env.rt.Image(p.ImageName()) == nil {
+			t.Error("image removed without --image")
+		}
+	})
+
+	t.Run("--image also removes the project image", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.saveProject("shells/bash")
+		env.mustRun("", "run")
+
+		env.mustRun("", "clean", "--image")
+		if img := env.rt.Image(docker.ImageName(env.project)); img != nil {
+			t.Errorf("project image %s not removed", img.Name)
+		}
+		if env.rt.Image(profile.BaseImageName) == nil {
+			t.Error("the base image should be kept")
+		}
+	})
+
+	t.Run("nothing to clean", func(t *testing.T) {
+		env := newTestEnv(t)
+		if out := env.mustRun("", "clean"); !strings.Contains(out, "No glovebox container found") {
+			t.Errorf("output = %q", out)
+		}
+	})
+
+	t.Run("--all asks for confirmation", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.mustRun("", "run")
+
+		env.mustRun("n\n", "clean", "--all")
+		if env.rt.Container(docker.ContainerName(env.project)) == nil {
+			t.Fatal("removed without confirmation")
+		}
+
+		env.mustRun("y\n", "clean", "--all")
+		if env.rt.Container(docker.ContainerName(env.project)) != nil || env.rt.Image(profile.BaseImageName) != nil {
+			t.Errorf("calls = %v, want the container and base image removed", env.rt.Calls)
+		}
+	})
+
+	t.Run("reports a daemon that can't be reached", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.rt.FailOn("*", errors.New("Cannot connect to the Docker daemon"))
+		if _, err := env.run("", "clean"); err == nil || !strings.Contains(err.Error(), "Cannot connect") {
+			t.Errorf("error = %v", err)
+		}
+	})
+}
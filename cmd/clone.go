@@ -63,11 +63,11 @@ func runClone(cmd *cobra.Command, args []string) error {
 	}
 
 	// Run glovebox in the cloned directory
-	return runRunWithPath(absPath)
+	return runRunWithPath(cmd, absPath)
 }
 
 // runRunWithPath is a helper to run glovebox with a specific path
-func runRunWithPath(path string) error {
+func runRunWithPath(cmd *cobra.Command, path string) error {
 	// Reuse the run command logic
-	return runRun(nil, []string{path})
+	return runRun(cmd, []string{path})
 }
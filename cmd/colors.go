@@ -6,6 +6,7 @@ import "github.com/fatih/color"
 var (
 	colorGreen  = color.New(color.FgGreen)
 	colorYellow = color.New(color.FgYellow)
+	colorRed    = color.New(color.FgRed)
 	colorBold   = color.New(color.Bold)
 	colorDim    = color.New(color.Faint)
 )
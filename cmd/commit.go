@@ -30,6 +30,8 @@ func init() {
 }
 
 func runCommit(cmd *cobra.Command, args []string) error {
+	rt := runtimeOf(cmd)
+
 	// Get current directory
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -45,7 +47,11 @@ func runCommit(cmd *cobra.Command, args []string) error {
 	containerName := docker.ContainerName(absPath)
 
 	// Check if container exists
-	if !rt.ContainerExists(containerName) {
+	exists, err := rt.ContainerExists(containerName)
+	if err != nil {
+		return err
+	}
+	if !exists {
 		return fmt.Errorf("no container found for this project\nRun 'glovebox run' first to create a container")
 	}
 
@@ -64,7 +70,7 @@ func runCommit(cmd *cobra.Command, args []string) error {
 	prompt := ui.NewPrompt()
 	fmt.Printf("Committing container to %s...\n", imageName)
 
-	if err := rt.Commit(containerName, imageName); err != nil {
+	if err := commitContainer(rt, containerName, imageName); err != nil {
 		return fmt.Errorf("committing container: %w", err)
 	}
 
@@ -92,5 +98,5 @@ func getImageNameForCommit(dir string) (string, error) {
 	}
 
 	// No project profile - use base image
-	return "glovebox:base", nil
+	return profile.BaseImageName, nil
 }
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/joelhelbling/glovebox/internal/docker"
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/profile"
+)
+
+func TestCommit(t *testing.T) {
+	t.Run("commits to the project image", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		p := env.saveProject("shells/bash")
+		env.mustRun("", "run")
+
+		out := env.mustRun("", "commit")
+		if len(env.rt.Commits) != 1 || env.rt.Commits[0].Image != p.ImageName() {
+			t.Errorf("commits = %+v, want one to %s", env.rt.Commits, p.ImageName())
+		}
+		if env.rt.Image(profile.BaseImageName) == nil {
+			t.Error("the base image should be untouched")
+		}
+		if !strings.Contains(out, "Next 'glovebox run' will start fresh") {
+			t.Errorf("output = %q", out)
+		}
+	})
+
+	t.Run("committed project still shows in ls", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		p := env.saveProject("shells/bash")
+		env.mustRun("", "run")
+		env.mustRun("", "commit")
+
+		if role := env.rt.Image(p.ImageName()).Labels[labels.Role]; role != labels.RoleProject {
+			t.Errorf("committed image role = %q, want %q", role, labels.RoleProject)
+		}
+		var doc struct {
+			Data struct {
+				Projects []struct {
+					Path      string `json:"path"`
+					Image     string `json:"image"`
+					ImageRole string `json:"image_role"`
+				} `json:"projects"`
+			} `json:"data"`
+		}
+		out := env.mustRun("", "ls", "-o", "json")
+		if err := json.Unmarshal([]byte(out), &doc); err != nil {
+			t.Fatalf("parsing ls output: %v\n%s", err, out)
+		}
+		projects := doc.Data.Projects
+		if len(projects) != 1 || projects[0].Path != env.project || projects[0].Image != p.ImageName() || projects[0].ImageRole != labels.RoleProject {
+			t.Errorf("ls projects = %+v, want the committed project image", projects)
+		}
+	})
+
+	t.Run("without a container", func(t *testing.T) {
+		env := newTestEnv(t)
+		if _, err := env.run("", "commit"); err == nil || !strings.Contains(err.Error(), "no container found") {
+			t.Errorf("error = %v", err)
+		}
+	})
+
+	t.Run("unsupported by the runtime", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.mustRun("", "run")
+		env.rt.Caps.SupportsCommit = false
+
+		if _, err := env.run("", "commit"); err == nil || !strings.Contains(err.Error(), "not supported") {
+			t.Errorf("error = %v", err)
+		}
+	})
+
+	t.Run("keeps the container when the commit fails", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.mustRun("", "run")
+		env.rt.FailOn("Commit", errors.New("disk full"))
+
+		if _, err := env.run("", "commit"); err == nil || !strings.Contains(err.Error(), "disk full") {
+			t.Errorf("error = %v", err)
+		}
+		if env.rt.Container(docker.ContainerName(env.project)) == nil {
+			t.Error("container removed after a failed commit")
+		}
+	})
+}
+
+func TestReset(t *testing.T) {
+	env := newTestEnv(t)
+	env.saveGlobal("os/ubuntu")
+	env.rt.ScriptSession(loadDiff(t, "brew-install.txt")...)
+	env.mustRun("", "run")
+
+	env.mustRun("", "reset")
+	if env.rt.Container(docker.ContainerName(env.project)) != nil {
+		t.Error("reset should remove the container")
+	}
+	if len(env.rt.Commits) != 0 {
+		t.Error("reset should not commit")
+	}
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/joelhelbling/glovebox/internal/inventory"
+	"github.com/joelhelbling/glovebox/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var dfCmd = &cobra.Command{
+	Use:   "df",
+	Short: "Show disk space used by glovebox",
+	Long: `Show the disk space used by glovebox images, containers and service
+data volumes.
+
+For each image built on a base, SHARED is the size of the layers it shares
+with that base and UNIQUE the size of its own layers, including changes
+saved with 'glovebox commit'. A container's size is its writable layer:
+changes made inside it that 'glovebox reset' would discard.
+
+The total counts shared layers once.
+
+Supports --output json|yaml for machine-readable output.`,
+	Args: cobra.NoArgs,
+	RunE: runDf,
+}
+
+func init() {
+	addOutputFlag(dfCmd)
+	rootCmd.AddCommand(dfCmd)
+}
+
+func runDf(cmd *cobra.Command, args []string) error {
+	rt := runtimeOf(cmd)
+
+	if err := output.ValidateFormat(outputFormat); err != nil {
+		return err
+	}
+
+	usage, err := inventory.CollectUsage(rt)
+	if err != nil {
+		return fmt.Errorf("measuring disk usage: %w", err)
+	}
+
+	if output.Structured(outputFormat) {
+		return output.Write(os.Stdout, outputFormat, "df", struct {
+			inventory.Usage `yaml:",inline"`
+			Total           int64 `json:"total" yaml:"total"`
+		}{usage, usage.Total()})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "IMAGE\tROLE\tSIZE\tSHARED\tUNIQUE\tBASE")
+	for _, img := range usage.Images {
+		base := img.Base
+		if base == "" {
+			base = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			img.Name, img.Role, formatSize(img.Size), formatSize(img.Shared), formatSize(img.Unique), base)
+	}
+
+	if len(usage.Containers) > 0 {
+		fmt.Fprintln(w, "\nCONTAINER\tROLE\tSIZE\tPROJECT")
+		for _, c := range usage.Containers {
+			project := "-"
+			if c.Project != "" {
+				project = collapsePath(c.Project)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Name, c.Role, formatSize(c.Size), project)
+		}
+	}
+
+	if len(usage.Volumes) > 0 {
+		fmt.Fprintln(w, "\nVOLUME\tSIZE")
+		for _, v := range usage.Volumes {
+			fmt.Fprintf(w, "%s\t%s\n", v.Name, formatSize(v.Size))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nTotal: %s\n", formatSize(usage.Total()))
+	return nil
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	"github.com/joelhelbling/glovebox/internal/docker"
+	"github.com/joelhelbling/glovebox/internal/output"
+	"github.com/joelhelbling/glovebox/internal/runtime"
 	"github.com/spf13/cobra"
 )
 
@@ -25,16 +27,25 @@ by the container runtime.
 Change types:
   A = Added
   C = Changed
-  D = Deleted`,
+  D = Deleted
+
+Use --output json or --output yaml for machine-readable output.`,
 	RunE: runDiff,
 }
 
 func init() {
 	diffCmd.Flags().BoolVar(&diffRaw, "raw", false, "Show raw diff output (no filtering)")
+	addOutputFlag(diffCmd)
 	rootCmd.AddCommand(diffCmd)
 }
 
 func runDiff(cmd *cobra.Command, args []string) error {
+	rt := runtimeOf(cmd)
+
+	if err := output.ValidateFormat(outputFormat); err != nil {
+		return err
+	}
+
 	// Get current directory
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -49,7 +60,14 @@ func runDiff(cmd *cobra.Command, args []string) error {
 	// Get container name for this project
 	containerName := docker.ContainerName(absPath)
 
-	if !rt.ContainerExists(containerName) {
+	exists, err := rt.ContainerExists(containerName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if output.Structured(outputFormat) {
+			return fmt.Errorf("no container found for this project")
+		}
 		fmt.Println("No container found for this project.")
 		return nil
 	}
@@ -64,110 +82,130 @@ func runDiff(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("getting container diff: %w", err)
 	}
 
-	// Convert to string format for existing processing logic
-	var lines []string
-	for _, d := range diffs {
-		lines = append(lines, fmt.Sprintf("%s %s", d.ChangeType, d.Path))
+	if output.Structured(outputFormat) {
+		report := diffReport{Container: containerName, Total: len(diffs)}
+		if diffRaw {
+			report.Changes = diffs
+		} else {
+			report = categorizeDiff(containerName, diffs)
+		}
+		return output.Write(os.Stdout, outputFormat, "diff", report)
 	}
 
-	if len(lines) == 0 {
+	if len(diffs) == 0 {
 		fmt.Println("No changes detected in container.")
 		return nil
 	}
 
 	if diffRaw {
 		// Raw mode: just print each diff line
-		for _, line := range lines {
-			fmt.Println(line)
+		for _, d := range diffs {
+			fmt.Printf("%s %s\n", d.ChangeType, d.Path)
 		}
 		return nil
 	}
 
-	// Use the shared filterNoise function
-	allChanges := lines
-
-	meaningful := filterNoise(allChanges)
-	noiseCount := len(allChanges) - len(meaningful)
+	report := categorizeDiff(containerName, diffs)
 
-	// Count workspace changes separately
-	workspaceCount := 0
-	for _, line := range allChanges {
-		parts := strings.SplitN(line, " ", 2)
-		if len(parts) == 2 && strings.HasPrefix(parts[1], "/workspace") {
-			workspaceCount++
+	// Print categorized output
+	printCategory := func(c diffCategory) {
+		showAll := c.Name == "Config files" || c.Name == "Other"
+		colorBold.Printf("\n%s (%d):\n", c.Name, len(c.Changes))
+		limit := len(c.Changes)
+		if !showAll && limit > 10 {
+			limit = 10
+		}
+		for _, d := range c.Changes[:limit] {
+			fmt.Printf("  %s %s\n", d.ChangeType, d.Path)
 		}
+		if limit < len(c.Changes) {
+			colorDim.Printf("  ... and %d more\n", len(c.Changes)-limit)
+		}
+	}
+
+	fmt.Printf("Container: %s\n", report.Container)
+	fmt.Printf("Total changes: %d\n", report.Total)
+
+	// Show meaningful changes first
+	for _, c := range report.Categories {
+		printCategory(c)
 	}
 
-	// Categorize meaningful changes
-	var (
-		brew   []string
-		config []string
-		system []string
-		other  []string
-	)
+	// Show filtered categories last
+	if report.Noise > 0 {
+		colorDim.Printf("\nFiltered as noise (%d): ", report.Noise)
+		colorDim.Printf("use --raw to see all\n")
+	}
+	if report.Workspace > 0 {
+		colorDim.Printf("Workspace mount (%d): ", report.Workspace)
+		colorDim.Printf("changes are on host filesystem\n")
+	}
 
+	return nil
+}
+
+// diffReport is a container's filesystem changes, with noise filtered out
+// and the rest grouped by category
+type diffReport struct {
+	Container  string             `json:"container" yaml:"container"`
+	Total      int                `json:"total" yaml:"total"`
+	Categories []diffCategory     `json:"categories,omitempty" yaml:"categories,omitempty"`
+	Changes    []runtime.FileDiff `json:"changes,omitempty" yaml:"changes,omitempty"` // every change, with --raw
+	Noise      int                `json:"noise" yaml:"noise"`
+	Workspace  int                `json:"workspace" yaml:"workspace"` // changes on the host's workspace mount
+}
+
+type diffCategory struct {
+	Name    string             `json:"name" yaml:"name"`
+	Changes []runtime.FileDiff `json:"changes" yaml:"changes"`
+}
+
+// categorizeDiff filters noise from a container's changes and groups the
+// rest into Homebrew, config files, system and other changes
+func categorizeDiff(containerName string, diffs []runtime.FileDiff) diffReport {
+	report := diffReport{Container: containerName, Total: len(diffs)}
+
+	// Convert to string format for the shared filterNoise function
+	var lines []string
+	for _, d := range diffs {
+		lines = append(lines, fmt.Sprintf("%s %s", d.ChangeType, d.Path))
+		if strings.HasPrefix(d.Path, "/workspace") {
+			report.Workspace++
+		}
+	}
+	meaningful := filterNoise(lines)
+	report.Noise = len(lines) - len(meaningful)
+
+	names := []string{"Homebrew", "Config files", "System", "Other"}
+	byName := make(map[string][]runtime.FileDiff)
 	for _, line := range meaningful {
 		parts := strings.SplitN(line, " ", 2)
 		if len(parts) != 2 {
 			continue
 		}
-		path := parts[1]
+		d := runtime.FileDiff{ChangeType: parts[0], Path: parts[1]}
 
-		switch {
+		switch path := d.Path; {
 		case strings.Contains(path, "/.linuxbrew/"):
-			brew = append(brew, line)
+			byName["Homebrew"] = append(byName["Homebrew"], d)
 		case strings.Contains(path, "/home/dev/.") || strings.Contains(path, "/root/."):
-			config = append(config, line)
+			byName["Config files"] = append(byName["Config files"], d)
 		case strings.HasPrefix(path, "/var/") || strings.HasPrefix(path, "/etc/") || strings.HasPrefix(path, "/usr/"):
-			system = append(system, line)
+			byName["System"] = append(byName["System"], d)
 		default:
-			other = append(other, line)
+			byName["Other"] = append(byName["Other"], d)
 		}
 	}
 
-	// Print categorized output
-	printCategory := func(name string, items []string, showAll bool) {
-		if len(items) == 0 {
-			return
-		}
-		sort.Strings(items)
-		colorBold.Printf("\n%s (%d):\n", name, len(items))
-		if showAll {
-			for _, item := range items {
-				fmt.Printf("  %s\n", item)
-			}
-		} else {
-			limit := 10
-			if len(items) < limit {
-				limit = len(items)
-			}
-			for _, item := range items[:limit] {
-				fmt.Printf("  %s\n", item)
-			}
-			if len(items) > 10 {
-				colorDim.Printf("  ... and %d more\n", len(items)-10)
-			}
+	for _, name := range names {
+		changes := byName[name]
+		if len(changes) == 0 {
+			continue
 		}
+		sort.Slice(changes, func(i, j int) bool {
+			return changes[i].ChangeType+" "+changes[i].Path < changes[j].ChangeType+" "+changes[j].Path
+		})
+		report.Categories = append(report.Categories, diffCategory{Name: name, Changes: changes})
 	}
-
-	fmt.Printf("Container: %s\n", containerName)
-	fmt.Printf("Total changes: %d\n", len(allChanges))
-
-	// Show meaningful changes first
-	printCategory("Homebrew", brew, false)
-	printCategory("Config files", config, true)
-	printCategory("System", system, false)
-	printCategory("Other", other, true)
-
-	// Show filtered categories last
-	if noiseCount > 0 {
-		colorDim.Printf("\nFiltered as noise (%d): ", noiseCount)
-		colorDim.Printf("use --raw to see all\n")
-	}
-	if workspaceCount > 0 {
-		colorDim.Printf("Workspace mount (%d): ", workspaceCount)
-		colorDim.Printf("changes are on host filesystem\n")
-	}
-
-	return nil
+	return report
 }
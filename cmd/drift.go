@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joelhelbling/glovebox/internal/digest"
+	"github.com/joelhelbling/glovebox/internal/dotfiles"
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+)
+
+// driftImageRebuilt is the drift reason for a container whose image was
+// rebuilt after it was created
+const driftImageRebuilt = "image rebuilt since creation"
+
+// containerSpec is the container glovebox would create for a project now
+type containerSpec struct {
+	Config      runtime.RunConfig
+	Passthrough []string // passthrough_env names, whether or not set on the host
+	ProfilePath string   // profile the container is created from
+	Access      hostAccess
+	Dotfiles    *dotfiles.Config // mounted dotfiles, fetched by resolveDotfiles
+	DotfilesErr error            // dotfiles couldn't be mounted; reported when creating
+}
+
+// newContainerSpec describes the container for a project from its profiles
+func newContainerSpec(containerName, imageName, hostPath string, withServices bool) (*containerSpec, error) {
+	workspacePath := "/" + filepath.Base(hostPath)
+
+	passthrough, err := profile.EffectivePassthroughEnv(hostPath)
+	if err != nil {
+		passthrough = nil
+	}
+
+	env := make(map[string]string)
+	for _, envName := range passthrough {
+		if val := os.Getenv(envName); val != "" {
+			env[envName] = val
+		}
+	}
+	env["MISE_TRUSTED_CONFIG_PATHS"] = fmt.Sprintf("%s:%s/**", workspacePath, workspacePath)
+
+	// Dotfiles are a convenience; don't refuse to start the container over them
+	mounts, dotfilesCfg, dotfilesErr := dotfilesMounts(hostPath)
+	profileMounts, err := profile.EffectiveMounts(hostPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading profile mounts: %w", err)
+	}
+	for _, m := range profileMounts {
+		mounts = append(mounts, runtime.Mount{Source: m.Source, Target: m.Target, ReadOnly: m.ReadOnly})
+	}
+
+	access, err := loadHostAccess(hostPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var network string
+	if withServices {
+		network = profile.NetworkName(containerName)
+	}
+
+	return &containerSpec{
+		Config: runtime.RunConfig{
+			ContainerName: containerName,
+			ImageName:     imageName,
+			HostPath:      hostPath,
+			WorkspacePath: workspacePath,
+			Env:           env,
+			Hostname:      "glovebox",
+			Mounts:        mounts,
+			Network:       network,
+			Ports:         access.portMappings(),
+			HostAlias:     access.hostAlias(),
+			Platform:      runPlatform(hostPath),
+		},
+		Passthrough: passthrough,
+		ProfilePath: effectiveProfilePath(hostPath),
+		Access:      access,
+		Dotfiles:    dotfilesCfg,
+		DotfilesErr: dotfilesErr,
+	}, nil
+}
+
+// resolveDotfiles fetches the dotfiles the container mounts, cloning or
+// updating a git checkout. Only creating the container needs them, so
+// status checks never touch the network or a checkout in use. Dotfiles that
+// can't be fetched aren't mounted (see runConfig).
+func (s *containerSpec) resolveDotfiles() {
+	if s.Dotfiles == nil {
+		return
+	}
+	if _, err := dotfiles.Resolve(s.Dotfiles); err != nil {
+		s.DotfilesErr = err
+	}
+}
+
+// effectiveProfilePath returns the path of the profile a project's container
+// is created from: the project profile, or the global profile without one
+func effectiveProfilePath(projectDir string) string {
+	if path := profile.ProjectPath(projectDir); isFile(path) {
+		return path
+	}
+	path, _ := profile.GlobalPath()
+	return path
+}
+
+// hash fingerprints the settings fixed at creation. Passthrough variables
+// count by name only, so a rotated token doesn't make the container stale.
+func (s *containerSpec) hash() string {
+	cfg := s.Config
+	var b strings.Builder
+	fmt.Fprintf(&b, "image=%s\nhost=%s\nworkspace=%s\nhostname=%s\nnetwork=%s\nhost-alias=%s\n",
+		cfg.ImageName, cfg.HostPath, cfg.WorkspacePath, cfg.Hostname, cfg.Network, cfg.HostAlias)
+
+	passthrough := make(map[string]bool)
+	for _, name := range s.Passthrough {
+		passthrough[name] = true
+		fmt.Fprintf(&b, "passthrough=%s\n", name)
+	}
+	keys := make([]string, 0, len(cfg.Env))
+	for k := range cfg.Env {
+		if !passthrough[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "env=%s=%s\n", k, cfg.Env[k])
+	}
+
+	for _, m := range cfg.Mounts {
+		fmt.Fprintf(&b, "mount=%s:%s:%t\n", m.Source, m.Target, m.ReadOnly)
+	}
+	for _, p := range cfg.Ports {
+		fmt.Fprintf(&b, "port=%s\n", p)
+	}
+	if cfg.Platform != "" {
+		fmt.Fprintf(&b, "platform=%s\n", cfg.Platform)
+	}
+	return strings.TrimPrefix(digest.Calculate(b.String()), "sha256:")[:12]
+}
+
+// runConfig returns the config for creating the container, labelled with
+// what it was created from
+func (s *containerSpec) runConfig(rt runtime.Runtime) runtime.RunConfig {
+	cfg := s.Config
+	env := make(map[string]string, len(cfg.Env)+1)
+	for k, v := range cfg.Env {
+		env[k] = v
+	}
+	// Identifies this container so on_create hooks run once per container
+	env["GLOVEBOX_INSTANCE"] = strconv.FormatInt(time.Now().UnixNano(), 36)
+	cfg.Env = env
+
+	cfg.Labels = labels.New(Version, labels.RoleContainer, time.Now())
+	cfg.Labels[labels.Project] = cfg.HostPath
+	if s.ProfilePath != "" {
+		cfg.Labels[labels.Profile] = s.ProfilePath
+	}
+	for k, v := range s.Access.labels() {
+		cfg.Labels[k] = v
+	}
+	cfg.Labels[labels.ConfigHash] = s.hash()
+	// The hash keeps the dotfiles mount, as status can't tell whether they
+	// could be fetched
+	if s.DotfilesErr != nil {
+		cfg.Mounts = slices.DeleteFunc(slices.Clone(cfg.Mounts), func(m runtime.Mount) bool {
+			return m.Target == dotfiles.ContainerDir
+		})
+	}
+	cfg.WorkspaceVolume = rt.Capabilities().Remote
+	if id, err := rt.GetImageDigest(cfg.ImageName); err == nil {
+		cfg.Labels[labels.ImageID] = id
+	}
+	return cfg
+}
+
+// drift lists the ways an existing container differs from the spec.
+// Containers created before glovebox recorded labels are never stale.
+func (s *containerSpec) drift(rt runtime.Runtime, containerName string) []string {
+	created, err := rt.ContainerLabels(containerName)
+	if err != nil {
+		return nil
+	}
+
+	var reasons []string
+	if id, ok := created[labels.ImageID]; ok {
+		if current, err := rt.GetImageDigest(s.Config.ImageName); err == nil && current != id {
+			reasons = append(reasons, driftImageRebuilt)
+		}
+	}
+
+	portsChanged := created[labels.Ports] != s.Access.portsLabel()
+	hostChanged := created[labels.HostAlias] != s.Access.hostAlias()
+	if portsChanged {
+		reasons = append(reasons, "ports changed since creation")
+	}
+	if hostChanged {
+		reasons = append(reasons, "host services changed since creation")
+	}
+	if hash, ok := created[labels.ConfigHash]; ok && hash != s.hash() && !portsChanged && !hostChanged {
+		reasons = append(reasons, "settings changed since creation (passthrough env, mounts, dotfiles or services)")
+	}
+	return reasons
+}
+
+// staleChoice is what to do with a container that no longer matches its profile
+type staleChoice int
+
+const (
+	staleKeep staleChoice = iota
+	staleRecreate
+	staleCommitRecreate
+)
+
+// promptStale explains why a container is stale and asks what to do.
+// Committing is only offered when the image is unchanged: the commit would
+// otherwise replace the rebuilt image with the old one plus the changes.
+func promptStale(rt runtime.Runtime, reasons []string) staleChoice {
+	colorYellow.Println("Container is stale:")
+	for _, r := range reasons {
+		fmt.Printf("  - %s\n", r)
+	}
+
+	canCommit := rt.Capabilities().SupportsCommit
+	for _, r := range reasons {
+		if r == driftImageRebuilt {
+			canCommit = false
+		}
+	}
+
+	fmt.Println("\nWhat would you like to do?")
+	fmt.Println("  1) Recreate it (discards changes made inside the container)")
+	if canCommit {
+		fmt.Println("  2) Commit its changes to the image, then recreate it")
+	}
+	fmt.Println("  3) Keep using it as is")
+	fmt.Print("\nSelect option [3]: ")
+
+	input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.TrimSpace(input) {
+	case "1":
+		return staleRecreate
+	case "2":
+		if canCommit {
+			return staleCommitRecreate
+		}
+	}
+	return staleKeep
+}
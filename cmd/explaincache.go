@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/joelhelbling/glovebox/internal/buildlog"
+	"github.com/joelhelbling/glovebox/internal/digest"
+	"github.com/joelhelbling/glovebox/internal/generator"
+	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+)
+
+// explainCache reports which of an image's layers building a pending
+// Dockerfile would reuse from the build cache and which it would rebuild,
+// without building anything. parentChanged is set when the parent image was
+// rebuilt since the image was, which invalidates every layer.
+func explainCache(rt runtime.Runtime, p *profile.Profile, dockerfilePath, imageName, newContent string, parentChanged bool, chain []*profile.Profile) error {
+	exists, err := rt.ImageExists(imageName)
+	if err != nil {
+		return fmt.Errorf("checking image %s: %w", imageName, err)
+	}
+	built, err := os.ReadFile(dockerfilePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("reading Dockerfile: %w", err)
+	}
+
+	report := buildlog.CompareCache(string(built), newContent)
+	ids := modIDs(chain)
+	label := func(s buildlog.Step) string {
+		if s.Mod == "" {
+			return s.Instruction
+		}
+		id := s.Mod
+		if mapped, ok := ids[s.Mod]; ok {
+			id = mapped
+		}
+		return fmt.Sprintf("%s (%s)", id, s.Section)
+	}
+
+	colorBold.Printf("Cache report for %s\n", imageName)
+	switch {
+	case !exists:
+		fmt.Printf("Image %s not found, so every step will run.\n", imageName)
+		report.Cached = 0
+	case built == nil:
+		fmt.Println("No Dockerfile from the last build, so every step will run.")
+		report.Cached = 0
+	case parentChanged:
+		fmt.Printf("%s has changed since %s was built, so every step will run.\n", p.ParentImageName(), imageName)
+		report.Cached = 0
+	default:
+		if digest.Calculate(string(built)) != p.Build.DockerfileDigest {
+			colorYellow.Println("⚠ The Dockerfile changed after the image was built; comparing with it as it is.")
+		}
+		if report.Change == buildlog.ChangeNone {
+			colorGreen.Printf("✓ All %d steps are cached. Nothing will be rebuilt.\n", len(report.Steps))
+			return nil
+		}
+		fmt.Printf("%s\n", describeCacheChange(report, ids))
+	}
+
+	rebuilt := report.Rebuilt()
+	fmt.Printf("%d of %d steps will be rebuilt:\n", len(rebuilt), len(report.Steps))
+	for _, s := range rebuilt {
+		fmt.Printf("  ✗ %s\n", label(s))
+	}
+
+	// Mods other than the changed one being rebuilt is what the stable
+	// strategy avoids
+	if exists && built != nil && !parentChanged && p.BuildStrategy != generator.StrategyStable {
+		for _, s := range rebuilt {
+			if s.Mod != "" && s.Mod != report.ChangedMod {
+				fmt.Println()
+				colorDim.Printf("Setting 'build_strategy: %s' in the profile keeps each mod's layers together,\n", generator.StrategyStable)
+				colorDim.Println("so a changed mod rebuilds less of the image.")
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// describeCacheChange explains the first step the cache can't provide
+func describeCacheChange(report buildlog.CacheReport, ids map[string]string) string {
+	first := report.Rebuilt()[0]
+	what := report.ChangedMod
+	if id, ok := ids[what]; ok {
+		what = id
+	}
+	switch {
+	case report.Change == buildlog.ChangeRemoved:
+		return fmt.Sprintf("Mod %s was removed at step %d.", what, first.Index)
+	case report.ChangedMod == "":
+		return fmt.Sprintf("Step %d changed: %s", first.Index, first.Instruction)
+	case report.Change == buildlog.ChangeAdded:
+		return fmt.Sprintf("Mod %s was added at step %d.", what, first.Index)
+	case report.Change == buildlog.ChangeModified:
+		return fmt.Sprintf("Mod %s changed its %s at step %d.", what, first.Section, first.Index)
+	}
+	return fmt.Sprintf("Steps were reordered; step %d is now %s %s.", first.Index, what, first.Section)
+}
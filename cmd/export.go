@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joelhelbling/glovebox/internal/devcontainer"
+	"github.com/joelhelbling/glovebox/internal/dotfiles"
+	"github.com/joelhelbling/glovebox/internal/generator"
+	"github.com/joelhelbling/glovebox/internal/mod"
+	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/spf13/cobra"
+)
+
+var exportForce bool
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the glovebox environment for other tools",
+}
+
+var exportDevcontainerCmd = &cobra.Command{
+	Use:   "devcontainer [directory]",
+	Short: "Write a .devcontainer for the project's glovebox environment",
+	Long: `Write .devcontainer/devcontainer.json and a Dockerfile describing the
+project's effective glovebox environment, so teammates can open it with VS
+Code Dev Containers or Codespaces without installing glovebox.
+
+The Dockerfile flattens the whole image chain (base, named profiles and
+project) into one multi-stage build. Mod files and build-time dotfiles are
+staged in .devcontainer/build-files/.
+
+Profile settings map to devcontainer properties:
+  passthrough_env     containerEnv using ${localEnv:NAME}
+  mounts              mounts
+  ports               forwardPorts
+  host_services       runArgs adding host.glovebox.internal
+  on_create hooks     onCreateCommand
+  on_start hooks      postStartCommand
+  build_args          build.args
+
+Settings without an equivalent, such as on_exit hooks and build secrets,
+are listed.
+Re-run after changing the profile to regenerate the files.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runExportDevcontainer,
+}
+
+func init() {
+	exportDevcontainerCmd.Flags().BoolVarP(&exportForce, "force", "f", false, "Overwrite a devcontainer.json not generated by glovebox")
+	exportCmd.AddCommand(exportDevcontainerCmd)
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExportDevcontainer(cmd *cobra.Command, args []string) error {
+	targetDir := "."
+	if len(args) > 0 {
+		targetDir = args[0]
+	}
+	absPath, err := filepath.Abs(targetDir)
+	if err != nil {
+		return fmt.Errorf("resolving path: %w", err)
+	}
+
+	chain, err := profile.EffectiveChain(absPath)
+	if err != nil {
+		return err
+	}
+	if len(chain) == 0 {
+		return fmt.Errorf("no profile found. Run 'glovebox init --base' first")
+	}
+
+	outDir := filepath.Join(absPath, ".devcontainer")
+	jsonPath := filepath.Join(outDir, "devcontainer.json")
+	if err := checkExportTarget(jsonPath); err != nil {
+		return err
+	}
+
+	dockerfile, files, err := flattenChain(chain)
+	if err != nil {
+		return err
+	}
+
+	env, err := exportEnvironment(absPath, chain)
+	if err != nil {
+		return err
+	}
+	cfg, warnings := devcontainer.Export(env, "Dockerfile")
+	if d, _ := profile.EffectiveDotfiles(absPath); d != nil && d.EffectiveApply() == dotfiles.ApplyCreate && d.IsGit() {
+		warnings = append(warnings, "dotfiles: git sources applied at container creation aren't exported; use 'apply: build' to bake them into the image")
+	}
+
+	data, err := cfg.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", outDir, err)
+	}
+	if err := generator.StageContextFiles(outDir, files); err != nil {
+		return err
+	}
+	dockerfilePath := filepath.Join(outDir, "Dockerfile")
+	if err := os.WriteFile(dockerfilePath, []byte(dockerfile), 0644); err != nil {
+		return fmt.Errorf("writing Dockerfile: %w", err)
+	}
+	if err := os.WriteFile(jsonPath, data, 0644); err != nil {
+		return fmt.Errorf("writing devcontainer.json: %w", err)
+	}
+
+	colorGreen.Printf("✓ Wrote %s\n", collapsePath(jsonPath))
+	colorGreen.Printf("✓ Wrote %s\n", collapsePath(dockerfilePath))
+	if len(warnings) > 0 {
+		colorYellow.Println("\nNot exported:")
+		for _, w := range warnings {
+			fmt.Printf("  - %s\n", w)
+		}
+	}
+	return nil
+}
+
+// checkExportTarget refuses to overwrite a devcontainer.json that glovebox
+// didn't write, unless --force is given
+func checkExportTarget(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("checking %s: %w", path, err)
+	}
+	defer f.Close()
+
+	firstLine, _ := bufio.NewReader(f).ReadString('\n')
+	if strings.TrimSpace(firstLine) == devcontainer.ExportHeader || exportForce {
+		return nil
+	}
+	return fmt.Errorf("%s already exists and wasn't generated by glovebox. Use --force to overwrite it", collapsePath(path))
+}
+
+// flattenChain generates the Dockerfile of every image in a profile chain and
+// joins them into one multi-stage Dockerfile, along with the files to stage
+// in its build context.
+func flattenChain(chain []*profile.Profile) (string, []generator.ContextFile, error) {
+	var stages []generator.Stage
+	var files []generator.ContextFile
+	staged := make(map[string]string)
+
+	for i, p := range chain {
+		opts, dotfilesFiles, err := dotfilesInputs(p)
+		if err != nil {
+			return "", nil, err
+		}
+		dockerfile, err := generateDockerfile(p, chain[:i], opts)
+		if err != nil {
+			return "", nil, fmt.Errorf("generating Dockerfile for %s: %w", p.ImageName(), err)
+		}
+
+		var layerFiles []generator.ContextFile
+		if p.IsBase() {
+			layerFiles, err = generator.BaseContextFiles(p.Mods)
+		} else {
+			layerFiles, err = generator.ProjectContextFiles(p.Mods, profile.ChainMods(chain[:i]))
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("collecting mod files: %w", err)
+		}
+
+		for _, f := range append(layerFiles, dotfilesFiles...) {
+			if prev, ok := staged[f.ContextPath]; ok {
+				if prev != f.HostPath {
+					return "", nil, fmt.Errorf("%s and %s would both be staged as %s; only one profile in the chain can bake in dotfiles", prev, f.HostPath, f.ContextPath)
+				}
+				continue
+			}
+			staged[f.ContextPath] = f.HostPath
+			files = append(files, f)
+		}
+
+		stages = append(stages, generator.Stage{Name: stageName(p), Dockerfile: dockerfile})
+	}
+
+	dockerfile, err := generator.Flatten(stages)
+	if err != nil {
+		return "", nil, err
+	}
+	return dockerfile, files, nil
+}
+
+// stageName names a profile's stage in a flattened Dockerfile after its image
+func stageName(p *profile.Profile) string {
+	switch {
+	case p.IsBase():
+		return strings.TrimPrefix(p.ImageName(), "glovebox:")
+	case p.Name != "":
+		return strings.TrimPrefix(profile.NamedImageName(p.Name), "glovebox:")
+	default:
+		return "project"
+	}
+}
+
+// exportEnvironment collects the runtime settings glovebox applies when it
+// creates a container for the project
+func exportEnvironment(projectDir string, chain []*profile.Profile) (devcontainer.Environment, error) {
+	workspace := "/" + filepath.Base(projectDir)
+	env := devcontainer.Environment{
+		Name:      filepath.Base(projectDir),
+		Workspace: workspace,
+		Env: map[string]string{
+			"MISE_TRUSTED_CONFIG_PATHS": fmt.Sprintf("%s:%s/**", workspace, workspace),
+		},
+	}
+
+	passthrough, err := profile.EffectivePassthroughEnv(projectDir)
+	if err != nil {
+		return env, err
+	}
+	env.PassthroughEnv = passthrough
+
+	if env.Mounts, err = profile.ChainMounts(chain); err != nil {
+		return env, err
+	}
+	if env.Ports, err = profile.ChainPorts(chain); err != nil {
+		return env, err
+	}
+	if env.HostServices, err = profile.ChainHostServices(chain); err != nil {
+		return env, err
+	}
+	env.BuildArgs = profile.ChainBuildArgs(chain)
+	// The flattened Dockerfile mounts the secrets of every mod in the chain
+	if env.BuildSecrets, err = generator.BaseBuildSecrets(profile.ChainMods(chain)); err != nil {
+		return env, fmt.Errorf("collecting build secrets: %w", err)
+	}
+
+	mods, err := mod.LoadMultiple(profile.ChainMods(chain))
+	if err != nil {
+		return env, fmt.Errorf("loading mods: %w", err)
+	}
+	phases := make(map[string]bool)
+	for _, m := range mods {
+		for _, phase := range mod.HookPhases {
+			if m.Hook(phase) != "" {
+				phases[phase] = true
+			}
+		}
+	}
+
+	// Dotfiles applied at creation are mounted and installed by an on_create hook
+	if d, err := profile.EffectiveDotfiles(projectDir); err != nil {
+		return env, err
+	} else if d != nil && d.EffectiveApply() == dotfiles.ApplyCreate {
+		phases[mod.HookOnCreate] = true
+		if !d.IsGit() {
+			env.Mounts = append(env.Mounts, profile.Mount{Source: d.Source, Target: dotfiles.ContainerDir, ReadOnly: true})
+		}
+	}
+
+	for _, phase := range mod.HookPhases {
+		if phases[phase] {
+			env.HookPhases = append(env.HookPhases, phase)
+		}
+	}
+	return env, nil
+}
@@ -5,15 +5,18 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/joelhelbling/glovebox/internal/devcontainer"
 	"github.com/joelhelbling/glovebox/internal/mod"
 	"github.com/joelhelbling/glovebox/internal/profile"
 	"github.com/spf13/cobra"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
 )
 
 // osDescriptions provides human-friendly descriptions for OS options
@@ -24,11 +27,17 @@ var osDescriptions = map[string]string{
 }
 
 var (
-	initBase bool
+	initBase    bool
+	initProfile string
+	initExtends string
+	initName    string
+	initUseBase string
+
+	initFromDevcontainer bool
 )
 
 var initCmd = &cobra.Command{
-	Use:   "init",
+	Use:   "init [devcontainer-path]",
 	Short: "Initialize a new glovebox profile",
 	Long: `Initialize a new glovebox profile interactively.
 
@@ -39,6 +48,24 @@ shell, editor, and tools. Build it once with 'glovebox build --base'.
 Without --base, creates a project-specific profile (.glovebox/profile.yaml)
 that extends the base image with additional tools for that project.
 
+Use --profile <name> to create a named profile (~/.glovebox/profiles/<name>/)
+that sits between the base and your projects, e.g. a team layer shared by
+several projects. Use --extends <name> to build a project (or another named
+profile) on top of a named profile instead of directly on the base.
+
+Use --base --name <name> to create an additional base (~/.glovebox/bases/<name>/)
+built as glovebox:base-<name>, e.g. a minimal Alpine base alongside a full
+Ubuntu one. Projects choose it with --use-base <name> (or 'base: <name>').
+
+Use --from-devcontainer to create the project profile from an existing
+devcontainer.json instead of interactively. Glovebox looks in
+.devcontainer/devcontainer.json, .devcontainer.json and
+.devcontainer/<name>/devcontainer.json unless a path is given. Features and
+images map to glovebox mods, host env vars to passthrough_env, bind mounts
+to profile mounts, and env vars and lifecycle commands to a generated mod
+(.glovebox/mods/custom/devcontainer.yaml). Anything that can't be translated
+is listed so you can port it by hand.
+
 CUSTOMIZATION:
 
 After init, you can customize your environment in several ways:
@@ -50,22 +77,71 @@ After init, you can customize your environment in several ways:
 
 Custom mods can be project-local (.glovebox/mods/) or global (~/.glovebox/mods/).
 See 'glovebox mod --help' for more details.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runInit,
 }
 
 func init() {
 	initCmd.Flags().BoolVarP(&initBase, "base", "b", false, "Create base profile instead of project-local")
+	initCmd.Flags().StringVar(&initProfile, "profile", "", "Create a named profile (e.g. team-web) instead of project-local")
+	initCmd.Flags().StringVar(&initExtends, "extends", "", "Named profile to build on instead of the base")
+	initCmd.Flags().StringVar(&initName, "name", "", "With --base, create a named base (e.g. py)")
+	initCmd.Flags().StringVar(&initUseBase, "use-base", "", "Named base to build on instead of glovebox:base")
+	initCmd.Flags().BoolVar(&initFromDevcontainer, "from-devcontainer", false, "Create the project profile from a devcontainer.json")
 	rootCmd.AddCommand(initCmd)
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
+	if initBase && (initProfile != "" || initExtends != "" || initUseBase != "") {
+		return fmt.Errorf("--base cannot be combined with --profile, --extends or --use-base")
+	}
+	if len(args) > 0 && !initFromDevcontainer {
+		return fmt.Errorf("a path argument is only accepted with --from-devcontainer")
+	}
+	if initFromDevcontainer && (initBase || initProfile != "") {
+		return fmt.Errorf("--from-devcontainer creates a project profile; it cannot be combined with --base or --profile")
+	}
+	if initName != "" && !initBase {
+		return fmt.Errorf("--name can only be used with --base")
+	}
+	if initExtends != "" && initUseBase != "" {
+		return fmt.Errorf("--use-base cannot be combined with --extends; the base is chosen by the root of the chain")
+	}
+	if initUseBase != "" {
+		base, err := profile.LoadBase(initUseBase)
+		if err != nil {
+			return fmt.Errorf("loading base %q: %w", initUseBase, err)
+		}
+		if base == nil {
+			return fmt.Errorf("base %q not found. Run 'glovebox init --base --name %s' first", initUseBase, initUseBase)
+		}
+	}
+	if initExtends != "" {
+		parent, err := profile.LoadNamed(initExtends)
+		if err != nil {
+			return fmt.Errorf("loading profile %q: %w", initExtends, err)
+		}
+		if parent == nil {
+			return fmt.Errorf("profile %q not found. Run 'glovebox init --profile %s' first", initExtends, initExtends)
+		}
+		if initExtends == initProfile {
+			return fmt.Errorf("profile %q cannot extend itself", initProfile)
+		}
+	}
+
 	// Determine profile path
 	var profilePath string
-	if initBase {
+	if initProfile != "" {
 		var err error
-		profilePath, err = profile.GlobalPath()
+		profilePath, err = profile.NamedPath(initProfile)
 		if err != nil {
-			return fmt.Errorf("getting global profile path: %w", err)
+			return err
+		}
+	} else if initBase {
+		var err error
+		profilePath, err = profile.BasePath(initName)
+		if err != nil {
+			return fmt.Errorf("getting base profile path: %w", err)
 		}
 	} else {
 		cwd, err := os.Getwd()
@@ -98,6 +174,14 @@ func runInit(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if initFromDevcontainer {
+		var devcontainerPath string
+		if len(args) > 0 {
+			devcontainerPath = args[0]
+		}
+		return initFromDevcontainerFile(devcontainerPath, profilePath)
+	}
+
 	// Interactive mod selection
 	selectedMods, err := interactiveModSelection(initBase)
 	if err != nil {
@@ -111,6 +195,8 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 	// Create and save profile
 	p := profile.NewProfile()
+	p.Extends = initExtends
+	p.Base = initUseBase
 	p.Mods = selectedMods
 	p.UpdateContentHash() // Store hash to detect future manual edits
 
@@ -127,6 +213,107 @@ func runInit(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// initFromDevcontainerFile creates the project profile at profilePath, and a
+// generated mod if needed, from a devcontainer.json. path may name the file
+// or a directory to search; it defaults to the current directory.
+func initFromDevcontainerFile(path, profilePath string) error {
+	projectDir := filepath.Dir(filepath.Dir(profilePath))
+	if path == "" {
+		path = projectDir
+	}
+	if info, err := os.Stat(path); err != nil {
+		return fmt.Errorf("devcontainer path: %w", err)
+	} else if info.IsDir() {
+		if path, err = devcontainer.Find(path); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := devcontainer.Load(path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Importing %s\n", collapsePath(path))
+
+	baseOS, err := getBaseOS()
+	if err != nil {
+		return fmt.Errorf("could not determine OS from base profile: %w\nRun 'glovebox init --base' first to create a base profile", err)
+	}
+	chain, err := (&profile.Profile{Extends: initExtends, Base: initUseBase}).Ancestors()
+	if err != nil {
+		return err
+	}
+	inherited := make(map[string]bool)
+	for _, id := range profile.ChainMods(chain) {
+		inherited[id] = true
+	}
+
+	result := devcontainer.Translate(cfg, "/"+filepath.Base(projectDir))
+	warnings := result.Warnings
+
+	p := profile.NewProfile()
+	p.Extends = initExtends
+	p.Base = initUseBase
+	p.PassthroughEnv = result.PassthroughEnv
+	p.Mounts = result.Mounts
+	p.Ports = result.Ports
+	for _, id := range result.Mods {
+		resolved, _, err := resolveModID(id, baseOS)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", id, strings.ReplaceAll(err.Error(), "\n", " ")))
+			continue
+		}
+		if !inherited[resolved] {
+			p.AddMod(resolved)
+		}
+	}
+
+	if result.Mod != nil {
+		modPath := filepath.Join(projectDir, profile.ProjectProfileDir, "mods", devcontainer.GeneratedModID+".yaml")
+		if err := writeGeneratedMod(modPath, result.Mod, path); err != nil {
+			return err
+		}
+		p.AddMod(devcontainer.GeneratedModID)
+		colorGreen.Printf("✓ Mod created at %s\n", modPath)
+	}
+
+	p.UpdateContentHash()
+	if err := p.SaveTo(profilePath); err != nil {
+		return fmt.Errorf("saving profile: %w", err)
+	}
+	colorGreen.Printf("✓ Profile created at %s\n", profilePath)
+
+	if len(p.Mods) > 0 {
+		fmt.Printf("  Mods: %s\n", strings.Join(p.Mods, ", "))
+	}
+	if len(warnings) > 0 {
+		colorYellow.Println("\nNot translated (port these by hand if you need them):")
+		for _, w := range warnings {
+			fmt.Printf("  - %s\n", w)
+		}
+	}
+
+	showNextSteps(false)
+	return nil
+}
+
+// writeGeneratedMod saves a mod generated from a devcontainer.json
+func writeGeneratedMod(path string, m *mod.Mod, source string) error {
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("encoding mod: %w", err)
+	}
+	header := fmt.Sprintf("# Generated by 'glovebox init --from-devcontainer' from %s\n", collapsePath(source))
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating mod directory: %w", err)
+	}
+	if err := os.WriteFile(path, append([]byte(header), data...), 0644); err != nil {
+		return fmt.Errorf("writing mod: %w", err)
+	}
+	return nil
+}
+
 // offerPostInitOptions prompts the user with optional next steps after profile creation
 func offerPostInitOptions(reader *bufio.Reader, profilePath string, isBase bool) {
 	fmt.Println("\nWhat would you like to do next?")
@@ -216,6 +403,11 @@ func runBuildCommand(isBase bool) {
 	args := []string{"build"}
 	if isBase {
 		args = append(args, "--base")
+		if initName != "" {
+			args = append(args, "--name", initName)
+		}
+	} else if initProfile != "" {
+		args = append(args, "--profile", initProfile)
 	}
 
 	fmt.Println("Building image...")
@@ -231,9 +423,15 @@ func runBuildCommand(isBase bool) {
 // showNextSteps displays the traditional next steps message
 func showNextSteps(isBase bool) {
 	fmt.Println("\nNext steps:")
-	if isBase {
+	if isBase && initName != "" {
+		fmt.Printf("  glovebox build --base --name %s      # Build %s\n", initName, profile.BaseImageFor(initName))
+		fmt.Printf("  glovebox init --use-base %s          # Create a project profile on top of it\n", initName)
+	} else if isBase {
 		fmt.Println("  glovebox build --base   # Build the base image (glovebox:base)")
 		fmt.Println("  glovebox run            # Run glovebox in any directory")
+	} else if initProfile != "" {
+		fmt.Printf("  glovebox build --profile %s          # Build %s\n", initProfile, profile.NamedImageName(initProfile))
+		fmt.Printf("  glovebox init --extends %s           # Create a project profile on top of it\n", initProfile)
 	} else {
 		fmt.Println("  glovebox build          # Build the project image")
 		fmt.Println("  glovebox run            # Run glovebox in this directory")
@@ -364,17 +562,20 @@ func interactiveModSelection(isBase bool) ([]string, error) {
 }
 
 // getBaseOS retrieves the OS from the global (base) profile
+// getBaseOS returns the OS of the image a new profile will build on, taking
+// --extends and --use-base into account
 func getBaseOS() (string, error) {
-	globalProfile, err := profile.LoadGlobal()
+	newProfile := &profile.Profile{Extends: initExtends, Base: initUseBase}
+	chain, err := newProfile.Ancestors()
 	if err != nil {
-		return "", fmt.Errorf("loading global profile: %w", err)
+		return "", err
 	}
-	if globalProfile == nil {
+	if len(chain) == 0 {
 		return "", fmt.Errorf("no global profile found")
 	}
 
-	// Find the OS mod in the profile
-	for _, modID := range globalProfile.Mods {
+	// Find the OS mod in the profile chain
+	for _, modID := range profile.ChainMods(chain) {
 		m, err := mod.Load(modID)
 		if err != nil {
 			continue
@@ -384,7 +585,7 @@ func getBaseOS() (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("no OS mod found in global profile")
+	return "", fmt.Errorf("no OS mod found in base profile")
 }
 
 // selectOS prompts the user to select an operating system
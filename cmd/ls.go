@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joelhelbling/glovebox/internal/inventory"
+	"github.com/joelhelbling/glovebox/internal/output"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+	"github.com/spf13/cobra"
+)
+
+var lsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List glovebox projects on this machine",
+	Long: `List every glovebox project on this machine, found through its
+container or project image.
+
+For each project this shows the host directory (marked missing when it no
+longer exists), the image with its size and age, the container state, when
+the container was last used and how many uncommitted changes it holds.
+
+Supports --output json|yaml for machine-readable output.`,
+	Args: cobra.NoArgs,
+	RunE: runLs,
+}
+
+func init() {
+	addOutputFlag(lsCmd)
+	rootCmd.AddCommand(lsCmd)
+}
+
+func runLs(cmd *cobra.Command, args []string) error {
+	rt := runtimeOf(cmd)
+
+	if err := output.ValidateFormat(outputFormat); err != nil {
+		return err
+	}
+
+	projects, err := inventory.Collect(rt)
+	if err != nil {
+		return fmt.Errorf("listing projects: %w", err)
+	}
+
+	countChanges(rt, projects)
+
+	if output.Structured(outputFormat) {
+		if projects == nil {
+			projects = []inventory.Project{}
+		}
+		return output.Write(os.Stdout, outputFormat, "ls", struct {
+			Projects []inventory.Project `json:"projects" yaml:"projects"`
+		}{projects})
+	}
+
+	if len(projects) == 0 {
+		fmt.Println("No glovebox projects found.")
+		return nil
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tPATH\tIMAGE\tSIZE\tBUILT\tCONTAINER\tLAST USED\tCHANGES")
+	for _, p := range projects {
+		path := p.Path
+		switch {
+		case path == "":
+			path = "-"
+		case !p.PathExists:
+			path += " (missing)"
+		}
+		image, size, built := "-", "-", "-"
+		if p.Image != "" {
+			image = p.Image
+			size = formatSize(p.ImageSize)
+		}
+		if p.ImageCreated != nil {
+			built = formatAge(now, *p.ImageCreated)
+		}
+		lastUsed := "-"
+		if p.LastUsed != nil {
+			lastUsed = formatAge(now, *p.LastUsed)
+		}
+		changes := "-"
+		if p.Changes != nil {
+			changes = fmt.Sprintf("%d", *p.Changes)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			p.Name(), path, image, size, built, p.State, lastUsed, changes)
+	}
+	return w.Flush()
+}
+
+// formatSize renders a byte count for display, e.g. "1.2 GB"
+func formatSize(bytes int64) string {
+	const unit = 1000
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "kMGTPE"[exp])
+}
+
+// formatAge renders how long ago t was, e.g. "3 days ago"
+func formatAge(now, t time.Time) string {
+	d := now.Sub(t)
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", unit)
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d.Minutes()), "minute")
+	case d < 24*time.Hour:
+		return plural(int(d.Hours()), "hour")
+	case d < 30*24*time.Hour:
+		return plural(int(d.Hours()/24), "day")
+	case d < 365*24*time.Hour:
+		return plural(int(d.Hours()/24/30), "month")
+	default:
+		return plural(int(d.Hours()/24/365), "year")
+	}
+}
+
+// countChanges fills in the uncommitted changes of the projects'
+// containers, where the runtime can tell
+func countChanges(rt runtime.Runtime, projects []inventory.Project) {
+	if !rt.Capabilities().SupportsDiff {
+		return
+	}
+	for i, p := range projects {
+		if p.Container == "" {
+			continue
+		}
+		if changes, err := getContainerChanges(rt, p.Container); err == nil {
+			n := len(filterNoise(changes))
+			projects[i].Changes = &n
+		}
+	}
+}
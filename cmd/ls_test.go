@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestLsDfOutput(t *testing.T) {
+	env := newTestEnv(t)
+	env.saveGlobal("os/ubuntu")
+	env.mustRun("", "run")
+
+	type lsDoc struct {
+		SchemaVersion int    `json:"schema_version"`
+		Kind          string `json:"kind"`
+		Data          struct {
+			Projects []struct {
+				Path  string `json:"path"`
+				State string `json:"state"`
+			} `json:"projects"`
+		} `json:"data"`
+	}
+	var ls lsDoc
+	out := env.mustRun("", "ls", "-o", "json")
+	if err := json.Unmarshal([]byte(out), &ls); err != nil {
+		t.Fatalf("parsing ls output: %v\n%s", err, out)
+	}
+	if ls.SchemaVersion != 1 || ls.Kind != "ls" || len(ls.Data.Projects) != 1 || ls.Data.Projects[0].Path != env.project {
+		t.Errorf("ls output = %+v", ls)
+	}
+
+	var doc struct {
+		Kind string `yaml:"kind"`
+		Data struct {
+			Containers []struct {
+				Name string `yaml:"name"`
+			} `yaml:"containers"`
+			Total *int64 `yaml:"total"`
+		} `yaml:"data"`
+	}
+	out = env.mustRun("", "df", "-o", "yaml")
+	if err := yaml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("parsing df output: %v\n%s", err, out)
+	}
+	if doc.Kind != "df" || len(doc.Data.Containers) != 1 || doc.Data.Total == nil {
+		t.Errorf("df output = %+v", doc)
+	}
+	if !strings.Contains(env.mustRun("", "df"), "Total:") {
+		t.Error("df without --output should print the table")
+	}
+}
@@ -9,11 +9,16 @@ import (
 	"strings"
 
 	"github.com/joelhelbling/glovebox/internal/mod"
+	"github.com/joelhelbling/glovebox/internal/output"
 	"github.com/joelhelbling/glovebox/internal/ui"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
-var modGlobal bool
+var (
+	modGlobal   bool
+	modResolved bool
+)
 
 var modCmd = &cobra.Command{
 	Use:   "mod",
@@ -61,7 +66,11 @@ This is useful for inspecting mods or creating custom overrides:
   glovebox mod cat ai/claude-code > .glovebox/mods/ai/claude-code.yaml
 
 The command respects the mod load order (local > global > embedded),
-so it shows the version that would actually be used.`,
+so it shows the version that would actually be used.
+
+With --resolved, shows the mod as glovebox uses it instead: where it was
+loaded from, what it provides and the mods it pulls in. Combine with
+--output json or --output yaml for machine-readable output.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runModCat,
 }
@@ -77,12 +86,17 @@ This shows built-in mods plus any custom mods found in:
   .glovebox/mods/         Project-local custom mods
 
 To create a custom mod, run:
-  glovebox mod create <name>`,
+  glovebox mod create <name>
+
+Use --output json or --output yaml for machine-readable output.`,
 	RunE: runModList,
 }
 
 func init() {
 	modCreateCmd.Flags().BoolVarP(&modGlobal, "global", "g", false, "Create in global mods directory")
+	modCatCmd.Flags().BoolVar(&modResolved, "resolved", false, "Show the mod's source, provides and dependencies")
+	addOutputFlag(modCatCmd)
+	addOutputFlag(modListCmd)
 	modCmd.AddCommand(modCreateCmd)
 	modCmd.AddCommand(modCatCmd)
 	modCmd.AddCommand(modListCmd)
@@ -169,6 +183,22 @@ category: %s
 # env:
 #   MY_VAR: value
 
+# Files to place in the image (optional)
+# Use content for inline text, or source for a path relative to this mod file
+# files:
+#   - dest: ~/.tmux.conf
+#     content: |
+#       set -g mouse on
+#   - source: config.fish
+#     dest: ~/.config/fish/config.fish
+#     owner: dev:dev
+#     mode: "0644"
+
+# Commands run inside the container as the dev user, in the workspace (optional)
+# on_create runs once per container, on_start on every start, on_exit when the shell exits
+# on_create: |
+#   mise install
+
 # Set as default shell (optional, use full path)
 # user_shell: /usr/bin/bash
 `, modName, category)
@@ -195,6 +225,28 @@ category: %s
 
 func runModCat(cmd *cobra.Command, args []string) error {
 	id := args[0]
+	if err := output.ValidateFormat(outputFormat); err != nil {
+		return err
+	}
+
+	if modResolved {
+		resolved, err := mod.Resolve(id)
+		if err != nil {
+			return err
+		}
+		if output.Structured(outputFormat) {
+			return output.Write(os.Stdout, outputFormat, "mod", resolved)
+		}
+		data, err := yaml.Marshal(resolved)
+		if err != nil {
+			return fmt.Errorf("encoding mod: %w", err)
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if output.Structured(outputFormat) {
+		return fmt.Errorf("--output %s requires --resolved", outputFormat)
+	}
 
 	data, _, err := mod.LoadRaw(id)
 	if err != nil {
@@ -207,12 +259,16 @@ func runModCat(cmd *cobra.Command, args []string) error {
 }
 
 func runModList(cmd *cobra.Command, args []string) error {
+	if err := output.ValidateFormat(outputFormat); err != nil {
+		return err
+	}
+
 	modsByCategory, err := mod.ListAll()
 	if err != nil {
 		return fmt.Errorf("listing mods: %w", err)
 	}
 
-	if len(modsByCategory) == 0 {
+	if len(modsByCategory) == 0 && !output.Structured(outputFormat) {
 		fmt.Println("No mods found.")
 		return nil
 	}
@@ -359,6 +415,12 @@ func runModList(cmd *cobra.Command, args []string) error {
 		categories = append(categories, category)
 	}
 
+	if output.Structured(outputFormat) {
+		return output.Write(os.Stdout, outputFormat, "mod-list", struct {
+			Categories []ui.ModCategory `json:"categories" yaml:"categories"`
+		}{categories})
+	}
+
 	// Render
 	modList := ui.NewModList()
 	modList.Print(categories)
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/joelhelbling/glovebox/internal/generator"
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/output"
+	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+	"github.com/spf13/cobra"
+)
+
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "Show base images whose OS image has moved upstream",
+	Long: `Check whether the OS image tags base images are pinned to, such as
+ubuntu:24.04, now point to a newer image in their registry.
+
+For each outdated base this lists the mods that would be reinstalled on the
+new image and the glovebox images built on the base, which are rebuilt after
+it. Update a base with 'glovebox build --base --update'.
+
+Use --output json or --output yaml for machine-readable output.`,
+	Args: cobra.NoArgs,
+	RunE: runOutdated,
+}
+
+func init() {
+	addOutputFlag(outdatedCmd)
+	rootCmd.AddCommand(outdatedCmd)
+}
+
+// baseUpdate is the upstream state of the OS image a base image is pinned to
+type baseUpdate struct {
+	Image    string   `json:"image" yaml:"image"`
+	From     string   `json:"from,omitempty" yaml:"from,omitempty"`
+	Pinned   string   `json:"pinned,omitempty" yaml:"pinned,omitempty"`   // digest the base is built from; "" when not pinned yet
+	Current  string   `json:"current,omitempty" yaml:"current,omitempty"` // digest the tag points to now
+	Outdated bool     `json:"outdated" yaml:"outdated"`
+	Mods     []string `json:"mods,omitempty" yaml:"mods,omitempty"`     // reinstalled on the new image
+	Images   []string `json:"images,omitempty" yaml:"images,omitempty"` // glovebox images built on the base
+	Update   string   `json:"update,omitempty" yaml:"update,omitempty"` // command that updates the base
+	Error    string   `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func runOutdated(cmd *cobra.Command, args []string) error {
+	rt := runtimeOf(cmd)
+
+	if err := output.ValidateFormat(outputFormat); err != nil {
+		return err
+	}
+
+	names, err := profile.ListBases()
+	if err != nil {
+		return fmt.Errorf("listing bases: %w", err)
+	}
+	var updates []baseUpdate
+	for _, name := range append([]string{""}, names...) {
+		p, err := profile.LoadBase(name)
+		if err != nil {
+			return fmt.Errorf("loading base profile: %w", err)
+		}
+		if p != nil {
+			updates = append(updates, checkBaseUpdate(rt, p, name))
+		}
+	}
+
+	if output.Structured(outputFormat) {
+		return output.Write(os.Stdout, outputFormat, "outdated", struct {
+			Bases []baseUpdate `json:"bases" yaml:"bases"`
+		}{updates})
+	}
+
+	if len(updates) == 0 {
+		fmt.Println("No base profiles found. Run 'glovebox init --global' to create one.")
+		return nil
+	}
+	for _, u := range updates {
+		printBaseUpdate(u)
+	}
+	return nil
+}
+
+// checkBaseUpdate looks up whether the tag a base image is pinned to has
+// moved, and what updating it would rebuild
+func checkBaseUpdate(rt runtime.Runtime, p *profile.Profile, name string) baseUpdate {
+	u := baseUpdate{Image: profile.BaseImageFor(name), Update: "glovebox build --base --update"}
+	if name != "" {
+		u.Update = fmt.Sprintf("glovebox build --base --name %s --update", name)
+	}
+
+	from, err := generator.BaseImage(p.Mods)
+	if err != nil {
+		u.Error = err.Error()
+		return u
+	}
+	u.From = from
+	if _, pinned, ok := strings.Cut(from, "@"); ok {
+		// The OS mod pins the image itself
+		u.Pinned, u.Update = pinned, ""
+		return u
+	}
+	if p.Build.From == from {
+		u.Pinned = p.Build.FromDigest
+	}
+
+	current, err := rt.ResolveImageDigest(from)
+	if err != nil {
+		u.Error = err.Error()
+		return u
+	}
+	u.Current = current
+	if u.Pinned == "" || u.Pinned == current {
+		if u.Pinned != "" {
+			u.Update = ""
+		}
+		return u
+	}
+
+	u.Outdated = true
+	u.Mods = p.Mods
+	u.Images, err = imagesBuiltOn(rt, u.Image)
+	if err != nil {
+		u.Error = err.Error()
+	}
+	return u
+}
+
+// imagesBuiltOn lists the glovebox images built on an image, directly or
+// through other images, from their parent labels
+func imagesBuiltOn(rt runtime.Runtime, imageName string) ([]string, error) {
+	images, err := rt.ListImages(runtime.ListFilter{Labels: labels.Selector("")})
+	if err != nil {
+		return nil, fmt.Errorf("listing images: %w", err)
+	}
+	children := make(map[string][]string)
+	for _, name := range images {
+		info, err := rt.InspectImage(name)
+		if err != nil {
+			continue
+		}
+		if parent := info.Labels[labels.Parent]; parent != "" {
+			children[parent] = append(children[parent], name)
+		}
+	}
+
+	var result []string
+	queue := []string{imageName}
+	for len(queue) > 0 {
+		next := children[queue[0]]
+		queue = append(queue[1:], next...)
+		result = append(result, next...)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func printBaseUpdate(u baseUpdate) {
+	switch {
+	case u.Error != "" && !u.Outdated:
+		colorYellow.Printf("⚠ %s: could not check %s: %s\n", u.Image, u.From, u.Error)
+	case u.Current == "" && u.Pinned != "":
+		fmt.Printf("%s  %s is pinned by its OS mod\n", u.Image, u.From)
+	case u.Pinned == "":
+		fmt.Printf("%s  %s is not pinned yet (now %s)\n", u.Image, u.From, shortDigest(u.Current))
+		colorDim.Printf("  Run '%s' to pin it.\n", u.Update)
+	case !u.Outdated:
+		colorGreen.Printf("✓ %s  %s is up to date (%s)\n", u.Image, u.From, shortDigest(u.Current))
+	default:
+		colorYellow.Printf("%s  %s has moved: %s → %s\n", u.Image, u.From, shortDigest(u.Pinned), shortDigest(u.Current))
+		fmt.Printf("  Mods reinstalled on the new image: %s\n", strings.Join(u.Mods, ", "))
+		if len(u.Images) > 0 {
+			fmt.Printf("  Images built on it, rebuilt after it: %s\n", strings.Join(u.Images, ", "))
+		}
+		if u.Error != "" {
+			colorYellow.Printf("  ⚠ %s\n", u.Error)
+		}
+		colorDim.Printf("  Run '%s' to update.\n", u.Update)
+	}
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/joelhelbling/glovebox/internal/profile"
+)
+
+const (
+	ubuntuDigest1 = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	ubuntuDigest2 = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+// baseDockerfile reads the generated Dockerfile of the global profile
+func baseDockerfile(t *testing.T) string {
+	t.Helper()
+	p, err := profile.LoadGlobal()
+	if err != nil || p == nil {
+		t.Fatalf("loading global profile: %v", err)
+	}
+	data, err := os.ReadFile(p.DockerfilePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestPinBaseImage(t *testing.T) {
+	t.Run("pins the OS image and keeps the pin when the tag moves", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.rt.Registry["ubuntu:24.04"] = ubuntuDigest1
+
+		out := env.mustRun("", "build", "--base")
+		if !strings.Contains(out, "Pinning ubuntu:24.04 to sha256:111111111111") {
+			t.Errorf("output = %q", out)
+		}
+		if !strings.Contains(baseDockerfile(t), "FROM ubuntu:24.04@"+ubuntuDigest1+"\n") {
+			t.Errorf("Dockerfile not pinned:\n%s", baseDockerfile(t))
+		}
+
+		env.rt.Registry["ubuntu:24.04"] = ubuntuDigest2
+		env.mustRun("", "build", "--base")
+		if !strings.Contains(baseDockerfile(t), ubuntuDigest1) {
+			t.Error("a rebuild should keep the pinned digest")
+		}
+
+		out = env.mustRun("", "build", "--base", "--update")
+		if !strings.Contains(out, "Updating ubuntu:24.04 from sha256:111111111111 to sha256:222222222222") {
+			t.Errorf("output = %q", out)
+		}
+		if !strings.Contains(baseDockerfile(t), ubuntuDigest2) {
+			t.Error("--update should pin the current digest")
+		}
+		p, _ := profile.LoadGlobal()
+		if p.Build.From != "ubuntu:24.04" || p.Build.FromDigest != ubuntuDigest2 {
+			t.Errorf("build info = %+v", p.Build)
+		}
+	})
+
+	t.Run("builds from the tag when it can't be resolved", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+
+		out := env.mustRun("", "build", "--base")
+		if !strings.Contains(out, "Could not pin ubuntu:24.04") {
+			t.Errorf("output = %q", out)
+		}
+		if !strings.Contains(baseDockerfile(t), "FROM ubuntu:24.04\n") {
+			t.Errorf("Dockerfile:\n%s", baseDockerfile(t))
+		}
+	})
+}
+
+func TestOutdated(t *testing.T) {
+	env := newTestEnv(t)
+	env.saveGlobal("os/ubuntu", "tools/homebrew-ubuntu")
+	project := env.saveProject("tools/mise")
+	env.rt.Registry["ubuntu:24.04"] = ubuntuDigest1
+	env.mustRun("", "build")
+
+	out := env.mustRun("", "outdated")
+	if !strings.Contains(out, "✓ glovebox:base  ubuntu:24.04 is up to date (sha256:111111111111)") {
+		t.Errorf("output = %q", out)
+	}
+
+	env.rt.Registry["ubuntu:24.04"] = ubuntuDigest2
+	out = env.mustRun("", "outdated")
+	for _, want := range []string{
+		"glovebox:base  ubuntu:24.04 has moved: sha256:111111111111 → sha256:222222222222",
+		"Mods reinstalled on the new image: os/ubuntu, tools/homebrew-ubuntu",
+		"Images built on it, rebuilt after it: " + project.ImageName(),
+		"glovebox build --base --update",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	out = env.mustRun("", "outdated", "-o", "json")
+	var doc struct {
+		Data struct {
+			Bases []baseUpdate `json:"bases"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("parsing output: %v\n%s", err, out)
+	}
+	if len(doc.Data.Bases) != 1 || !doc.Data.Bases[0].Outdated || doc.Data.Bases[0].Current != ubuntuDigest2 {
+		t.Errorf("bases = %+v", doc.Data.Bases)
+	}
+}
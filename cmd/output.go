@@ -0,0 +1,14 @@
+package cmd
+
+import (
+	"github.com/joelhelbling/glovebox/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// outputFormat is the --output flag of commands with machine-readable output
+var outputFormat string
+
+// addOutputFlag gives a command the --output flag
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", output.Text, "Output format: text, json or yaml")
+}
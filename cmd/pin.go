@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+)
+
+// pinBaseImage returns the digest to pin a base image's OS image to: the one
+// recorded when it was pinned, so rebuilds use the same image, or else the
+// one its tag points to now. The pin is recorded in the profile's build
+// info. "" leaves the tag floating, when the OS mod pins it itself or the
+// tag can't be resolved.
+func pinBaseImage(rt runtime.Runtime, p *profile.Profile, from string) string {
+	if strings.Contains(from, "@") {
+		return ""
+	}
+	pinned := p.Build.From == from && p.Build.FromDigest != ""
+	if pinned && !buildUpdate {
+		return p.Build.FromDigest
+	}
+
+	current, err := rt.ResolveImageDigest(from)
+	if err != nil {
+		if pinned {
+			colorYellow.Printf("⚠ Could not check %s for updates: %v\n", from, err)
+			return p.Build.FromDigest
+		}
+		if !errors.Is(err, runtime.ErrNotSupported) {
+			colorYellow.Printf("⚠ Could not pin %s to a digest, building from the tag: %v\n", from, err)
+		}
+		p.Build.From, p.Build.FromDigest = "", ""
+		return ""
+	}
+
+	switch {
+	case pinned && current != p.Build.FromDigest:
+		fmt.Printf("Updating %s from %s to %s\n", from, shortDigest(p.Build.FromDigest), shortDigest(current))
+	case pinned:
+		fmt.Printf("%s is up to date (%s)\n", from, shortDigest(current))
+	default:
+		fmt.Printf("Pinning %s to %s\n", from, shortDigest(current))
+	}
+	p.Build.From, p.Build.FromDigest = from, current
+	return current
+}
+
+// shortDigest abbreviates a digest for display
+func shortDigest(d string) string {
+	algorithm, hex, ok := strings.Cut(d, ":")
+	if !ok || len(hex) <= 12 {
+		return d
+	}
+	return algorithm + ":" + hex[:12]
+}
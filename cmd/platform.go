@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/joelhelbling/glovebox/internal/mod"
+	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+)
+
+// targetPlatforms returns the platforms to build a profile chain's image
+// for: those given with --platform, or else the chain's platform setting.
+// None means the runtime's default.
+func targetPlatforms(chain []*profile.Profile) ([]string, error) {
+	list := buildPlatform
+	if list == "" {
+		list = profile.ChainPlatform(chain)
+	}
+	return mod.ParsePlatforms(list)
+}
+
+// runPlatform returns the platform to run a project's image as: the one it
+// is built for, when that is a single platform. Otherwise the runtime picks.
+func runPlatform(projectDir string) string {
+	list, err := profile.EffectivePlatform(projectDir)
+	if err != nil {
+		return ""
+	}
+	platforms, err := mod.ParsePlatforms(list)
+	if err != nil || len(platforms) != 1 {
+		return ""
+	}
+	return platforms[0]
+}
+
+// platformMismatch explains why an image can't serve the given platforms,
+// or returns "" when it can. An image serves them when it is built for any
+// of them: Docker reports just one platform of a multi-platform image.
+// Images whose platforms the runtime doesn't report are assumed to serve.
+func platformMismatch(rt runtime.Runtime, imageName string, platforms []string) (string, error) {
+	if len(platforms) == 0 {
+		return "", nil
+	}
+	info, err := rt.InspectImage(imageName)
+	if err != nil {
+		return "", fmt.Errorf("inspecting image %s: %w", imageName, err)
+	}
+	if len(info.Platforms) == 0 {
+		return "", nil
+	}
+	for _, want := range platforms {
+		if slices.ContainsFunc(info.Platforms, func(have string) bool { return mod.PlatformMatches(want, have) }) {
+			return "", nil
+		}
+	}
+	return fmt.Sprintf("%s is built for %s, not %s", imageName, strings.Join(info.Platforms, ", "), strings.Join(platforms, ", ")), nil
+}
+
+// formatPlatforms shows an image's platforms for status
+func formatPlatforms(platforms []string) string {
+	if len(platforms) == 0 {
+		return "unknown"
+	}
+	return strings.Join(platforms, ", ")
+}
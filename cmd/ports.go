@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+)
+
+// hostAccess is how a project's container and the host reach each other:
+// ports published on the host and host services reached from the container
+type hostAccess struct {
+	Ports        []profile.Port
+	HostServices map[string]int
+}
+
+// loadHostAccess collects the ports and host services of a project's chain
+func loadHostAccess(projectDir string) (hostAccess, error) {
+	var access hostAccess
+	var err error
+	if access.Ports, err = profile.EffectivePorts(projectDir); err != nil {
+		return access, err
+	}
+	if access.HostServices, err = profile.EffectiveHostServices(projectDir); err != nil {
+		return access, err
+	}
+	return access, nil
+}
+
+// hostAlias returns the hostname the container reaches the host by, or ""
+// when the project declares no host services
+func (a hostAccess) hostAlias() string {
+	if len(a.HostServices) == 0 {
+		return ""
+	}
+	return profile.HostAlias
+}
+
+func (a hostAccess) portMappings() []runtime.PortMapping {
+	var mappings []runtime.PortMapping
+	for _, p := range a.Ports {
+		mappings = append(mappings, runtime.PortMapping{
+			HostIP:        p.HostIP,
+			HostPort:      p.HostPort,
+			ContainerPort: p.ContainerPort,
+			Protocol:      p.Protocol,
+		})
+	}
+	return mappings
+}
+
+// labels records the creation-time settings on the container
+func (a hostAccess) labels() map[string]string {
+	return map[string]string{
+		labels.Ports:     a.portsLabel(),
+		labels.HostAlias: a.hostAlias(),
+	}
+}
+
+func (a hostAccess) portsLabel() string {
+	specs := make([]string, 0, len(a.Ports))
+	for _, p := range a.Ports {
+		specs = append(specs, p.String())
+	}
+	return strings.Join(specs, ",")
+}
+
+// portSummaries describes each published port for display, e.g. "localhost:8080 → 3000"
+func (a hostAccess) portSummaries() []string {
+	var summaries []string
+	for _, p := range a.Ports {
+		host := p.HostIP
+		if host == "127.0.0.1" {
+			host = "localhost"
+		}
+		summary := fmt.Sprintf("%s:%d → %d", host, p.HostPort, p.ContainerPort)
+		if p.Protocol != "tcp" {
+			summary += "/" + p.Protocol
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// hostServiceSummaries describes each host service for display, e.g.
+// "ollama (host.glovebox.internal:11434)"
+func (a hostAccess) hostServiceSummaries() []string {
+	var summaries []string
+	for _, name := range profile.HostServiceNames(a.HostServices) {
+		summaries = append(summaries, fmt.Sprintf("%s (%s:%d)", name, profile.HostAlias, a.HostServices[name]))
+	}
+	return summaries
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/joelhelbling/glovebox/internal/inventory"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneDryRun             bool
+	pruneForce              bool
+	pruneUnusedDays         int
+	pruneIncludeUncommitted bool
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove glovebox containers and images that are no longer needed",
+	Long: `Remove glovebox containers and images across all projects that are no
+longer needed:
+
+  - Containers and project images whose project directory no longer exists
+  - Stopped containers unused for --unused-days days, when given
+  - Old image generations left behind when an image was rebuilt
+  - Project images built on an outdated base or profile image, when no
+    container uses them (the next run rebuilds them)
+
+Running containers, and images they use, are never removed. Nor are
+containers with uncommitted changes, or whose changes can't be counted,
+unless --include-uncommitted is given: removing them loses that work.
+Sidecar services of removed containers go too; their data volumes are kept.
+
+Use --dry-run to see what would be removed and how much space it would
+reclaim without removing anything.`,
+	Args: cobra.NoArgs,
+	RunE: runPrune,
+}
+
+func init() {
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Show what would be removed without removing it")
+	pruneCmd.Flags().BoolVarP(&pruneForce, "force", "f", false, "Skip confirmation prompt")
+	pruneCmd.Flags().IntVar(&pruneUnusedDays, "unused-days", 0, "Remove stopped containers unused for this many days (0 keeps them)")
+	pruneCmd.Flags().BoolVar(&pruneIncludeUncommitted, "include-uncommitted", false, "Also remove containers with uncommitted changes")
+	rootCmd.AddCommand(pruneCmd)
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	rt := runtimeOf(cmd)
+
+	green := color.New(color.FgGreen)
+	yellow := color.New(color.FgYellow)
+
+	projects, err := inventory.Collect(rt)
+	if err != nil {
+		return fmt.Errorf("listing projects: %w", err)
+	}
+	superseded, err := inventory.Superseded(rt)
+	if err != nil {
+		return fmt.Errorf("listing superseded images: %w", err)
+	}
+
+	helpers, err := inventory.Helpers(rt)
+	if err != nil {
+		return fmt.Errorf("listing helper containers: %w", err)
+	}
+	countChanges(rt, projects)
+
+	candidates, kept := inventory.Plan(projects, superseded, helpers, inventory.PruneOptions{
+		UnusedFor:          time.Duration(pruneUnusedDays) * 24 * time.Hour,
+		IncludeUncommitted: pruneIncludeUncommitted,
+		Now:                time.Now(),
+	})
+	if len(kept) > 0 {
+		yellow.Printf("Keeping %d container(s) that may hold uncommitted work (use --include-uncommitted to remove them):\n", len(kept))
+		for _, c := range kept {
+			fmt.Printf("  %s (%s)\n", c.Name, changesText(c))
+		}
+		fmt.Println()
+	}
+	if len(candidates) == 0 {
+		fmt.Println("Nothing to prune.")
+		return nil
+	}
+
+	if pruneDryRun {
+		fmt.Println("Would remove:")
+	} else {
+		fmt.Println("Will remove:")
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, c := range candidates {
+		size := ""
+		if c.Size > 0 {
+			size = formatSize(c.Size)
+		}
+		project := c.Project
+		if project == "" {
+			project = "-"
+		}
+		reason := c.Reason
+		if c.Kind == inventory.KindContainer {
+			reason += ", " + changesText(c)
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", c.Kind, c.Name, project, size, reason)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\nReclaimable: up to %s\n", formatSize(inventory.Reclaimable(candidates)))
+
+	if pruneDryRun {
+		return nil
+	}
+	if !pruneForce {
+		fmt.Print("\nContinue? [y/N] ")
+		if !confirmPrompt() {
+			fmt.Println("Aborted.")
+			return nil
+		}
+	}
+
+	var reclaimed int64
+	for _, c := range candidates {
+		switch c.Kind {
+		case inventory.KindContainer:
+			if err := removeContainer(rt, c.Name, green); err != nil {
+				yellow.Printf("Warning: could not remove container %s: %v\n", c.Name, err)
+				continue
+			}
+			removeServices(rt, c.Name, false, green, yellow)
+		case inventory.KindImage:
+			if err := removeImage(rt, c.Name, green); err != nil {
+				yellow.Printf("Warning: could not remove image %s: %v\n", c.Name, err)
+				continue
+			}
+			reclaimed += c.Size
+		}
+	}
+	fmt.Printf("\nReclaimed up to %s\n", formatSize(reclaimed))
+	return nil
+}
+
+// changesText describes a container candidate's uncommitted changes
+func changesText(c inventory.Candidate) string {
+	switch {
+	case c.Changes == nil:
+		return "uncommitted changes unknown"
+	case *c.Changes == 1:
+		return "1 uncommitted change"
+	default:
+		return fmt.Sprintf("%d uncommitted changes", *c.Changes)
+	}
+}
@@ -0,0 +1,296 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/joelhelbling/glovebox/internal/digest"
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+	"github.com/spf13/cobra"
+)
+
+var (
+	registryBase    bool
+	registryName    string
+	registryProfile string
+	registryFlag    string
+)
+
+var pushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Share a built image through a registry",
+	Long: `Push a glovebox image to the registry namespace set with 'registry:' in
+the profile (or --registry), so teammates with the same profile can pull it
+instead of building it.
+
+Images are pushed as <registry>/glovebox:base (or base-<name>),
+<registry>/glovebox:profile-<name> for named profiles and
+<registry>/glovebox:<directory> for projects.
+
+The image must be up to date with its profile: it records the profile's
+content hash, which 'glovebox pull' checks.
+
+--base pushes the base image (--base --name <name> for a named base),
+--profile <name> a named profile's image. Otherwise the project's image is
+pushed when there is a project profile, or else the base image.`,
+	Args: cobra.NoArgs,
+	RunE: runPush,
+}
+
+var pullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Use an image a teammate pushed instead of building it",
+	Long: `Pull the image for a profile from its registry namespace (see 'glovebox
+push') and use it as the profile's image, as if it had been built here.
+
+The pulled image is only used when it was built from a profile with the
+same content as yours; otherwise it is removed again and you are told to
+make the profiles match or build the image yourself.
+
+Takes the same --base, --name and --profile flags as push.`,
+	Args: cobra.NoArgs,
+	RunE: runPull,
+}
+
+func init() {
+	for _, c := range []*cobra.Command{pushCmd, pullCmd} {
+		c.Flags().BoolVar(&registryBase, "base", false, "Use the base image")
+		c.Flags().StringVar(&registryName, "name", "", "With --base, use a named base (e.g. py)")
+		c.Flags().StringVar(&registryProfile, "profile", "", "Use a named profile's image (e.g. team-web)")
+		c.Flags().StringVar(&registryFlag, "registry", "", "Registry namespace (e.g. ghcr.io/acme), instead of the profile's")
+		c.MarkFlagsMutuallyExclusive("base", "profile")
+		rootCmd.AddCommand(c)
+	}
+}
+
+func runPush(cmd *cobra.Command, args []string) error {
+	rt := runtimeOf(cmd)
+
+	p, chain, err := registryTarget()
+	if err != nil {
+		return err
+	}
+	namespace, err := registryNamespace(chain)
+	if err != nil {
+		return err
+	}
+
+	imageName := p.ImageName()
+	reason, err := imageStaleness(rt, p, chain[:len(chain)-1])
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		return fmt.Errorf("%s. Run 'glovebox build' before pushing, so the image matches the profile", reason)
+	}
+	info, err := rt.InspectImage(imageName)
+	if err != nil {
+		return fmt.Errorf("inspecting image %s: %w", imageName, err)
+	}
+	if info.Labels[labels.ContentHash] != p.ComputeContentHash() {
+		return fmt.Errorf("%s was built from an earlier version of %s. Run 'glovebox build' before pushing", imageName, collapsePath(p.Path))
+	}
+
+	ref := remoteImageName(namespace, p)
+	if err := rt.TagImage(imageName, ref); err != nil {
+		return err
+	}
+	defer untag(rt, ref)
+
+	fmt.Printf("Pushing %s to %s...\n", imageName, ref)
+	if err := rt.PushImage(ref); err != nil {
+		return err
+	}
+	colorGreen.Printf("✓ Pushed %s as %s\n", imageName, ref)
+	if !p.IsBase() {
+		colorDim.Printf("Teammates pulling it should also pull %s, the image it was built on.\n", p.ParentImageName())
+	}
+	return nil
+}
+
+func runPull(cmd *cobra.Command, args []string) error {
+	rt := runtimeOf(cmd)
+
+	p, chain, err := registryTarget()
+	if err != nil {
+		return err
+	}
+	namespace, err := registryNamespace(chain)
+	if err != nil {
+		return err
+	}
+
+	ref := remoteImageName(namespace, p)
+	fmt.Printf("Pulling %s...\n", ref)
+	if err := rt.PullImage(ref); err != nil {
+		return err
+	}
+	info, err := rt.InspectImage(ref)
+	if err != nil {
+		untag(rt, ref)
+		return fmt.Errorf("inspecting image %s: %w", ref, err)
+	}
+
+	// Only use an image built from the same profile content
+	hash, want := info.Labels[labels.ContentHash], p.ComputeContentHash()
+	if hash != want {
+		untag(rt, ref)
+		if hash == "" {
+			return fmt.Errorf("%s doesn't record the profile it was built from. Push it again with this version of glovebox", ref)
+		}
+		return fmt.Errorf("%s was built from a different profile than %s (content hash %s, yours is %s). Make the profiles match, or build the image with 'glovebox build'", ref, collapsePath(p.Path), hash, want)
+	}
+
+	target := p.ImageName()
+	adoptBuildInfo(p, target, info.Labels)
+
+	// Project images are relabeled with this machine's paths, which ls and
+	// prune go by. Base and named profile images keep their ID, which the
+	// images built on them record.
+	if !p.IsBase() && p.Name == "" {
+		err = relabelImage(rt, ref, target, imageLabels(p), info.Platforms)
+	} else {
+		err = rt.TagImage(ref, target)
+	}
+	untag(rt, ref)
+	if err != nil {
+		return err
+	}
+
+	writePulledDockerfile(p, chain[:len(chain)-1], ref)
+	if err := p.Save(); err != nil {
+		return fmt.Errorf("saving profile: %w", err)
+	}
+
+	colorGreen.Printf("✓ Pulled %s as %s\n", ref, target)
+	if !p.IsBase() {
+		warnImportedParent(rt, p.ParentImageName(), info.Labels[labels.ParentID], target)
+	}
+	return nil
+}
+
+// registryTarget loads the profile whose image push or pull shares, and
+// the chain of profiles it extends, ending with it
+func registryTarget() (*profile.Profile, []*profile.Profile, error) {
+	if registryName != "" && !registryBase {
+		return nil, nil, fmt.Errorf("--name can only be used with --base")
+	}
+
+	var p *profile.Profile
+	var err error
+	switch {
+	case registryBase:
+		if p, err = profile.LoadBase(registryName); err != nil {
+			return nil, nil, fmt.Errorf("loading base profile: %w", err)
+		}
+		if p == nil && registryName != "" {
+			return nil, nil, fmt.Errorf("no base named %q found. Run 'glovebox init --base --name %s' first", registryName, registryName)
+		}
+	case registryProfile != "":
+		if p, err = profile.LoadNamed(registryProfile); err != nil {
+			return nil, nil, fmt.Errorf("loading profile %q: %w", registryProfile, err)
+		}
+		if p == nil {
+			return nil, nil, fmt.Errorf("profile %q not found. Run 'glovebox init --profile %s' first", registryProfile, registryProfile)
+		}
+	default:
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, nil, fmt.Errorf("getting current directory: %w", err)
+		}
+		if p, err = profile.LoadProject(cwd); err != nil {
+			return nil, nil, fmt.Errorf("checking project profile: %w", err)
+		}
+		if p == nil {
+			if p, err = profile.LoadGlobal(); err != nil {
+				return nil, nil, fmt.Errorf("checking global profile: %w", err)
+			}
+		}
+	}
+	if p == nil {
+		return nil, nil, fmt.Errorf("no profile found. Run 'glovebox init' or 'glovebox init --global' first")
+	}
+
+	ancestors, err := p.Ancestors()
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, append(slices.Clone(ancestors), p), nil
+}
+
+// registryNamespace returns the registry namespace a chain's image is
+// shared through: --registry, or else the chain's registry setting
+func registryNamespace(chain []*profile.Profile) (string, error) {
+	namespace := registryFlag
+	if namespace == "" {
+		namespace = profile.ChainRegistry(chain)
+	}
+	if namespace == "" {
+		return "", fmt.Errorf("no registry configured. Set 'registry:' in the profile (e.g. registry: ghcr.io/acme) or use --registry")
+	}
+	if strings.Contains(namespace, "://") {
+		return "", fmt.Errorf("invalid registry %q: use a host and path without a scheme, e.g. ghcr.io/acme", namespace)
+	}
+	return strings.TrimSuffix(namespace, "/"), nil
+}
+
+// remoteImageName is the name a profile's image is shared as. Project
+// images are named after their directory, without the hash of its path
+// that keeps local names apart.
+func remoteImageName(namespace string, p *profile.Profile) string {
+	name := p.ImageName()
+	if !p.IsBase() && p.Name == "" {
+		name = "glovebox:" + filepath.Base(filepath.Dir(filepath.Dir(p.Path)))
+	}
+	return namespace + "/" + name
+}
+
+// adoptBuildInfo records a pulled image as the profile's build, from the
+// labels it was built with
+func adoptBuildInfo(p *profile.Profile, imageName string, l map[string]string) {
+	p.Build.ImageName = imageName
+	p.Build.DockerfileDigest = l[labels.DockerfileDigest]
+	if created, err := time.Parse(time.RFC3339, l[labels.CreatedAt]); err == nil {
+		p.Build.LastBuiltAt = created
+	}
+	if !p.IsBase() {
+		p.Build.BaseDigest = l[labels.ParentID]
+	}
+	if from := l[labels.From]; from != "" {
+		p.Build.From, p.Build.FromDigest, _ = strings.Cut(from, "@")
+	}
+}
+
+// writePulledDockerfile generates the Dockerfile of a pulled image's
+// profile, so status compares the profile with the image as it would after
+// a build. It warns when the Dockerfile differs from the one the image was
+// built from, as happens when mod definitions differ between machines.
+func writePulledDockerfile(p *profile.Profile, ancestors []*profile.Profile, ref string) {
+	content, err := generateDockerfile(p, ancestors, recordedOptions(p))
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(p.DockerfilePath()), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(p.DockerfilePath(), []byte(content), 0644)
+	}
+	if err != nil {
+		colorYellow.Printf("Warning: could not generate the Dockerfile: %v\n", err)
+		return
+	}
+	if digest.Calculate(content) != p.Build.DockerfileDigest {
+		colorYellow.Printf("⚠ The Dockerfile generated here differs from the one %s was built from; your mods may differ from the ones it was built with.\n", ref)
+	}
+}
+
+// untag removes a name given to an image for a push or pull
+func untag(rt runtime.Runtime, ref string) {
+	if err := rt.RemoveImage(ref); err != nil {
+		colorYellow.Printf("Warning: could not remove %s: %v\n", ref, err)
+	}
+}
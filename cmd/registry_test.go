@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/profile"
+)
+
+// saveTeamProfiles writes the base and project profiles a team shares,
+// pushing to a local registry
+func saveTeamProfiles(t *testing.T, env *testEnv) {
+	t.Helper()
+	base := env.saveGlobal("os/ubuntu", "tools/homebrew-ubuntu")
+	base.Registry = "localhost:5000/team"
+	if err := base.Save(); err != nil {
+		t.Fatal(err)
+	}
+	env.saveProject("tools/mise")
+}
+
+func TestPushPull(t *testing.T) {
+	t.Run("shares built images with a teammate", func(t *testing.T) {
+		pusher := newTestEnv(t)
+		saveTeamProfiles(t, pusher)
+		pusher.mustRun("", "build")
+		out := pusher.mustRun("", "push", "--base")
+		if !strings.Contains(out, "✓ Pushed glovebox:base as localhost:5000/team/glovebox:base") {
+			t.Errorf("output = %q", out)
+		}
+		pusher.mustRun("", "push")
+		if _, ok := pusher.rt.Remote["localhost:5000/team/glovebox:app"]; !ok {
+			t.Errorf("project not pushed by directory name: %v", pusher.rt.Remote)
+		}
+		if pusher.rt.Image("localhost:5000/team/glovebox:base") != nil {
+			t.Error("the registry name should be removed after pushing")
+		}
+		baseID := pusher.rt.Image(profile.BaseImageName).ID
+
+		env := newTestEnv(t)
+		env.rt.Remote = pusher.rt.Remote
+		saveTeamProfiles(t, env)
+		out = env.mustRun("", "pull", "--base")
+		if strings.Contains(out, "⚠") {
+			t.Errorf("pulling for the same profile should not warn:\n%s", out)
+		}
+		if img := env.rt.Image(profile.BaseImageName); img == nil || img.ID != baseID {
+			t.Fatalf("base image = %+v, want ID %s", img, baseID)
+		}
+		env.mustRun("", "pull")
+		target := profile.GenerateImageName(env.project)
+		img := env.rt.Image(target)
+		if img == nil || img.Labels[labels.Project] != env.project {
+			t.Fatalf("project image = %+v", img)
+		}
+		if env.rt.Image("localhost:5000/team/glovebox:app") != nil {
+			t.Error("the registry name should be removed after pulling")
+		}
+
+		out = env.mustRun("", "status")
+		if !strings.Contains(out, "Up to date") || strings.Contains(out, "has changed") {
+			t.Errorf("status after pull:\n%s", out)
+		}
+		env.mustRun("", "run")
+		if len(env.rt.Builds) != 1 { // relabeling the project image
+			t.Errorf("builds = %d, want none besides relabeling", len(env.rt.Builds))
+		}
+	})
+
+	t.Run("refuses images built from another profile", func(t *testing.T) {
+		pusher := newTestEnv(t)
+		saveTeamProfiles(t, pusher)
+		pusher.mustRun("", "build", "--base")
+		pusher.mustRun("", "push", "--base")
+
+		env := newTestEnv(t)
+		env.rt.Remote = pusher.rt.Remote
+		env.saveGlobal("os/ubuntu")
+		_, err := env.run("", "pull", "--base", "--registry", "localhost:5000/team")
+		if err == nil || !strings.Contains(err.Error(), "built from a different profile") {
+			t.Errorf("error = %v", err)
+		}
+		if len(env.rt.Images()) != 0 {
+			t.Errorf("the pulled image should be removed: %v", env.rt.Images())
+		}
+	})
+
+	t.Run("pushes only images up to date with their profile", func(t *testing.T) {
+		env := newTestEnv(t)
+		saveTeamProfiles(t, env)
+		env.mustRun("", "build", "--base")
+		global, err := profile.LoadGlobal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		global.AddMod("tools/mise")
+		if err := global.Save(); err != nil {
+			t.Fatal(err)
+		}
+		_, err = env.run("", "push", "--base")
+		if err == nil || !strings.Contains(err.Error(), "Run 'glovebox build' before pushing") {
+			t.Errorf("error = %v", err)
+		}
+	})
+
+	t.Run("pushes only images built from the current profile", func(t *testing.T) {
+		env := newTestEnv(t)
+		saveTeamProfiles(t, env)
+		env.mustRun("", "build", "--base")
+		global, err := profile.LoadGlobal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		global.PassthroughEnv = []string{"GITHUB_TOKEN"}
+		if err := global.Save(); err != nil {
+			t.Fatal(err)
+		}
+		_, err = env.run("", "push", "--base")
+		if err == nil || !strings.Contains(err.Error(), "built from an earlier version") {
+			t.Errorf("error = %v", err)
+		}
+	})
+
+	t.Run("needs a registry", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.mustRun("", "build")
+		_, err := env.run("", "push")
+		if err == nil || !strings.Contains(err.Error(), "no registry configured") {
+			t.Errorf("error = %v", err)
+		}
+		_, err = env.run("", "push", "--registry", "https://ghcr.io/acme")
+		if err == nil || !strings.Contains(err.Error(), "without a scheme") {
+			t.Errorf("error = %v", err)
+		}
+	})
+}
@@ -28,6 +28,8 @@ func init() {
 }
 
 func runReset(cmd *cobra.Command, args []string) error {
+	rt := runtimeOf(cmd)
+
 	// Get current directory
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -43,7 +45,11 @@ func runReset(cmd *cobra.Command, args []string) error {
 	containerName := docker.ContainerName(absPath)
 
 	// Check if container exists
-	if !rt.ContainerExists(containerName) {
+	exists, err := rt.ContainerExists(containerName)
+	if err != nil {
+		return err
+	}
+	if !exists {
 		fmt.Println("No container found for this project. Nothing to reset.")
 		return nil
 	}
@@ -1,20 +1,36 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 
+	"github.com/joelhelbling/glovebox/internal/profile"
 	"github.com/joelhelbling/glovebox/internal/runtime"
 	"github.com/spf13/cobra"
 )
 
-var (
-	// rt is the active container runtime, set during PersistentPreRunE.
-	rt runtime.Runtime
+// runtimeOverride is set via the --runtime flag.
+var runtimeOverride string
 
-	// runtimeOverride is set via the --runtime flag.
-	runtimeOverride string
-)
+// runtimeKey is the context key of the runtime commands run against.
+type runtimeKey struct{}
+
+// withRuntime returns a context carrying the container runtime.
+func withRuntime(ctx context.Context, rt runtime.Runtime) context.Context {
+	return context.WithValue(ctx, runtimeKey{}, rt)
+}
+
+// runtimeOf returns the runtime a command runs against: the one detected in
+// PersistentPreRunE, or one injected with ExecuteContext.
+func runtimeOf(cmd *cobra.Command) runtime.Runtime {
+	if cmd.Context() == nil {
+		return nil
+	}
+	rt, _ := cmd.Context().Value(runtimeKey{}).(runtime.Runtime)
+	return rt
+}
 
 var rootCmd = &cobra.Command{
 	Use:   "glovebox",
@@ -28,15 +44,19 @@ Supports multiple container runtimes. Use --runtime to override auto-detection.`
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		// Skip runtime detection for commands that don't need it
 		switch cmd.Name() {
-		case "help", "version", "init", "mod":
+		case "help", "version", "init", "mod", "export":
 			return nil
 		}
-		// Also skip if this is a child of "mod" (e.g., "mod list")
-		if cmd.Parent() != nil && cmd.Parent().Name() == "mod" {
+		// Also skip for children of "mod" (e.g., "mod list") and "export"
+		if cmd.Parent() != nil && (cmd.Parent().Name() == "mod" || cmd.Parent().Name() == "export") {
+			return nil
+		}
+		// A runtime was injected (tests)
+		if runtimeOf(cmd) != nil {
 			return nil
 		}
 
-		result, err := runtime.Detect(runtimeOverride, runtime.Stdio{
+		result, err := runtime.Detect(runtimeOverride, runtimeHost(cmd, args), runtime.Stdio{
 			Stdin:  os.Stdin,
 			Stdout: os.Stdout,
 			Stderr: os.Stderr,
@@ -48,11 +68,27 @@ Supports multiple container runtimes. Use --runtime to override auto-detection.`
 			colorYellow.Println(result.FallbackMsg)
 			fmt.Println()
 		}
-		rt = result.Runtime
+		cmd.SetContext(withRuntime(cmd.Context(), result.Runtime))
 		return nil
 	},
 }
 
+// runtimeHost returns the runtime_host setting of the project a command
+// works on: the directory given to run or clean, or the current directory.
+// Profile errors are left for the command to report.
+func runtimeHost(cmd *cobra.Command, args []string) string {
+	dir := "."
+	if (cmd.Name() == "run" || cmd.Name() == "clean") && len(args) > 0 {
+		dir = args[0]
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	host, _ := profile.EffectiveRuntimeHost(absDir)
+	return host
+}
+
 func init() {
 	rootCmd.PersistentFlags().StringVar(&runtimeOverride, "runtime", "", "Container runtime to use (e.g., docker)")
 }
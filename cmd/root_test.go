@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/joelhelbling/glovebox/internal/docker"
+	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/joelhelbling/glovebox/internal/runtime/runtimetest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// origDir is the package directory, where testdata lives; tests change the
+// working directory
+var origDir, _ = os.Getwd()
+
+// testEnv is a sandbox for running commands against a fake runtime: a
+// temporary HOME and a project directory as the working directory
+type testEnv struct {
+	t       *testing.T
+	rt      *runtimetest.FakeRuntime
+	home    string
+	project string
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	home := realTempDir(t)
+	project := filepath.Join(realTempDir(t), "app")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+	t.Chdir(project)
+	return &testEnv{t: t, rt: runtimetest.New(), home: home, project: project}
+}
+
+// realTempDir is a temporary directory with symlinks resolved, so paths
+// match the working directory commands see (macOS links /var to /private/var)
+func realTempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// saveGlobal writes the global profile
+func (e *testEnv) saveGlobal(mods ...string) *profile.Profile {
+	e.t.Helper()
+	path, err := profile.GlobalPath()
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	return e.save(path, mods)
+}
+
+// saveProject writes the project's profile
+func (e *testEnv) saveProject(mods ...string) *profile.Profile {
+	e.t.Helper()
+	return e.save(profile.ProjectPath(e.project), mods)
+}
+
+func (e *testEnv) save(path string, mods []string) *profile.Profile {
+	e.t.Helper()
+	p := profile.NewProfile()
+	p.Mods = mods
+	if err := p.SaveTo(path); err != nil {
+		e.t.Fatalf("saving profile: %v", err)
+	}
+	p.Path = path
+	return p
+}
+
+// run executes glovebox with args against the fake runtime, feeding stdin
+// to prompts, and returns what it printed
+func (e *testEnv) run(stdin string, args ...string) (string, error) {
+	e.t.Helper()
+	resetCommands(rootCmd)
+
+	inR, inW, err := os.Pipe()
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	go func() {
+		_, _ = io.WriteString(inW, stdin)
+		inW.Close()
+	}()
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(outR)
+		done <- string(data)
+	}()
+
+	origIn, origOut, origColorOut, origNoColor := os.Stdin, os.Stdout, color.Output, color.NoColor
+	os.Stdin, os.Stdout, color.Output, color.NoColor = inR, outW, outW, true
+	defer func() {
+		os.Stdin, os.Stdout, color.Output, color.NoColor = origIn, origOut, origColorOut, origNoColor
+		inR.Close()
+	}()
+
+	rootCmd.SetArgs(args)
+	rootCmd.SetOut(outW)
+	rootCmd.SetErr(io.Discard)
+	err = rootCmd.ExecuteContext(withRuntime(context.Background(), e.rt))
+	outW.Close()
+	return <-done, err
+}
+
+// mustRun is run failing the test on error
+func (e *testEnv) mustRun(stdin string, args ...string) string {
+	e.t.Helper()
+	out, err := e.run(stdin, args...)
+	if err != nil {
+		e.t.Fatalf("glovebox %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return out
+}
+
+// resetCommands clears what a previous execution left on the package-level
+// commands: flag values and contexts
+func resetCommands(c *cobra.Command) {
+	c.SetContext(nil)
+	reset := func(f *pflag.Flag) {
+		_ = f.Value.Set(f.DefValue)
+		f.Changed = false
+	}
+	c.Flags().VisitAll(reset)
+	c.PersistentFlags().VisitAll(reset)
+	for _, child := range c.Commands() {
+		resetCommands(child)
+	}
+}
+
+func TestRuntimeInjection(t *testing.T) {
+	env := newTestEnv(t)
+
+	// An injected runtime skips detection, so this works without Docker
+	out := env.mustRun("", "reset")
+	if !strings.Contains(out, "No container found") {
+		t.Errorf("output = %q", out)
+	}
+	if !env.rt.Called("ContainerExists " + docker.ContainerName(env.project)) {
+		t.Errorf("calls = %v, want the injected runtime used", env.rt.Calls)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/joelhelbling/glovebox/internal/docker"
+	"github.com/joelhelbling/glovebox/internal/dotfiles"
 	"github.com/joelhelbling/glovebox/internal/mod"
 	"github.com/joelhelbling/glovebox/internal/profile"
 	"github.com/joelhelbling/glovebox/internal/runtime"
@@ -39,6 +40,8 @@ func init() {
 }
 
 func runRun(cmd *cobra.Command, args []string) error {
+	rt := runtimeOf(cmd)
+
 	// Determine target directory
 	targetDir := "."
 	if len(args) > 0 {
@@ -61,7 +64,7 @@ func runRun(cmd *cobra.Command, args []string) error {
 	}
 
 	// Determine which image to use
-	imageName, err := determineImage(absPath)
+	imageName, err := determineImage(rt, absPath)
 	if err != nil {
 		return err
 	}
@@ -71,8 +74,16 @@ func runRun(cmd *cobra.Command, args []string) error {
 	dirName := filepath.Base(absPath)
 
 	// Check if container already exists
-	containerExists := rt.ContainerExists(containerName)
-	containerRunning := rt.ContainerRunning(containerName)
+	containerExists, err := rt.ContainerExists(containerName)
+	if err != nil {
+		return err
+	}
+	containerRunning := false
+	if containerExists {
+		if containerRunning, err = rt.ContainerRunning(containerName); err != nil {
+			return err
+		}
+	}
 
 	// Mount workspace at /<dirName> so the prompt shows the project name
 	workspacePath := "/" + dirName
@@ -105,6 +116,15 @@ func runRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	services, err := loadServices(absPath)
+	if err != nil {
+		return err
+	}
+	spec, err := newContainerSpec(containerName, imageName, absPath, services != nil)
+	if err != nil {
+		return err
+	}
+
 	// Display the banner
 	banner := ui.NewBanner()
 	banner.Print(ui.BannerInfo{
@@ -114,69 +134,160 @@ func runRun(cmd *cobra.Command, args []string) error {
 		Container:       containerName,
 		ContainerStatus: containerStatus,
 		PassthroughEnv:  passthroughVars,
+		Services:        serviceSummaries(services),
+		Ports:           spec.Access.portSummaries(),
+		HostServices:    spec.Access.hostServiceSummaries(),
 	})
+	if rt.Capabilities().Remote {
+		colorDim.Println("Remote Docker host: the workspace is synced rather than mounted, and ports are published there.")
+		fmt.Println()
+	}
 
 	if containerRunning {
+		if reasons := spec.drift(rt, containerName); len(reasons) > 0 {
+			colorYellow.Printf("Container is stale: %s. Exit all sessions and run again to recreate it.\n", strings.Join(reasons, "; "))
+		}
 		// Container is already running - attach to it
 		colorYellow.Printf("Attaching to running container...\n")
-		return attachToContainer(containerName)
+		return attachToContainer(rt, containerName, absPath, workspacePath)
 	}
 
 	if containerExists {
-		// Container exists but stopped - start it
-		if err := startContainer(containerName, absPath, workspacePath); err != nil {
-			return err
+		if reasons := spec.drift(rt, containerName); len(reasons) > 0 {
+			recreated, err := handleStaleContainer(rt, containerName, imageName, reasons)
+			if err != nil {
+				return err
+			}
+			containerExists = !recreated
 		}
+	}
+
+	network, err := startServices(rt, containerName, services)
+	if err != nil {
+		stopServices(rt, containerName, services)
+		return err
+	}
+
+	if containerExists {
+		// Container exists but stopped - start it
+		err = startContainer(rt, containerName, absPath, workspacePath)
 	} else {
-		// Create new container (passthrough already computed above)
-		if err := createAndStartContainerWithEnv(containerName, imageName, absPath, workspacePath, passthroughVars); err != nil {
-			return err
+		// Create new container
+		spec.resolveDotfiles()
+		if spec.DotfilesErr != nil {
+			colorYellow.Printf("Warning: dotfiles not mounted: %v\n", spec.DotfilesErr)
 		}
+		cfg := spec.runConfig(rt)
+		cfg.Network = network
+		err = createContainer(rt, cfg)
+	}
+	stopServices(rt, containerName, services)
+	if err != nil {
+		return err
 	}
 
 	// After container exits, check for changes and offer to commit
-	return handlePostExit(containerName, imageName)
+	return handlePostExit(rt, containerName, imageName)
 }
 
-// attachToContainer attaches to a running container
-func attachToContainer(name string) error {
-	return rt.Attach(name)
+// handleStaleContainer asks what to do with a container that no longer
+// matches its profile and removes it, committing it first if asked. It
+// reports whether the container was removed.
+func handleStaleContainer(rt runtime.Runtime, containerName, imageName string, reasons []string) (bool, error) {
+	switch promptStale(rt, reasons) {
+	case staleCommitRecreate:
+		fmt.Printf("Committing container to %s...\n", imageName)
+		if err := commitContainer(rt, containerName, imageName); err != nil {
+			return false, fmt.Errorf("committing container: %w", err)
+		}
+	case staleRecreate:
+	default:
+		fmt.Println()
+		return false, nil
+	}
+
+	if err := deleteContainer(rt, containerName); err != nil {
+		return false, fmt.Errorf("removing container: %w", err)
+	}
+	colorDim.Println("Recreating container...")
+	fmt.Println()
+	return true, nil
+}
+
+// attachToContainer attaches to a running container. On a remote host the
+// workspace was synced in when the container started, so it's only synced
+// back.
+func attachToContainer(rt runtime.Runtime, name, hostPath, workspacePath string) error {
+	err := rt.Attach(name)
+	if !rt.Capabilities().Remote {
+		return err
+	}
+	if syncErr := pullWorkspace(rt, name, hostPath, workspacePath, nil); syncErr != nil {
+		if err != nil {
+			colorYellow.Printf("Warning: could not sync the workspace back: %v\n", syncErr)
+			return err
+		}
+		return fmt.Errorf("syncing workspace back: %w", syncErr)
+	}
+	return err
 }
 
 // startContainer starts an existing stopped container
-func startContainer(name, hostPath, workspacePath string) error {
-	return rt.StartInteractive(name)
+func startContainer(rt runtime.Runtime, name, hostPath, workspacePath string) error {
+	if !rt.Capabilities().Remote {
+		return rt.StartInteractive(name)
+	}
+	return syncedSession(rt, name, hostPath, workspacePath, func() error {
+		return rt.StartInteractive(name)
+	})
 }
 
-// createAndStartContainerWithEnv creates a new container with pre-computed env vars
-func createAndStartContainerWithEnv(name, imageName, hostPath, workspacePath string, _ []string) error {
-	passthroughEnv, err := profile.EffectivePassthroughEnv(hostPath)
-	if err != nil {
-		passthroughEnv = nil
+// createContainer creates and runs a new container. On a remote host it's
+// created first, so the workspace can be synced into it before it starts;
+// bind mounts of host paths are dropped there.
+func createContainer(rt runtime.Runtime, cfg runtime.RunConfig) error {
+	if !rt.Capabilities().Remote {
+		return rt.RunInteractive(cfg)
+	}
+	for _, m := range cfg.Mounts {
+		colorYellow.Printf("Warning: %s not mounted: host paths aren't available on a remote Docker host\n", m.Target)
+	}
+	cfg.Mounts = nil
+	if err := rt.CreateInteractive(cfg); err != nil {
+		return err
 	}
+	// Nothing was synced into the new container yet
+	if err := removeSyncSnapshot(cfg.ContainerName); err != nil {
+		return err
+	}
+	return startContainer(rt, cfg.ContainerName, cfg.HostPath, cfg.WorkspacePath)
+}
 
-	env := make(map[string]string)
-	for _, envName := range passthroughEnv {
-		if val := os.Getenv(envName); val != "" {
-			env[envName] = val
-		}
+// dotfilesMounts returns the read-only dotfiles mount for profiles that
+// install dotfiles at container creation, and their config. The entrypoint
+// installs them on first start. The mount is of where the dotfiles will
+// be; nothing is fetched until the container is created (see
+// containerSpec.resolveDotfiles).
+func dotfilesMounts(hostPath string) ([]runtime.Mount, *dotfiles.Config, error) {
+	cfg, err := profile.EffectiveDotfiles(hostPath)
+	if err != nil || cfg == nil || cfg.EffectiveApply() != dotfiles.ApplyCreate {
+		return nil, nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
 	}
-	env["MISE_TRUSTED_CONFIG_PATHS"] = fmt.Sprintf("%s:%s/**", workspacePath, workspacePath)
 
-	return rt.RunInteractive(runtime.RunConfig{
-		ContainerName: name,
-		ImageName:     imageName,
-		HostPath:      hostPath,
-		WorkspacePath: workspacePath,
-		Env:           env,
-		Hostname:      "glovebox",
-	})
+	dir, err := dotfiles.CachePath(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return []runtime.Mount{{Source: dir, Target: dotfiles.ContainerDir, ReadOnly: true}}, cfg, nil
 }
 
 // handlePostExit shows a summary of container changes (no prompt)
-func handlePostExit(containerName, imageName string) error {
+func handlePostExit(rt runtime.Runtime, containerName, imageName string) error {
 	// Get the diff
-	changes, err := getContainerDiff(containerName)
+	changes, err := getContainerDiff(rt, containerName)
 	if err != nil {
 		// Don't fail on diff errors, just show simple exit
 		prompt := ui.NewPrompt()
@@ -195,7 +306,7 @@ func handlePostExit(containerName, imageName string) error {
 }
 
 // getContainerDiff returns the filesystem changes in a container
-func getContainerDiff(name string) ([]string, error) {
+func getContainerDiff(rt runtime.Runtime, name string) ([]string, error) {
 	caps := rt.Capabilities()
 	if !caps.SupportsDiff {
 		return nil, nil
@@ -420,17 +531,23 @@ func summarizeChanges(changes []string) []string {
 	return result
 }
 
-// commitContainer commits container changes to its image
-func commitContainer(containerName, imageName string) error {
-	return rt.Commit(containerName, imageName)
+// commitContainer commits container changes to its image, keeping the
+// image's labels so it still shows as the image it replaces
+func commitContainer(rt runtime.Runtime, containerName, imageName string) error {
+	img, err := rt.InspectImage(imageName)
+	if err != nil {
+		return fmt.Errorf("inspecting image %s: %w", imageName, err)
+	}
+	return rt.Commit(containerName, imageName, img.Labels)
 }
 
 // deleteContainer removes a container without printing
-func deleteContainer(containerName string) error {
+func deleteContainer(rt runtime.Runtime, containerName string) error {
 	return rt.RemoveContainer(containerName)
 }
 
 // getOSFromProfile determines the OS name from the effective profile
+// and the profiles it extends
 func getOSFromProfile(dir string) string {
 	// Try project profile first, then global
 	p, err := profile.LoadProject(dir)
@@ -441,8 +558,14 @@ func getOSFromProfile(dir string) string {
 		}
 	}
 
-	// Look for OS mod in profile
-	for _, modID := range p.Mods {
+	chain, err := p.Ancestors()
+	if err != nil {
+		chain = nil
+	}
+	chain = append(chain, p)
+
+	// Look for OS mod in the profile chain
+	for _, modID := range profile.ChainMods(chain) {
 		m, err := mod.Load(modID)
 		if err != nil {
 			continue
@@ -455,7 +578,7 @@ func getOSFromProfile(dir string) string {
 }
 
 // determineImage figures out which image to use for the given directory
-func determineImage(dir string) (string, error) {
+func determineImage(rt runtime.Runtime, dir string) (string, error) {
 	// Check for project profile
 	projectProfile, err := profile.LoadProject(dir)
 	if err != nil {
@@ -466,9 +589,13 @@ func determineImage(dir string) (string, error) {
 		// Project profile exists - use project image
 		imageName := projectProfile.ImageName()
 
-		if !rt.ImageExists(imageName) {
+		exists, err := rt.ImageExists(imageName)
+		if err != nil {
+			return "", fmt.Errorf("checking project image: %w", err)
+		}
+		if !exists {
 			colorYellow.Printf("Project image %s not found. Building...\n\n", imageName)
-			if err := buildProjectImage(projectProfile); err != nil {
+			if err := buildProjectImage(rt, projectProfile); err != nil {
 				return "", fmt.Errorf("building project image: %w", err)
 			}
 			fmt.Println()
@@ -478,7 +605,11 @@ func determineImage(dir string) (string, error) {
 	}
 
 	// No project profile - use base image
-	if !rt.ImageExists("glovebox:base") {
+	exists, err := rt.ImageExists(profile.BaseImageName)
+	if err != nil {
+		return "", fmt.Errorf("checking base image: %w", err)
+	}
+	if !exists {
 		// Check if global profile exists
 		globalProfile, err := profile.LoadGlobal()
 		if err != nil {
@@ -489,12 +620,12 @@ func determineImage(dir string) (string, error) {
 			return "", fmt.Errorf("no glovebox profile found.\nRun 'glovebox init --global' to create a global profile first")
 		}
 
-		colorYellow.Println("Base image glovebox:base not found. Building...")
-		if err := buildBaseImage(); err != nil {
+		colorYellow.Printf("Base image %s not found. Building...\n", profile.BaseImageName)
+		if err := buildBaseImage(rt, ""); err != nil {
 			return "", fmt.Errorf("building base image: %w", err)
 		}
 		fmt.Println()
 	}
 
-	return "glovebox:base", nil
+	return profile.BaseImageName, nil
 }
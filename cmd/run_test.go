@@ -0,0 +1,376 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joelhelbling/glovebox/internal/docker"
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+	"github.com/joelhelbling/glovebox/internal/runtime/runtimetest"
+)
+
+// loadDiff reads a fixture from testdata/diffs
+func loadDiff(t *testing.T, name string) []runtime.FileDiff {
+	t.Helper()
+	diffs, err := runtimetest.LoadDiff(filepath.Join(origDir, "testdata", "diffs", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return diffs
+}
+
+func TestRun(t *testing.T) {
+	t.Run("builds the missing base image and creates the container", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+
+		out := env.mustRun("", "run")
+
+		if len(env.rt.Builds) != 1 || env.rt.Builds[0].ImageName != profile.BaseImageName {
+			t.Fatalf("builds = %+v, want %s built", env.rt.Builds, profile.BaseImageName)
+		}
+		if role := env.rt.Builds[0].Labels[labels.Role]; role != labels.RoleBase {
+			t.Errorf("base image role label = %q", role)
+		}
+		c := env.rt.Container(docker.ContainerName(env.project))
+		if c == nil || c.Run == nil {
+			t.Fatalf("container not created; calls = %v", env.rt.Calls)
+		}
+		if c.Image != profile.BaseImageName || c.Run.WorkspacePath != "/app" || c.Run.HostPath != env.project {
+			t.Errorf("run config = %+v", c.Run)
+		}
+		if c.Running {
+			t.Error("container should be stopped after the session ends")
+		}
+		if !strings.Contains(out, "Session ended") {
+			t.Errorf("output = %q, want the exit summary", out)
+		}
+	})
+
+	t.Run("summarizes a session's changes and commits them", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.rt.ScriptSession(loadDiff(t, "brew-install.txt")...)
+
+		out := env.mustRun("", "run")
+		for _, want := range []string{"uncommitted changes", "brew install ripgrep", "modified .bashrc", "glovebox commit"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+		if strings.Contains(out, "brew-install.log") || strings.Contains(out, ".bash_history") {
+			t.Errorf("noise shown in summary:\n%s", out)
+		}
+
+		containerName := docker.ContainerName(env.project)
+		built := env.rt.Image(profile.BaseImageName).ID
+		env.mustRun("", "commit")
+
+		if len(env.rt.Commits) != 1 || env.rt.Commits[0].Image != profile.BaseImageName || len(env.rt.Commits[0].Diffs) == 0 {
+			t.Fatalf("commits = %+v", env.rt.Commits)
+		}
+		if env.rt.Container(containerName) != nil {
+			t.Error("commit should remove the container")
+		}
+		committed := env.rt.Image(profile.BaseImageName).ID
+		if committed == built {
+			t.Fatal("commit should replace the image")
+		}
+
+		// The next run starts fresh from the committed image
+		env.mustRun("", "run")
+		if c := env.rt.Container(containerName); c == nil || c.ImageID != committed {
+			t.Errorf("container = %+v, want one created from %s", c, committed)
+		}
+		if len(env.rt.Builds) != 1 {
+			t.Errorf("builds = %d, want the committed image reused", len(env.rt.Builds))
+		}
+	})
+
+	t.Run("noise-only sessions report no changes", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.rt.ScriptSession(loadDiff(t, "noise-only.txt")...)
+
+		out := env.mustRun("", "run")
+		if strings.Contains(out, "uncommitted changes") {
+			t.Errorf("output = %q, want no changes reported", out)
+		}
+	})
+
+	t.Run("resumes an existing container", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.mustRun("", "run")
+		env.rt.Calls = nil
+
+		env.mustRun("", "run")
+		containerName := docker.ContainerName(env.project)
+		if !env.rt.Called("StartInteractive "+containerName) || env.rt.Called("RunInteractive "+containerName) {
+			t.Errorf("calls = %v, want the stopped container started", env.rt.Calls)
+		}
+	})
+
+	t.Run("attaches to a running container", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.mustRun("", "run")
+		containerName := docker.ContainerName(env.project)
+		env.rt.Container(containerName).Running = true
+
+		out := env.mustRun("", "run")
+		if !env.rt.Called("Attach "+containerName) || !strings.Contains(out, "Attaching") {
+			t.Errorf("calls = %v, want an attach", env.rt.Calls)
+		}
+	})
+
+	t.Run("builds the project image on top of the base", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		p := env.saveProject("shells/bash")
+
+		env.mustRun("", "run")
+		var built []string
+		for _, b := range env.rt.Builds {
+			built = append(built, b.ImageName)
+		}
+		if strings.Join(built, " ") != profile.BaseImageName+" "+p.ImageName() {
+			t.Errorf("built %v, want base then project", built)
+		}
+		if c := env.rt.Container(docker.ContainerName(env.project)); c == nil || c.Image != p.ImageName() {
+			t.Errorf("container = %+v, want the project image", c)
+		}
+	})
+
+	t.Run("without a profile", func(t *testing.T) {
+		env := newTestEnv(t)
+		if _, err := env.run("", "run"); err == nil || !strings.Contains(err.Error(), "glovebox init --global") {
+			t.Errorf("error = %v, want a hint to init", err)
+		}
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		tests := []struct {
+			method  string
+			err     error
+			wantErr string
+		}{
+			{"ImageExists", runtime.ErrDaemonUnavailable, "container runtime is not running"},
+			{"BuildImage", errors.New("no space left on device"), "building base image"},
+			{"RunInteractive", errors.New("docker error (exit 125)"), "exit 125"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.method, func(t *testing.T) {
+				env := newTestEnv(t)
+				env.saveGlobal("os/ubuntu")
+				env.rt.FailOn(tt.method, tt.err)
+
+				_, err := env.run("", "run")
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+			})
+		}
+	})
+
+	t.Run("diff failures don't fail the session", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.rt.FailOn("Diff", errors.New("diff failed"))
+
+		if out := env.mustRun("", "run"); !strings.Contains(out, "Session ended") {
+			t.Errorf("output = %q, want the exit summary", out)
+		}
+	})
+	t.Run("syncs the workspace with a remote host", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.rt.Caps.Remote = true
+		writeProjectFile(t, env, "main.go", "package main")
+		writeProjectFile(t, env, "notes.txt", "todo")
+		writeProjectFile(t, env, "old.txt", "obsolete")
+
+		env.rt.OnSession = func(c *runtimetest.Container) {
+			if c.Workspace["main.go"] != "package main" {
+				t.Errorf("workspace in the session = %v", c.Workspace)
+			}
+			c.Workspace["main.go"] = "package main // edited remotely"
+			c.Workspace["gen/out.txt"] = "generated"
+			delete(c.Workspace, "old.txt")
+		}
+		out := env.mustRun("", "run")
+
+		containerName := docker.ContainerName(env.project)
+		for _, call := range []string{
+			"CreateInteractive " + containerName,
+			"CopyToContainer " + containerName + " /app",
+			"StartInteractive " + containerName,
+			"CopyFromContainer " + containerName + " /app",
+		} {
+			if !env.rt.Called(call) {
+				t.Errorf("calls = %v, want %s", env.rt.Calls, call)
+			}
+		}
+		if c := env.rt.Container(containerName); c == nil || !c.Run.WorkspaceVolume {
+			t.Errorf("container = %+v, want the workspace in a volume", c)
+		}
+		if got := readProjectFile(t, env, "main.go"); got != "package main // edited remotely" {
+			t.Errorf("main.go = %q", got)
+		}
+		if got := readProjectFile(t, env, "gen/out.txt"); got != "generated" {
+			t.Errorf("gen/out.txt = %q", got)
+		}
+		if _, err := os.Stat(filepath.Join(env.project, "old.txt")); !os.IsNotExist(err) {
+			t.Error("old.txt deleted in the session should be deleted")
+		}
+		if !strings.Contains(out, "Synced workspace back: 2 updated, 1 removed") {
+			t.Errorf("output = %q", out)
+		}
+
+		// Edits made between sessions reach the container when it resumes
+		writeProjectFile(t, env, "notes.txt", "done")
+		env.rt.OnSession = func(c *runtimetest.Container) {
+			if c.Workspace["notes.txt"] != "done" {
+				t.Errorf("notes.txt in the session = %q", c.Workspace["notes.txt"])
+			}
+		}
+		out = env.mustRun("", "run")
+		if strings.Contains(out, "Synced workspace back") {
+			t.Errorf("output = %q, want nothing synced back from an unchanged workspace", out)
+		}
+	})
+
+	t.Run("file deleted on host between sessions", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.rt.Caps.Remote = true
+		writeProjectFile(t, env, "main.go", "package main")
+		writeProjectFile(t, env, "scratch.txt", "temporary")
+		env.mustRun("", "run")
+
+		if err := os.Remove(filepath.Join(env.project, "scratch.txt")); err != nil {
+			t.Fatal(err)
+		}
+		env.rt.OnSession = func(c *runtimetest.Container) {
+			if _, ok := c.Workspace["scratch.txt"]; ok {
+				t.Errorf("workspace in the session = %v, want scratch.txt gone", c.Workspace)
+			}
+		}
+		env.mustRun("", "run")
+
+		if !env.rt.Called("ClearDirectory " + docker.ContainerName(env.project) + " /app") {
+			t.Errorf("calls = %v, want the workspace cleared before syncing", env.rt.Calls)
+		}
+		if _, err := os.Stat(filepath.Join(env.project, "scratch.txt")); !os.IsNotExist(err) {
+			t.Error("scratch.txt deleted on the host came back")
+		}
+	})
+
+	t.Run("work left in the container is synced back before clearing", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.rt.Caps.Remote = true
+		writeProjectFile(t, env, "main.go", "package main")
+		env.mustRun("", "run")
+
+		// A session whose sync-back never happened
+		containerName := docker.ContainerName(env.project)
+		env.rt.Container(containerName).Workspace["unsynced.txt"] = "work"
+
+		env.rt.FailOn("CopyFromContainer", errors.New("connection lost"))
+		env.rt.Calls = nil
+		if _, err := env.run("", "run"); err == nil || !strings.Contains(err.Error(), "connection lost") {
+			t.Errorf("error = %v, want the failed sync-back", err)
+		}
+		if env.rt.Called("ClearDirectory " + containerName + " /app") {
+			t.Error("the workspace was cleared although its work couldn't be synced back")
+		}
+
+		env.rt.FailOn("CopyFromContainer", nil)
+		env.mustRun("", "run")
+		if got := readProjectFile(t, env, "unsynced.txt"); got != "work" {
+			t.Errorf("unsynced.txt = %q, want the container's work kept", got)
+		}
+	})
+
+	t.Run("recreated container doesn't remove project files", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.rt.Caps.Remote = true
+		writeProjectFile(t, env, "main.go", "package main")
+		env.mustRun("", "run")
+
+		env.mustRun("", "clean")
+		env.mustRun("", "run")
+		if got := readProjectFile(t, env, "main.go"); got != "package main" {
+			t.Errorf("main.go = %q, want it kept", got)
+		}
+	})
+
+	t.Run("file edited on host during session", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.rt.Caps.Remote = true
+		writeProjectFile(t, env, "notes.txt", "todo")
+		writeProjectFile(t, env, "main.go", "package main")
+
+		env.rt.OnSession = func(c *runtimetest.Container) {
+			writeProjectFile(t, env, "notes.txt", "edited on the host")
+			writeProjectFile(t, env, "main.go", "package main // host")
+			c.Workspace["main.go"] = "package main // container"
+		}
+		out := env.mustRun("", "run")
+
+		if got := readProjectFile(t, env, "notes.txt"); got != "edited on the host" {
+			t.Errorf("notes.txt = %q, want the host's edit kept", got)
+		}
+		if got := readProjectFile(t, env, "main.go"); got != "package main // host" {
+			t.Errorf("main.go = %q, want the host's edit kept", got)
+		}
+		if got := readProjectFile(t, env, "main.go.glovebox-conflict"); got != "package main // container" {
+			t.Errorf("conflict copy = %q, want the container's version", got)
+		}
+		if !strings.Contains(out, "1 file(s) changed both here and in the container") || !strings.Contains(out, "  main.go\n") {
+			t.Errorf("output = %q, want the conflict reported", out)
+		}
+	})
+
+	t.Run("syncs back after a failed remote session", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.rt.Caps.Remote = true
+		env.rt.FailOn("StartInteractive", errors.New("docker error (exit 125)"))
+
+		if _, err := env.run("", "run"); err == nil || !strings.Contains(err.Error(), "exit 125") {
+			t.Errorf("error = %v", err)
+		}
+		if !env.rt.Called("CopyFromContainer " + docker.ContainerName(env.project) + " /app") {
+			t.Errorf("calls = %v, want the workspace synced back", env.rt.Calls)
+		}
+	})
+}
+
+func writeProjectFile(t *testing.T, env *testEnv, name, content string) {
+	t.Helper()
+	p := filepath.Join(env.project, name)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readProjectFile(t *testing.T, env *testEnv, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(env.project, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
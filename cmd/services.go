@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+)
+
+// loadServices returns the project profile declaring sidecar services, or
+// nil when the project has none
+func loadServices(projectDir string) (*profile.Profile, error) {
+	p, err := profile.LoadProject(projectDir)
+	if err != nil || p == nil || len(p.Services) == 0 {
+		return nil, err
+	}
+	if err := p.ValidateServices(); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.Path, err)
+	}
+	return p, nil
+}
+
+// serviceSummaries describes each service for display, e.g. "db (postgres:16)"
+func serviceSummaries(p *profile.Profile) []string {
+	if p == nil {
+		return nil
+	}
+	var summaries []string
+	for _, name := range p.ServiceNames() {
+		summaries = append(summaries, fmt.Sprintf("%s (%s)", name, p.Services[name].Image))
+	}
+	return summaries
+}
+
+// startServices creates the project's private network and starts each of
+// its services there. It returns the network the glovebox container should
+// join, or "" when the project has no services.
+func startServices(rt runtime.Runtime, containerName string, p *profile.Profile) (string, error) {
+	if p == nil {
+		return "", nil
+	}
+
+	network := profile.NetworkName(containerName)
+	exists, err := rt.NetworkExists(network)
+	if err != nil {
+		return "", fmt.Errorf("checking network %s: %w", network, err)
+	}
+	if !exists {
+		if err := rt.CreateNetwork(network); err != nil {
+			return "", fmt.Errorf("creating network %s: %w", network, err)
+		}
+	}
+
+	for _, name := range p.ServiceNames() {
+		svcContainer := profile.ServiceContainerName(containerName, name)
+		state, err := serviceState(rt, containerName, name)
+		if err != nil {
+			return "", fmt.Errorf("checking service %s: %w", name, err)
+		}
+		switch state {
+		case "running":
+			continue
+		case "stopped":
+			if err := rt.StartContainer(svcContainer); err != nil {
+				return "", fmt.Errorf("starting service %s: %w", name, err)
+			}
+		default:
+			if err := rt.RunDetached(serviceConfig(containerName, network, name, p)); err != nil {
+				return "", fmt.Errorf("starting service %s: %w", name, err)
+			}
+		}
+		colorDim.Printf("Started service %s\n", name)
+	}
+	return network, nil
+}
+
+// serviceConfig describes the container for one of a project's services
+func serviceConfig(containerName, network, name string, p *profile.Profile) runtime.ServiceConfig {
+	svc := p.Services[name]
+	cfg := runtime.ServiceConfig{
+		ContainerName: profile.ServiceContainerName(containerName, name),
+		ImageName:     svc.Image,
+		Network:       network,
+		Alias:         name,
+		Env:           svc.Env,
+		Command:       svc.Command,
+		Labels:        labels.New(Version, labels.RoleService, time.Now()),
+	}
+	cfg.Labels[labels.Project] = filepath.Dir(filepath.Dir(p.Path))
+	cfg.Labels[labels.Profile] = p.Path
+	for _, target := range svc.Volumes {
+		cfg.Volumes = append(cfg.Volumes, runtime.VolumeMount{
+			Name:   profile.ServiceVolumeName(containerName, name, target),
+			Target: path.Clean(target),
+		})
+	}
+	return cfg
+}
+
+// stopServices stops a project's running services when its session ends.
+// Their containers and volumes are kept for the next run.
+func stopServices(rt runtime.Runtime, containerName string, p *profile.Profile) {
+	if p == nil {
+		return
+	}
+	for _, name := range p.ServiceNames() {
+		svcContainer := profile.ServiceContainerName(containerName, name)
+		if running, err := rt.ContainerRunning(svcContainer); err != nil {
+			colorYellow.Printf("Warning: could not check service %s: %v\n", name, err)
+			continue
+		} else if !running {
+			continue
+		}
+		if err := rt.StopContainer(svcContainer); err != nil {
+			colorYellow.Printf("Warning: could not stop service %s: %v\n", name, err)
+		}
+	}
+}
+
+// serviceState reports whether a service's container is running, stopped or
+// not yet created
+func serviceState(rt runtime.Runtime, containerName, name string) (string, error) {
+	svcContainer := profile.ServiceContainerName(containerName, name)
+	exists, err := rt.ContainerExists(svcContainer)
+	if err != nil || !exists {
+		return "not created", err
+	}
+	running, err := rt.ContainerRunning(svcContainer)
+	if err != nil {
+		return "", err
+	}
+	if running {
+		return "running", nil
+	}
+	return "stopped", nil
+}
+
+// findServiceContainers lists the service containers belonging to a
+// project's glovebox container, whether or not they are still in its profile
+func findServiceContainers(rt runtime.Runtime, containerName string) ([]string, error) {
+	prefix := profile.ServiceContainerName(containerName, "")
+	containers, err := rt.ListContainers(runtime.ListFilter{Name: prefix}, true)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, c := range containers {
+		if strings.HasPrefix(c.Name, prefix) {
+			names = append(names, c.Name)
+		}
+	}
+	return names, nil
+}
+
+// removeServices removes a project's service containers and network, and
+// with removeVolumes also the volumes holding their data
+func removeServices(rt runtime.Runtime, containerName string, removeVolumes bool, green, yellow *color.Color) {
+	services, err := findServiceContainers(rt, containerName)
+	if err != nil {
+		yellow.Printf("Warning: could not list service containers: %v\n", err)
+	}
+	for _, name := range services {
+		if err := removeContainer(rt, name, green); err != nil {
+			yellow.Printf("Warning: could not remove container %s: %v\n", name, err)
+		}
+	}
+
+	network := profile.NetworkName(containerName)
+	if exists, err := rt.NetworkExists(network); err != nil {
+		yellow.Printf("Warning: could not check network %s: %v\n", network, err)
+	} else if exists {
+		if err := rt.RemoveNetwork(network); err != nil {
+			yellow.Printf("Warning: could not remove network %s: %v\n", network, err)
+		} else {
+			green.Printf("Removed network: %s\n", network)
+		}
+	}
+
+	if removeVolumes {
+		volumes, err := rt.ListVolumes(profile.ServiceContainerName(containerName, ""))
+		if err != nil {
+			yellow.Printf("Warning: could not list volumes: %v\n", err)
+		}
+		for _, v := range volumes {
+			removeVolume(rt, v, green, yellow)
+		}
+	}
+}
+
+func removeVolume(rt runtime.Runtime, name string, green, yellow *color.Color) {
+	if err := rt.RemoveVolume(name); err != nil {
+		yellow.Printf("Warning: could not remove volume %s: %v\n", name, err)
+		return
+	}
+	green.Printf("Removed volume: %s\n", name)
+}
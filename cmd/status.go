@@ -4,11 +4,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 
 	"github.com/joelhelbling/glovebox/internal/digest"
 	"github.com/joelhelbling/glovebox/internal/docker"
+	"github.com/joelhelbling/glovebox/internal/dotfiles"
 	"github.com/joelhelbling/glovebox/internal/generator"
+	"github.com/joelhelbling/glovebox/internal/inventory"
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/mod"
+	"github.com/joelhelbling/glovebox/internal/output"
 	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/joelhelbling/glovebox/internal/runtime"
 	"github.com/joelhelbling/glovebox/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -16,15 +24,25 @@ import (
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show profile and Dockerfile status",
-	Long:  `Show the current status of your glovebox profiles, images, and Dockerfiles.`,
-	RunE:  runStatus,
+	Long: `Show the current status of your glovebox profiles, images, and Dockerfiles.
+
+Use --output json or --output yaml for machine-readable output, e.g. for a
+shell prompt or status line.`,
+	RunE: runStatus,
 }
 
 func init() {
+	addOutputFlag(statusCmd)
 	rootCmd.AddCommand(statusCmd)
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
+	rt := runtimeOf(cmd)
+
+	if err := output.ValidateFormat(outputFormat); err != nil {
+		return err
+	}
+
 	cwd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("getting current directory: %w", err)
@@ -42,17 +60,58 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("checking project profile: %w", err)
 	}
 
+	// Resolve the profiles the project extends
+	var ancestors []*profile.Profile
+	var chainErr error
+	if projectProfile != nil {
+		ancestors, chainErr = projectProfile.Ancestors()
+	}
+
 	// Build sections
 	var sections []ui.StatusSection
 
-	// Base image section
-	sections = append(sections, buildBaseSection(globalProfile))
+	// Base image sections: the default base, then any named bases
+	sections = append(sections, buildBaseSection(rt, globalProfile, ""))
+	baseNames, err := profile.ListBases()
+	if err != nil {
+		return fmt.Errorf("listing bases: %w", err)
+	}
+	for _, name := range baseNames {
+		baseProfile, err := profile.LoadBase(name)
+		if err != nil {
+			return fmt.Errorf("loading base %q: %w", name, err)
+		}
+		sections = append(sections, buildBaseSection(rt, baseProfile, name))
+	}
+
+	// Named profiles between the base and the project
+	for i, a := range ancestors {
+		if !a.IsBase() {
+			sections = append(sections, buildNamedProfileSection(rt, a, ancestors[:i]))
+		}
+	}
 
 	// Project image section
-	sections = append(sections, buildProjectSection(projectProfile, globalProfile))
+	sections = append(sections, buildProjectSection(rt, projectProfile, ancestors, chainErr))
 
 	// Container section
-	sections = append(sections, buildContainerSection(cwd))
+	sections = append(sections, buildContainerSection(rt, cwd))
+
+	// Sidecar services
+	if projectProfile != nil && len(projectProfile.Services) > 0 {
+		sections = append(sections, buildServicesSection(rt, cwd, projectProfile))
+	}
+
+	// Disk usage of the project's image, container and service volumes
+	if section, ok := buildDiskSection(rt, cwd); ok {
+		sections = append(sections, section)
+	}
+
+	if output.Structured(outputFormat) {
+		return output.Write(os.Stdout, outputFormat, "status", struct {
+			Sections []ui.StatusSection `json:"sections" yaml:"sections"`
+		}{sections})
+	}
 
 	// Render
 	status := ui.NewStatus()
@@ -63,10 +122,14 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func buildBaseSection(globalProfile *profile.Profile) ui.StatusSection {
+// buildBaseSection describes a base image; name is empty for the default base
+func buildBaseSection(rt runtime.Runtime, baseProfile *profile.Profile, name string) ui.StatusSection {
 	section := ui.StatusSection{Title: "Base Image"}
+	if name != "" {
+		section.Title = "Base Image: " + name
+	}
 
-	if globalProfile == nil {
+	if baseProfile == nil {
 		section.Items = append(section.Items,
 			ui.StatusItem{Label: "Profile", Value: "Not configured", Status: ui.StatusWarning},
 			ui.StatusItem{Value: "Run 'glovebox init --global' to create.", Status: ui.StatusInfo},
@@ -75,93 +138,157 @@ func buildBaseSection(globalProfile *profile.Profile) ui.StatusSection {
 	}
 
 	// Image status
+	imageName := profile.BaseImageFor(name)
 	imageStatus := ui.StatusOK
 	imageNote := ""
-	if !rt.ImageExists("glovebox:base") {
+	if exists, err := rt.ImageExists(imageName); err != nil {
+		imageStatus = ui.StatusWarning
+		imageNote = err.Error()
+	} else if !exists {
 		imageStatus = ui.StatusWarning
 		imageNote = "Run 'glovebox build --base' to build."
+		if name != "" {
+			imageNote = fmt.Sprintf("Run 'glovebox build --base --name %s' to build.", name)
+		}
 	}
 	section.Items = append(section.Items,
-		ui.StatusItem{Label: "Image", Value: "glovebox:base", Status: imageStatus, Note: imageNote},
+		ui.StatusItem{Label: "Image", Value: imageName, Status: imageStatus, Note: imageNote},
 	)
+	if imageNote == "" {
+		section.Items = append(section.Items, platformStatusItem(rt, imageName, []*profile.Profile{baseProfile}))
+	}
 
 	// Profile path
-	globalPath, _ := profile.GlobalPath()
 	section.Items = append(section.Items,
-		ui.StatusItem{Label: "Profile", Value: collapsePath(globalPath)},
+		ui.StatusItem{Label: "Profile", Value: collapsePath(baseProfile.Path)},
 	)
 
 	// Mods
 	section.Items = append(section.Items,
-		ui.StatusItem{Label: "Mods", Value: fmt.Sprintf("%d", len(globalProfile.Mods))},
+		ui.StatusItem{Label: "Mods", Value: fmt.Sprintf("%d", len(baseProfile.Mods))},
 	)
-	for _, m := range globalProfile.Mods {
+	for _, m := range baseProfile.Mods {
 		section.Items = append(section.Items,
 			ui.StatusItem{Value: m, IsList: true, Indent: 1},
 		)
 	}
 
 	// Dockerfile status
-	dockerfilePath := globalProfile.DockerfilePath()
-	section.Items = append(section.Items, getDockerfileStatusItems(globalProfile, dockerfilePath, func(mods []string) (string, error) {
-		return generator.GenerateBase(mods)
+	dockerfilePath := baseProfile.DockerfilePath()
+	section.Items = append(section.Items, getDockerfileStatusItems(baseProfile, dockerfilePath, func(mods []string) (string, error) {
+		return generator.GenerateBaseWithOptions(mods, recordedOptions(baseProfile))
 	})...)
+	section.Items = append(section.Items, getDotfilesStatusItems(baseProfile)...)
+
+	return section
+}
 
+func buildNamedProfileSection(rt runtime.Runtime, p *profile.Profile, ancestors []*profile.Profile) ui.StatusSection {
+	section := ui.StatusSection{Title: "Profile: " + p.Name}
+	section.Items = derivedImageStatusItems(rt, p, ancestors, fmt.Sprintf("Run 'glovebox build --profile %s' to build.", p.Name))
 	return section
 }
 
-func buildProjectSection(projectProfile *profile.Profile, globalProfile *profile.Profile) ui.StatusSection {
+func buildProjectSection(rt runtime.Runtime, projectProfile *profile.Profile, ancestors []*profile.Profile, chainErr error) ui.StatusSection {
 	section := ui.StatusSection{Title: "Project Image"}
 
 	if projectProfile == nil {
 		section.Items = append(section.Items,
-			ui.StatusItem{Label: "Profile", Value: "None (will use glovebox:base)", Status: ui.StatusInfo},
+			ui.StatusItem{Label: "Profile", Value: fmt.Sprintf("None (will use %s)", profile.BaseImageName), Status: ui.StatusInfo},
 			ui.StatusItem{Value: "Run 'glovebox init' to create a project-specific profile.", Status: ui.StatusInfo},
 		)
 		return section
 	}
 
+	if chainErr != nil {
+		section.Items = append(section.Items,
+			ui.StatusItem{Label: "Profile", Value: collapsePath(projectProfile.Path)},
+			ui.StatusItem{Label: "Extends", Value: projectProfile.Extends, Status: ui.StatusWarning, Note: chainErr.Error()},
+		)
+		return section
+	}
+
+	section.Items = derivedImageStatusItems(rt, projectProfile, ancestors, "Run 'glovebox build' to build.")
+	return section
+}
+
+// platformStatusItem shows the platforms an image is built for, warning
+// when they aren't those its profile chain asks for
+func platformStatusItem(rt runtime.Runtime, imageName string, chain []*profile.Profile) ui.StatusItem {
+	info, err := rt.InspectImage(imageName)
+	if err != nil {
+		return ui.StatusItem{Label: "Platform", Value: "unknown", Status: ui.StatusWarning, Note: err.Error()}
+	}
+	item := ui.StatusItem{Label: "Platform", Value: formatPlatforms(info.Platforms)}
+	platforms, err := mod.ParsePlatforms(profile.ChainPlatform(chain))
+	if err != nil {
+		item.Status, item.Note = ui.StatusWarning, err.Error()
+		return item
+	}
+	if mismatch, err := platformMismatch(rt, imageName, platforms); err == nil && mismatch != "" {
+		item.Status, item.Note = ui.StatusWarning, mismatch+". Rebuild to match the profile."
+	}
+	return item
+}
+
+// derivedImageStatusItems describes the image of a project or named profile
+// built on top of the given chain of ancestor profiles.
+func derivedImageStatusItems(rt runtime.Runtime, p *profile.Profile, ancestors []*profile.Profile, buildHint string) []ui.StatusItem {
+	var items []ui.StatusItem
+
 	// Image status
-	imageName := projectProfile.ImageName()
+	imageName := p.ImageName()
 	imageStatus := ui.StatusOK
 	imageNote := ""
-	if !rt.ImageExists(imageName) {
+	if exists, err := rt.ImageExists(imageName); err != nil {
+		imageStatus = ui.StatusWarning
+		imageNote = err.Error()
+	} else if !exists {
 		imageStatus = ui.StatusWarning
-		imageNote = "Run 'glovebox build' to build."
+		imageNote = buildHint
 	}
-	section.Items = append(section.Items,
+	items = append(items,
 		ui.StatusItem{Label: "Image", Value: imageName, Status: imageStatus, Note: imageNote},
 	)
+	if imageNote == "" {
+		items = append(items, platformStatusItem(rt, imageName, append(slices.Clone(ancestors), p)))
+	}
 
-	// Profile path
-	section.Items = append(section.Items,
-		ui.StatusItem{Label: "Profile", Value: collapsePath(projectProfile.Path)},
+	// Profile path and parent
+	items = append(items,
+		ui.StatusItem{Label: "Profile", Value: collapsePath(p.Path)},
+		ui.StatusItem{Label: "Extends", Value: p.ParentImageName()},
 	)
 
 	// Mods
-	section.Items = append(section.Items,
-		ui.StatusItem{Label: "Mods", Value: fmt.Sprintf("%d", len(projectProfile.Mods))},
+	items = append(items,
+		ui.StatusItem{Label: "Mods", Value: fmt.Sprintf("%d", len(p.Mods))},
 	)
-	for _, m := range projectProfile.Mods {
-		section.Items = append(section.Items,
+	for _, m := range p.Mods {
+		items = append(items,
 			ui.StatusItem{Value: m, IsList: true, Indent: 1},
 		)
 	}
 
 	// Dockerfile status
-	dockerfilePath := projectProfile.DockerfilePath()
-	var baseMods []string
-	if globalProfile != nil {
-		baseMods = globalProfile.Mods
-	}
-	section.Items = append(section.Items, getDockerfileStatusItems(projectProfile, dockerfilePath, func(mods []string) (string, error) {
-		return generator.GenerateProject(mods, baseMods)
+	items = append(items, getDockerfileStatusItems(p, p.DockerfilePath(), func(mods []string) (string, error) {
+		return generateDockerfile(p, ancestors, recordedOptions(p))
 	})...)
 
-	return section
+	// Parent image rebuilt since this image was built
+	if p.Build.BaseDigest != "" {
+		if parentDigest, err := rt.GetImageDigest(p.ParentImageName()); err == nil && parentDigest != p.Build.BaseDigest {
+			items = append(items,
+				ui.StatusItem{Value: fmt.Sprintf("%s has changed since last build", p.ParentImageName()), Status: ui.StatusWarning, Indent: 1},
+			)
+		}
+	}
+
+	items = append(items, getDotfilesStatusItems(p)...)
+	return items
 }
 
-func buildContainerSection(cwd string) ui.StatusSection {
+func buildContainerSection(rt runtime.Runtime, cwd string) ui.StatusSection {
 	section := ui.StatusSection{Title: "Container"}
 
 	// Workspace
@@ -177,19 +304,30 @@ func buildContainerSection(cwd string) ui.StatusSection {
 		ui.StatusItem{Label: "Container", Value: containerName},
 	)
 
-	if rt.ContainerExists(containerName) {
-		if rt.ContainerRunning(containerName) {
+	exists, err := rt.ContainerExists(containerName)
+	var running bool
+	if err == nil && exists {
+		running, err = rt.ContainerRunning(containerName)
+	}
+	if err != nil {
+		section.Items = append(section.Items,
+			ui.StatusItem{Label: "Status", Value: "Unknown", Status: ui.StatusWarning, Note: err.Error()},
+		)
+	} else if exists {
+		if running {
 			section.Items = append(section.Items,
 				ui.StatusItem{Label: "Status", Value: "Running", Status: ui.StatusOK},
 			)
+			section.Items = append(section.Items, containerDriftItems(rt, cwd, containerName)...)
 		} else {
 			section.Items = append(section.Items,
 				ui.StatusItem{Label: "Status", Value: "Stopped (will resume on next run)", Status: ui.StatusOK},
 			)
+			section.Items = append(section.Items, containerDriftItems(rt, cwd, containerName)...)
 			// Check for uncommitted changes (only if runtime supports diff)
 			caps := rt.Capabilities()
 			if caps.SupportsDiff {
-				changes, err := getContainerChanges(containerName)
+				changes, err := getContainerChanges(rt, containerName)
 				if err == nil && len(changes) > 0 {
 					section.Items = append(section.Items,
 						ui.StatusItem{Label: "Changes", Value: fmt.Sprintf("%d uncommitted", len(changes)), Status: ui.StatusWarning},
@@ -206,6 +344,120 @@ func buildContainerSection(cwd string) ui.StatusSection {
 	return section
 }
 
+// containerDriftItems reports the ways an existing container no longer
+// matches its profiles and image
+func containerDriftItems(rt runtime.Runtime, cwd, containerName string) []ui.StatusItem {
+	imageName, err := getImageNameForCommit(cwd)
+	if err != nil {
+		return nil
+	}
+	services, _ := loadServices(cwd)
+	spec, err := newContainerSpec(containerName, imageName, cwd, services != nil)
+	if err != nil {
+		return nil
+	}
+
+	var items []ui.StatusItem
+	for _, reason := range spec.drift(rt, containerName) {
+		items = append(items, ui.StatusItem{
+			Label:  "Drift",
+			Value:  "container is stale: " + reason,
+			Status: ui.StatusWarning,
+			Note:   "'glovebox run' offers to recreate it",
+		})
+	}
+	return items
+}
+
+// buildDiskSection describes the disk space taken by the project; ok is
+// false when it has nothing on disk yet
+func buildDiskSection(rt runtime.Runtime, cwd string) (ui.StatusSection, bool) {
+	section := ui.StatusSection{Title: "Disk Usage"}
+	containerName := docker.ContainerName(cwd)
+
+	if img, err := rt.InspectImage(docker.ImageName(cwd)); err == nil {
+		images := []runtime.ImageInfo{img}
+		bases, _ := rt.ListImages(runtime.ListFilter{Labels: labels.Selector(labels.RoleBase)})
+		if !slices.Contains(bases, profile.BaseImageName) {
+			bases = append(bases, profile.BaseImageName)
+		}
+		for _, name := range bases {
+			if base, err := rt.InspectImage(name); err == nil {
+				images = append(images, base)
+			}
+		}
+		for _, u := range inventory.ImagesUsage(images) {
+			if u.Name != img.Name {
+				continue
+			}
+			value := formatSize(u.Size)
+			if u.Base != "" {
+				value = fmt.Sprintf("%s (%s shared with %s, %s unique)", value, formatSize(u.Shared), u.Base, formatSize(u.Unique))
+			}
+			section.Items = append(section.Items, ui.StatusItem{Label: "Image", Value: value})
+		}
+	}
+
+	usage, err := rt.DiskUsage()
+	if err != nil {
+		return section, len(section.Items) > 0
+	}
+	if size, ok := usage.Containers[containerName]; ok {
+		section.Items = append(section.Items,
+			ui.StatusItem{Label: "Container", Value: fmt.Sprintf("%s writable layer", formatSize(size))},
+		)
+	}
+	volumes, _ := rt.ListVolumes(profile.ServiceContainerName(containerName, ""))
+	if len(volumes) > 0 {
+		sort.Strings(volumes)
+		section.Items = append(section.Items,
+			ui.StatusItem{Label: "Volumes", Value: fmt.Sprintf("%d", len(volumes))},
+		)
+		for _, v := range volumes {
+			section.Items = append(section.Items,
+				ui.StatusItem{Value: fmt.Sprintf("%s (%s)", v, formatSize(usage.Volumes[v])), IsList: true, Indent: 1},
+			)
+		}
+	}
+	return section, len(section.Items) > 0
+}
+
+// buildServicesSection lists the project's sidecar services and their state
+func buildServicesSection(rt runtime.Runtime, cwd string, p *profile.Profile) ui.StatusSection {
+	section := ui.StatusSection{Title: "Services"}
+	if err := p.ValidateServices(); err != nil {
+		section.Items = append(section.Items,
+			ui.StatusItem{Label: "Profile", Value: "Invalid services", Status: ui.StatusWarning, Note: err.Error()},
+		)
+		return section
+	}
+
+	containerName := docker.ContainerName(cwd)
+	section.Items = append(section.Items,
+		ui.StatusItem{Label: "Network", Value: profile.NetworkName(containerName)},
+	)
+	for _, name := range p.ServiceNames() {
+		svc := p.Services[name]
+		state, err := serviceState(rt, containerName, name)
+		status, note := ui.StatusInfo, ""
+		switch {
+		case err != nil:
+			state, status, note = "unknown", ui.StatusWarning, err.Error()
+		case state == "running":
+			status = ui.StatusOK
+		}
+		section.Items = append(section.Items,
+			ui.StatusItem{Label: name, Value: fmt.Sprintf("%s (%s)", svc.Image, state), Status: status, Note: note},
+		)
+		for _, target := range svc.Volumes {
+			section.Items = append(section.Items,
+				ui.StatusItem{Value: fmt.Sprintf("%s → %s", profile.ServiceVolumeName(containerName, name, target), target), IsList: true, Indent: 1},
+			)
+		}
+	}
+	return section
+}
+
 func getDockerfileStatusItems(p *profile.Profile, dockerfilePath string, generateFunc func([]string) (string, error)) []ui.StatusItem {
 	var items []ui.StatusItem
 
@@ -267,7 +519,60 @@ func getDockerfileStatusItems(p *profile.Profile, dockerfilePath string, generat
 	return items
 }
 
-func getContainerChanges(name string) ([]string, error) {
+// recordedOptions returns the generator options as of the profile's last
+// build, so dotfiles content changes are reported separately from profile edits.
+func recordedOptions(p *profile.Profile) generator.Options {
+	return generator.Options{
+		Dotfiles:         p.Dotfiles,
+		DotfilesRevision: p.Build.DotfilesRevision,
+		Strategy:         p.BuildStrategy,
+		From:             p.Build.From,
+		FromDigest:       p.Build.FromDigest,
+	}
+}
+
+func getDotfilesStatusItems(p *profile.Profile) []ui.StatusItem {
+	if p.Dotfiles == nil {
+		return nil
+	}
+
+	items := []ui.StatusItem{
+		{Label: "Dotfiles", Value: p.Dotfiles.Source},
+	}
+
+	if p.Dotfiles.EffectiveApply() == dotfiles.ApplyCreate {
+		items = append(items,
+			ui.StatusItem{Value: "Installed when a container is created", Status: ui.StatusInfo, Indent: 1},
+		)
+		return items
+	}
+
+	if p.Build.DotfilesRevision == "" {
+		items = append(items,
+			ui.StatusItem{Value: "Not yet built into image", Status: ui.StatusWarning, Indent: 1},
+		)
+		return items
+	}
+
+	current, err := dotfiles.CurrentRevision(p.Dotfiles)
+	switch {
+	case err != nil:
+		items = append(items,
+			ui.StatusItem{Value: fmt.Sprintf("Could not check for changes (%v)", err), Status: ui.StatusInfo, Indent: 1},
+		)
+	case current != p.Build.DotfilesRevision:
+		items = append(items,
+			ui.StatusItem{Value: "Changed since last build", Status: ui.StatusWarning, Indent: 1},
+		)
+	default:
+		items = append(items,
+			ui.StatusItem{Value: fmt.Sprintf("Up to date (%s)", dotfiles.ShortRevision(current)), Status: ui.StatusOK, Indent: 1},
+		)
+	}
+	return items
+}
+
+func getContainerChanges(rt runtime.Runtime, name string) ([]string, error) {
 	diffs, err := rt.Diff(name)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joelhelbling/glovebox/internal/docker"
+	"github.com/joelhelbling/glovebox/internal/dotfiles"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+	"github.com/joelhelbling/glovebox/internal/ui"
+)
+
+// statusItem finds an item by section title and label in status JSON output
+func statusItem(t *testing.T, out, title, label string) *ui.StatusItem {
+	t.Helper()
+	var doc struct {
+		Kind string `json:"kind"`
+		Data struct {
+			Sections []struct {
+				Title string `json:"title"`
+				Items []struct {
+					Label  string `json:"label"`
+					Value  string `json:"value"`
+					Status string `json:"status"`
+					Note   string `json:"note"`
+				} `json:"items"`
+			} `json:"sections"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("parsing status output: %v\n%s", err, out)
+	}
+	if doc.Kind != "status" {
+		t.Fatalf("kind = %q, want status", doc.Kind)
+	}
+	for _, s := range doc.Data.Sections {
+		if s.Title != title {
+			continue
+		}
+		for _, it := range s.Items {
+			if it.Label == label {
+				item := &ui.StatusItem{Label: it.Label, Value: it.Value, Note: it.Note}
+				if it.Status == "warning" {
+					item.Status = ui.StatusWarning
+				}
+				return item
+			}
+		}
+	}
+	return nil
+}
+
+func TestStatus(t *testing.T) {
+	t.Run("reports the container", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+
+		out := env.mustRun("", "status", "-o", "json")
+		if item := statusItem(t, out, "Container", "Status"); item == nil || item.Value != "Will be created on first run" {
+			t.Errorf("status before run = %+v", item)
+		}
+
+		env.mustRun("", "run")
+		out = env.mustRun("", "status", "-o", "json")
+		if item := statusItem(t, out, "Container", "Status"); item == nil || !strings.HasPrefix(item.Value, "Stopped") {
+			t.Errorf("status after run = %+v", item)
+		}
+	})
+
+	t.Run("warns when the runtime can't be reached", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.saveGlobal("os/ubuntu")
+		env.rt.FailOn("*", runtime.ErrDaemonUnavailable)
+
+		out := env.mustRun("", "status", "-o", "json")
+		item := statusItem(t, out, "Container", "Status")
+		if item == nil || item.Value != "Unknown" || item.Status != ui.StatusWarning || item.Note == "" {
+			t.Errorf("status = %+v, want an Unknown warning", item)
+		}
+	})
+
+	t.Run("checks drift without fetching dotfiles", func(t *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git not available")
+		}
+		env := newTestEnv(t)
+		repo := filepath.Join(env.home, "dotfiles.git")
+		commit := func(content string) {
+			t.Helper()
+			if err := os.WriteFile(filepath.Join(repo, ".zshrc"), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			for _, args := range [][]string{{"add", "."}, {"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "--quiet", "-m", content}} {
+				if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+					t.Fatalf("git %v: %v\n%s", args, err, out)
+				}
+			}
+		}
+		if out, err := exec.Command("git", "init", "--quiet", repo).CombinedOutput(); err != nil {
+			t.Fatalf("git init: %v\n%s", err, out)
+		}
+		commit("v1")
+
+		p := env.saveGlobal("os/ubuntu")
+		p.Dotfiles = &dotfiles.Config{Source: "file://" + repo, Apply: dotfiles.ApplyCreate}
+		if err := p.Save(); err != nil {
+			t.Fatal(err)
+		}
+		env.mustRun("", "run")
+
+		cfg := p.Dotfiles
+		checkout, err := dotfiles.CachePath(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		before, err := dotfiles.LocalRevision(cfg, checkout)
+		if err != nil {
+			t.Fatalf("dotfiles not fetched when creating the container: %v", err)
+		}
+		commit("v2")
+
+		out := env.mustRun("", "status", "-o", "json")
+		if item := statusItem(t, out, "Container", "Drift"); item != nil {
+			t.Errorf("drift = %+v, want none", item)
+		}
+		if after, _ := dotfiles.LocalRevision(cfg, checkout); after != before {
+			t.Errorf("status updated the dotfiles checkout from %s to %s", before, after)
+		}
+	})
+
+	t.Run("dotfiles that couldn't be fetched aren't drift", func(t *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git not available")
+		}
+		env := newTestEnv(t)
+		p := env.saveGlobal("os/ubuntu")
+		p.Dotfiles = &dotfiles.Config{Source: "file://" + filepath.Join(env.home, "missing.git"), Apply: dotfiles.ApplyCreate}
+		if err := p.Save(); err != nil {
+			t.Fatal(err)
+		}
+
+		if out := env.mustRun("", "run"); !strings.Contains(out, "dotfiles not mounted") {
+			t.Errorf("output = %q, want the failed fetch reported", out)
+		}
+		c := env.rt.Container(docker.ContainerName(env.project))
+		for _, m := range c.Run.Mounts {
+			if m.Target == dotfiles.ContainerDir {
+				t.Errorf("mounts = %+v, want the dotfiles left out", c.Run.Mounts)
+			}
+		}
+
+		out := env.mustRun("", "status", "-o", "json")
+		if item := statusItem(t, out, "Container", "Drift"); item != nil {
+			t.Errorf("drift = %+v, want none", item)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+	"github.com/joelhelbling/glovebox/internal/workspace"
+)
+
+// A remote Docker host can't bind-mount the project, so the container keeps
+// its workspace in a volume: the project is copied in before each session
+// and copied back when the session ends. A snapshot of what was copied in
+// is kept, so copying back only overwrites files the project didn't change
+// meanwhile, also when another glovebox process attached to the session.
+
+// errSyncAborted stops packing when the copy to the container fails
+var errSyncAborted = errors.New("workspace sync aborted")
+
+// syncedSession runs an interactive session in a container whose workspace
+// is synced with the project. The workspace is synced back even when the
+// session fails, so no work is lost.
+func syncedSession(rt runtime.Runtime, containerName, hostPath, workspacePath string, session func() error) error {
+	colorDim.Printf("Syncing %s to the remote Docker host...\n", collapsePath(hostPath))
+	pushed, err := pushWorkspace(rt, containerName, hostPath, workspacePath)
+	if err != nil {
+		return fmt.Errorf("syncing workspace: %w", err)
+	}
+
+	sessionErr := session()
+	if err := pullWorkspace(rt, containerName, hostPath, workspacePath, pushed); err != nil {
+		if sessionErr != nil {
+			colorYellow.Printf("Warning: could not sync the workspace back: %v\n", err)
+			return sessionErr
+		}
+		return fmt.Errorf("syncing workspace back: %w", err)
+	}
+	return sessionErr
+}
+
+// pushWorkspace replaces the container's workspace with the project,
+// returning a snapshot of the files it sent. The workspace is emptied
+// first, so files deleted from the project since the last session don't
+// linger in the container and come back with the next sync. Work a session
+// left in the container without syncing it back, as when the connection
+// was lost, is synced back before; the workspace is kept when that fails.
+func pushWorkspace(rt runtime.Runtime, containerName, hostPath, workspacePath string) (workspace.Snapshot, error) {
+	if err := pullWorkspace(rt, containerName, hostPath, workspacePath, nil); err != nil {
+		return nil, fmt.Errorf("syncing back work left in the container, which is kept: %w", err)
+	}
+	helper := labels.New(Version, labels.RoleHelper, time.Now())
+	helper[labels.Project] = hostPath
+	if err := rt.ClearDirectory(containerName, workspacePath, helper); err != nil {
+		return nil, err
+	}
+
+	type packed struct {
+		files workspace.Snapshot
+		err   error
+	}
+	r, w := io.Pipe()
+	done := make(chan packed, 1)
+	go func() {
+		files, err := workspace.Pack(hostPath, w)
+		w.CloseWithError(err)
+		done <- packed{files, err}
+	}()
+
+	copyErr := rt.CopyToContainer(containerName, workspacePath, r)
+	r.CloseWithError(errSyncAborted)
+	result := <-done
+	// A packing failure also fails the copy; report its cause
+	if result.err != nil && !errors.Is(result.err, errSyncAborted) {
+		return nil, result.err
+	}
+	if copyErr != nil {
+		return nil, copyErr
+	}
+	if err := saveSyncSnapshot(containerName, result.files); err != nil {
+		return nil, err
+	}
+	return result.files, nil
+}
+
+// pullWorkspace copies the container's workspace back to the project.
+// Changes made in the session are applied to files the project hasn't
+// changed since they were pushed; pushed files that are gone were deleted
+// in the session. Files changed on both sides are reported, not
+// overwritten. A nil snapshot is read from the last push.
+func pullWorkspace(rt runtime.Runtime, containerName, hostPath, workspacePath string, pushed workspace.Snapshot) error {
+	if pushed == nil {
+		var err error
+		if pushed, err = loadSyncSnapshot(containerName); err != nil {
+			return err
+		}
+	}
+	archive, err := rt.CopyFromContainer(containerName, workspacePath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	stats, err := workspace.Unpack(archive, hostPath, pushed)
+	if err != nil {
+		return err
+	}
+	if stats.Updated > 0 || stats.Removed > 0 {
+		colorDim.Printf("Synced workspace back: %d updated, %d removed\n", stats.Updated, stats.Removed)
+	}
+	if len(stats.Conflicts) > 0 {
+		colorYellow.Printf("⚠ %d file(s) changed both here and in the container were kept as they are here:\n", len(stats.Conflicts))
+		for _, name := range stats.Conflicts {
+			fmt.Printf("  %s\n", name)
+		}
+		colorDim.Printf("The container's versions of changed files are saved beside them, ending in %s.\n", workspace.ConflictSuffix)
+	}
+	return nil
+}
+
+// syncSnapshotPath is where the snapshot of a container's last push is kept
+func syncSnapshotPath(containerName string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting home directory: %w", err)
+	}
+	return filepath.Join(home, ".glovebox", "cache", "sync", containerName+".json"), nil
+}
+
+func saveSyncSnapshot(containerName string, s workspace.Snapshot) error {
+	file, err := syncSnapshotPath(containerName)
+	if err != nil {
+		return err
+	}
+	if err := s.Save(file); err != nil {
+		return fmt.Errorf("saving workspace snapshot: %w", err)
+	}
+	return nil
+}
+
+// removeSyncSnapshot forgets the last push to a container, for a container
+// that was recreated under its name
+func removeSyncSnapshot(containerName string) error {
+	file, err := syncSnapshotPath(containerName)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing workspace snapshot: %w", err)
+	}
+	return nil
+}
+
+func loadSyncSnapshot(containerName string) (workspace.Snapshot, error) {
+	file, err := syncSnapshotPath(containerName)
+	if err != nil {
+		return nil, err
+	}
+	s, err := workspace.LoadSnapshot(file)
+	if err != nil {
+		return nil, fmt.Errorf("loading workspace snapshot: %w", err)
+	}
+	return s, nil
+}
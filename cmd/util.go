@@ -16,3 +16,9 @@ func collapsePath(path string) string {
 	}
 	return path
 }
+
+// isFile reports whether path exists and is a regular file
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
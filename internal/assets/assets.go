@@ -6,3 +6,6 @@ import (
 
 //go:embed entrypoint.sh
 var EntrypointScript string
+
+//go:embed hooks.sh
+var HooksScript string
@@ -1,5 +1,65 @@
 #!/bin/bash
 set -euo pipefail
 
-# Execute the requested command (default shell)
-exec "$@"
+hooks=/usr/local/bin/glovebox-hooks
+state="$HOME/.local/state/glovebox"
+
+# Runtimes without --add-host (Apple Containers) ask us to map the host alias
+# to the default gateway, which is the host
+if [ -n "${GLOVEBOX_HOST_ALIAS:-}" ] && ! grep -q "[[:space:]]$GLOVEBOX_HOST_ALIAS\$" /etc/hosts; then
+  gw=$(awk '$2 == "00000000" { print $3; exit }' /proc/net/route 2>/dev/null || true)
+  if [ -n "$gw" ]; then
+    ip=$(printf '%d.%d.%d.%d' "0x${gw:6:2}" "0x${gw:4:2}" "0x${gw:2:2}" "0x${gw:0:2}")
+    echo "$ip $GLOVEBOX_HOST_ALIAS" | sudo -n tee -a /etc/hosts >/dev/null 2>&1 ||
+      echo "glovebox: could not add $GLOVEBOX_HOST_ALIAS to /etc/hosts" >&2
+  fi
+fi
+
+# Tools that run the hooks themselves (e.g. a devcontainer.json export) set
+# GLOVEBOX_SKIP_HOOKS so they don't run twice
+if [ -n "${GLOVEBOX_SKIP_HOOKS:-}" ]; then
+  exec "$@"
+fi
+
+# on_create hooks run on the first start of this container. GLOVEBOX_INSTANCE
+# is set when the container is created, so a marker carried into an image by
+# `glovebox commit` doesn't suppress hooks in new containers.
+instance="${GLOVEBOX_INSTANCE:-default}"
+if [ -x "$hooks" ] && [ "$(cat "$state/created" 2>/dev/null)" != "$instance" ]; then
+  "$hooks" on_create
+  mkdir -p "$state" && echo "$instance" > "$state/created"
+fi
+
+if [ -x "$hooks" ]; then
+  "$hooks" on_start
+fi
+
+# Without exit hooks, hand the process over to the command (default shell)
+if [ ! -x "$hooks" ] || [ -z "$(ls -A /etc/glovebox/hooks/on_exit 2>/dev/null)" ]; then
+  exec "$@"
+fi
+
+# Run the command in the background and pass on the signals that stop the
+# container (`docker stop` sends TERM), so the exit hooks still run after it
+# exits. A background command reads /dev/null unless stdin is redirected,
+# and ignores INT and QUIT unless their traps are reset.
+( trap - INT QUIT; exec "$@" ) <&0 &
+child=$!
+interrupted=
+for sig in TERM INT HUP; do
+  trap "interrupted=1; kill -$sig $child 2>/dev/null || true" "$sig"
+done
+
+# A trapped signal interrupts wait with 128+signal before the command has
+# exited, so wait again for its status
+status=0
+wait "$child" || status=$?
+while [ -n "$interrupted" ] && [ "$status" -gt 128 ]; do
+  interrupted=
+  status=0
+  wait "$child" || status=$?
+done
+trap - TERM INT HUP
+
+"$hooks" on_exit
+exit "$status"
@@ -0,0 +1,27 @@
+#!/bin/bash
+# Runs the mod lifecycle hooks for one phase: glovebox-hooks <on_create|on_start|on_exit>
+# Hooks run in order; a failing hook is reported but never stops the others.
+set -uo pipefail
+
+phase="${1:?usage: glovebox-hooks <phase>}"
+dir="/etc/glovebox/hooks/$phase"
+
+[ -d "$dir" ] || exit 0
+
+failed=0
+for hook in "$dir"/*.sh; do
+  [ -e "$hook" ] || continue
+  name="$(basename "$hook" .sh)"
+  echo "glovebox: $phase: ${name#*-*-}"
+  bash "$hook"
+  status=$?
+  if [ "$status" -ne 0 ]; then
+    echo "glovebox: $phase hook ${name#*-*-} failed (exit $status)" >&2
+    failed=$((failed + 1))
+  fi
+done
+
+if [ "$failed" -gt 0 ]; then
+  echo "glovebox: $failed $phase hook(s) failed; continuing" >&2
+fi
+exit 0
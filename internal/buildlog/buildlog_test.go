@@ -0,0 +1,176 @@
+package buildlog
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readTestdata(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSteps(t *testing.T) {
+	steps := Steps(readTestdata(t, "Dockerfile"))
+
+	var got []string
+	for _, s := range steps {
+		got = append(got, s.Label())
+	}
+	want := []string{
+		"FROM ubuntu:24.04",
+		"ubuntu run_as_root",
+		"RUN cat > /usr/local/bin/entrypoint.sh <<'EOF'",
+		"RUN chmod 755 /usr/local/bin/entrypoint.sh",
+		"COPY --chown=root:root --chmod=0755 <<'GLOVEBOX_FILE' /usr/local/bin/glovebox-hooks",
+		"WORKDIR /home/dev",
+		"homebrew-ubuntu run_as_user",
+		"WORKDIR /workspace",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Steps() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if steps[6].Index != 7 {
+		t.Errorf("homebrew step index = %d, want 7", steps[6].Index)
+	}
+}
+
+func TestStepsSections(t *testing.T) {
+	dockerfile := `FROM glovebox:base
+
+USER root
+
+# tmux-config files
+# source: tmux.conf (sha256:abc)
+COPY --chown=dev:dev build-files/tmux-config/0-tmux.conf /home/dev/.tmux.conf
+COPY --chown=dev:dev <<'GLOVEBOX_FILE' /home/dev/.tmux.local
+set -g mouse on
+GLOVEBOX_FILE
+
+# node on_create hook
+COPY --chown=root:root --chmod=0755 <<'GLOVEBOX_FILE' /etc/glovebox/hooks/on_create/10-node
+#!/bin/bash
+npm ci
+GLOVEBOX_FILE
+
+# Switch back to non-root user
+USER dev
+WORKDIR /home/dev
+`
+	var got []string
+	for _, s := range Steps(dockerfile) {
+		got = append(got, s.Mod+"|"+s.Section)
+	}
+	want := []string{"|", "tmux-config|files", "tmux-config|files", "node|on_create", "|"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Steps() = %v, want %v", got, want)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	t.Run("attributes a failure to its mod", func(t *testing.T) {
+		p := NewProgress(readTestdata(t, "Dockerfile"))
+		var finished []Result
+		p.OnStep = func(r Result) { finished = append(finished, r) }
+
+		// Output arrives in arbitrary chunks
+		output := readTestdata(t, "homebrew-failure.txt")
+		for len(output) > 0 {
+			n := min(len(output), 37)
+			_, _ = p.Write([]byte(output[:n]))
+			output = output[n:]
+		}
+		p.Flush()
+
+		f := p.Failure()
+		if f == nil {
+			t.Fatal("Failure() = nil")
+		}
+		if f.Mod != "homebrew-ubuntu" || f.Section != SectionRunAsUser {
+			t.Errorf("failed in %q %q, want homebrew-ubuntu run_as_user", f.Mod, f.Section)
+		}
+		wantTail := []string{
+			"==> Checking for `sudo` access (which may request your password)...",
+			"==> Downloading and installing Homebrew...",
+			"curl: (6) Could not resolve host: github.com",
+			"Failed during: git fetch --force origin",
+		}
+		if !slices.Equal(f.Tail, wantTail) {
+			t.Errorf("Tail = %q", f.Tail)
+		}
+		if !strings.Contains(f.Error, "exit code: 1") {
+			t.Errorf("Error = %q", f.Error)
+		}
+
+		if len(finished) != 6 {
+			t.Fatalf("finished %d steps, want 6", len(finished))
+		}
+		if !finished[0].Cached || finished[1].Mod != "ubuntu" || finished[1].Duration != 42300*time.Millisecond {
+			t.Errorf("finished = %+v", finished[:2])
+		}
+
+		timings := p.Timings()
+		if timings[0].Mod != "ubuntu" || timings[0].Duration != 42300*time.Millisecond {
+			t.Errorf("Timings() = %+v, want ubuntu slowest", timings)
+		}
+	})
+
+	t.Run("keeps the last lines of output without a failed step", func(t *testing.T) {
+		p := NewProgress("FROM scratch\n")
+		for i := range 30 {
+			_, _ = p.Write([]byte("line " + strings.Repeat("x", i) + "\n"))
+		}
+		_, _ = p.Write([]byte("ERROR: failed to solve: dockerfile parse error"))
+		p.Flush()
+
+		if p.Failure() != nil {
+			t.Errorf("Failure() = %+v, want nil", p.Failure())
+		}
+		tail := p.Tail()
+		if len(tail) != TailLines || tail[len(tail)-1] != "ERROR: failed to solve: dockerfile parse error" {
+			t.Errorf("Tail() = %q", tail)
+		}
+	})
+}
+
+func TestCompareCache(t *testing.T) {
+	built := readTestdata(t, "Dockerfile")
+
+	tests := []struct {
+		name    string
+		pending string
+		cached  int
+		change  string
+		mod     string
+	}{
+		{"unchanged", built, 8, ChangeNone, ""},
+		{"script edited", strings.Replace(built, "Install Homebrew", "Install brew", 1), 6, ChangeModified, "homebrew-ubuntu"},
+		{"environment changed", strings.Replace(built, "ENV HOMEBREW_PREFIX", "ENV HOMEBREW_NO_ANALYTICS=1\nENV HOMEBREW_PREFIX", 1), 6, ChangeModified, "homebrew-ubuntu"},
+		{"header comment changed", strings.Replace(built, "# Generated by glovebox", "# Generated by glovebox v2", 1), 8, ChangeNone, ""},
+		{"mod removed", built[:strings.Index(built, "# homebrew-ubuntu setup (user)")] + "WORKDIR /workspace\n", 6, ChangeRemoved, "homebrew-ubuntu"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := CompareCache(built, tt.pending)
+			if r.Cached != tt.cached || r.Change != tt.change || r.ChangedMod != tt.mod {
+				t.Errorf("CompareCache() = cached %d, %q %q; want cached %d, %q %q",
+					r.Cached, r.Change, r.ChangedMod, tt.cached, tt.change, tt.mod)
+			}
+		})
+	}
+
+	t.Run("nothing built", func(t *testing.T) {
+		r := CompareCache("", built)
+		if r.Cached != 0 || len(r.Rebuilt()) != 8 || r.Change != ChangeAdded {
+			t.Errorf("CompareCache() = %+v", r)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package buildlog
+
+// Changes to a Dockerfile, as CompareCache describes them
+const (
+	ChangeNone     = ""
+	ChangeAdded    = "added"    // the mod, or step, is new to the image
+	ChangeRemoved  = "removed"  // the mod that was here is no longer in the image
+	ChangeModified = "modified" // the step's mod, or glovebox's own step, changed
+	ChangeMoved    = "moved"    // the step now follows different steps
+)
+
+// CacheReport predicts how much of an image BuildKit will rebuild for a
+// pending Dockerfile: every step from the first one that changed.
+type CacheReport struct {
+	Steps []Step // the pending Dockerfile's steps
+	// Cached is how many leading steps are unchanged and will come from
+	// the cache
+	Cached int
+	// Change explains the first rebuilt step. It is ChangeNone when every
+	// step is cached.
+	Change string
+	// ChangedMod is the mod the change is about, "" for glovebox's own steps
+	ChangedMod string
+}
+
+// Rebuilt returns the steps that will be rebuilt
+func (r CacheReport) Rebuilt() []Step {
+	return r.Steps[r.Cached:]
+}
+
+// CompareCache compares the Dockerfile an image was built from with a
+// pending one. Only the Dockerfile is considered: a rebuilt parent image or
+// changed build args also invalidate the cache.
+func CompareCache(built, pending string) CacheReport {
+	old := Steps(built)
+	report := CacheReport{Steps: Steps(pending)}
+	for report.Cached < len(report.Steps) && report.Cached < len(old) &&
+		report.Steps[report.Cached].Text == old[report.Cached].Text {
+		report.Cached++
+	}
+	if report.Cached == len(report.Steps) {
+		return report
+	}
+
+	next := report.Steps[report.Cached]
+	oldMods := stepMods(old)
+	newMods := stepMods(report.Steps)
+	switch {
+	case report.Cached == len(old), next.Mod != "" && !oldMods[next.Mod]:
+		report.Change, report.ChangedMod = ChangeAdded, next.Mod
+	case report.Cached < len(old) && old[report.Cached].Mod != "" && !newMods[old[report.Cached].Mod]:
+		report.Change, report.ChangedMod = ChangeRemoved, old[report.Cached].Mod
+	case report.Cached < len(old) && old[report.Cached].Label() == next.Label():
+		report.Change, report.ChangedMod = ChangeModified, next.Mod
+	default:
+		report.Change, report.ChangedMod = ChangeMoved, next.Mod
+	}
+	return report
+}
+
+// stepMods returns the mods that have steps
+func stepMods(steps []Step) map[string]bool {
+	mods := make(map[string]bool)
+	for _, s := range steps {
+		if s.Mod != "" {
+			mods[s.Mod] = true
+		}
+	}
+	return mods
+}
@@ -0,0 +1,216 @@
+package buildlog
+
+import (
+	"bytes"
+	"cmp"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TailLines is how much of a failed step's output a Failure keeps
+const TailLines = 20
+
+// Result is a finished build step
+type Result struct {
+	Step
+	Duration time.Duration
+	Cached   bool
+}
+
+// Failure describes the step a build failed in
+type Failure struct {
+	Step         // zero when the failure couldn't be tied to a step
+	Error string // BuildKit's error message
+	Tail  []string
+}
+
+// Timing is the time a build spent on one mod's steps
+type Timing struct {
+	Mod      string // "" for glovebox's own steps
+	Duration time.Duration
+	Steps    int
+	Cached   int
+}
+
+// Lines of BuildKit's plain progress output: "#7 [3/9] RUN ...",
+// "#7 0.312 output", "#7 DONE 1.2s", "#7 CACHED" and "#7 ERROR: ..."
+var (
+	vertexLine = regexp.MustCompile(`^#(\d+) \[(?:\S+ )?\s*(\d+)/\d+\] `)
+	logLine    = regexp.MustCompile(`^#(\d+) \d+\.\d+ (.*)$`)
+	doneLine   = regexp.MustCompile(`^#(\d+) DONE (\d+(?:\.\d+)?)s$`)
+	cachedLine = regexp.MustCompile(`^#(\d+) CACHED$`)
+	errorLine  = regexp.MustCompile(`^#(\d+) ERROR: (.*)$`)
+)
+
+// vertex is a build operation BuildKit reports progress for
+type vertex struct {
+	step *Step // nil for internal operations
+	tail []string
+}
+
+// Progress follows a build's plain progress output as it is written. It is
+// safe for the build's stdout and stderr to write to it concurrently.
+type Progress struct {
+	// OnStep, if set, is called as each numbered step finishes
+	OnStep func(Result)
+
+	mu       sync.Mutex
+	steps    map[int]Step
+	vertices map[string]*vertex
+	results  []Result
+	failure  *Failure
+	tail     []string // the last lines of all output
+	partial  []byte
+}
+
+// NewProgress follows the build of a Dockerfile generated by glovebox
+func NewProgress(dockerfile string) *Progress {
+	p := &Progress{steps: make(map[int]Step), vertices: make(map[string]*vertex)}
+	for _, s := range Steps(dockerfile) {
+		p.steps[s.Index] = s
+	}
+	return p
+}
+
+// Write consumes progress output, line by line
+func (p *Progress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	data := append(p.partial, b...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		p.line(strings.TrimRight(string(data[:i]), "\r"))
+		data = data[i+1:]
+	}
+	p.partial = append([]byte(nil), data...)
+	return len(b), nil
+}
+
+// Flush consumes a final line without a newline
+func (p *Progress) Flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.partial) > 0 {
+		p.line(string(p.partial))
+		p.partial = nil
+	}
+}
+
+func (p *Progress) line(line string) {
+	p.tail = appendTail(p.tail, line)
+
+	if m := vertexLine.FindStringSubmatch(line); m != nil {
+		v := p.vertex(m[1])
+		if index, err := strconv.Atoi(m[2]); err == nil && v.step == nil {
+			if s, ok := p.steps[index]; ok {
+				v.step = &s
+			}
+		}
+		return
+	}
+	if m := logLine.FindStringSubmatch(line); m != nil {
+		v := p.vertex(m[1])
+		v.tail = appendTail(v.tail, m[2])
+		return
+	}
+	if m := doneLine.FindStringSubmatch(line); m != nil {
+		seconds, _ := strconv.ParseFloat(m[2], 64)
+		p.finish(m[1], time.Duration(seconds*float64(time.Second)), false)
+		return
+	}
+	if m := cachedLine.FindStringSubmatch(line); m != nil {
+		p.finish(m[1], 0, true)
+		return
+	}
+	if m := errorLine.FindStringSubmatch(line); m != nil {
+		v := p.vertex(m[1])
+		// The first error is the cause; others are steps canceled by it
+		if p.failure == nil || (p.failure.Index == 0 && v.step != nil) {
+			f := &Failure{Error: m[2], Tail: v.tail}
+			if v.step != nil {
+				f.Step = *v.step
+			}
+			p.failure = f
+		}
+	}
+}
+
+func (p *Progress) vertex(id string) *vertex {
+	v, ok := p.vertices[id]
+	if !ok {
+		v = &vertex{}
+		p.vertices[id] = v
+	}
+	return v
+}
+
+// finish records a numbered step's result
+func (p *Progress) finish(id string, d time.Duration, cached bool) {
+	v := p.vertex(id)
+	if v.step == nil {
+		return
+	}
+	r := Result{Step: *v.step, Duration: d, Cached: cached}
+	p.results = append(p.results, r)
+	if p.OnStep != nil {
+		p.OnStep(r)
+	}
+}
+
+// Failure returns the step the build failed in, or nil if no step failed.
+// A failure outside any step, such as a Dockerfile error, has a zero Step.
+func (p *Progress) Failure() *Failure {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.failure
+}
+
+// Tail returns the last lines of the whole output
+func (p *Progress) Tail() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.tail...)
+}
+
+// Timings totals the finished steps by mod, slowest first. Glovebox's own
+// steps are totalled under "".
+func (p *Progress) Timings() []Timing {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var timings []Timing
+	index := make(map[string]int)
+	for _, r := range p.results {
+		i, ok := index[r.Mod]
+		if !ok {
+			i = len(timings)
+			index[r.Mod] = i
+			timings = append(timings, Timing{Mod: r.Mod})
+		}
+		timings[i].Duration += r.Duration
+		timings[i].Steps++
+		if r.Cached {
+			timings[i].Cached++
+		}
+	}
+	slices.SortStableFunc(timings, func(a, b Timing) int {
+		return cmp.Compare(b.Duration, a.Duration)
+	})
+	return timings
+}
+
+// appendTail adds a line, keeping the last TailLines
+func appendTail(lines []string, line string) []string {
+	lines = append(lines, line)
+	if len(lines) > TailLines {
+		lines = append(lines[:0:0], lines[len(lines)-TailLines:]...)
+	}
+	return lines
+}
@@ -0,0 +1,130 @@
+// Package buildlog follows BuildKit's plain progress output while a generated
+// Dockerfile builds, attributing each build step to the mod that produced it.
+package buildlog
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Sections of a mod that become build steps
+const (
+	SectionRunAsRoot = "run_as_root"
+	SectionRunAsUser = "run_as_user"
+	SectionFiles     = "files"
+)
+
+// Step is a Dockerfile instruction that BuildKit runs as a numbered step
+type Step struct {
+	Index       int    // BuildKit's step number, the FROM being 1
+	Instruction string // the instruction's first line
+	Mod         string // name of the mod the step belongs to; "" for glovebox's own steps
+	Section     string // run_as_root, run_as_user, files or a hook phase
+
+	// Text is what BuildKit's cache key for the step depends on in the
+	// Dockerfile: the instruction with any heredoc bodies, and the ENV, ARG
+	// and USER instructions and comments (such as source digests) since the
+	// previous step
+	Text string
+}
+
+// Label names the step for people: its mod and section, or its instruction
+func (s Step) Label() string {
+	if s.Mod == "" {
+		return s.Instruction
+	}
+	return s.Mod + " " + s.Section
+}
+
+// The generator introduces each mod's instructions with one of these comments
+var (
+	setupMarker = regexp.MustCompile(`^# (\S+) setup \((root|user)\)$`)
+	filesMarker = regexp.MustCompile(`^# (\S+) files$`)
+	hookMarker  = regexp.MustCompile(`^# (\S+) (on_create|on_start|on_exit) hook$`)
+	heredoc     = regexp.MustCompile(`<<-?(?:'([^']+)'|"([^"]+)"|(\w+))`)
+)
+
+// numbered are the instructions BuildKit counts as steps
+var numbered = map[string]bool{"FROM": true, "RUN": true, "COPY": true, "ADD": true, "WORKDIR": true}
+
+// Steps lists the numbered steps of a single-stage Dockerfile generated by
+// glovebox, in order.
+func Steps(dockerfile string) []Step {
+	var steps []Step
+	var mod, section string
+	var text, comments []string
+	lines := strings.Split(dockerfile, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case line == "":
+			mod, section = "", ""
+			comments = nil // a comment block of its own, such as the header
+			continue
+		case strings.HasPrefix(line, "#"):
+			mod, section = marker(line, mod, section)
+			comments = append(comments, line)
+			continue
+		}
+
+		end := skipBody(lines, i)
+		text = append(text, comments...)
+		text = append(text, lines[i:end+1]...)
+		comments = nil
+
+		keyword, _, _ := strings.Cut(line, " ")
+		keyword = strings.ToUpper(keyword)
+		if numbered[keyword] {
+			steps = append(steps, Step{
+				Index:       len(steps) + 1,
+				Instruction: line,
+				Mod:         mod,
+				Section:     section,
+				Text:        strings.Join(text, "\n"),
+			})
+			text = nil
+		}
+		i = end
+	}
+	return steps
+}
+
+// marker returns the mod and section a comment introduces. Comments that
+// belong to a section, such as a source file's digest, keep the current one.
+func marker(comment, mod, section string) (string, string) {
+	if m := setupMarker.FindStringSubmatch(comment); m != nil {
+		if m[2] == "root" {
+			return m[1], SectionRunAsRoot
+		}
+		return m[1], SectionRunAsUser
+	}
+	if m := filesMarker.FindStringSubmatch(comment); m != nil {
+		return m[1], SectionFiles
+	}
+	if m := hookMarker.FindStringSubmatch(comment); m != nil {
+		return m[1], m[2]
+	}
+	if strings.HasPrefix(comment, "# source: ") {
+		return mod, section
+	}
+	return "", ""
+}
+
+// skipBody returns the last line of the instruction starting at line i,
+// past line continuations and heredoc bodies
+func skipBody(lines []string, i int) int {
+	start := i
+	for strings.HasSuffix(strings.TrimSpace(lines[i]), `\`) && i+1 < len(lines) {
+		i++
+	}
+	for _, m := range heredoc.FindAllStringSubmatch(strings.Join(lines[start:i+1], "\n"), -1) {
+		delim := m[1] + m[2] + m[3]
+		for i+1 < len(lines) {
+			i++
+			if strings.TrimSpace(lines[i]) == delim {
+				break
+			}
+		}
+	}
+	return i
+}
@@ -0,0 +1,157 @@
+// Package bundle reads and writes image bundles: a glovebox image together
+// with the profile it was built from, so a built environment can be moved to
+// another machine without a registry or network access.
+//
+// A bundle is a tar archive holding, in order:
+//
+//	manifest.yaml  what the bundle holds (see Manifest)
+//	profile.yaml   the profile, with the build info of the image
+//	Dockerfile     the Dockerfile the image was built from, when there is one
+//	image.tar      the image, as the runtime saved it
+package bundle
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the bundle format version Write produces
+const Version = 1
+
+// Kinds of image a bundle holds
+const (
+	KindBase    = "base"
+	KindProject = "project"
+)
+
+// Entry names
+const (
+	manifestEntry   = "manifest.yaml"
+	profileEntry    = "profile.yaml"
+	dockerfileEntry = "Dockerfile"
+	imageEntry      = "image.tar"
+)
+
+// Manifest describes a bundle's image
+type Manifest struct {
+	Version    int       `yaml:"version"`
+	Kind       string    `yaml:"kind"`                // KindBase or KindProject
+	Image      string    `yaml:"image"`               // name the image was saved under
+	BaseName   string    `yaml:"base_name,omitempty"` // for named bases
+	Parent     string    `yaml:"parent,omitempty"`    // for projects, the image it was built on
+	ParentID   string    `yaml:"parent_id,omitempty"` // ID of the parent at build time
+	Mods       []string  `yaml:"mods"`                // every mod in the image, its parents' included
+	Platforms  []string  `yaml:"platforms,omitempty"`
+	Glovebox   string    `yaml:"glovebox"` // version that exported it
+	ExportedAt time.Time `yaml:"exported_at"`
+}
+
+// Bundle is the metadata of a bundle: everything but the image
+type Bundle struct {
+	Manifest   Manifest
+	Profile    []byte
+	Dockerfile []byte // nil when not included
+}
+
+// Write writes a bundle with the image archive read from image, which must
+// hold size bytes.
+func Write(w io.Writer, b Bundle, image io.Reader, size int64) error {
+	manifest, err := yaml.Marshal(b.Manifest)
+	if err != nil {
+		return fmt.Errorf("encoding manifest: %w", err)
+	}
+
+	tw := tar.NewWriter(w)
+	modTime := b.Manifest.ExportedAt
+	if err := writeFile(tw, manifestEntry, manifest, modTime); err != nil {
+		return err
+	}
+	if err := writeFile(tw, profileEntry, b.Profile, modTime); err != nil {
+		return err
+	}
+	if b.Dockerfile != nil {
+		if err := writeFile(tw, dockerfileEntry, b.Dockerfile, modTime); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.WriteHeader(&tar.Header{Name: imageEntry, Mode: 0o644, Size: size, ModTime: modTime}); err != nil {
+		return fmt.Errorf("writing %s: %w", imageEntry, err)
+	}
+	if _, err := io.Copy(tw, image); err != nil {
+		return fmt.Errorf("writing %s: %w", imageEntry, err)
+	}
+	return tw.Close()
+}
+
+func writeFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: modTime}); err != nil {
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	return nil
+}
+
+// Read reads a bundle and hands it, with a reader of its image archive, to
+// load. The image is streamed, not buffered, so load is called once the
+// metadata before it has been read and checked.
+func Read(r io.Reader, load func(b Bundle, image io.Reader) error) error {
+	var b Bundle
+	var haveManifest bool
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("not a glovebox image bundle: no %s", imageEntry)
+		}
+		if err != nil {
+			return fmt.Errorf("reading bundle: %w", err)
+		}
+
+		if hdr.Name == imageEntry {
+			if !haveManifest || b.Profile == nil {
+				return fmt.Errorf("not a glovebox image bundle: %s comes before %s and %s", imageEntry, manifestEntry, profileEntry)
+			}
+			return load(b, tr)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", hdr.Name, err)
+		}
+		switch hdr.Name {
+		case manifestEntry:
+			if err := yaml.Unmarshal(data, &b.Manifest); err != nil {
+				return fmt.Errorf("parsing %s: %w", manifestEntry, err)
+			}
+			if err := b.Manifest.validate(); err != nil {
+				return err
+			}
+			haveManifest = true
+		case profileEntry:
+			b.Profile = data
+		case dockerfileEntry:
+			b.Dockerfile = data
+		}
+	}
+}
+
+func (m Manifest) validate() error {
+	if m.Version > Version {
+		return fmt.Errorf("bundle format %d is newer than this glovebox supports (%d); upgrade glovebox to import it", m.Version, Version)
+	}
+	if m.Kind != KindBase && m.Kind != KindProject {
+		return fmt.Errorf("bundle holds an unknown kind of image %q", m.Kind)
+	}
+	if m.Image == "" {
+		return fmt.Errorf("bundle manifest names no image")
+	}
+	return nil
+}
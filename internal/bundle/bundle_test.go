@@ -0,0 +1,85 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteRead(t *testing.T) {
+	b := Bundle{
+		Manifest: Manifest{
+			Version:    Version,
+			Kind:       KindProject,
+			Image:      "glovebox:app-1234567",
+			Parent:     "glovebox:base",
+			Mods:       []string{"os/ubuntu", "tools/mise"},
+			ExportedAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		},
+		Profile:    []byte("mods:\n  - tools/mise\n"),
+		Dockerfile: []byte("FROM glovebox:base\n"),
+	}
+	image := "image layers"
+
+	var buf bytes.Buffer
+	if err := Write(&buf, b, strings.NewReader(image), int64(len(image))); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	var got Bundle
+	var gotImage string
+	err := Read(&buf, func(b Bundle, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		got, gotImage = b, string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if got.Manifest.Image != b.Manifest.Image || got.Manifest.Parent != "glovebox:base" || len(got.Manifest.Mods) != 2 {
+		t.Errorf("manifest = %+v", got.Manifest)
+	}
+	if string(got.Profile) != string(b.Profile) || string(got.Dockerfile) != string(b.Dockerfile) || gotImage != image {
+		t.Errorf("bundle = %+v, image %q", got, gotImage)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	archive := func(entries ...string) io.Reader {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for i := 0; i < len(entries); i += 2 {
+			_ = tw.WriteHeader(&tar.Header{Name: entries[i], Mode: 0o644, Size: int64(len(entries[i+1]))})
+			_, _ = tw.Write([]byte(entries[i+1]))
+		}
+		_ = tw.Close()
+		return &buf
+	}
+	manifest := "version: 1\nkind: base\nimage: glovebox:base\n"
+
+	tests := []struct {
+		name    string
+		archive io.Reader
+		want    string
+	}{
+		{"not a tar", strings.NewReader("hello"), "reading bundle"},
+		{"no image", archive(manifestEntry, manifest, profileEntry, "mods: []\n"), "no image.tar"},
+		{"image first", archive(imageEntry, "layers", manifestEntry, manifest), "comes before"},
+		{"newer format", archive(manifestEntry, "version: 2\nkind: base\nimage: glovebox:base\n"), "upgrade glovebox"},
+		{"unknown kind", archive(manifestEntry, "version: 1\nkind: volume\nimage: x\n"), "unknown kind"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Read(tt.archive, func(Bundle, io.Reader) error {
+				t.Error("load should not be called")
+				return nil
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Read() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,357 @@
+// Package devcontainer reads devcontainer.json files (the Dev Containers
+// specification used by VS Code and Codespaces) and translates them into
+// glovebox profiles and mods.
+package devcontainer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Config is the subset of devcontainer.json that glovebox understands.
+// Properties it doesn't translate are listed in Other.
+type Config struct {
+	Name              string                    `json:"name,omitempty"`
+	Image             string                    `json:"image,omitempty"`
+	Build             *Build                    `json:"build,omitempty"`
+	DockerFile        string                    `json:"dockerFile,omitempty"` // legacy form of build.dockerfile
+	Features          map[string]map[string]any `json:"features,omitempty"`
+	ContainerEnv      map[string]string         `json:"containerEnv,omitempty"`
+	RemoteEnv         map[string]string         `json:"remoteEnv,omitempty"`
+	Mounts            []Mount                   `json:"mounts,omitempty"`
+	OnCreateCommand   *Command                  `json:"onCreateCommand,omitempty"`
+	PostCreateCommand *Command                  `json:"postCreateCommand,omitempty"`
+	PostStartCommand  *Command                  `json:"postStartCommand,omitempty"`
+	ForwardPorts      []Port                    `json:"forwardPorts,omitempty"`
+	RunArgs           []string                  `json:"runArgs,omitempty"` // written by Export; not imported
+	RemoteUser        string                    `json:"remoteUser,omitempty"`
+	ContainerUser     string                    `json:"containerUser,omitempty"`
+	WorkspaceFolder   string                    `json:"workspaceFolder,omitempty"`
+	WorkspaceMount    string                    `json:"workspaceMount,omitempty"`
+
+	// Other lists top-level properties present in the file that glovebox
+	// doesn't translate, sorted
+	Other []string `json:"-"`
+}
+
+// Build describes a Dockerfile-based devcontainer
+type Build struct {
+	Dockerfile string            `json:"dockerfile,omitempty"`
+	Context    string            `json:"context,omitempty"`
+	Args       map[string]string `json:"args,omitempty"`
+	Target     string            `json:"target,omitempty"`
+}
+
+// Mount is a devcontainer mount, given either as a Docker --mount string
+// ("source=...,target=...,type=bind") or as an object
+type Mount struct {
+	Type     string `json:"type,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Target   string `json:"target,omitempty"`
+	ReadOnly bool   `json:"readonly,omitempty"`
+}
+
+// UnmarshalJSON accepts both the string and object forms of a mount
+func (m *Mount) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return m.parseString(s)
+	}
+	type plain Mount
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return fmt.Errorf("mount must be a string or an object: %w", err)
+	}
+	*m = Mount(p)
+	return nil
+}
+
+// parseString parses the Docker --mount syntax
+func (m *Mount) parseString(s string) error {
+	for _, part := range strings.Split(s, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch strings.ToLower(key) {
+		case "type":
+			m.Type = value
+		case "source", "src":
+			m.Source = value
+		case "target", "dst", "destination":
+			m.Target = value
+		case "readonly", "ro":
+			m.ReadOnly = value == "" || value == "true" || value == "1"
+		}
+	}
+	if m.Target == "" {
+		return fmt.Errorf("mount %q has no target", s)
+	}
+	return nil
+}
+
+// MarshalJSON writes the mount in the string form, which every devcontainer
+// implementation accepts
+func (m Mount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// String renders the mount in Docker --mount syntax
+func (m Mount) String() string {
+	parts := []string{"type=" + m.EffectiveType()}
+	if m.Source != "" {
+		parts = append(parts, "source="+m.Source)
+	}
+	parts = append(parts, "target="+m.Target)
+	if m.ReadOnly {
+		parts = append(parts, "readonly")
+	}
+	return strings.Join(parts, ",")
+}
+
+// EffectiveType returns the mount type, defaulting to volume as Docker does
+func (m Mount) EffectiveType() string {
+	if m.Type == "" {
+		return "volume"
+	}
+	return m.Type
+}
+
+// Command is a lifecycle command: a shell string, an argument list, or an
+// object of named commands that run in parallel
+type Command struct {
+	Shell    string
+	Args     []string
+	Parallel map[string]*Command
+}
+
+// UnmarshalJSON accepts the string, array and object forms of a command
+func (c *Command) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &c.Shell); err == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &c.Args); err == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &c.Parallel); err == nil {
+		return nil
+	}
+	return fmt.Errorf("command must be a string, an array or an object")
+}
+
+// MarshalJSON writes the command back in its original form
+func (c Command) MarshalJSON() ([]byte, error) {
+	switch {
+	case c.Args != nil:
+		return json.Marshal(c.Args)
+	case c.Parallel != nil:
+		return json.Marshal(c.Parallel)
+	default:
+		return json.Marshal(c.Shell)
+	}
+}
+
+// Script renders the command as shell script lines. Parallel commands are
+// run one after another, in name order.
+func (c *Command) Script() string {
+	switch {
+	case c == nil:
+		return ""
+	case c.Args != nil:
+		quoted := make([]string, len(c.Args))
+		for i, a := range c.Args {
+			quoted[i] = shellQuote(a)
+		}
+		return strings.Join(quoted, " ")
+	case c.Parallel != nil:
+		names := make([]string, 0, len(c.Parallel))
+		for name := range c.Parallel {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var lines []string
+		for _, name := range names {
+			if script := c.Parallel[name].Script(); script != "" {
+				lines = append(lines, "# "+name, script)
+			}
+		}
+		return strings.Join(lines, "\n")
+	default:
+		return c.Shell
+	}
+}
+
+// Port is a forwardPorts entry: a port number or a "host:port" string
+type Port string
+
+// UnmarshalJSON accepts numeric and string ports
+func (p *Port) UnmarshalJSON(data []byte) error {
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		*p = Port(fmt.Sprint(n))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("port must be a number or a string")
+	}
+	*p = Port(s)
+	return nil
+}
+
+// MarshalJSON writes plain port numbers as numbers
+func (p Port) MarshalJSON() ([]byte, error) {
+	var n int
+	if _, err := fmt.Sscanf(string(p), "%d", &n); err == nil && fmt.Sprint(n) == string(p) {
+		return json.Marshal(n)
+	}
+	return json.Marshal(string(p))
+}
+
+// knownProperties are the top-level properties Config translates
+var knownProperties = map[string]bool{
+	"$schema": true, "name": true, "image": true, "build": true, "dockerFile": true,
+	"features": true, "containerEnv": true, "remoteEnv": true, "mounts": true,
+	"onCreateCommand": true, "postCreateCommand": true, "postStartCommand": true,
+	"forwardPorts": true, "remoteUser": true, "containerUser": true,
+	"workspaceFolder": true, "workspaceMount": true,
+}
+
+// Find locates the devcontainer.json for a project directory, checking the
+// standard locations in the order the specification lists them.
+func Find(dir string) (string, error) {
+	candidates := []string{
+		filepath.Join(dir, ".devcontainer", "devcontainer.json"),
+		filepath.Join(dir, ".devcontainer.json"),
+	}
+	nested, _ := filepath.Glob(filepath.Join(dir, ".devcontainer", "*", "devcontainer.json"))
+	sort.Strings(nested)
+	candidates = append(candidates, nested...)
+
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no devcontainer.json found in %s", dir)
+}
+
+// Load reads and parses a devcontainer.json file
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading devcontainer.json: %w", err)
+	}
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse parses devcontainer.json content, which is JSON with comments and
+// trailing commas allowed
+func Parse(data []byte) (*Config, error) {
+	clean := StripJSONC(data)
+
+	var cfg Config
+	if err := json.Unmarshal(clean, &cfg); err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(clean, &raw); err != nil {
+		return nil, err
+	}
+	for key := range raw {
+		if !knownProperties[key] {
+			cfg.Other = append(cfg.Other, key)
+		}
+	}
+	sort.Strings(cfg.Other)
+
+	return &cfg, nil
+}
+
+// StripJSONC removes // and /* */ comments and trailing commas, leaving
+// string contents untouched
+func StripJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++ // skip the closing '/'
+		case c == ',':
+			// Drop the comma if the next significant character closes a
+			// container, looking past whitespace and comments
+			j := i + 1
+			for j < len(data) {
+				switch {
+				case strings.ContainsRune(" \t\r\n", rune(data[j])):
+					j++
+					continue
+				case data[j] == '/' && j+1 < len(data) && data[j+1] == '/':
+					for j < len(data) && data[j] != '\n' {
+						j++
+					}
+					continue
+				case data[j] == '/' && j+1 < len(data) && data[j+1] == '*':
+					j += 2
+					for j+1 < len(data) && !(data[j] == '*' && data[j+1] == '/') {
+						j++
+					}
+					j += 2
+					continue
+				}
+				break
+			}
+			if j < len(data) && (data[j] == '}' || data[j] == ']') {
+				continue
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// shellQuote wraps a value in single quotes when it needs quoting
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:@%+", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,246 @@
+package devcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joelhelbling/glovebox/internal/profile"
+)
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"line comment", "{\"a\": 1 // note\n}", "{\"a\": 1 \n}"},
+		{"block comment", `{/* x */"a": 1}`, `{"a": 1}`},
+		{"trailing comma", "{\"a\": [1, 2,],\n}", "{\"a\": [1, 2]\n}"},
+		{"trailing comma before a line comment", "{\"a\": 1, // note\n}", "{\"a\": 1 \n}"},
+		{"trailing comma before a block comment", `[1, /* x */]`, `[1 ]`},
+		{"comma before a comment and a value", "[1, // note\n2]", "[1, \n2]"},
+		{"comment markers in strings", `{"url": "http://x/*y*/", "s": "a,}"}`, `{"url": "http://x/*y*/", "s": "a,}"}`},
+		{"escaped quote", `{"s": "a\"//b"}`, `{"s": "a\"//b"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(StripJSONC([]byte(tt.input))); got != tt.want {
+				t.Errorf("StripJSONC() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+const sampleConfig = `{
+	// Sample from a typical Node project
+	"name": "web",
+	"image": "mcr.microsoft.com/devcontainers/typescript-node:1-20-bookworm",
+	"features": {
+		"ghcr.io/devcontainers/features/python:1": { "version": "3.11" },
+		"ghcr.io/devcontainers/features/common-utils:2": {},
+		"ghcr.io/devcontainers/features/docker-in-docker:2": {},
+	},
+	"containerEnv": {
+		"NODE_ENV": "development",
+		"PATH": "${containerEnv:PATH}:${containerWorkspaceFolder}/bin",
+		"GREETING": "hello world"
+	},
+	"remoteEnv": {
+		"GITHUB_TOKEN": "${localEnv:GITHUB_TOKEN}",
+		"NPM_TOKEN": "${localEnv:MY_NPM_TOKEN}",
+		"MIXED": "prefix-${localEnv:USER}"
+	},
+	"mounts": [
+		"source=${localEnv:HOME}/.aws,target=/home/vscode/.aws,type=bind,readonly",
+		{ "source": "${localWorkspaceFolder}/cache", "target": "/cache", "type": "bind" },
+		"source=node_modules,target=${containerWorkspaceFolder}/node_modules,type=volume"
+	],
+	"onCreateCommand": "npm ci",
+	"postCreateCommand": ["npm", "run", "setup db"],
+	"postStartCommand": { "server": "npm start", "watch": "npm run watch" },
+	"forwardPorts": [3000, "db:5432"],
+	"customizations": { "vscode": { "extensions": ["dbaeumer.vscode-eslint"] } },
+	"runArgs": ["--init"]
+}`
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte(sampleConfig))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if cfg.Name != "web" {
+		t.Errorf("Name = %q, want web", cfg.Name)
+	}
+	if len(cfg.Features) != 3 {
+		t.Errorf("len(Features) = %d, want 3", len(cfg.Features))
+	}
+	if want := []string{"customizations", "runArgs"}; !reflect.DeepEqual(cfg.Other, want) {
+		t.Errorf("Other = %v, want %v", cfg.Other, want)
+	}
+
+	wantMounts := []Mount{
+		{Type: "bind", Source: "${localEnv:HOME}/.aws", Target: "/home/vscode/.aws", ReadOnly: true},
+		{Type: "bind", Source: "${localWorkspaceFolder}/cache", Target: "/cache"},
+		{Type: "volume", Source: "node_modules", Target: "${containerWorkspaceFolder}/node_modules"},
+	}
+	if !reflect.DeepEqual(cfg.Mounts, wantMounts) {
+		t.Errorf("Mounts = %+v, want %+v", cfg.Mounts, wantMounts)
+	}
+
+	if got := cfg.OnCreateCommand.Script(); got != "npm ci" {
+		t.Errorf("onCreateCommand = %q", got)
+	}
+	if got := cfg.PostCreateCommand.Script(); got != "npm run 'setup db'" {
+		t.Errorf("postCreateCommand = %q", got)
+	}
+	if got := cfg.PostStartCommand.Script(); got != "# server\nnpm start\n# watch\nnpm run watch" {
+		t.Errorf("postStartCommand = %q", got)
+	}
+	if want := []Port{"3000", "db:5432"}; !reflect.DeepEqual(cfg.ForwardPorts, want) {
+		t.Errorf("ForwardPorts = %v, want %v", cfg.ForwardPorts, want)
+	}
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"folder", []string{".devcontainer/devcontainer.json", ".devcontainer.json"}, ".devcontainer/devcontainer.json"},
+		{"root file", []string{".devcontainer.json"}, ".devcontainer.json"},
+		{"named config", []string{".devcontainer/web/devcontainer.json", ".devcontainer/api/devcontainer.json"}, ".devcontainer/api/devcontainer.json"},
+		{"none", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, f := range tt.files {
+				path := filepath.Join(dir, f)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := Find(dir)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("Find() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Find() error = %v", err)
+			}
+			if want := filepath.Join(dir, tt.want); got != want {
+				t.Errorf("Find() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	cfg, err := Parse([]byte(sampleConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := Translate(cfg, "/web")
+
+	if want := []string{"languages/nodejs", "languages/python"}; !reflect.DeepEqual(result.Mods, want) {
+		t.Errorf("Mods = %v, want %v", result.Mods, want)
+	}
+	if want := []string{"GITHUB_TOKEN", "MY_NPM_TOKEN"}; !reflect.DeepEqual(result.PassthroughEnv, want) {
+		t.Errorf("PassthroughEnv = %v, want %v", result.PassthroughEnv, want)
+	}
+
+	wantMounts := []profile.Mount{
+		{Source: "~/.aws", Target: "/home/dev/.aws", ReadOnly: true},
+		{Source: "cache", Target: "/cache"},
+	}
+	if !reflect.DeepEqual(result.Mounts, wantMounts) {
+		t.Errorf("Mounts = %+v, want %+v", result.Mounts, wantMounts)
+	}
+
+	if result.Mod == nil {
+		t.Fatal("expected a generated mod")
+	}
+	wantEnv := map[string]string{
+		"NODE_ENV": "development",
+		"PATH":     "${PATH}:/web/bin",
+		"GREETING": `"hello world"`,
+	}
+	if !reflect.DeepEqual(result.Mod.Env, wantEnv) {
+		t.Errorf("Env = %v, want %v", result.Mod.Env, wantEnv)
+	}
+	if want := "# onCreateCommand\nnpm ci\n\n# postCreateCommand\nnpm run 'setup db'\n"; result.Mod.OnCreate != want {
+		t.Errorf("OnCreate = %q, want %q", result.Mod.OnCreate, want)
+	}
+	if !strings.Contains(result.Mod.OnStart, "npm start") {
+		t.Errorf("OnStart = %q, want it to run npm start", result.Mod.OnStart)
+	}
+
+	if want := []string{"3000"}; !reflect.DeepEqual(result.Ports, want) {
+		t.Errorf("Ports = %v, want %v", result.Ports, want)
+	}
+
+	warnings := strings.Join(result.Warnings, "\n")
+	for _, want := range []string{
+		"feature ghcr.io/devcontainers/features/docker-in-docker:2",
+		"version 3.11",
+		"NPM_TOKEN",
+		"MIXED",
+		"only bind mounts",
+		"forwardPorts (db:5432)",
+		"customizations: not supported",
+		"runArgs: not supported",
+	} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("warnings missing %q:\n%s", want, warnings)
+		}
+	}
+	if strings.Contains(warnings, "common-utils") {
+		t.Errorf("common-utils should be covered by the base, got warning:\n%s", warnings)
+	}
+}
+
+func TestTranslateImage(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		wantMods []string
+		wantWarn string
+	}{
+		{"devcontainers image", Config{Image: "mcr.microsoft.com/devcontainers/python:3.12"}, []string{"languages/python"}, ""},
+		{"devcontainers base", Config{Image: "mcr.microsoft.com/devcontainers/base:ubuntu"}, nil, ""},
+		{"other image", Config{Image: "golang:1.22"}, nil, "image (golang:1.22)"},
+		{"dockerfile", Config{Build: &Build{Dockerfile: "Dockerfile"}}, nil, "build.dockerfile (Dockerfile)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Translate(&tt.cfg, "/app")
+			if !reflect.DeepEqual(result.Mods, tt.wantMods) {
+				t.Errorf("Mods = %v, want %v", result.Mods, tt.wantMods)
+			}
+			warnings := strings.Join(result.Warnings, "\n")
+			if tt.wantWarn == "" && warnings != "" {
+				t.Errorf("unexpected warnings: %s", warnings)
+			}
+			if tt.wantWarn != "" && !strings.Contains(warnings, tt.wantWarn) {
+				t.Errorf("warnings = %q, want %q", warnings, tt.wantWarn)
+			}
+			if result.Mod != nil {
+				t.Errorf("Mod = %+v, want nil", result.Mod)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package devcontainer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/joelhelbling/glovebox/internal/generator"
+	"github.com/joelhelbling/glovebox/internal/mod"
+	"github.com/joelhelbling/glovebox/internal/profile"
+)
+
+// ExportHeader marks a devcontainer.json written by glovebox, so it can be
+// regenerated without clobbering a hand-written one
+const ExportHeader = "// Generated by 'glovebox export devcontainer' - regenerate rather than edit"
+
+// Environment describes a glovebox environment to express as a devcontainer
+type Environment struct {
+	Name           string
+	Workspace      string            // container path glovebox mounts the project at
+	PassthroughEnv []string          // host variables passed into the container
+	Env            map[string]string // fixed variables set when the container is created
+	Mounts         []profile.Mount   // sources as written in the profiles
+	Ports          []profile.Port    // container ports published on the host
+	HostServices   map[string]int    // host ports reached via profile.HostAlias
+	HookPhases     []string          // lifecycle phases with hooks installed in the image
+	BuildArgs      map[string]string // values for ARGs declared by mods
+	BuildSecrets   []mod.BuildSecret // secrets the image's RUN steps mount
+}
+
+// Export builds a devcontainer.json for an environment whose image is built
+// from dockerfile, a path relative to the devcontainer.json. Settings with no
+// devcontainer equivalent are returned as warnings.
+func Export(env Environment, dockerfile string) (*Config, []string) {
+	var warnings []string
+
+	cfg := &Config{
+		Name:            env.Name,
+		Build:           &Build{Dockerfile: dockerfile, Context: ".", Args: env.BuildArgs},
+		RemoteUser:      "dev",
+		WorkspaceFolder: env.Workspace,
+		WorkspaceMount:  Mount{Type: "bind", Source: "${localWorkspaceFolder}", Target: env.Workspace}.String(),
+		ContainerEnv: map[string]string{
+			// The devcontainer runs the hooks itself; don't let the entrypoint repeat them
+			"GLOVEBOX_SKIP_HOOKS": "1",
+		},
+	}
+
+	for k, v := range env.Env {
+		cfg.ContainerEnv[k] = v
+	}
+	for _, name := range env.PassthroughEnv {
+		cfg.ContainerEnv[name] = "${localEnv:" + name + "}"
+	}
+
+	for _, m := range env.Mounts {
+		cfg.Mounts = append(cfg.Mounts, Mount{
+			Type:     "bind",
+			Source:   exportMountSource(m.Source),
+			Target:   m.Target,
+			ReadOnly: m.ReadOnly,
+		})
+	}
+
+	for _, p := range env.Ports {
+		cfg.ForwardPorts = append(cfg.ForwardPorts, Port(fmt.Sprint(p.ContainerPort)))
+		if p.HostPort != p.ContainerPort {
+			warnings = append(warnings, fmt.Sprintf("port %s: forwarded ports keep the container's port number on the host", p))
+		}
+	}
+	if len(env.HostServices) > 0 {
+		cfg.RunArgs = append(cfg.RunArgs, "--add-host="+profile.HostAlias+":host-gateway")
+	}
+
+	for _, phase := range env.HookPhases {
+		run := &Command{Shell: generator.HooksRunnerPath + " " + phase}
+		switch phase {
+		case mod.HookOnCreate:
+			cfg.OnCreateCommand = run
+		case mod.HookOnStart:
+			cfg.PostStartCommand = run
+		default:
+			warnings = append(warnings, fmt.Sprintf("%s hooks: devcontainers have no equivalent lifecycle event; they won't run", phase))
+		}
+	}
+
+	for _, s := range env.BuildSecrets {
+		if s.Required {
+			warnings = append(warnings, fmt.Sprintf("build secret %s: devcontainers can't pass build secrets, and the image won't build without it", s.ID))
+		} else {
+			warnings = append(warnings, fmt.Sprintf("build secret %s: devcontainers can't pass build secrets; the image is built without it", s.ID))
+		}
+	}
+
+	return cfg, warnings
+}
+
+// exportMountSource rewrites a profile mount source with devcontainer
+// variables so it works on any host
+func exportMountSource(source string) string {
+	switch {
+	case source == "~" || strings.HasPrefix(source, "~/"):
+		return "${localEnv:HOME}" + strings.TrimPrefix(source, "~")
+	case path.IsAbs(source):
+		return source
+	default:
+		return path.Join("${localWorkspaceFolder}", source)
+	}
+}
+
+// Marshal renders the config as devcontainer.json, starting with ExportHeader
+func (c *Config) Marshal() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(ExportHeader + "\n")
+
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false) // keep shell operators like && readable
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(c); err != nil {
+		return nil, fmt.Errorf("encoding devcontainer.json: %w", err)
+	}
+	return b.Bytes(), nil
+}
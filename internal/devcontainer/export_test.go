@@ -0,0 +1,120 @@
+package devcontainer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joelhelbling/glovebox/internal/mod"
+	"github.com/joelhelbling/glovebox/internal/profile"
+)
+
+func TestExport(t *testing.T) {
+	env := Environment{
+		Name:           "web",
+		Workspace:      "/web",
+		PassthroughEnv: []string{"GITHUB_TOKEN"},
+		Env:            map[string]string{"MISE_TRUSTED_CONFIG_PATHS": "/web:/web/**"},
+		Mounts: []profile.Mount{
+			{Source: "~/.aws", Target: "/home/dev/.aws", ReadOnly: true},
+			{Source: "data", Target: "/data"},
+			{Source: "/srv/cache", Target: "/cache"},
+		},
+		HookPhases: []string{"on_create", "on_start", "on_exit"},
+	}
+
+	cfg, warnings := Export(env, "Dockerfile")
+
+	if cfg.Build == nil || cfg.Build.Dockerfile != "Dockerfile" {
+		t.Errorf("Build = %+v, want Dockerfile build", cfg.Build)
+	}
+	if cfg.RemoteUser != "dev" || cfg.WorkspaceFolder != "/web" {
+		t.Errorf("RemoteUser = %q, WorkspaceFolder = %q", cfg.RemoteUser, cfg.WorkspaceFolder)
+	}
+	if got := cfg.ContainerEnv["GITHUB_TOKEN"]; got != "${localEnv:GITHUB_TOKEN}" {
+		t.Errorf("GITHUB_TOKEN = %q, want ${localEnv:GITHUB_TOKEN}", got)
+	}
+	if cfg.ContainerEnv["GLOVEBOX_SKIP_HOOKS"] == "" {
+		t.Error("expected GLOVEBOX_SKIP_HOOKS so the entrypoint doesn't repeat hooks")
+	}
+
+	wantSources := []string{"${localEnv:HOME}/.aws", "${localWorkspaceFolder}/data", "/srv/cache"}
+	for i, m := range cfg.Mounts {
+		if m.Source != wantSources[i] || m.Type != "bind" {
+			t.Errorf("mount %d = %+v, want bind from %s", i, m, wantSources[i])
+		}
+	}
+
+	if got := cfg.OnCreateCommand.Script(); got != "/usr/local/bin/glovebox-hooks on_create" {
+		t.Errorf("onCreateCommand = %q", got)
+	}
+	if got := cfg.PostStartCommand.Script(); got != "/usr/local/bin/glovebox-hooks on_start" {
+		t.Errorf("postStartCommand = %q", got)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "on_exit") {
+		t.Errorf("warnings = %v, want one about on_exit", warnings)
+	}
+
+	t.Run("marshalled output parses back", func(t *testing.T) {
+		data, err := cfg.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		if !strings.HasPrefix(string(data), ExportHeader+"\n") {
+			t.Error("expected export header")
+		}
+
+		parsed, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if !reflect.DeepEqual(parsed.Mounts, cfg.Mounts) {
+			t.Errorf("Mounts = %+v, want %+v", parsed.Mounts, cfg.Mounts)
+		}
+		if !reflect.DeepEqual(parsed.ContainerEnv, cfg.ContainerEnv) {
+			t.Errorf("ContainerEnv = %v, want %v", parsed.ContainerEnv, cfg.ContainerEnv)
+		}
+		if len(parsed.Other) != 0 {
+			t.Errorf("Other = %v, want none", parsed.Other)
+		}
+	})
+
+	t.Run("ports and host services", func(t *testing.T) {
+		cfg, warnings := Export(Environment{
+			Name:      "web",
+			Workspace: "/web",
+			Ports: []profile.Port{
+				{HostIP: "127.0.0.1", HostPort: 3000, ContainerPort: 3000, Protocol: "tcp"},
+				{HostIP: "127.0.0.1", HostPort: 8080, ContainerPort: 80, Protocol: "tcp"},
+			},
+			HostServices: map[string]int{"ollama": 11434},
+		}, "Dockerfile")
+
+		if want := []Port{"3000", "80"}; !reflect.DeepEqual(cfg.ForwardPorts, want) {
+			t.Errorf("ForwardPorts = %v, want %v", cfg.ForwardPorts, want)
+		}
+		if want := []string{"--add-host=host.glovebox.internal:host-gateway"}; !reflect.DeepEqual(cfg.RunArgs, want) {
+			t.Errorf("RunArgs = %v, want %v", cfg.RunArgs, want)
+		}
+		if len(warnings) != 1 || !strings.Contains(warnings[0], "8080:80") {
+			t.Errorf("warnings = %v, want one about 8080:80", warnings)
+		}
+	})
+
+	t.Run("build args and secrets", func(t *testing.T) {
+		cfg, warnings := Export(Environment{
+			Name:         "web",
+			Workspace:    "/web",
+			BuildArgs:    map[string]string{"NODE_VERSION": "22"},
+			BuildSecrets: []mod.BuildSecret{{ID: "npmrc", Required: true}, {ID: "gh_token", Env: "GH_TOKEN"}},
+		}, "Dockerfile")
+
+		if want := map[string]string{"NODE_VERSION": "22"}; !reflect.DeepEqual(cfg.Build.Args, want) {
+			t.Errorf("Build.Args = %v, want %v", cfg.Build.Args, want)
+		}
+		if len(warnings) != 2 || !strings.Contains(warnings[0], "npmrc") || !strings.Contains(warnings[0], "won't build") ||
+			!strings.Contains(warnings[1], "gh_token") {
+			t.Errorf("warnings = %v, want the required npmrc and optional gh_token secrets", warnings)
+		}
+	})
+}
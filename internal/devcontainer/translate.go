@@ -0,0 +1,361 @@
+package devcontainer
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/joelhelbling/glovebox/internal/mod"
+	"github.com/joelhelbling/glovebox/internal/profile"
+)
+
+// GeneratedModID is the ID of the custom mod written for a devcontainer's
+// environment and lifecycle commands
+const GeneratedModID = "custom/devcontainer"
+
+// Result is a devcontainer translated into glovebox terms. Mod IDs may name
+// an OS-agnostic mod (e.g. "languages/python") that the caller resolves to
+// the variant for the profile's OS.
+type Result struct {
+	Mods           []string
+	PassthroughEnv []string
+	Mounts         []profile.Mount
+	Ports          []string // port specs for the profile's ports
+	Mod            *mod.Mod // nil when nothing needs a generated mod
+	Warnings       []string // everything that couldn't be translated
+}
+
+// featureMods maps devcontainer feature names to glovebox mods. An empty
+// value means the feature is already covered by every glovebox base.
+var featureMods = map[string]string{
+	"node":         "languages/nodejs",
+	"python":       "languages/python",
+	"ruby":         "languages/ruby",
+	"common-utils": "",
+	"git":          "",
+}
+
+// imageMods maps devcontainers/images repositories to glovebox mods
+var imageMods = map[string]string{
+	"javascript-node": "languages/nodejs",
+	"typescript-node": "languages/nodejs",
+	"python":          "languages/python",
+	"ruby":            "languages/ruby",
+	"base":            "",
+	"universal":       "",
+}
+
+var (
+	localEnvPattern     = regexp.MustCompile(`\$\{localEnv:([^}:]+)(:[^}]*)?\}`)
+	containerEnvPattern = regexp.MustCompile(`\$\{containerEnv:([^}:]+)(:[^}]*)?\}`)
+	variablePattern     = regexp.MustCompile(`\$\{[^}]+\}`)
+)
+
+// Translate maps a devcontainer config onto glovebox. workspace is the path
+// glovebox mounts the project at in the container (e.g. "/myapp").
+func Translate(cfg *Config, workspace string) *Result {
+	t := &translator{
+		cfg:       cfg,
+		workspace: workspace,
+		result:    &Result{},
+		generated: &mod.Mod{
+			Name:        "devcontainer",
+			Description: "Generated from devcontainer.json",
+			Category:    "custom",
+		},
+	}
+
+	t.translateImage()
+	t.translateFeatures()
+	t.translateEnv("containerEnv", cfg.ContainerEnv)
+	t.translateEnv("remoteEnv", cfg.RemoteEnv)
+	t.translateMounts()
+	t.translateCommands()
+
+	t.translatePorts()
+	if cfg.WorkspaceFolder != "" && cfg.WorkspaceFolder != workspace {
+		t.warn("workspaceFolder (%s): glovebox mounts the project at %s", cfg.WorkspaceFolder, workspace)
+	}
+	if cfg.WorkspaceMount != "" {
+		t.warn("workspaceMount: glovebox always bind-mounts the project directory")
+	}
+	for _, key := range cfg.Other {
+		t.warn("%s: not supported", key)
+	}
+
+	g := t.generated
+	if len(g.Env) > 0 || g.OnCreate != "" || g.OnStart != "" {
+		t.result.Mod = g
+	}
+	return t.result
+}
+
+type translator struct {
+	cfg       *Config
+	workspace string
+	result    *Result
+	generated *mod.Mod
+}
+
+func (t *translator) warn(format string, args ...any) {
+	t.result.Warnings = append(t.result.Warnings, fmt.Sprintf(format, args...))
+}
+
+func (t *translator) addMod(id string) {
+	for _, existing := range t.result.Mods {
+		if existing == id {
+			return
+		}
+	}
+	t.result.Mods = append(t.result.Mods, id)
+}
+
+func (t *translator) translateImage() {
+	if dockerfile := t.dockerfile(); dockerfile != "" {
+		t.warn("build.dockerfile (%s): Dockerfile builds can't be translated; port its steps into a mod", dockerfile)
+	}
+	if t.cfg.Image == "" {
+		return
+	}
+
+	repo := imageRepository(t.cfg.Image)
+	name := path.Base(repo)
+	if strings.HasPrefix(repo, "mcr.microsoft.com/") && strings.Contains(repo, "devcontainers/") {
+		if id, ok := imageMods[name]; ok {
+			if id != "" {
+				t.addMod(id)
+			}
+			return
+		}
+	}
+	t.warn("image (%s): no matching glovebox mods; the project builds on your glovebox base instead", t.cfg.Image)
+}
+
+func (t *translator) dockerfile() string {
+	if t.cfg.Build != nil && t.cfg.Build.Dockerfile != "" {
+		return t.cfg.Build.Dockerfile
+	}
+	return t.cfg.DockerFile
+}
+
+func (t *translator) translateFeatures() {
+	ids := make([]string, 0, len(t.cfg.Features))
+	for id := range t.cfg.Features {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		modID, ok := featureMods[featureName(id)]
+		switch {
+		case !ok:
+			t.warn("feature %s: no matching glovebox mod", id)
+		case modID != "":
+			t.addMod(modID)
+			if version, _ := t.cfg.Features[id]["version"].(string); version != "" && version != "latest" && version != "lts" {
+				t.warn("feature %s: version %s not pinned; the mod installs the latest release", id, version)
+			}
+		}
+	}
+}
+
+func (t *translator) translateEnv(section string, env map[string]string) {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := env[key]
+
+		// A bare ${localEnv:NAME} passes a host variable through
+		if m := localEnvPattern.FindStringSubmatch(value); m != nil && m[0] == value {
+			if m[1] != key {
+				t.warn("%s.%s: glovebox passes host variables through under their own name; passing %s instead", section, key, m[1])
+			}
+			t.addPassthrough(m[1])
+			continue
+		}
+		if localEnvPattern.MatchString(value) {
+			t.warn("%s.%s: host variables can only be passed through whole; skipped %q", section, key, value)
+			continue
+		}
+
+		value = containerEnvPattern.ReplaceAllString(value, "$${$1}")
+		value = t.replaceWorkspace(value)
+		if unsupported := unknownVariables(value); len(unsupported) > 0 {
+			t.warn("%s.%s: unsupported variable %s", section, key, strings.Join(unsupported, ", "))
+			continue
+		}
+
+		if t.generated.Env == nil {
+			t.generated.Env = make(map[string]string)
+		}
+		if strings.ContainsAny(value, " \t") {
+			value = `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+		}
+		t.generated.Env[key] = value
+	}
+}
+
+func (t *translator) addPassthrough(name string) {
+	for _, existing := range t.result.PassthroughEnv {
+		if existing == name {
+			return
+		}
+	}
+	t.result.PassthroughEnv = append(t.result.PassthroughEnv, name)
+}
+
+func (t *translator) translateMounts() {
+	for _, m := range t.cfg.Mounts {
+		if m.EffectiveType() != "bind" {
+			t.warn("mount %s: only bind mounts are supported", m)
+			continue
+		}
+		source, ok := t.hostPath(m.Source)
+		if !ok {
+			t.warn("mount %s: unsupported source path", m)
+			continue
+		}
+		t.result.Mounts = append(t.result.Mounts, profile.Mount{
+			Source:   source,
+			Target:   t.containerPath(t.replaceWorkspace(m.Target)),
+			ReadOnly: m.ReadOnly,
+		})
+	}
+}
+
+// translatePorts publishes forwarded container ports on the same host port.
+// Ports of other containers ("db:5432") have no glovebox equivalent.
+func (t *translator) translatePorts() {
+	for _, p := range t.cfg.ForwardPorts {
+		if _, err := profile.ParsePort(string(p)); err != nil || strings.Contains(string(p), ":") {
+			t.warn("forwardPorts (%s): only ports of the dev container itself can be published", p)
+			continue
+		}
+		t.result.Ports = append(t.result.Ports, string(p))
+	}
+}
+
+// hostPath rewrites a mount source into profile form: "~/" for the home
+// directory, relative for paths inside the project
+func (t *translator) hostPath(source string) (string, bool) {
+	for _, home := range []string{"${localEnv:HOME}", "${localEnv:USERPROFILE}"} {
+		source = strings.ReplaceAll(source, home, "~")
+	}
+	if rest, ok := strings.CutPrefix(source, "${localWorkspaceFolder}"); ok {
+		rest = strings.TrimPrefix(rest, "/")
+		if rest == "" {
+			rest = "."
+		}
+		source = rest
+	}
+	if source == "" || variablePattern.MatchString(source) {
+		return "", false
+	}
+	return source, true
+}
+
+// containerPath moves paths under the devcontainer user's home to the
+// glovebox dev user's home
+func (t *translator) containerPath(p string) string {
+	for _, user := range t.remoteUsers() {
+		home := "/home/" + user
+		if p == home || strings.HasPrefix(p, home+"/") {
+			return "/home/dev" + strings.TrimPrefix(p, home)
+		}
+	}
+	if p == "/root" || strings.HasPrefix(p, "/root/") {
+		return "/home/dev" + strings.TrimPrefix(p, "/root")
+	}
+	return p
+}
+
+func (t *translator) remoteUsers() []string {
+	users := []string{"vscode", "node", "codespace"}
+	for _, u := range []string{t.cfg.RemoteUser, t.cfg.ContainerUser} {
+		if u != "" && u != "root" {
+			users = append(users, u)
+		}
+	}
+	return users
+}
+
+func (t *translator) replaceWorkspace(s string) string {
+	s = strings.ReplaceAll(s, "${containerWorkspaceFolder}", t.workspace)
+	s = strings.ReplaceAll(s, "${containerWorkspaceFolderBasename}", path.Base(t.workspace))
+	return s
+}
+
+func (t *translator) translateCommands() {
+	var create []string
+	for _, c := range []struct {
+		name string
+		cmd  *Command
+	}{
+		{"onCreateCommand", t.cfg.OnCreateCommand},
+		{"postCreateCommand", t.cfg.PostCreateCommand},
+	} {
+		if script := t.script(c.cmd); script != "" {
+			create = append(create, "# "+c.name+"\n"+script)
+		}
+	}
+	if len(create) > 0 {
+		t.generated.OnCreate = strings.Join(create, "\n\n") + "\n"
+	}
+	if script := t.script(t.cfg.PostStartCommand); script != "" {
+		t.generated.OnStart = "# postStartCommand\n" + script + "\n"
+	}
+}
+
+func (t *translator) script(c *Command) string {
+	script := c.Script()
+	if script == "" {
+		return ""
+	}
+	script = t.replaceWorkspace(script)
+	return containerEnvPattern.ReplaceAllString(script, "$${$1}")
+}
+
+// hostVariables are devcontainer variables without a container equivalent
+var hostVariables = map[string]bool{
+	"${localWorkspaceFolder}":         true,
+	"${localWorkspaceFolderBasename}": true,
+	"${devcontainerId}":               true,
+}
+
+// unknownVariables lists devcontainer ${...} variables glovebox can't
+// resolve. Plain ${NAME} references are left for the shell.
+func unknownVariables(s string) []string {
+	var unknown []string
+	for _, v := range variablePattern.FindAllString(s, -1) {
+		if strings.Contains(v, ":") || hostVariables[v] {
+			unknown = append(unknown, v)
+		}
+	}
+	return unknown
+}
+
+// featureName extracts the short name from a feature reference, e.g.
+// "ghcr.io/devcontainers/features/node:1" -> "node"
+func featureName(ref string) string {
+	ref = strings.SplitN(ref, "@", 2)[0]
+	name := path.Base(ref)
+	if i := strings.LastIndex(name, ":"); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// imageRepository strips the tag or digest from an image reference
+func imageRepository(image string) string {
+	image = strings.SplitN(image, "@", 2)[0]
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
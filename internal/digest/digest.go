@@ -3,7 +3,9 @@ package digest
 import (
 	"crypto/sha256"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 )
 
 // Calculate computes a SHA256 digest of the given content
@@ -21,6 +23,56 @@ func CalculateFile(path string) (string, error) {
 	return Calculate(string(data)), nil
 }
 
+// CalculatePath computes a SHA256 digest of a file or directory tree.
+// Directory digests cover each entry's relative path, type and contents,
+// so both renames and content edits are detected. VCS metadata
+// (.git) is skipped.
+func CalculatePath(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("reading path for digest: %w", err)
+	}
+	if !info.IsDir() {
+		return CalculateFile(path)
+	}
+
+	hash := sha256.New()
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			fmt.Fprintf(hash, "d %s\n", rel)
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(hash, "l %s %s\n", rel, target)
+		default:
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(hash, "f %s %d\n", rel, len(data))
+			hash.Write(data)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("reading directory for digest: %w", err)
+	}
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil)), nil
+}
+
 // Match checks if the given content matches the expected digest
 func Match(content, expected string) bool {
 	return Calculate(content) == expected
@@ -227,3 +227,76 @@ func TestMatchFile(t *testing.T) {
 		}
 	})
 }
+
+func TestCalculatePath(t *testing.T) {
+	writeTree := func(t *testing.T, files map[string]string) string {
+		t.Helper()
+		dir := t.TempDir()
+		for name, content := range files {
+			full := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+				t.Fatalf("failed to create dir: %v", err)
+			}
+			if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to write file: %v", err)
+			}
+		}
+		return dir
+	}
+
+	t.Run("file matches CalculateFile", func(t *testing.T) {
+		dir := writeTree(t, map[string]string{"a.txt": "hello"})
+		got, err := CalculatePath(filepath.Join(dir, "a.txt"))
+		if err != nil {
+			t.Fatalf("CalculatePath() error = %v", err)
+		}
+		if got != Calculate("hello") {
+			t.Errorf("CalculatePath() = %q, want %q", got, Calculate("hello"))
+		}
+	})
+
+	t.Run("identical trees produce identical digests", func(t *testing.T) {
+		files := map[string]string{".bashrc": "alias ll='ls -la'", ".config/fish/config.fish": "set -x EDITOR nvim"}
+		first, err := CalculatePath(writeTree(t, files))
+		if err != nil {
+			t.Fatalf("CalculatePath() error = %v", err)
+		}
+		second, err := CalculatePath(writeTree(t, files))
+		if err != nil {
+			t.Fatalf("CalculatePath() error = %v", err)
+		}
+		if first != second {
+			t.Errorf("expected identical digests, got %q and %q", first, second)
+		}
+	})
+
+	t.Run("content change alters digest", func(t *testing.T) {
+		before, _ := CalculatePath(writeTree(t, map[string]string{"a": "1"}))
+		after, _ := CalculatePath(writeTree(t, map[string]string{"a": "2"}))
+		if before == after {
+			t.Error("expected digest to change when content changes")
+		}
+	})
+
+	t.Run("rename alters digest", func(t *testing.T) {
+		before, _ := CalculatePath(writeTree(t, map[string]string{"a": "1"}))
+		after, _ := CalculatePath(writeTree(t, map[string]string{"b": "1"}))
+		if before == after {
+			t.Error("expected digest to change when a file is renamed")
+		}
+	})
+
+	t.Run("ignores .git directory", func(t *testing.T) {
+		before, _ := CalculatePath(writeTree(t, map[string]string{"a": "1"}))
+		after, _ := CalculatePath(writeTree(t, map[string]string{"a": "1", ".git/HEAD": "ref: refs/heads/main"}))
+		if before != after {
+			t.Error("expected .git contents to be ignored")
+		}
+	})
+
+	t.Run("missing path errors", func(t *testing.T) {
+		if _, err := CalculatePath("/nonexistent/path"); err == nil {
+			t.Error("expected error for missing path")
+		}
+	})
+}
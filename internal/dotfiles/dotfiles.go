@@ -0,0 +1,333 @@
+// Package dotfiles resolves a user's dotfiles source (a local directory or a
+// git repository) and renders the script that installs them into a glovebox
+// image or container.
+package dotfiles
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/joelhelbling/glovebox/internal/digest"
+)
+
+// Install strategies
+const (
+	StrategySymlink = "symlink" // link listed files from the dotfiles dir into $HOME
+	StrategyCopy    = "copy"    // copy listed files into $HOME
+	StrategyScript  = "script"  // run an install command from the dotfiles dir
+)
+
+// When dotfiles are applied
+const (
+	ApplyBuild  = "build"  // baked into the image at build time
+	ApplyCreate = "create" // mounted and installed when a container is created
+)
+
+const (
+	// ContainerDir is where the dotfiles source lives inside the container
+	ContainerDir = "/home/dev/.dotfiles"
+
+	// InstallScriptPath is where the generated installer is placed in the image
+	InstallScriptPath = "/usr/local/bin/glovebox-dotfiles"
+
+	// DefaultScript is run by the script strategy when none is configured
+	DefaultScript = "./install.sh"
+
+	// lsRemoteTimeout bounds the network check used by status
+	lsRemoteTimeout = 10 * time.Second
+)
+
+// Config describes where dotfiles come from and how they are installed
+type Config struct {
+	Source   string   `yaml:"source"`             // local directory or git URL
+	Ref      string   `yaml:"ref,omitempty"`      // git branch or tag (default: remote HEAD)
+	Strategy string   `yaml:"strategy,omitempty"` // symlink (default), copy, or script
+	Files    []string `yaml:"files,omitempty"`    // for symlink/copy; default is every top-level dotfile
+	Script   string   `yaml:"script,omitempty"`   // for script strategy (e.g. "./install.sh", "stow -t ~ */")
+	Apply    string   `yaml:"apply,omitempty"`    // build (default) or create
+}
+
+// EffectiveStrategy returns the configured strategy or the default
+func (c *Config) EffectiveStrategy() string {
+	if c.Strategy == "" {
+		return StrategySymlink
+	}
+	return c.Strategy
+}
+
+// EffectiveApply returns when dotfiles are applied, defaulting to build
+func (c *Config) EffectiveApply() string {
+	if c.Apply == "" {
+		return ApplyBuild
+	}
+	return c.Apply
+}
+
+// EffectiveScript returns the install command for the script strategy
+func (c *Config) EffectiveScript() string {
+	if c.Script == "" {
+		return DefaultScript
+	}
+	return c.Script
+}
+
+// IsGit reports whether the source is a git repository URL rather than a local path
+func (c *Config) IsGit() bool {
+	src := c.Source
+	return strings.Contains(src, "://") || strings.HasPrefix(src, "git@") || strings.HasSuffix(src, ".git")
+}
+
+// Validate checks that the configuration is usable
+func (c *Config) Validate() error {
+	if c.Source == "" {
+		return fmt.Errorf("dotfiles: source is required")
+	}
+
+	switch c.EffectiveStrategy() {
+	case StrategySymlink, StrategyCopy:
+		if c.Script != "" {
+			return fmt.Errorf("dotfiles: script is only used with strategy %q", StrategyScript)
+		}
+	case StrategyScript:
+		if len(c.Files) > 0 {
+			return fmt.Errorf("dotfiles: files is not used with strategy %q", StrategyScript)
+		}
+	default:
+		return fmt.Errorf("dotfiles: unknown strategy %q (expected symlink, copy, or script)", c.Strategy)
+	}
+
+	switch c.EffectiveApply() {
+	case ApplyBuild, ApplyCreate:
+	default:
+		return fmt.Errorf("dotfiles: unknown apply %q (expected build or create)", c.Apply)
+	}
+
+	for _, f := range c.Files {
+		if f == "" || filepath.IsAbs(f) || strings.Contains(f, "..") {
+			return fmt.Errorf("dotfiles: invalid file %q (must be relative to the dotfiles directory)", f)
+		}
+	}
+	return nil
+}
+
+// Resolve returns a local directory containing the dotfiles. Local sources
+// are returned as absolute paths; git sources are cloned (or updated) into
+// the glovebox cache directory.
+func Resolve(c *Config) (string, error) {
+	dir, err := CachePath(c)
+	if err != nil {
+		return "", err
+	}
+	if !c.IsGit() {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return "", fmt.Errorf("dotfiles: %w", err)
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("dotfiles: %s is not a directory", dir)
+		}
+		return dir, nil
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return "", fmt.Errorf("dotfiles: creating cache directory: %w", err)
+		}
+		if err := runGit("", "clone", "--quiet", "--depth", "1", c.Source, dir); err != nil {
+			return "", fmt.Errorf("dotfiles: cloning %s: %w", c.Source, err)
+		}
+	}
+
+	ref := c.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if err := runGit(dir, "fetch", "--quiet", "--depth", "1", "origin", ref); err != nil {
+		return "", fmt.Errorf("dotfiles: fetching %s: %w", c.Source, err)
+	}
+	if err := runGit(dir, "checkout", "--quiet", "--force", "FETCH_HEAD"); err != nil {
+		return "", fmt.Errorf("dotfiles: checking out %s: %w", ref, err)
+	}
+	return dir, nil
+}
+
+// CachePath returns the directory Resolve returns, without fetching or
+// checking anything: the local directory, or the git checkout in the cache
+func CachePath(c *Config) (string, error) {
+	if !c.IsGit() {
+		return localPath(c.Source)
+	}
+	return cacheDir(c.Source)
+}
+
+// LocalRevision identifies the content of a resolved dotfiles directory.
+// Git checkouts are identified by commit, local directories by a tree digest.
+func LocalRevision(c *Config, dir string) (string, error) {
+	if c.IsGit() {
+		out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+		if err != nil {
+			return "", fmt.Errorf("dotfiles: reading commit: %w", err)
+		}
+		return "git:" + strings.TrimSpace(string(out)), nil
+	}
+	return digest.CalculatePath(dir)
+}
+
+// CurrentRevision reports the latest revision of the dotfiles source without
+// modifying the cache. Git sources are checked with `git ls-remote`.
+func CurrentRevision(c *Config) (string, error) {
+	if !c.IsGit() {
+		dir, err := Resolve(c)
+		if err != nil {
+			return "", err
+		}
+		return LocalRevision(c, dir)
+	}
+
+	ref := c.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), lsRemoteTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", c.Source, ref)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("dotfiles: checking %s: %w", c.Source, err)
+	}
+	commit := parseLsRemote(string(out))
+	if commit == "" {
+		return "", fmt.Errorf("dotfiles: ref %q not found in %s", ref, c.Source)
+	}
+	return "git:" + commit, nil
+}
+
+// parseLsRemote extracts the commit from `git ls-remote` output, preferring
+// the peeled commit of annotated tags ("ref^{}") when present.
+func parseLsRemote(output string) string {
+	var first string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if strings.HasSuffix(fields[1], "^{}") {
+			return fields[0]
+		}
+		if first == "" {
+			first = fields[0]
+		}
+	}
+	return first
+}
+
+// ShortRevision returns a compact form of a revision for display
+func ShortRevision(rev string) string {
+	if commit, ok := strings.CutPrefix(rev, "git:"); ok {
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		return commit
+	}
+	return digest.Short(rev)
+}
+
+// InstallScript renders the shell script that installs dotfiles from
+// ContainerDir into the dev user's home directory.
+func InstallScript(c *Config) string {
+	var b strings.Builder
+
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString("# Generated by glovebox - installs dotfiles from " + ContainerDir + "\n")
+	b.WriteString("set -e\n")
+	b.WriteString("cd " + ContainerDir + "\n\n")
+
+	switch c.EffectiveStrategy() {
+	case StrategyScript:
+		b.WriteString(c.EffectiveScript() + "\n")
+	default:
+		verb := "link"
+		if c.EffectiveStrategy() == StrategyCopy {
+			verb = "copy"
+		}
+		b.WriteString(`install_file() {
+  src="` + ContainerDir + `/$1"
+  dest="$HOME/$1"
+  mkdir -p "$(dirname "$dest")"
+  # Keep a backup of anything the dotfiles replace
+  if [ -e "$dest" ] && [ ! -L "$dest" ]; then
+    rm -rf "$dest.glovebox-bak"
+    mv "$dest" "$dest.glovebox-bak"
+  fi
+`)
+		if verb == "link" {
+			b.WriteString("  ln -sfn \"$src\" \"$dest\"\n")
+		} else {
+			b.WriteString("  rm -f \"$dest\"\n  cp -a \"$src\" \"$dest\"\n")
+		}
+		b.WriteString("}\n\n")
+
+		if len(c.Files) > 0 {
+			for _, f := range c.Files {
+				b.WriteString("install_file " + shellQuote(f) + "\n")
+			}
+		} else {
+			b.WriteString(`for f in .[!.]*; do
+  case "$f" in
+    .git|.gitignore|.gitmodules|.github) continue ;;
+  esac
+  [ -e "$f" ] || continue
+  install_file "$f"
+done
+`)
+		}
+	}
+	return b.String()
+}
+
+// localPath expands ~ and makes a local source absolute
+func localPath(src string) (string, error) {
+	if src == "~" || strings.HasPrefix(src, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("getting home directory: %w", err)
+		}
+		src = filepath.Join(home, strings.TrimPrefix(src, "~"))
+	}
+	return filepath.Abs(src)
+}
+
+// cacheDir returns the checkout location for a git source
+func cacheDir(source string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting home directory: %w", err)
+	}
+	hash := sha256.Sum256([]byte(source))
+	return filepath.Join(home, ".glovebox", "cache", "dotfiles", fmt.Sprintf("%x", hash)[:12]), nil
+}
+
+// runGit runs a git command, optionally inside dir, surfacing its stderr on failure
+func runGit(dir string, args ...string) error {
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// shellQuote wraps a value in single quotes for use in a POSIX shell script
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,208 @@
+package dotfiles
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"defaults", Config{Source: "~/dotfiles"}, false},
+		{"copy with files", Config{Source: "~/dotfiles", Strategy: StrategyCopy, Files: []string{".zshrc", ".config/nvim"}}, false},
+		{"script", Config{Source: "https://github.com/me/dotfiles", Strategy: StrategyScript, Script: "stow -t ~ */"}, false},
+		{"create", Config{Source: "~/dotfiles", Apply: ApplyCreate}, false},
+		{"missing source", Config{}, true},
+		{"unknown strategy", Config{Source: "~/dotfiles", Strategy: "rsync"}, true},
+		{"unknown apply", Config{Source: "~/dotfiles", Apply: "later"}, true},
+		{"script with symlink", Config{Source: "~/dotfiles", Script: "./install.sh"}, true},
+		{"files with script", Config{Source: "~/dotfiles", Strategy: StrategyScript, Files: []string{".zshrc"}}, true},
+		{"absolute file", Config{Source: "~/dotfiles", Files: []string{"/etc/passwd"}}, true},
+		{"escaping file", Config{Source: "~/dotfiles", Files: []string{"../secrets"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsGit(t *testing.T) {
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{"~/dotfiles", false},
+		{"/home/me/dotfiles", false},
+		{"https://github.com/me/dotfiles", true},
+		{"git@github.com:me/dotfiles.git", true},
+		{"ssh://git@example.com/dotfiles", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			c := &Config{Source: tt.source}
+			if got := c.IsGit(); got != tt.want {
+				t.Errorf("IsGit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstallScript(t *testing.T) {
+	t.Run("symlink listed files", func(t *testing.T) {
+		script := InstallScript(&Config{Source: "x", Files: []string{".zshrc", "it's"}})
+		if !strings.Contains(script, `ln -sfn "$src" "$dest"`) {
+			t.Error("expected symlink install")
+		}
+		if !strings.Contains(script, "install_file '.zshrc'\n") || !strings.Contains(script, `install_file 'it'\''s'`) {
+			t.Errorf("expected quoted file list, got:\n%s", script)
+		}
+	})
+
+	t.Run("copy all top-level dotfiles", func(t *testing.T) {
+		script := InstallScript(&Config{Source: "x", Strategy: StrategyCopy})
+		if !strings.Contains(script, `cp -a "$src" "$dest"`) {
+			t.Error("expected copy install")
+		}
+		if !strings.Contains(script, "for f in .[!.]*; do") {
+			t.Error("expected loop over top-level dotfiles")
+		}
+	})
+
+	t.Run("script strategy runs command", func(t *testing.T) {
+		script := InstallScript(&Config{Source: "x", Strategy: StrategyScript})
+		if !strings.Contains(script, "cd "+ContainerDir+"\n") || !strings.Contains(script, "\n./install.sh\n") {
+			t.Errorf("expected default install script, got:\n%s", script)
+		}
+	})
+}
+
+func TestParseLsRemote(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"branch", "abc123\trefs/heads/main\n", "abc123"},
+		{"annotated tag", "tag111\trefs/tags/v1\ncommit222\trefs/tags/v1^{}\n", "commit222"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseLsRemote(tt.output); got != tt.want {
+				t.Errorf("parseLsRemote() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveLocal(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, "dotfiles")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".zshrc"), []byte("alias g=git\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{Source: "~/dotfiles"}
+	resolved, err := Resolve(cfg)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if resolved != dir {
+		t.Errorf("Resolve() = %q, want %q", resolved, dir)
+	}
+
+	before, err := CurrentRevision(cfg)
+	if err != nil {
+		t.Fatalf("CurrentRevision() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".zshrc"), []byte("alias g=git\nalias k=kubectl\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	after, err := CurrentRevision(cfg)
+	if err != nil {
+		t.Fatalf("CurrentRevision() error = %v", err)
+	}
+	if before == after {
+		t.Error("expected revision to change when dotfiles change")
+	}
+
+	if _, err := Resolve(&Config{Source: "~/missing"}); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
+
+func TestResolveGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// A local repository stands in for the remote
+	repo := filepath.Join(home, "remote.git")
+	mustGit(t, "", "init", "--quiet", repo)
+	if err := os.WriteFile(filepath.Join(repo, ".gitconfig"), []byte("[user]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustGit(t, repo, "add", ".")
+	mustGit(t, repo, "-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "--quiet", "-m", "init")
+
+	cfg := &Config{Source: "file://" + repo}
+	cached, err := CachePath(cfg)
+	if err != nil {
+		t.Fatalf("CachePath() error = %v", err)
+	}
+	if _, err := os.Stat(cached); !os.IsNotExist(err) {
+		t.Errorf("CachePath() created %s", cached)
+	}
+	dir, err := Resolve(cfg)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if dir != cached {
+		t.Errorf("Resolve() = %q, want CachePath() %q", dir, cached)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".gitconfig")); err != nil {
+		t.Errorf("expected checkout to contain .gitconfig: %v", err)
+	}
+
+	local, err := LocalRevision(cfg, dir)
+	if err != nil {
+		t.Fatalf("LocalRevision() error = %v", err)
+	}
+	remote, err := CurrentRevision(cfg)
+	if err != nil {
+		t.Fatalf("CurrentRevision() error = %v", err)
+	}
+	if local != remote {
+		t.Errorf("LocalRevision() = %q, CurrentRevision() = %q, want equal", local, remote)
+	}
+	if !strings.HasPrefix(local, "git:") {
+		t.Errorf("expected git revision, got %q", local)
+	}
+}
+
+func mustGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	if err := runGit(dir, args...); err != nil {
+		t.Fatalf("git %v: %v", args, err)
+	}
+}
@@ -0,0 +1,237 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/joelhelbling/glovebox/internal/mod"
+)
+
+// devUID owns the dev user's cache and secret mounts so run_as_user steps
+// can use them. The OS mods create dev with this uid and gid.
+const devUID = 1000
+
+// SecretsDir is where BuildKit mounts build secrets during a RUN step
+const SecretsDir = "/run/secrets"
+
+// cachePreset is the download cache of a package manager a mod can name in
+// cache_mounts
+type cachePreset struct {
+	targets []string // "~/" is the home of the user the step runs as
+	system  bool     // only root steps install system packages
+	setup   string   // makes the package manager keep its downloads
+	cleanup string   // undoes setup before the step's layer is committed
+}
+
+// cachePresets are the package managers with known cache locations. The
+// system ones lock their cache, which can't be shared by concurrent builds.
+var cachePresets = map[string]cachePreset{
+	"apt": {
+		targets: []string{"/var/cache/apt"},
+		system:  true,
+		// Docker's Debian and Ubuntu images delete packages after installing
+		setup:   "mv /etc/apt/apt.conf.d/docker-clean /etc/apt/docker-clean.glovebox 2>/dev/null || true",
+		cleanup: "mv /etc/apt/docker-clean.glovebox /etc/apt/apt.conf.d/docker-clean 2>/dev/null || true",
+	},
+	"dnf": {
+		targets: []string{"/var/cache/dnf", "/var/cache/libdnf5"},
+		system:  true,
+		setup:   "cp /etc/dnf/dnf.conf /etc/dnf/dnf.conf.glovebox && echo keepcache=True >> /etc/dnf/dnf.conf",
+		cleanup: "mv /etc/dnf/dnf.conf.glovebox /etc/dnf/dnf.conf",
+	},
+	"apk": {
+		targets: []string{"/var/cache/apk"},
+		system:  true,
+		setup:   "ln -sfn /var/cache/apk /etc/apk/cache",
+		cleanup: "rm -f /etc/apk/cache",
+	},
+	"npm": {targets: []string{"~/.npm"}},
+	"pip": {targets: []string{"~/.cache/pip"}},
+}
+
+// CachePresetNames lists the package managers cache_mounts can name
+func CachePresetNames() []string {
+	names := make([]string, 0, len(cachePresets))
+	for name := range cachePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeRunStep emits one of a mod's setup scripts as a RUN heredoc, with the
+// cache and secret mounts the mod asks for
+func writeRunStep(b *strings.Builder, m *mod.Mod, script string, asUser bool) error {
+	mounts, prelude, err := runMounts(m, asUser)
+	if err != nil {
+		return err
+	}
+
+	who := "root"
+	if asUser {
+		who = "user"
+	}
+	b.WriteString(fmt.Sprintf("# %s setup (%s)\n", m.Name, who))
+	b.WriteString("RUN ")
+	for _, mount := range mounts {
+		b.WriteString(mount + " ")
+	}
+	b.WriteString("<<'EOF'\n")
+	b.WriteString("set -e\n")
+	b.WriteString(prelude)
+	b.WriteString(strings.TrimSpace(script))
+	b.WriteString("\nEOF\n\n")
+	return nil
+}
+
+// runMounts returns the --mount flags for a mod's RUN step and the shell
+// lines that prepare them: package manager settings, restored when the step
+// exits, and secrets exported as environment variables.
+func runMounts(m *mod.Mod, asUser bool) ([]string, string, error) {
+	home := "/root"
+	owner := ""
+	if asUser {
+		home = "/home/dev"
+		owner = fmt.Sprintf(",uid=%d,gid=%d", devUID, devUID)
+	}
+
+	var mounts []string
+	var setup, cleanup []string
+	for _, name := range m.CacheMounts {
+		preset, ok := cachePresets[name]
+		if !ok {
+			if !strings.HasPrefix(name, "/") && !strings.HasPrefix(name, "~/") {
+				return nil, "", fmt.Errorf("mod %q: unknown cache mount %q (use one of %s, or an absolute path)",
+					m.Name, name, strings.Join(CachePresetNames(), ", "))
+			}
+			preset = cachePreset{targets: []string{name}}
+		}
+		if preset.system && asUser {
+			continue
+		}
+		for _, target := range preset.targets {
+			mount := "--mount=type=cache,target=" + expandHome(target, home)
+			if preset.system {
+				mount += ",sharing=locked"
+			} else {
+				mount += owner
+			}
+			mounts = append(mounts, mount)
+		}
+		if preset.setup != "" {
+			setup = append(setup, preset.setup)
+			cleanup = append(cleanup, preset.cleanup)
+		}
+	}
+
+	var exports []string
+	for _, s := range m.BuildSecrets {
+		if err := s.Validate(); err != nil {
+			return nil, "", fmt.Errorf("mod %q: %w", m.Name, err)
+		}
+		mount := "--mount=type=secret,id=" + s.ID + owner
+		if s.Required {
+			mount += ",required=true"
+		}
+		mounts = append(mounts, mount)
+		if s.Env != "" {
+			file := path.Join(SecretsDir, s.ID)
+			exports = append(exports, fmt.Sprintf("if [ -f %s ]; then export %s=\"$(cat %s)\"; fi", file, s.Env, file))
+		}
+	}
+
+	var prelude strings.Builder
+	if len(setup) > 0 {
+		prelude.WriteString(fmt.Sprintf("trap '%s' EXIT\n", strings.Join(cleanup, "; ")))
+		prelude.WriteString(strings.Join(setup, "\n") + "\n")
+	}
+	for _, e := range exports {
+		prelude.WriteString(e + "\n")
+	}
+	return mounts, prelude.String(), nil
+}
+
+// expandHome resolves a leading ~/ against home
+func expandHome(p, home string) string {
+	if strings.HasPrefix(p, "~/") {
+		return home + "/" + strings.TrimPrefix(p, "~/")
+	}
+	return p
+}
+
+// writeBuildArgs declares the build args mods accept, with their defaults
+func writeBuildArgs(b *strings.Builder, mods []*mod.Mod) error {
+	args := make(map[string]string)
+	for _, m := range mods {
+		for name, value := range m.BuildArgs {
+			if err := mod.ValidateBuildArg(name); err != nil {
+				return fmt.Errorf("mod %q: %w", m.Name, err)
+			}
+			args[name] = value
+		}
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b.WriteString("# Build arguments from mods\n")
+	for _, name := range names {
+		if args[name] == "" {
+			b.WriteString(fmt.Sprintf("ARG %s\n", name))
+		} else {
+			b.WriteString(fmt.Sprintf("ARG %s=%s\n", name, args[name]))
+		}
+	}
+	b.WriteString("\n")
+	return nil
+}
+
+// BaseBuildSecrets returns the build secrets that the Dockerfile produced by
+// GenerateBase mounts.
+func BaseBuildSecrets(modIDs []string) ([]mod.BuildSecret, error) {
+	mods, err := mod.LoadMultiple(modIDs)
+	if err != nil {
+		return nil, fmt.Errorf("loading mods: %w", err)
+	}
+	return collectBuildSecrets(mods), nil
+}
+
+// ProjectBuildSecrets returns the build secrets that the Dockerfile produced
+// by GenerateProject mounts.
+func ProjectBuildSecrets(modIDs []string, baseModIDs []string) ([]mod.BuildSecret, error) {
+	mods, err := mod.LoadMultipleExcluding(modIDs, baseModIDs)
+	if err != nil {
+		return nil, fmt.Errorf("loading mods: %w", err)
+	}
+	return collectBuildSecrets(mods), nil
+}
+
+// collectBuildSecrets merges the mods' build secrets by id. A secret is
+// required when any mod requires it.
+func collectBuildSecrets(mods []*mod.Mod) []mod.BuildSecret {
+	var result []mod.BuildSecret
+	index := make(map[string]int)
+	for _, m := range mods {
+		for _, s := range m.BuildSecrets {
+			i, ok := index[s.ID]
+			if !ok {
+				index[s.ID] = len(result)
+				result = append(result, s)
+				continue
+			}
+			result[i].Required = result[i].Required || s.Required
+			if result[i].Env == "" {
+				result[i].Env = s.Env
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// StageContextFiles copies host files into the build context directory,
+// replacing anything staged by a previous build.
+func StageContextFiles(contextDir string, files []ContextFile) error {
+	stagingDir := filepath.Join(contextDir, StagedFilesDir)
+	if err := os.RemoveAll(stagingDir); err != nil {
+		return fmt.Errorf("clearing staged files: %w", err)
+	}
+
+	for _, f := range files {
+		dest := filepath.Join(contextDir, filepath.FromSlash(f.ContextPath))
+		if err := copyPath(f.HostPath, dest); err != nil {
+			return fmt.Errorf("staging %s: %w", f.HostPath, err)
+		}
+	}
+	return nil
+}
+
+// copyPath copies a file or directory tree, preserving permissions and
+// symlinks. VCS metadata (.git) is not copied.
+func copyPath(src, dest string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return copyEntry(src, dest, info)
+	}
+
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return copyEntry(p, filepath.Join(dest, rel), info)
+	})
+}
+
+// copyEntry copies a single directory, symlink or regular file
+func copyEntry(src, dest string, info fs.FileInfo) error {
+	switch {
+	case info.IsDir():
+		return os.MkdirAll(dest, info.Mode().Perm()|0700)
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		return os.Symlink(target, dest)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -2,16 +2,122 @@ package generator
 
 import (
 	"fmt"
+	"path"
+	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/joelhelbling/glovebox/internal/assets"
+	"github.com/joelhelbling/glovebox/internal/digest"
+	"github.com/joelhelbling/glovebox/internal/dotfiles"
 	"github.com/joelhelbling/glovebox/internal/mod"
+	"github.com/joelhelbling/glovebox/internal/profile"
 )
 
+// StagedFilesDir is the build context subdirectory holding host files
+// referenced by mods. It is recreated next to the Dockerfile on every build.
+const StagedFilesDir = "build-files"
+
+// fileDelimiter terminates inline file content in COPY heredocs. It is
+// deliberately unusual so config files containing "EOF" are safe.
+const fileDelimiter = "GLOVEBOX_FILE"
+
+// HooksDir is where lifecycle hook scripts are installed in the image,
+// one subdirectory per phase. The entrypoint runs them via HooksRunnerPath.
+const HooksDir = "/etc/glovebox/hooks"
+
+// HooksRunnerPath is the script that runs every hook for a phase
+const HooksRunnerPath = "/usr/local/bin/glovebox-hooks"
+
+// Hook layers order hooks from the base image before those from images
+// built on it. An image's layer is its depth in the extends chain.
+const (
+	hookLayerBase    = 0
+	hookLayerProject = 1
+)
+
+// DefaultParentImage is the image project Dockerfiles extend by default
+const DefaultParentImage = profile.BaseImageName
+
+// DotfilesContextPath is where the dotfiles source is staged in the build context
+var DotfilesContextPath = path.Join(StagedFilesDir, "_dotfiles")
+
+// Options holds profile-level settings that shape the generated Dockerfile
+type Options struct {
+	// Dotfiles installs the user's dotfiles. Nil when none are configured.
+	Dotfiles *dotfiles.Config
+	// DotfilesRevision identifies the dotfiles content baked into the image
+	DotfilesRevision string
+	// ParentImage is the image a project Dockerfile extends (default glovebox:base)
+	ParentImage string
+	// Layer is the image's depth in the extends chain, which orders its
+	// lifecycle hooks after its parents'. Project images default to 1.
+	Layer int
+	// Strategy orders the Dockerfile's instructions (default StrategyDependency)
+	Strategy string
+	// Platforms are the platforms the image is built for. Every mod must
+	// support all of them; empty is the runtime's default.
+	Platforms []string
+	// FromDigest pins a base Dockerfile's FROM to a digest, when the OS
+	// mod's image is From. Otherwise the image's tag is used as is.
+	From       string
+	FromDigest string
+}
+
+func (o Options) parentImage() string {
+	if o.ParentImage == "" {
+		return DefaultParentImage
+	}
+	return o.ParentImage
+}
+
+// from returns the image a base Dockerfile starts from
+func (o Options) from(osMod *mod.Mod) string {
+	if o.FromDigest != "" && o.From == osMod.DockerfileFrom {
+		return osMod.DockerfileFrom + "@" + o.FromDigest
+	}
+	return osMod.DockerfileFrom
+}
+
+func (o Options) layer() int {
+	if o.Layer == 0 {
+		return hookLayerProject
+	}
+	return o.Layer
+}
+
+// ContextFile is a host file or directory that must be staged into the
+// build context before the generated Dockerfile can be built.
+type ContextFile struct {
+	HostPath    string // absolute path on the host
+	ContextPath string // slash-separated path relative to the build context
+}
+
+// BaseImage returns the image the OS mod among modIDs builds on, its
+// dockerfile_from.
+func BaseImage(modIDs []string) (string, error) {
+	mods, err := mod.LoadMultiple(modIDs)
+	if err != nil {
+		return "", fmt.Errorf("loading mods: %w", err)
+	}
+	osMod, err := mod.ValidateMods(mods)
+	if err != nil {
+		return "", fmt.Errorf("validating mods: %w", err)
+	}
+	if osMod == nil || osMod.DockerfileFrom == "" {
+		return "", fmt.Errorf("no OS mod with dockerfile_from found")
+	}
+	return osMod.DockerfileFrom, nil
+}
+
 // GenerateBase creates a base Dockerfile from a list of mod IDs.
 // This is used for the global profile and produces a standalone image.
 func GenerateBase(modIDs []string) (string, error) {
+	return GenerateBaseWithOptions(modIDs, Options{})
+}
+
+// GenerateBaseWithOptions is GenerateBase with profile-level settings applied
+func GenerateBaseWithOptions(modIDs []string, opts Options) (string, error) {
 	mods, err := mod.LoadMultiple(modIDs)
 	if err != nil {
 		return "", fmt.Errorf("loading mods: %w", err)
@@ -28,6 +134,15 @@ func GenerateBase(modIDs []string) (string, error) {
 	if osMod.DockerfileFrom == "" {
 		return "", fmt.Errorf("OS mod %q does not specify dockerfile_from", osMod.Name)
 	}
+	if err := ValidateStrategy(opts.Strategy); err != nil {
+		return "", err
+	}
+	if err := mod.ValidatePlatforms(mods, opts.Platforms); err != nil {
+		return "", fmt.Errorf("validating mods: %w", err)
+	}
+	if opts.Strategy == StrategyStable {
+		mods = stableOrder(mods, osMod)
+	}
 
 	var b strings.Builder
 
@@ -46,25 +161,39 @@ func GenerateBase(modIDs []string) (string, error) {
 	b.WriteString("\n")
 
 	// Base image from OS mod
-	b.WriteString(fmt.Sprintf("FROM %s\n\n", osMod.DockerfileFrom))
+	b.WriteString(fmt.Sprintf("FROM %s\n\n", opts.from(osMod)))
+
+	if err := writeBuildArgs(&b, mods); err != nil {
+		return "", err
+	}
+
+	if opts.Strategy == StrategyStable {
+		if err := writeStableBase(&b, mods, opts); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	}
 
 	// Run as root commands (in mod order)
 	for _, m := range mods {
 		if m.RunAsRoot != "" {
-			b.WriteString(fmt.Sprintf("# %s setup (root)\n", m.Name))
-			b.WriteString("RUN <<'EOF'\n")
-			b.WriteString("set -e\n")
-			b.WriteString(strings.TrimSpace(m.RunAsRoot))
-			b.WriteString("\nEOF\n\n")
+			if err := writeRunStep(&b, m, m.RunAsRoot, false); err != nil {
+				return "", err
+			}
 		}
 	}
 
-	// Write entrypoint script inline using heredoc
-	b.WriteString("# Create entrypoint script\n")
-	b.WriteString("RUN cat > /usr/local/bin/entrypoint.sh <<'EOF'\n")
-	b.WriteString(strings.TrimSpace(assets.EntrypointScript))
-	b.WriteString("\nEOF\n")
-	b.WriteString("RUN chmod 755 /usr/local/bin/entrypoint.sh\n\n")
+	// Files from mods (after root setup so destinations can rely on installed packages)
+	for _, m := range mods {
+		if err := writeModFiles(&b, m); err != nil {
+			return "", err
+		}
+	}
+
+	// Lifecycle hooks from mods
+	writeHooks(&b, mods, hookLayerBase)
+
+	writeEntrypoint(&b)
 
 	// Switch to non-root user
 	b.WriteString("# Switch to non-root user\n")
@@ -102,14 +231,17 @@ func GenerateBase(modIDs []string) (string, error) {
 	// Run as user commands
 	for _, m := range mods {
 		if m.RunAsUser != "" {
-			b.WriteString(fmt.Sprintf("# %s setup (user)\n", m.Name))
-			b.WriteString("RUN <<'EOF'\n")
-			b.WriteString("set -e\n")
-			b.WriteString(strings.TrimSpace(m.RunAsUser))
-			b.WriteString("\nEOF\n\n")
+			if err := writeRunStep(&b, m, m.RunAsUser, true); err != nil {
+				return "", err
+			}
 		}
 	}
 
+	// Dotfiles go last so they can use tools installed by mods (stow, chezmoi, ...)
+	if err := writeDotfiles(&b, opts, hookLayerBase); err != nil {
+		return "", err
+	}
+
 	// Working directory
 	b.WriteString("# Set working directory for mounted projects\n")
 	b.WriteString("WORKDIR /workspace\n\n")
@@ -125,22 +257,53 @@ func GenerateBase(modIDs []string) (string, error) {
 	return b.String(), nil
 }
 
+// writeEntrypoint installs the container entrypoint and the lifecycle hook
+// runner it uses
+func writeEntrypoint(b *strings.Builder) {
+	// Write entrypoint script inline using heredoc
+	b.WriteString("# Create entrypoint script\n")
+	b.WriteString("RUN cat > /usr/local/bin/entrypoint.sh <<'EOF'\n")
+	b.WriteString(strings.TrimSpace(assets.EntrypointScript))
+	b.WriteString("\nEOF\n")
+	b.WriteString("RUN chmod 755 /usr/local/bin/entrypoint.sh\n\n")
+
+	// Hook runner used by the entrypoint
+	b.WriteString("# Create lifecycle hook runner\n")
+	b.WriteString(fmt.Sprintf("COPY --chown=root:root --chmod=0755 <<'%s' %s\n", fileDelimiter, HooksRunnerPath))
+	b.WriteString(strings.TrimSpace(assets.HooksScript))
+	b.WriteString("\n" + fileDelimiter + "\n\n")
+}
+
 // GenerateProject creates a project Dockerfile that extends the base image.
 // It only includes project-specific mods (additive to base).
-// baseModIDs should contain the mods already installed in the base image,
-// so their dependencies won't be redundantly included.
+// baseModIDs should contain the mods already installed in the parent images
+// (the whole extends chain), so their dependencies won't be redundantly included.
 func GenerateProject(modIDs []string, baseModIDs []string) (string, error) {
+	return GenerateProjectWithOptions(modIDs, baseModIDs, Options{})
+}
+
+// GenerateProjectWithOptions is GenerateProject with profile-level settings applied
+func GenerateProjectWithOptions(modIDs []string, baseModIDs []string, opts Options) (string, error) {
 	mods, err := mod.LoadMultipleExcluding(modIDs, baseModIDs)
 	if err != nil {
 		return "", fmt.Errorf("loading mods: %w", err)
 	}
+	if err := ValidateStrategy(opts.Strategy); err != nil {
+		return "", err
+	}
+	if err := mod.ValidatePlatforms(mods, opts.Platforms); err != nil {
+		return "", fmt.Errorf("validating mods: %w", err)
+	}
+	if opts.Strategy == StrategyStable {
+		mods = stableOrder(mods, nil)
+	}
 
 	var b strings.Builder
 
 	// Header
 	b.WriteString("# Generated by glovebox - DO NOT EDIT DIRECTLY\n")
 	b.WriteString("#\n")
-	b.WriteString("# This extends glovebox:base with project-specific tools.\n")
+	b.WriteString(fmt.Sprintf("# This extends %s with project-specific tools.\n", opts.parentImage()))
 	b.WriteString("# To modify:\n")
 	b.WriteString("#   glovebox add <mod>      Add a mod\n")
 	b.WriteString("#   glovebox remove <mod>   Remove a mod\n")
@@ -156,8 +319,19 @@ func GenerateProject(modIDs []string, baseModIDs []string) (string, error) {
 	}
 	b.WriteString("\n")
 
-	// Extend base image
-	b.WriteString("FROM glovebox:base\n\n")
+	// Extend parent image
+	b.WriteString(fmt.Sprintf("FROM %s\n\n", opts.parentImage()))
+
+	if err := writeBuildArgs(&b, mods); err != nil {
+		return "", err
+	}
+
+	if opts.Strategy == StrategyStable {
+		if err := writeStableProject(&b, mods, opts); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	}
 
 	// Switch to root for installations
 	b.WriteString("USER root\n\n")
@@ -165,14 +339,22 @@ func GenerateProject(modIDs []string, baseModIDs []string) (string, error) {
 	// Run as root commands (in mod order)
 	for _, m := range mods {
 		if m.RunAsRoot != "" {
-			b.WriteString(fmt.Sprintf("# %s setup (root)\n", m.Name))
-			b.WriteString("RUN <<'EOF'\n")
-			b.WriteString("set -e\n")
-			b.WriteString(strings.TrimSpace(m.RunAsRoot))
-			b.WriteString("\nEOF\n\n")
+			if err := writeRunStep(&b, m, m.RunAsRoot, false); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	// Files from mods
+	for _, m := range mods {
+		if err := writeModFiles(&b, m); err != nil {
+			return "", err
 		}
 	}
 
+	// Lifecycle hooks from mods
+	writeHooks(&b, mods, opts.layer())
+
 	// Switch back to non-root user
 	b.WriteString("# Switch back to non-root user\n")
 	b.WriteString("USER dev\n")
@@ -197,14 +379,17 @@ func GenerateProject(modIDs []string, baseModIDs []string) (string, error) {
 	// Run as user commands
 	for _, m := range mods {
 		if m.RunAsUser != "" {
-			b.WriteString(fmt.Sprintf("# %s setup (user)\n", m.Name))
-			b.WriteString("RUN <<'EOF'\n")
-			b.WriteString("set -e\n")
-			b.WriteString(strings.TrimSpace(m.RunAsUser))
-			b.WriteString("\nEOF\n\n")
+			if err := writeRunStep(&b, m, m.RunAsUser, true); err != nil {
+				return "", err
+			}
 		}
 	}
 
+	// Dotfiles
+	if err := writeDotfiles(&b, opts, opts.layer()); err != nil {
+		return "", err
+	}
+
 	// Set working directory
 	b.WriteString("# Set working directory for mounted projects\n")
 	b.WriteString("WORKDIR /workspace\n")
@@ -212,6 +397,184 @@ func GenerateProject(modIDs []string, baseModIDs []string) (string, error) {
 	return b.String(), nil
 }
 
+// BaseContextFiles returns the host files that the Dockerfile produced by
+// GenerateBase expects to find in its build context.
+func BaseContextFiles(modIDs []string) ([]ContextFile, error) {
+	mods, err := mod.LoadMultiple(modIDs)
+	if err != nil {
+		return nil, fmt.Errorf("loading mods: %w", err)
+	}
+	return collectContextFiles(mods)
+}
+
+// ProjectContextFiles returns the host files that the Dockerfile produced by
+// GenerateProject expects to find in its build context.
+func ProjectContextFiles(modIDs []string, baseModIDs []string) ([]ContextFile, error) {
+	mods, err := mod.LoadMultipleExcluding(modIDs, baseModIDs)
+	if err != nil {
+		return nil, fmt.Errorf("loading mods: %w", err)
+	}
+	return collectContextFiles(mods)
+}
+
+// collectContextFiles lists the source files referenced by the mods' files sections
+func collectContextFiles(mods []*mod.Mod) ([]ContextFile, error) {
+	var result []ContextFile
+	for _, m := range mods {
+		for i, f := range m.Files {
+			if f.Source == "" {
+				continue
+			}
+			hostPath, err := m.SourcePath(f)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, ContextFile{
+				HostPath:    hostPath,
+				ContextPath: contextPath(m, i, f),
+			})
+		}
+	}
+	return result, nil
+}
+
+// contextPath returns where a mod's source file is staged in the build context.
+// The index keeps entries unique when two sources share a basename.
+func contextPath(m *mod.Mod, index int, f mod.File) string {
+	name := fmt.Sprintf("%d-%s", index, filepath.Base(f.Source))
+	return path.Join(StagedFilesDir, m.Name, name)
+}
+
+// writeModFiles emits COPY instructions for a mod's files section.
+// Source files carry a digest comment so edits to them change the Dockerfile
+// (and therefore show up as a pending rebuild).
+func writeModFiles(b *strings.Builder, m *mod.Mod) error {
+	if len(m.Files) == 0 {
+		return nil
+	}
+
+	b.WriteString(fmt.Sprintf("# %s files\n", m.Name))
+	for i, f := range m.Files {
+		if err := f.Validate(); err != nil {
+			return fmt.Errorf("mod %q: %w", m.Name, err)
+		}
+
+		flags := "--chown=" + f.EffectiveOwner()
+		if f.Mode != "" {
+			flags += " --chmod=" + f.Mode
+		}
+
+		if f.Content != "" {
+			b.WriteString(fmt.Sprintf("COPY %s <<'%s' %s\n", flags, fileDelimiter, f.ContainerDest()))
+			b.WriteString(f.Content)
+			if !strings.HasSuffix(f.Content, "\n") {
+				b.WriteString("\n")
+			}
+			b.WriteString(fileDelimiter + "\n")
+			continue
+		}
+
+		hostPath, err := m.SourcePath(f)
+		if err != nil {
+			return err
+		}
+		sum, err := digest.CalculatePath(hostPath)
+		if err != nil {
+			return fmt.Errorf("mod %q: %w", m.Name, err)
+		}
+		b.WriteString(fmt.Sprintf("# source: %s (%s)\n", f.Source, digest.Short(sum)))
+		b.WriteString(fmt.Sprintf("COPY %s %s %s\n", flags, contextPath(m, i, f), f.ContainerDest()))
+	}
+	b.WriteString("\n")
+	return nil
+}
+
+// writeHooks installs each mod's lifecycle hooks as scripts under HooksDir.
+// File names sort by layer and then mod order, so hooks run base-first and in
+// dependency order within an image.
+func writeHooks(b *strings.Builder, mods []*mod.Mod, layer int) {
+	for i, m := range mods {
+		writeModHooks(b, m, layer, i+1)
+	}
+}
+
+// writeModHooks installs one mod's lifecycle hooks; index is the mod's
+// position in the image
+func writeModHooks(b *strings.Builder, m *mod.Mod, layer, index int) {
+	for _, phase := range mod.HookPhases {
+		script := m.Hook(phase)
+		if script == "" {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("# %s %s hook\n", m.Name, phase))
+		writeHookFile(b, phase, hookFileName(layer, index, m.Name), strings.TrimSpace(script))
+	}
+}
+
+// writeHookFile emits a COPY heredoc placing one hook script in the image
+func writeHookFile(b *strings.Builder, phase, name, script string) {
+	b.WriteString(fmt.Sprintf("COPY --chown=root:root --chmod=0755 <<'%s' %s\n", fileDelimiter, path.Join(HooksDir, phase, name)))
+	b.WriteString("#!/bin/bash\n")
+	b.WriteString("set -e\n")
+	b.WriteString(script)
+	b.WriteString("\n" + fileDelimiter + "\n\n")
+}
+
+// hookFileName returns the sortable script name for a hook
+func hookFileName(layer, index int, name string) string {
+	safe := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, name)
+	return fmt.Sprintf("%02d-%02d-%s.sh", layer, index, safe)
+}
+
+// DotfilesContextFile returns the build context entry for a dotfiles directory
+// resolved on the host. Only dotfiles applied at build time need staging.
+func DotfilesContextFile(cfg *dotfiles.Config, hostDir string) []ContextFile {
+	if cfg == nil || cfg.EffectiveApply() != dotfiles.ApplyBuild {
+		return nil
+	}
+	return []ContextFile{{HostPath: hostDir, ContextPath: DotfilesContextPath}}
+}
+
+// writeDotfiles emits the dotfiles installer and, when applied at build time,
+// copies the dotfiles into the image and runs it. Otherwise an on_create hook
+// installs the dotfiles mounted into the container. The revision comment makes
+// changes to the dotfiles source show up as a pending rebuild.
+func writeDotfiles(b *strings.Builder, opts Options, layer int) error {
+	cfg := opts.Dotfiles
+	if cfg == nil {
+		return nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	if cfg.EffectiveApply() == dotfiles.ApplyBuild {
+		b.WriteString(fmt.Sprintf("# Dotfiles from %s (%s)\n", cfg.Source, dotfiles.ShortRevision(opts.DotfilesRevision)))
+	} else {
+		b.WriteString(fmt.Sprintf("# Dotfiles from %s (installed when the container is created)\n", cfg.Source))
+	}
+	b.WriteString(fmt.Sprintf("COPY --chown=root:root --chmod=0755 <<'%s' %s\n", fileDelimiter, dotfiles.InstallScriptPath))
+	b.WriteString(dotfiles.InstallScript(cfg))
+	b.WriteString(fileDelimiter + "\n")
+
+	if cfg.EffectiveApply() == dotfiles.ApplyBuild {
+		b.WriteString(fmt.Sprintf("COPY --chown=dev:dev %s %s\n", DotfilesContextPath, dotfiles.ContainerDir))
+		b.WriteString(fmt.Sprintf("RUN %s\n\n", dotfiles.InstallScriptPath))
+		return nil
+	}
+
+	// Runs before any mod's on_create hook in the same layer
+	b.WriteString("\n")
+	writeHookFile(b, mod.HookOnCreate, hookFileName(layer, 0, "dotfiles"),
+		fmt.Sprintf("[ -d %s ] || exit 0\nexec %s", dotfiles.ContainerDir, dotfiles.InstallScriptPath))
+	return nil
+}
+
 // collectEnvVars gathers environment variables, later mods override earlier
 func collectEnvVars(mods []*mod.Mod) map[string]string {
 	result := make(map[string]string)
@@ -233,3 +596,51 @@ func determineDefaultShell(mods []*mod.Mod) string {
 	}
 	return shell
 }
+
+// Stage is one layer of an image chain in a flattened Dockerfile
+type Stage struct {
+	Name       string // stage name, e.g. "base" or "project"
+	Dockerfile string // the layer's generated Dockerfile
+}
+
+// Flatten joins the Dockerfiles of an image chain, root first, into a single
+// multi-stage Dockerfile. Each stage builds FROM the previous stage instead
+// of its glovebox parent image, so the result builds without glovebox.
+func Flatten(stages []Stage) (string, error) {
+	if len(stages) == 0 {
+		return "", fmt.Errorf("no stages to flatten")
+	}
+
+	var b strings.Builder
+	b.WriteString("# Generated by glovebox export - DO NOT EDIT DIRECTLY\n")
+	b.WriteString("#\n")
+	b.WriteString("# Stages, one per glovebox image layer:\n")
+	for _, s := range stages {
+		b.WriteString(fmt.Sprintf("#   - %s\n", s.Name))
+	}
+
+	for i, s := range stages {
+		lines := strings.Split(strings.TrimRight(s.Dockerfile, "\n"), "\n")
+		from := -1
+		for j, line := range lines {
+			if strings.HasPrefix(line, "FROM ") {
+				from = j
+				break
+			}
+		}
+		if from < 0 {
+			return "", fmt.Errorf("stage %s has no FROM instruction", s.Name)
+		}
+
+		parent := strings.TrimSpace(strings.TrimPrefix(lines[from], "FROM "))
+		if i > 0 {
+			parent = stages[i-1].Name
+		}
+		lines[from] = fmt.Sprintf("FROM %s AS %s", parent, s.Name)
+
+		b.WriteString(fmt.Sprintf("\n# ---- %s ----\n", s.Name))
+		b.WriteString(strings.Join(lines, "\n"))
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
@@ -1,9 +1,13 @@
 package generator
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/joelhelbling/glovebox/internal/dotfiles"
 	"github.com/joelhelbling/glovebox/internal/mod"
 )
 
@@ -297,3 +301,594 @@ func TestOSModValidation(t *testing.T) {
 		}
 	})
 }
+
+// writeLocalMod writes a mod into a temporary ~/.glovebox/mods directory and
+// points HOME at it so mod.Load can find it.
+func writeLocalMod(t *testing.T, id, content string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	modDir := filepath.Join(home, ".glovebox", "mods")
+	modPath := filepath.Join(modDir, id+".yaml")
+	if err := os.MkdirAll(filepath.Dir(modPath), 0755); err != nil {
+		t.Fatalf("failed to create mod dir: %v", err)
+	}
+	if err := os.WriteFile(modPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write mod: %v", err)
+	}
+	return filepath.Dir(modPath)
+}
+
+func TestModFiles(t *testing.T) {
+	t.Run("inline content becomes COPY heredoc", func(t *testing.T) {
+		writeLocalMod(t, "custom/tmux-config", `name: tmux-config
+description: tmux settings
+category: custom
+files:
+  - dest: ~/.tmux.conf
+    mode: "0644"
+    content: |
+      set -g mouse on
+`)
+		dockerfile, err := GenerateBase([]string{"os/ubuntu", "custom/tmux-config"})
+		if err != nil {
+			t.Fatalf("GenerateBase() error = %v", err)
+		}
+
+		want := "COPY --chown=dev:dev --chmod=0644 <<'GLOVEBOX_FILE' /home/dev/.tmux.conf\nset -g mouse on\nGLOVEBOX_FILE\n"
+		if !strings.Contains(dockerfile, want) {
+			t.Errorf("expected heredoc COPY for inline content, got:\n%s", dockerfile)
+		}
+	})
+
+	t.Run("source file is referenced from build context", func(t *testing.T) {
+		modDir := writeLocalMod(t, "custom/fish-config", `name: fish-config
+description: fish settings
+category: custom
+files:
+  - source: config.fish
+    dest: /home/dev/.config/fish/config.fish
+    owner: root:root
+`)
+		if err := os.WriteFile(filepath.Join(modDir, "config.fish"), []byte("set -x EDITOR nvim\n"), 0644); err != nil {
+			t.Fatalf("failed to write source file: %v", err)
+		}
+
+		dockerfile, err := GenerateBase([]string{"os/ubuntu", "custom/fish-config"})
+		if err != nil {
+			t.Fatalf("GenerateBase() error = %v", err)
+		}
+		if !strings.Contains(dockerfile, "COPY --chown=root:root build-files/fish-config/0-config.fish /home/dev/.config/fish/config.fish") {
+			t.Errorf("expected COPY from staged build context, got:\n%s", dockerfile)
+		}
+
+		files, err := BaseContextFiles([]string{"os/ubuntu", "custom/fish-config"})
+		if err != nil {
+			t.Fatalf("BaseContextFiles() error = %v", err)
+		}
+		if len(files) != 1 {
+			t.Fatalf("expected 1 context file, got %d", len(files))
+		}
+		if files[0].HostPath != filepath.Join(modDir, "config.fish") {
+			t.Errorf("unexpected host path %q", files[0].HostPath)
+		}
+		if files[0].ContextPath != "build-files/fish-config/0-config.fish" {
+			t.Errorf("unexpected context path %q", files[0].ContextPath)
+		}
+	})
+
+	t.Run("source edits change the Dockerfile", func(t *testing.T) {
+		modDir := writeLocalMod(t, "custom/gitconfig", `name: gitconfig
+description: git settings
+category: custom
+files:
+  - source: gitconfig
+    dest: ~/.gitconfig
+`)
+		source := filepath.Join(modDir, "gitconfig")
+		os.WriteFile(source, []byte("[user]\n  name = A\n"), 0644)
+		before, err := GenerateBase([]string{"os/ubuntu", "custom/gitconfig"})
+		if err != nil {
+			t.Fatalf("GenerateBase() error = %v", err)
+		}
+		os.WriteFile(source, []byte("[user]\n  name = B\n"), 0644)
+		after, err := GenerateBase([]string{"os/ubuntu", "custom/gitconfig"})
+		if err != nil {
+			t.Fatalf("GenerateBase() error = %v", err)
+		}
+		if before == after {
+			t.Error("expected Dockerfile to change when a source file changes")
+		}
+	})
+
+	t.Run("missing source file errors", func(t *testing.T) {
+		writeLocalMod(t, "custom/broken", `name: broken
+description: references a missing file
+category: custom
+files:
+  - source: nope.conf
+    dest: /etc/nope.conf
+`)
+		if _, err := GenerateBase([]string{"os/ubuntu", "custom/broken"}); err == nil {
+			t.Error("expected error for missing source file")
+		}
+	})
+
+	t.Run("project Dockerfile includes files", func(t *testing.T) {
+		writeLocalMod(t, "custom/npmrc", `name: npmrc
+description: npm settings
+category: custom
+files:
+  - dest: ~/.npmrc
+    content: fund=false
+`)
+		dockerfile, err := GenerateProject([]string{"custom/npmrc"}, []string{"os/ubuntu"})
+		if err != nil {
+			t.Fatalf("GenerateProject() error = %v", err)
+		}
+		copyIdx := strings.Index(dockerfile, "COPY --chown=dev:dev <<'GLOVEBOX_FILE' /home/dev/.npmrc")
+		userIdx := strings.Index(dockerfile, "USER dev")
+		if copyIdx == -1 {
+			t.Fatalf("expected COPY for npmrc, got:\n%s", dockerfile)
+		}
+		if copyIdx > userIdx {
+			t.Error("expected files to be copied before switching back to dev")
+		}
+	})
+}
+
+func TestStageContextFiles(t *testing.T) {
+	src := t.TempDir()
+	os.WriteFile(filepath.Join(src, "tmux.conf"), []byte("set -g mouse on\n"), 0600)
+	os.MkdirAll(filepath.Join(src, "nvim", "lua"), 0755)
+	os.WriteFile(filepath.Join(src, "nvim", "init.lua"), []byte("require('config')\n"), 0644)
+	os.WriteFile(filepath.Join(src, "nvim", "lua", "config.lua"), []byte("-- config\n"), 0644)
+
+	contextDir := t.TempDir()
+	stale := filepath.Join(contextDir, StagedFilesDir, "old", "leftover")
+	os.MkdirAll(filepath.Dir(stale), 0755)
+	os.WriteFile(stale, []byte("x"), 0644)
+
+	err := StageContextFiles(contextDir, []ContextFile{
+		{HostPath: filepath.Join(src, "tmux.conf"), ContextPath: "build-files/tmux/0-tmux.conf"},
+		{HostPath: filepath.Join(src, "nvim"), ContextPath: "build-files/neovim/0-nvim"},
+	})
+	if err != nil {
+		t.Fatalf("StageContextFiles() error = %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(contextDir, "build-files", "tmux", "0-tmux.conf"))
+	if err != nil {
+		t.Fatalf("expected staged file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600 to be preserved, got %o", info.Mode().Perm())
+	}
+	if _, err := os.Stat(filepath.Join(contextDir, "build-files", "neovim", "0-nvim", "lua", "config.lua")); err != nil {
+		t.Errorf("expected directory tree to be staged: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("expected previously staged files to be removed")
+	}
+}
+
+func TestDotfiles(t *testing.T) {
+	t.Run("build mode copies and installs dotfiles", func(t *testing.T) {
+		opts := Options{
+			Dotfiles:         &dotfiles.Config{Source: "~/dotfiles", Files: []string{".gitconfig"}},
+			DotfilesRevision: "git:0123456789abcdef0123",
+		}
+		dockerfile, err := GenerateBaseWithOptions([]string{"os/ubuntu"}, opts)
+		if err != nil {
+			t.Fatalf("GenerateBaseWithOptions() error = %v", err)
+		}
+
+		for _, want := range []string{
+			"# Dotfiles from ~/dotfiles (0123456789ab)",
+			"COPY --chown=root:root --chmod=0755 <<'GLOVEBOX_FILE' /usr/local/bin/glovebox-dotfiles",
+			"install_file '.gitconfig'",
+			"COPY --chown=dev:dev build-files/_dotfiles /home/dev/.dotfiles",
+			"RUN /usr/local/bin/glovebox-dotfiles",
+		} {
+			if !strings.Contains(dockerfile, want) {
+				t.Errorf("expected %q in Dockerfile, got:\n%s", want, dockerfile)
+			}
+		}
+
+		// Dotfiles are installed after mod user setup, before the workspace is set
+		if strings.Index(dockerfile, "glovebox-dotfiles") > strings.Index(dockerfile, "WORKDIR /workspace") {
+			t.Error("dotfiles should be installed before WORKDIR /workspace")
+		}
+	})
+
+	t.Run("create mode only installs the script", func(t *testing.T) {
+		opts := Options{Dotfiles: &dotfiles.Config{Source: "~/dotfiles", Apply: dotfiles.ApplyCreate}}
+		dockerfile, err := GenerateProjectWithOptions([]string{"tools/mise"}, []string{"os/ubuntu", "tools/homebrew-ubuntu"}, opts)
+		if err != nil {
+			t.Fatalf("GenerateProjectWithOptions() error = %v", err)
+		}
+
+		if !strings.Contains(dockerfile, "/usr/local/bin/glovebox-dotfiles") {
+			t.Error("expected install script in Dockerfile")
+		}
+		if strings.Contains(dockerfile, "build-files/_dotfiles") || strings.Contains(dockerfile, "RUN /usr/local/bin/glovebox-dotfiles") {
+			t.Errorf("create mode should not bake dotfiles into the image, got:\n%s", dockerfile)
+		}
+	})
+
+	t.Run("revision change alters Dockerfile", func(t *testing.T) {
+		cfg := &dotfiles.Config{Source: "~/dotfiles"}
+		a, err := GenerateBaseWithOptions([]string{"os/ubuntu"}, Options{Dotfiles: cfg, DotfilesRevision: "git:aaaa"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := GenerateBaseWithOptions([]string{"os/ubuntu"}, Options{Dotfiles: cfg, DotfilesRevision: "git:bbbb"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a == b {
+			t.Error("expected different Dockerfiles for different dotfiles revisions")
+		}
+	})
+
+	t.Run("invalid config is rejected", func(t *testing.T) {
+		opts := Options{Dotfiles: &dotfiles.Config{Source: "~/dotfiles", Strategy: "rsync"}}
+		if _, err := GenerateBaseWithOptions([]string{"os/ubuntu"}, opts); err == nil {
+			t.Error("expected error for unknown strategy")
+		}
+	})
+
+	t.Run("context file only in build mode", func(t *testing.T) {
+		if files := DotfilesContextFile(&dotfiles.Config{Source: "x"}, "/host/dotfiles"); len(files) != 1 || files[0].ContextPath != DotfilesContextPath {
+			t.Errorf("DotfilesContextFile() = %v, want one entry at %s", files, DotfilesContextPath)
+		}
+		if files := DotfilesContextFile(&dotfiles.Config{Source: "x", Apply: dotfiles.ApplyCreate}, "/host/dotfiles"); files != nil {
+			t.Errorf("DotfilesContextFile() = %v, want nil for create mode", files)
+		}
+	})
+}
+
+func TestHooks(t *testing.T) {
+	t.Run("base installs runner and mod hooks", func(t *testing.T) {
+		writeLocalMod(t, "custom/node-project", `name: node-project
+description: project setup
+category: custom
+on_create: |
+  npm ci
+on_exit: |
+  echo bye
+`)
+		dockerfile, err := GenerateBase([]string{"os/ubuntu", "custom/node-project"})
+		if err != nil {
+			t.Fatalf("GenerateBase() error = %v", err)
+		}
+
+		for _, want := range []string{
+			"COPY --chown=root:root --chmod=0755 <<'GLOVEBOX_FILE' /usr/local/bin/glovebox-hooks",
+			"# node-project on_create hook\nCOPY --chown=root:root --chmod=0755 <<'GLOVEBOX_FILE' /etc/glovebox/hooks/on_create/00-02-node-project.sh\n#!/bin/bash\nset -e\nnpm ci\nGLOVEBOX_FILE\n",
+			"/etc/glovebox/hooks/on_exit/00-02-node-project.sh",
+		} {
+			if !strings.Contains(dockerfile, want) {
+				t.Errorf("expected %q in Dockerfile, got:\n%s", want, dockerfile)
+			}
+		}
+		if strings.Contains(dockerfile, "/etc/glovebox/hooks/on_start/") {
+			t.Error("no on_start hook should be emitted")
+		}
+	})
+
+	t.Run("project hooks sort after base hooks", func(t *testing.T) {
+		writeLocalMod(t, "custom/refresh", `name: refresh
+description: refresh credentials
+category: custom
+on_start: |
+  refresh-creds
+`)
+		dockerfile, err := GenerateProject([]string{"custom/refresh"}, []string{"os/ubuntu"})
+		if err != nil {
+			t.Fatalf("GenerateProject() error = %v", err)
+		}
+		if !strings.Contains(dockerfile, "/etc/glovebox/hooks/on_start/01-01-refresh.sh") {
+			t.Errorf("expected project-layer hook, got:\n%s", dockerfile)
+		}
+		if strings.Contains(dockerfile, "glovebox-hooks") {
+			t.Error("project image should reuse the runner from the base image")
+		}
+	})
+
+	t.Run("create-mode dotfiles install via on_create hook", func(t *testing.T) {
+		opts := Options{Dotfiles: &dotfiles.Config{Source: "~/dotfiles", Apply: dotfiles.ApplyCreate}}
+		dockerfile, err := GenerateBaseWithOptions([]string{"os/ubuntu"}, opts)
+		if err != nil {
+			t.Fatalf("GenerateBaseWithOptions() error = %v", err)
+		}
+		if !strings.Contains(dockerfile, "/etc/glovebox/hooks/on_create/00-00-dotfiles.sh") {
+			t.Errorf("expected dotfiles on_create hook, got:\n%s", dockerfile)
+		}
+	})
+}
+
+func TestHookFileName(t *testing.T) {
+	if got := hookFileName(1, 3, "my tool/v2"); got != "01-03-my-tool-v2.sh" {
+		t.Errorf("hookFileName() = %q, want %q", got, "01-03-my-tool-v2.sh")
+	}
+}
+
+func TestGenerateProjectParentImage(t *testing.T) {
+	opts := Options{ParentImage: "glovebox:profile-team-web", Layer: 2}
+	dockerfile, err := GenerateProjectWithOptions([]string{"tools/mise"}, []string{"os/ubuntu", "tools/homebrew-ubuntu"}, opts)
+	if err != nil {
+		t.Fatalf("GenerateProjectWithOptions() error = %v", err)
+	}
+	if !strings.Contains(dockerfile, "FROM glovebox:profile-team-web\n") {
+		t.Errorf("expected FROM parent image, got:\n%s", dockerfile)
+	}
+	if strings.Contains(dockerfile, "FROM glovebox:base") {
+		t.Error("should not extend glovebox:base")
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	base, err := GenerateBase([]string{"os/ubuntu", "tools/homebrew-ubuntu"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, err := GenerateProjectWithOptions([]string{"tools/mise"}, []string{"os/ubuntu", "tools/homebrew-ubuntu"}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flat, err := Flatten([]Stage{{Name: "base", Dockerfile: base}, {Name: "project", Dockerfile: project}})
+	if err != nil {
+		t.Fatalf("Flatten() error = %v", err)
+	}
+
+	if !strings.Contains(flat, "FROM ubuntu:24.04 AS base\n") {
+		t.Errorf("expected base stage FROM the OS image, got:\n%s", flat)
+	}
+	if !strings.Contains(flat, "FROM base AS project\n") {
+		t.Errorf("expected project stage FROM base, got:\n%s", flat)
+	}
+	if strings.Contains(flat, "FROM glovebox:") {
+		t.Error("flattened Dockerfile should not build on glovebox images")
+	}
+	if strings.Index(flat, "AS base") > strings.Index(flat, "AS project") {
+		t.Error("stages should be in chain order")
+	}
+
+	if _, err := Flatten([]Stage{{Name: "bad", Dockerfile: "RUN true\n"}}); err == nil {
+		t.Error("expected error for a stage without FROM")
+	}
+}
+
+func TestBuildKitMounts(t *testing.T) {
+	t.Run("cache mounts", func(t *testing.T) {
+		writeLocalMod(t, "custom/cached", `name: cached
+description: installs with cached downloads
+category: custom
+cache_mounts: [apt, npm, /opt/cache]
+run_as_root: apt-get update && apt-get install -y jq
+run_as_user: npm install -g typescript
+`)
+		dockerfile, err := GenerateBase([]string{"os/ubuntu", "custom/cached"})
+		if err != nil {
+			t.Fatalf("GenerateBase() error = %v", err)
+		}
+
+		for _, want := range []string{
+			"RUN --mount=type=cache,target=/var/cache/apt,sharing=locked --mount=type=cache,target=/root/.npm --mount=type=cache,target=/opt/cache <<'EOF'\n",
+			"trap 'mv /etc/apt/docker-clean.glovebox /etc/apt/apt.conf.d/docker-clean 2>/dev/null || true' EXIT\n",
+			"RUN --mount=type=cache,target=/home/dev/.npm,uid=1000,gid=1000 --mount=type=cache,target=/opt/cache,uid=1000,gid=1000 <<'EOF'\nset -e\nnpm install -g typescript\n",
+		} {
+			if !strings.Contains(dockerfile, want) {
+				t.Errorf("expected %q in:\n%s", want, dockerfile)
+			}
+		}
+		if strings.Count(dockerfile, "--mount") != 5 {
+			t.Errorf("expected apt's cache in root steps only, got:\n%s", dockerfile)
+		}
+	})
+
+	t.Run("unknown cache mount errors", func(t *testing.T) {
+		writeLocalMod(t, "custom/bad-cache", `name: bad-cache
+description: names an unknown package manager
+category: custom
+cache_mounts: [cargo]
+run_as_root: echo hi
+`)
+		_, err := GenerateBase([]string{"os/ubuntu", "custom/bad-cache"})
+		if err == nil || !strings.Contains(err.Error(), `unknown cache mount "cargo"`) {
+			t.Errorf("GenerateBase() error = %v", err)
+		}
+	})
+
+	t.Run("secrets are mounted and exported", func(t *testing.T) {
+		writeLocalMod(t, "custom/private", `name: private
+description: installs from a private registry
+category: custom
+build_secrets:
+  - id: npm_token
+    env: NPM_TOKEN
+    required: true
+run_as_user: npm install -g @acme/cli
+`)
+		dockerfile, err := GenerateProject([]string{"custom/private"}, []string{"os/ubuntu"})
+		if err != nil {
+			t.Fatalf("GenerateProject() error = %v", err)
+		}
+		want := "RUN --mount=type=secret,id=npm_token,uid=1000,gid=1000,required=true <<'EOF'\n" +
+			"set -e\n" +
+			"if [ -f /run/secrets/npm_token ]; then export NPM_TOKEN=\"$(cat /run/secrets/npm_token)\"; fi\n" +
+			"npm install -g @acme/cli\n"
+		if !strings.Contains(dockerfile, want) {
+			t.Errorf("expected secret mount, got:\n%s", dockerfile)
+		}
+
+		secrets, err := ProjectBuildSecrets([]string{"custom/private"}, []string{"os/ubuntu"})
+		if err != nil {
+			t.Fatalf("ProjectBuildSecrets() error = %v", err)
+		}
+		if len(secrets) != 1 || secrets[0] != (mod.BuildSecret{ID: "npm_token", Env: "NPM_TOKEN", Required: true}) {
+			t.Errorf("secrets = %+v", secrets)
+		}
+	})
+
+	t.Run("invalid secret id errors", func(t *testing.T) {
+		writeLocalMod(t, "custom/bad-secret", `name: bad-secret
+description: has an unusable secret id
+category: custom
+build_secrets:
+  - id: "npm token"
+run_as_root: echo hi
+`)
+		if _, err := GenerateBase([]string{"os/ubuntu", "custom/bad-secret"}); err == nil {
+			t.Error("expected error for invalid secret id")
+		}
+	})
+
+	t.Run("build args are declared after FROM", func(t *testing.T) {
+		writeLocalMod(t, "custom/versioned", `name: versioned
+description: installs a pinned version
+category: custom
+build_args:
+  TOOL_VERSION: "1.2.3"
+  REGISTRY: ""
+run_as_root: echo $TOOL_VERSION
+`)
+		dockerfile, err := GenerateBase([]string{"os/ubuntu", "custom/versioned"})
+		if err != nil {
+			t.Fatalf("GenerateBase() error = %v", err)
+		}
+		argIdx := strings.Index(dockerfile, "# Build arguments from mods\nARG REGISTRY\nARG TOOL_VERSION=1.2.3\n")
+		if argIdx == -1 {
+			t.Fatalf("expected sorted ARGs, got:\n%s", dockerfile)
+		}
+		if argIdx < strings.Index(dockerfile, "FROM ") || argIdx > strings.Index(dockerfile, "RUN ") {
+			t.Error("expected ARGs between FROM and the first RUN")
+		}
+	})
+}
+
+func TestCollectBuildSecrets(t *testing.T) {
+	mods := []*mod.Mod{
+		{Name: "a", BuildSecrets: []mod.BuildSecret{{ID: "token"}, {ID: "key", Env: "KEY"}}},
+		{Name: "b", BuildSecrets: []mod.BuildSecret{{ID: "token", Env: "TOKEN", Required: true}}},
+	}
+	got := collectBuildSecrets(mods)
+	want := []mod.BuildSecret{{ID: "token", Env: "TOKEN", Required: true}, {ID: "key", Env: "KEY"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("collectBuildSecrets() = %+v, want %+v", got, want)
+	}
+}
+
+func TestStableStrategy(t *testing.T) {
+	dir := writeLocalMod(t, "custom/greet", `name: greet
+description: greets
+category: custom
+run_as_root: echo hello
+`)
+	brewtool := `name: brewtool
+description: installs with homebrew
+category: custom
+requires: [homebrew]
+run_as_user: brew install jq
+`
+	if err := os.WriteFile(filepath.Join(dir, "brewtool.yaml"), []byte(brewtool), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{"os/ubuntu", "custom/greet", "custom/brewtool", "tools/homebrew-ubuntu"}
+
+	t.Run("orders local mods last, after what they require", func(t *testing.T) {
+		dockerfile, err := GenerateBaseWithOptions(ids, Options{Strategy: StrategyStable})
+		if err != nil {
+			t.Fatalf("GenerateBaseWithOptions() error = %v", err)
+		}
+
+		var last int
+		for _, marker := range []string{
+			"# ubuntu setup (root)",
+			"# homebrew-ubuntu environment",
+			"# homebrew-ubuntu setup (user)",
+			"# greet setup (root)",
+			"# brewtool setup (user)",
+		} {
+			i := strings.Index(dockerfile, marker)
+			if i < last {
+				t.Fatalf("expected %q after the previous marker in:\n%s", marker, dockerfile)
+			}
+			last = i
+		}
+	})
+
+	t.Run("keeps local bin dirs first in PATH", func(t *testing.T) {
+		dockerfile, err := GenerateBaseWithOptions(ids, Options{Strategy: StrategyStable})
+		if err != nil {
+			t.Fatalf("GenerateBaseWithOptions() error = %v", err)
+		}
+		want := `ENV PATH="/home/dev/.local/bin:/usr/local/bin:/home/linuxbrew/.linuxbrew/bin:`
+		if !strings.Contains(dockerfile, want) {
+			t.Errorf("expected %q in:\n%s", want, dockerfile)
+		}
+	})
+
+	t.Run("unknown strategy errors", func(t *testing.T) {
+		_, err := GenerateBaseWithOptions([]string{"os/ubuntu"}, Options{Strategy: "fastest"})
+		if err == nil || !strings.Contains(err.Error(), `unknown build strategy "fastest"`) {
+			t.Errorf("error = %v", err)
+		}
+	})
+}
+
+func TestPlatforms(t *testing.T) {
+	writeLocalMod(t, "custom/x86-tool", `name: x86-tool
+description: ships amd64 binaries only
+category: custom
+platforms: [amd64]
+run_as_root: echo installing
+`)
+	ids := []string{"os/ubuntu", "custom/x86-tool"}
+
+	if _, err := GenerateBaseWithOptions(ids, Options{Platforms: []string{"linux/amd64"}}); err != nil {
+		t.Errorf("GenerateBaseWithOptions(amd64) error = %v", err)
+	}
+	_, err := GenerateBaseWithOptions(ids, Options{Platforms: []string{"linux/amd64", "linux/arm64"}})
+	if err == nil || !strings.Contains(err.Error(), `mod "x86-tool" doesn't support linux/arm64`) {
+		t.Errorf("GenerateBaseWithOptions(amd64, arm64) error = %v", err)
+	}
+	_, err = GenerateProjectWithOptions([]string{"custom/x86-tool"}, []string{"os/ubuntu"}, Options{Platforms: []string{"linux/arm64"}})
+	if err == nil {
+		t.Error("GenerateProjectWithOptions(arm64) should refuse the mod")
+	}
+}
+
+func TestPinnedFrom(t *testing.T) {
+	ids := []string{"os/ubuntu"}
+	from, err := BaseImage(ids)
+	if err != nil || from != "ubuntu:24.04" {
+		t.Fatalf("BaseImage() = %q, %v, want ubuntu:24.04", from, err)
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"unpinned", Options{}, "FROM ubuntu:24.04\n"},
+		{"pinned", Options{From: "ubuntu:24.04", FromDigest: "sha256:abc"}, "FROM ubuntu:24.04@sha256:abc\n"},
+		{"pin for another image", Options{From: "ubuntu:22.04", FromDigest: "sha256:abc"}, "FROM ubuntu:24.04\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, strategy := range []string{StrategyDependency, StrategyStable} {
+				tt.opts.Strategy = strategy
+				got, err := GenerateBaseWithOptions(ids, tt.opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.Contains(got, tt.want) {
+					t.Errorf("%s strategy: Dockerfile missing %q:\n%s", strategy, tt.want, got)
+				}
+			}
+		})
+	}
+}
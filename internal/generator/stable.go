@@ -0,0 +1,192 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/joelhelbling/glovebox/internal/mod"
+)
+
+// Build strategies order a Dockerfile's instructions. BuildKit reuses cached
+// layers up to the first changed instruction, so the order decides how much
+// a profile change rebuilds.
+const (
+	// StrategyDependency runs every mod's root setup, then every mod's user
+	// setup, in dependency order. Any new mod rebuilds all user setup.
+	StrategyDependency = "dependency"
+	// StrategyStable emits each mod's instructions together, ordered from
+	// the most to the least stable: the OS mod, built-in mods, then local
+	// mods, each in the order they were added to the profile. A new or
+	// edited mod only rebuilds the layers from its own on.
+	StrategyStable = "stable"
+)
+
+// Strategies lists the build strategies
+var Strategies = []string{StrategyDependency, StrategyStable}
+
+// ValidateStrategy checks that a build strategy is known. Empty is the
+// default, StrategyDependency.
+func ValidateStrategy(strategy string) error {
+	if strategy == "" || strategy == StrategyDependency || strategy == StrategyStable {
+		return nil
+	}
+	return fmt.Errorf("unknown build strategy %q (use %s)", strategy, strings.Join(Strategies, " or "))
+}
+
+// stableOrder reorders mods from the most to the least stable while keeping
+// every mod after the mods it requires. mods must be in dependency order.
+func stableOrder(mods []*mod.Mod, osMod *mod.Mod) []*mod.Mod {
+	rank := func(m *mod.Mod) int {
+		switch {
+		case m == osMod:
+			return 0
+		case m.Dir == "":
+			return 1 // built-in
+		}
+		return 2 // local mods are the ones people edit
+	}
+
+	// Requirements no mod in this image provides are met by a parent image
+	provides := mod.BuildProvidesMap(mods)
+	placed := make(map[string]bool)
+	ready := func(m *mod.Mod) bool {
+		for _, req := range m.Requires {
+			if len(provides[req]) > 0 && !placed[req] {
+				return false
+			}
+		}
+		return true
+	}
+
+	remaining := make([]*mod.Mod, len(mods))
+	copy(remaining, mods)
+	sort.SliceStable(remaining, func(i, j int) bool { return rank(remaining[i]) < rank(remaining[j]) })
+
+	result := make([]*mod.Mod, 0, len(mods))
+	for len(remaining) > 0 {
+		next := 0 // dependency order guarantees progress; this is a fallback
+		for i, m := range remaining {
+			if ready(m) {
+				next = i
+				break
+			}
+		}
+		m := remaining[next]
+		remaining = append(remaining[:next], remaining[next+1:]...)
+		result = append(result, m)
+		for _, p := range m.EffectiveProvides() {
+			placed[p] = true
+		}
+	}
+	return result
+}
+
+// stableWriter emits mods one at a time, switching users only as needed
+type stableWriter struct {
+	b        *strings.Builder
+	user     string
+	pathBase string // prefix for PATH values; base images add the local bin dirs
+	layer    int
+}
+
+func (w *stableWriter) switchUser(user string) {
+	if w.user == user {
+		return
+	}
+	w.user = user
+	if user == "root" {
+		w.b.WriteString("USER root\nWORKDIR /\n\n")
+		return
+	}
+	w.b.WriteString("USER dev\nWORKDIR /home/dev\n\n")
+}
+
+// writeMod emits everything a mod adds to the image. Its environment is set
+// before its user setup, and after that of the mods it requires.
+func (w *stableWriter) writeMod(m *mod.Mod, index int) error {
+	if m.RunAsRoot != "" {
+		w.switchUser("root")
+		if err := writeRunStep(w.b, m, m.RunAsRoot, false); err != nil {
+			return err
+		}
+	}
+	if err := writeModFiles(w.b, m); err != nil {
+		return err
+	}
+	writeModHooks(w.b, m, w.layer, index)
+
+	if len(m.Env) > 0 {
+		keys := make([]string, 0, len(m.Env))
+		for key := range m.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		w.b.WriteString(fmt.Sprintf("# %s environment\n", m.Name))
+		for _, key := range keys {
+			if key == "PATH" {
+				w.b.WriteString(fmt.Sprintf("ENV PATH=\"%s%s\"\n", w.pathBase, m.Env[key]))
+				continue
+			}
+			w.b.WriteString(fmt.Sprintf("ENV %s=%s\n", key, m.Env[key]))
+		}
+		w.b.WriteString("\n")
+	}
+
+	if m.RunAsUser != "" {
+		w.switchUser("dev")
+		if err := writeRunStep(w.b, m, m.RunAsUser, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeStableBase writes the rest of a base Dockerfile, after its FROM and
+// ARGs, with the stable strategy
+func writeStableBase(b *strings.Builder, mods []*mod.Mod, opts Options) error {
+	// glovebox's own files only change with glovebox
+	writeEntrypoint(b)
+	b.WriteString("# Ensure local binaries are in PATH\n")
+	b.WriteString("ENV PATH=\"/home/dev/.local/bin:/usr/local/bin:$PATH\"\n\n")
+
+	w := &stableWriter{b: b, user: "root", pathBase: "/home/dev/.local/bin:/usr/local/bin:", layer: hookLayerBase}
+	for i, m := range mods {
+		if err := w.writeMod(m, i+1); err != nil {
+			return err
+		}
+	}
+	w.switchUser("dev")
+
+	if err := writeDotfiles(b, opts, hookLayerBase); err != nil {
+		return err
+	}
+
+	b.WriteString("# Set working directory for mounted projects\n")
+	b.WriteString("WORKDIR /workspace\n\n")
+	b.WriteString("# Use entrypoint for container initialization\n")
+	b.WriteString("ENTRYPOINT [\"/usr/local/bin/entrypoint.sh\"]\n")
+	b.WriteString(fmt.Sprintf("CMD [\"%s\"]\n", determineDefaultShell(mods)))
+	return nil
+}
+
+// writeStableProject writes the rest of a project Dockerfile, after its
+// FROM and ARGs, with the stable strategy
+func writeStableProject(b *strings.Builder, mods []*mod.Mod, opts Options) error {
+	// Start from a known user and directory, whatever the parent ended with
+	w := &stableWriter{b: b, layer: opts.layer()}
+	for i, m := range mods {
+		if err := w.writeMod(m, i+1); err != nil {
+			return err
+		}
+	}
+	w.switchUser("dev")
+
+	if err := writeDotfiles(b, opts, opts.layer()); err != nil {
+		return err
+	}
+
+	b.WriteString("# Set working directory for mounted projects\n")
+	b.WriteString("WORKDIR /workspace\n")
+	return nil
+}
@@ -0,0 +1,232 @@
+// Package inventory collects the glovebox projects on this machine from the
+// containers and images the runtime knows about.
+package inventory
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/profile"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+)
+
+// Container states
+const (
+	StateRunning = "running"
+	StateStopped = "stopped"
+	StateNone    = "none"
+)
+
+// Project is a glovebox project found through its container or image
+type Project struct {
+	Path         string     `json:"path" yaml:"path"` // empty for resources created before glovebox labelled them
+	PathExists   bool       `json:"path_exists" yaml:"path_exists"`
+	Image        string     `json:"image,omitempty" yaml:"image,omitempty"`
+	ImageRole    string     `json:"image_role,omitempty" yaml:"image_role,omitempty"` // project, or base/profile when shared with other projects
+	ImageSize    int64      `json:"image_size,omitempty" yaml:"image_size,omitempty"`
+	ImageCreated *time.Time `json:"image_created,omitempty" yaml:"image_created,omitempty"`
+	StaleBase    bool       `json:"stale_base,omitempty" yaml:"stale_base,omitempty"` // image was built on an older version of its parent
+	Container    string     `json:"container,omitempty" yaml:"container,omitempty"`
+	State        string     `json:"state" yaml:"state"`
+	LastUsed     *time.Time `json:"last_used,omitempty" yaml:"last_used,omitempty"`
+	Changes      *int       `json:"uncommitted_changes,omitempty" yaml:"uncommitted_changes,omitempty"` // nil when unknown
+
+	// ContainerImageID is the image the container runs, which may be an
+	// older generation than Image after a rebuild
+	ContainerImageID string `json:"-" yaml:"-"`
+}
+
+// Name returns the directory name of the project, falling back to its
+// container or image name for unlabelled projects
+func (p Project) Name() string {
+	if p.Path != "" {
+		return filepath.Base(p.Path)
+	}
+	if p.Container != "" {
+		return p.Container
+	}
+	return p.Image
+}
+
+// Collect finds every project with a glovebox container or project image
+func Collect(rt runtime.Runtime) ([]Project, error) {
+	containers, err := gloveboxContainers(rt, labels.RoleContainer)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := rt.ListImages(runtime.ListFilter{Labels: labels.Selector(labels.RoleProject)})
+	if err != nil {
+		return nil, err
+	}
+	// Project images built before labels are found by name
+	legacyImages, err := rt.ListImages(runtime.ListFilter{Name: "glovebox:*"})
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range legacyImages {
+		if !slices.Contains(names, name) && isLegacyProjectImage(name, nil) {
+			names = append(names, name)
+		}
+	}
+	images := make(map[string]runtime.ImageInfo)
+	for _, name := range names {
+		if info, err := rt.InspectImage(name); err == nil {
+			images[name] = info
+		}
+	}
+	// Images in use by containers, such as the base image, are reported too
+	for _, c := range containers {
+		if _, ok := images[c.Image]; !ok {
+			if info, err := rt.InspectImage(c.Image); err == nil {
+				images[c.Image] = info
+			}
+		}
+	}
+
+	projects := Assemble(containers, images)
+	parentIDs := make(map[string]string)
+	for i, p := range projects {
+		if p.Path != "" {
+			_, err := os.Stat(p.Path)
+			projects[i].PathExists = err == nil
+		}
+
+		img := images[p.Image]
+		parent, builtOn := img.Labels[labels.Parent], img.Labels[labels.ParentID]
+		if p.ImageRole != labels.RoleProject || parent == "" {
+			continue
+		}
+		if _, ok := parentIDs[parent]; !ok {
+			parentIDs[parent], _ = rt.GetImageDigest(parent)
+		}
+		projects[i].StaleBase = parentIDs[parent] != "" && parentIDs[parent] != builtOn
+	}
+	return projects, nil
+}
+
+// gloveboxContainers lists the containers glovebox created with a role (""
+// for any), including stopped ones. Containers created before labels are
+// found by name and counted as interactive containers.
+func gloveboxContainers(rt runtime.Runtime, role string) ([]runtime.ContainerInfo, error) {
+	containers, err := rt.ListContainers(runtime.ListFilter{Labels: labels.Selector(role), Times: true}, true)
+	if err != nil {
+		return nil, err
+	}
+	if role != "" && role != labels.RoleContainer {
+		return containers, nil
+	}
+	legacy, err := rt.ListContainers(runtime.ListFilter{Name: "glovebox-", Times: true}, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range legacy {
+		if _, ok := c.Labels[labels.Managed]; !ok && strings.HasPrefix(c.Image, "glovebox:") {
+			containers = append(containers, c)
+		}
+	}
+	return containers, nil
+}
+
+// Assemble groups containers and images by project. Labelled resources are
+// matched by project path; unlabelled ones by their shared name suffix
+// (glovebox-<dir>-<hash> and glovebox:<dir>-<hash>).
+func Assemble(containers []runtime.ContainerInfo, images map[string]runtime.ImageInfo) []Project {
+	byKey := make(map[string]*Project)
+	var keys []string
+	project := func(key string) *Project {
+		if p, ok := byKey[key]; ok {
+			return p
+		}
+		p := &Project{State: StateNone}
+		byKey[key] = p
+		keys = append(keys, key)
+		return p
+	}
+
+	for _, c := range containers {
+		path := c.Labels[labels.Project]
+		key := path
+		if key == "" {
+			key = "legacy:" + strings.TrimPrefix(c.Name, "glovebox-")
+		}
+
+		p := project(key)
+		p.Path = path
+		p.Container = c.Name
+		p.ContainerImageID = c.ImageID
+		p.State = StateStopped
+		if c.Running {
+			p.State = StateRunning
+		}
+		if last := c.LastUsed(); !last.IsZero() {
+			p.LastUsed = &last
+		}
+		setImage(p, c.Image, images)
+	}
+
+	for name, img := range images {
+		if img.Labels[labels.Role] != labels.RoleProject && !isLegacyProjectImage(name, img.Labels) {
+			continue
+		}
+		key := img.Labels[labels.Project]
+		if key == "" {
+			key = "legacy:" + strings.TrimPrefix(name, "glovebox:")
+		}
+		p := project(key)
+		p.Path = img.Labels[labels.Project]
+		if p.Image == "" {
+			setImage(p, name, images)
+		}
+	}
+
+	sort.Strings(keys)
+	projects := make([]Project, 0, len(keys))
+	for _, key := range keys {
+		projects = append(projects, *byKey[key])
+	}
+	return projects
+}
+
+// isLegacyProjectImage reports whether an image is a project image built
+// before glovebox labelled its images
+func isLegacyProjectImage(name string, l map[string]string) bool {
+	if _, ok := l[labels.Managed]; ok {
+		return false
+	}
+	return imageRole(name, nil) == labels.RoleProject
+}
+
+// imageRole returns the role of an image, working it out from the name for
+// unlabelled images
+func imageRole(name string, l map[string]string) string {
+	switch {
+	case l[labels.Role] != "":
+		return l[labels.Role]
+	case profile.IsBaseImage(name):
+		return labels.RoleBase
+	case strings.HasPrefix(name, profile.NamedImageName("")):
+		return labels.RoleProfile
+	case strings.HasPrefix(name, "glovebox:"):
+		return labels.RoleProject
+	default:
+		return ""
+	}
+}
+
+func setImage(p *Project, name string, images map[string]runtime.ImageInfo) {
+	p.Image = name
+	p.ImageRole = imageRole(name, images[name].Labels)
+	if img, ok := images[name]; ok {
+		p.ImageSize = img.Size
+		if !img.Created.IsZero() {
+			created := img.Created
+			p.ImageCreated = &created
+		}
+	}
+}
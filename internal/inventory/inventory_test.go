@@ -0,0 +1,80 @@
+package inventory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+)
+
+func TestAssemble(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	projectLabels := func(path string) map[string]string {
+		l := labels.New("dev", labels.RoleProject, time.Time{})
+		l[labels.Project] = path
+		return l
+	}
+	containerLabels := func(path string) map[string]string {
+		l := labels.New("dev", labels.RoleContainer, time.Time{})
+		l[labels.Project] = path
+		return l
+	}
+
+	containers := []runtime.ContainerInfo{
+		{Name: "glovebox-app-1111111", Image: "glovebox:app-1111111", Labels: containerLabels("/code/app"),
+			Running: true, Created: day(1), StartedAt: day(4)},
+		{Name: "glovebox-api-2222222", Image: "glovebox:base", Labels: containerLabels("/code/api"),
+			Created: day(2), StartedAt: day(2), FinishedAt: day(3)},
+		{Name: "glovebox-old-3333333", Image: "glovebox:old-3333333"},
+	}
+	images := map[string]runtime.ImageInfo{
+		"glovebox:app-1111111":   {Size: 100, Created: day(1), Labels: projectLabels("/code/app")},
+		"glovebox:web-4444444":   {Size: 200, Labels: projectLabels("/code/web")},
+		"glovebox:base":          {Size: 50, Labels: labels.New("dev", labels.RoleBase, time.Time{})},
+		"glovebox:old-3333333":   {Size: 300},
+		"glovebox:stale-5555555": {Size: 400},
+		"glovebox:profile-rust":  {Size: 500},
+	}
+
+	projects := Assemble(containers, images)
+
+	tests := []struct {
+		name      string
+		path      string
+		container string
+		image     string
+		size      int64
+		state     string
+		lastUsed  time.Time
+	}{
+		{"api", "/code/api", "glovebox-api-2222222", "glovebox:base", 50, StateStopped, day(3)},
+		{"app", "/code/app", "glovebox-app-1111111", "glovebox:app-1111111", 100, StateRunning, day(4)},
+		{"web", "/code/web", "", "glovebox:web-4444444", 200, StateNone, time.Time{}},
+		{"glovebox-old-3333333", "", "glovebox-old-3333333", "glovebox:old-3333333", 300, StateStopped, time.Time{}},
+		{"glovebox:stale-5555555", "", "", "glovebox:stale-5555555", 400, StateNone, time.Time{}},
+	}
+	if len(projects) != len(tests) {
+		t.Fatalf("Assemble() returned %d projects, want %d: %+v", len(projects), len(tests), projects)
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := projects[i]
+			if p.Name() != tt.name {
+				t.Errorf("Name() = %q, want %q", p.Name(), tt.name)
+			}
+			if p.Path != tt.path || p.Container != tt.container || p.Image != tt.image {
+				t.Errorf("got path %q, container %q, image %q", p.Path, p.Container, p.Image)
+			}
+			if p.ImageSize != tt.size {
+				t.Errorf("ImageSize = %d, want %d", p.ImageSize, tt.size)
+			}
+			if p.State != tt.state {
+				t.Errorf("State = %q, want %q", p.State, tt.state)
+			}
+			if tt.lastUsed.IsZero() != (p.LastUsed == nil) || (p.LastUsed != nil && !p.LastUsed.Equal(tt.lastUsed)) {
+				t.Errorf("LastUsed = %v, want %v", p.LastUsed, tt.lastUsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package inventory
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+)
+
+// Kinds of prune candidates
+const (
+	KindContainer = "container"
+	KindImage     = "image"
+)
+
+// Candidate is a container or image that can be pruned
+type Candidate struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"` // image ID for superseded images
+	Project string `json:"project,omitempty"`
+	Reason  string `json:"reason"`
+	Size    int64  `json:"size,omitempty"`                // images only
+	Changes *int   `json:"uncommitted_changes,omitempty"` // containers only; nil when unknown
+	role    string
+}
+
+// PruneOptions selects what to prune besides orphans, superseded images and
+// images built on an outdated parent, which are always candidates
+type PruneOptions struct {
+	UnusedFor          time.Duration // stopped containers unused this long; 0 keeps them
+	IncludeUncommitted bool          // also containers with uncommitted changes, or unknown ones
+	Now                time.Time
+}
+
+// Superseded lists glovebox images left untagged when a rebuild took their name
+func Superseded(rt runtime.Runtime) ([]runtime.ImageInfo, error) {
+	ids, err := rt.ListImages(runtime.ListFilter{Labels: labels.Selector(""), Dangling: true})
+	if err != nil {
+		return nil, err
+	}
+	var images []runtime.ImageInfo
+	for _, id := range ids {
+		if info, err := rt.InspectImage(id); err == nil {
+			images = append(images, info)
+		}
+	}
+	return images, nil
+}
+
+// Helpers lists the helper containers glovebox left behind, such as when
+// removing one failed
+func Helpers(rt runtime.Runtime) ([]runtime.ContainerInfo, error) {
+	return rt.ListContainers(runtime.ListFilter{Labels: labels.Selector(labels.RoleHelper)}, true)
+}
+
+// Plan picks what to prune, in removal order: containers first, then images
+// with children before parents. Running containers and images still in use
+// by a container that stays are never picked. Containers with uncommitted
+// changes, or whose changes are unknown, are only picked with
+// IncludeUncommitted; otherwise they are returned as kept. Stopped helper
+// containers hold no work and are always picked.
+func Plan(projects []Project, superseded []runtime.ImageInfo, helpers []runtime.ContainerInfo, opts PruneOptions) (candidates, kept []Candidate) {
+	inUse := make(map[string]bool)
+
+	for _, h := range helpers {
+		if !h.Running {
+			candidates = append(candidates, Candidate{Kind: KindContainer, Name: h.Name, Project: h.Labels[labels.Project], Reason: "leftover helper container", Changes: new(int)})
+		}
+	}
+
+	for _, p := range projects {
+		orphan := p.Path != "" && !p.PathExists
+		removeContainer := false
+		if p.Container != "" && p.State != StateRunning {
+			c := Candidate{Kind: KindContainer, Name: p.Container, Project: p.Path, Changes: p.Changes}
+			switch {
+			case orphan:
+				c.Reason = "directory missing"
+			case opts.UnusedFor > 0 && p.LastUsed != nil && opts.Now.Sub(*p.LastUsed) >= opts.UnusedFor:
+				days := int(opts.Now.Sub(*p.LastUsed).Hours() / 24)
+				c.Reason = fmt.Sprintf("unused for %d days", days)
+			}
+			switch {
+			case c.Reason == "":
+			case c.Uncommitted() && !opts.IncludeUncommitted:
+				kept = append(kept, c)
+			default:
+				removeContainer = true
+				candidates = append(candidates, c)
+			}
+		}
+		if p.Container != "" && !removeContainer {
+			inUse[p.Image] = true
+			inUse[p.ContainerImageID] = true
+			continue
+		}
+
+		if p.ImageRole != labels.RoleProject {
+			continue
+		}
+		switch {
+		case orphan:
+			candidates = append(candidates, Candidate{Kind: KindImage, Name: p.Image, Project: p.Path, Reason: "directory missing", Size: p.ImageSize, role: p.ImageRole})
+		case p.StaleBase:
+			candidates = append(candidates, Candidate{Kind: KindImage, Name: p.Image, Project: p.Path, Reason: "built on an outdated parent image", Size: p.ImageSize, role: p.ImageRole})
+		}
+	}
+
+	for _, img := range superseded {
+		if inUse[img.ID] {
+			continue
+		}
+		candidates = append(candidates, Candidate{
+			Kind:    KindImage,
+			Name:    img.ID,
+			Project: img.Labels[labels.Project],
+			Reason:  "superseded by a rebuild",
+			Size:    img.Size,
+			role:    img.Labels[labels.Role],
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return removalRank(candidates[i]) < removalRank(candidates[j])
+	})
+	return candidates, kept
+}
+
+// Uncommitted reports whether a container candidate may hold work that
+// removing it loses: uncommitted changes, or changes that couldn't be counted
+func (c Candidate) Uncommitted() bool {
+	return c.Kind == KindContainer && (c.Changes == nil || *c.Changes > 0)
+}
+
+// Reclaimable totals the size of the images among the candidates. Images
+// can share layers, so this is an upper bound.
+func Reclaimable(candidates []Candidate) int64 {
+	var total int64
+	for _, c := range candidates {
+		total += c.Size
+	}
+	return total
+}
+
+func removalRank(c Candidate) int {
+	if c.Kind == KindContainer {
+		return 0
+	}
+	switch c.role {
+	case labels.RoleBase:
+		return 3
+	case labels.RoleProfile:
+		return 2
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,136 @@
+package inventory
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+)
+
+func TestPlan(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	ago := func(days int) *time.Time {
+		t := now.AddDate(0, 0, -days)
+		return &t
+	}
+	none, some := new(int), new(int)
+	*some = 3
+	opts := PruneOptions{UnusedFor: 30 * 24 * time.Hour, Now: now}
+
+	tests := []struct {
+		name       string
+		projects   []Project
+		superseded []runtime.ImageInfo
+		helpers    []runtime.ContainerInfo
+		opts       *PruneOptions // opts when nil
+		want       []string
+		wantKept   []string
+	}{
+		{
+			name: "orphan removes container then image",
+			projects: []Project{{Path: "/gone", Image: "glovebox:gone-1", ImageRole: labels.RoleProject, ImageSize: 10,
+				Container: "glovebox-gone-1", State: StateStopped, Changes: none}},
+			want: []string{"container glovebox-gone-1: directory missing", "image glovebox:gone-1: directory missing"},
+		},
+		{
+			name: "orphan keeps shared base image",
+			projects: []Project{{Path: "/gone", Image: "glovebox:base", ImageRole: labels.RoleBase,
+				Container: "glovebox-gone-1", State: StateStopped, Changes: none}},
+			want: []string{"container glovebox-gone-1: directory missing"},
+		},
+		{
+			name: "running orphan is kept",
+			projects: []Project{{Path: "/gone", Image: "glovebox:gone-1", ImageRole: labels.RoleProject,
+				Container: "glovebox-gone-1", State: StateRunning}},
+		},
+		{
+			name: "unused container",
+			projects: []Project{
+				{Path: "/old", PathExists: true, Image: "glovebox:old-1", ImageRole: labels.RoleProject, Container: "glovebox-old-1", State: StateStopped, LastUsed: ago(45), Changes: none},
+				{Path: "/new", PathExists: true, Image: "glovebox:new-1", ImageRole: labels.RoleProject, Container: "glovebox-new-1", State: StateStopped, LastUsed: ago(3), Changes: none},
+			},
+			want: []string{"container glovebox-old-1: unused for 45 days"},
+		},
+		{
+			name: "leftover helper containers",
+			helpers: []runtime.ContainerInfo{
+				{Name: "eager_turing", Labels: map[string]string{labels.Role: labels.RoleHelper, labels.Project: "/app"}},
+				{Name: "busy_hopper", Running: true},
+			},
+			opts: &PruneOptions{Now: now},
+			want: []string{"container eager_turing: leftover helper container"},
+		},
+		{
+			name: "unused containers are kept by default",
+			projects: []Project{{Path: "/old", PathExists: true, Image: "glovebox:old-1", ImageRole: labels.RoleProject,
+				Container: "glovebox-old-1", State: StateStopped, LastUsed: ago(400), Changes: none}},
+			opts: &PruneOptions{Now: now},
+		},
+		{
+			name: "stale container with changes is kept, and its image",
+			projects: []Project{
+				{Path: "/old", PathExists: true, Image: "glovebox:old-1", ImageRole: labels.RoleProject, Container: "glovebox-old-1", State: StateStopped, LastUsed: ago(45), Changes: some},
+				{Path: "/gone", Image: "glovebox:gone-1", ImageRole: labels.RoleProject, Container: "glovebox-gone-1", State: StateStopped},
+			},
+			wantKept: []string{"container glovebox-old-1: unused for 45 days", "container glovebox-gone-1: directory missing"},
+		},
+		{
+			name: "stale container with changes, included",
+			projects: []Project{{Path: "/old", PathExists: true, Image: "glovebox:old-1", ImageRole: labels.RoleProject,
+				Container: "glovebox-old-1", State: StateStopped, LastUsed: ago(45), Changes: some}},
+			opts: &PruneOptions{UnusedFor: opts.UnusedFor, IncludeUncommitted: true, Now: now},
+			want: []string{"container glovebox-old-1: unused for 45 days"},
+		},
+		{
+			name: "stale base image only without a container",
+			projects: []Project{
+				{Path: "/a", PathExists: true, Image: "glovebox:a-1", ImageRole: labels.RoleProject, StaleBase: true},
+				{Path: "/b", PathExists: true, Image: "glovebox:b-1", ImageRole: labels.RoleProject, StaleBase: true, Container: "glovebox-b-1", State: StateStopped, LastUsed: ago(1)},
+			},
+			want: []string{"image glovebox:a-1: built on an outdated parent image"},
+		},
+		{
+			name: "superseded images not in use, children first",
+			projects: []Project{{Path: "/a", PathExists: true, Image: "glovebox:a-1", ImageRole: labels.RoleProject,
+				Container: "glovebox-a-1", ContainerImageID: "sha256:old-a", State: StateStopped, LastUsed: ago(1)}},
+			superseded: []runtime.ImageInfo{
+				{ID: "sha256:old-base", Labels: map[string]string{labels.Role: labels.RoleBase}},
+				{ID: "sha256:old-a", Labels: map[string]string{labels.Role: labels.RoleProject}},
+				{ID: "sha256:older-a", Labels: map[string]string{labels.Role: labels.RoleProject}},
+			},
+			want: []string{"image sha256:older-a: superseded by a rebuild", "image sha256:old-base: superseded by a rebuild"},
+		},
+	}
+
+	describe := func(candidates []Candidate) []string {
+		var out []string
+		for _, c := range candidates {
+			out = append(out, c.Kind+" "+c.Name+": "+c.Reason)
+		}
+		return out
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := opts
+			if tt.opts != nil {
+				o = *tt.opts
+			}
+			candidates, kept := Plan(tt.projects, tt.superseded, tt.helpers, o)
+			if got := describe(candidates); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Plan() = %q, want %q", got, tt.want)
+			}
+			if got := describe(kept); !reflect.DeepEqual(got, tt.wantKept) {
+				t.Errorf("Plan() kept %q, want %q", got, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestReclaimable(t *testing.T) {
+	candidates := []Candidate{{Kind: KindContainer}, {Kind: KindImage, Size: 100}, {Kind: KindImage, Size: 50}}
+	if got := Reclaimable(candidates); got != 150 {
+		t.Errorf("Reclaimable() = %d, want 150", got)
+	}
+}
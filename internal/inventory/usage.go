@@ -0,0 +1,163 @@
+package inventory
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+)
+
+// ImageUsage is the disk space taken by a glovebox image
+type ImageUsage struct {
+	Name    string `json:"name" yaml:"name"`
+	Role    string `json:"role" yaml:"role"`
+	Project string `json:"project,omitempty" yaml:"project,omitempty"`
+	Size    int64  `json:"size" yaml:"size"`
+	Base    string `json:"base,omitempty" yaml:"base,omitempty"` // base image it shares layers with
+	Shared  int64  `json:"shared" yaml:"shared"`                 // size of the layers shared with Base
+	Unique  int64  `json:"unique" yaml:"unique"`                 // size of its own layers
+}
+
+// ContainerUsage is the disk space taken by a container's writable layer
+type ContainerUsage struct {
+	Name    string `json:"name" yaml:"name"`
+	Role    string `json:"role,omitempty" yaml:"role,omitempty"`
+	Project string `json:"project,omitempty" yaml:"project,omitempty"`
+	Size    int64  `json:"size" yaml:"size"`
+}
+
+// VolumeUsage is the disk space taken by a service data volume
+type VolumeUsage struct {
+	Name string `json:"name" yaml:"name"`
+	Size int64  `json:"size" yaml:"size"`
+}
+
+// Usage is the disk space taken by everything glovebox created
+type Usage struct {
+	Images     []ImageUsage     `json:"images" yaml:"images"`
+	Containers []ContainerUsage `json:"containers" yaml:"containers"`
+	Volumes    []VolumeUsage    `json:"volumes" yaml:"volumes"`
+}
+
+// Total is the disk space taken overall. Layers shared with a base are
+// counted once, with the base.
+func (u Usage) Total() int64 {
+	var total int64
+	for _, img := range u.Images {
+		total += img.Unique
+	}
+	for _, c := range u.Containers {
+		total += c.Size
+	}
+	for _, v := range u.Volumes {
+		total += v.Size
+	}
+	return total
+}
+
+// CollectUsage measures the images, containers and volumes glovebox created
+func CollectUsage(rt runtime.Runtime) (Usage, error) {
+	var usage Usage
+
+	names, err := rt.ListImages(runtime.ListFilter{Labels: labels.Selector("")})
+	if err != nil {
+		return usage, err
+	}
+	legacy, err := rt.ListImages(runtime.ListFilter{Name: "glovebox:*"})
+	if err != nil {
+		return usage, err
+	}
+	for _, name := range legacy {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	var images []runtime.ImageInfo
+	for _, name := range names {
+		if info, err := rt.InspectImage(name); err == nil {
+			images = append(images, info)
+		}
+	}
+	usage.Images = ImagesUsage(images)
+
+	du, err := rt.DiskUsage()
+	if err != nil {
+		return usage, err
+	}
+	containers, err := gloveboxContainers(rt, "")
+	if err != nil {
+		return usage, err
+	}
+	for _, c := range containers {
+		role := c.Labels[labels.Role]
+		if role == "" {
+			role = labels.RoleContainer
+		}
+		usage.Containers = append(usage.Containers, ContainerUsage{
+			Name:    c.Name,
+			Role:    role,
+			Project: c.Labels[labels.Project],
+			Size:    du.Containers[c.Name],
+		})
+	}
+	sort.Slice(usage.Containers, func(i, j int) bool { return usage.Containers[i].Name < usage.Containers[j].Name })
+
+	volumes, err := rt.ListVolumes("glovebox-")
+	if err != nil {
+		return usage, err
+	}
+	sort.Strings(volumes)
+	for _, v := range volumes {
+		usage.Volumes = append(usage.Volumes, VolumeUsage{Name: v, Size: du.Volumes[v]})
+	}
+	return usage, nil
+}
+
+// ImagesUsage works out how much of each image is shared with the base it
+// was built on: the base image whose layers are the longest prefix of its own.
+// Images are sorted bases first, then by name.
+func ImagesUsage(images []runtime.ImageInfo) []ImageUsage {
+	var bases []runtime.ImageInfo
+	for _, img := range images {
+		if imageRole(img.Name, img.Labels) == labels.RoleBase {
+			bases = append(bases, img)
+		}
+	}
+
+	usage := make([]ImageUsage, 0, len(images))
+	for _, img := range images {
+		u := ImageUsage{
+			Name:    img.Name,
+			Role:    imageRole(img.Name, img.Labels),
+			Project: img.Labels[labels.Project],
+			Size:    img.Size,
+			Unique:  img.Size,
+		}
+		if u.Role != labels.RoleBase {
+			var layers int
+			for _, base := range bases {
+				if len(base.Layers) > layers && isPrefix(base.Layers, img.Layers) {
+					layers = len(base.Layers)
+					u.Base = base.Name
+					u.Shared = base.Size
+					u.Unique = img.Size - base.Size
+				}
+			}
+		}
+		usage = append(usage, u)
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		bi, bj := usage[i].Role == labels.RoleBase, usage[j].Role == labels.RoleBase
+		if bi != bj {
+			return bi
+		}
+		return usage[i].Name < usage[j].Name
+	})
+	return usage
+}
+
+func isPrefix(prefix, layers []string) bool {
+	return len(prefix) <= len(layers) && slices.Equal(prefix, layers[:len(prefix)])
+}
@@ -0,0 +1,44 @@
+package inventory
+
+import (
+	"testing"
+
+	"github.com/joelhelbling/glovebox/internal/labels"
+	"github.com/joelhelbling/glovebox/internal/runtime"
+)
+
+func TestImagesUsage(t *testing.T) {
+	images := []runtime.ImageInfo{
+		{Name: "glovebox:app-1111111", Size: 1500, Layers: []string{"a", "b", "c", "d"}},
+		{Name: "glovebox:base", Size: 1000, Layers: []string{"a", "b"}},
+		{Name: "glovebox:base-py", Size: 1200, Layers: []string{"a", "b", "c"}, Labels: map[string]string{labels.Role: labels.RoleBase}},
+		{Name: "glovebox:other-2222222", Size: 300, Layers: []string{"x"}, Labels: map[string]string{labels.Role: labels.RoleProject, labels.Project: "/code/other"}},
+	}
+
+	got := ImagesUsage(images)
+	want := []ImageUsage{
+		{Name: "glovebox:base", Role: labels.RoleBase, Size: 1000, Unique: 1000},
+		{Name: "glovebox:base-py", Role: labels.RoleBase, Size: 1200, Unique: 1200},
+		{Name: "glovebox:app-1111111", Role: labels.RoleProject, Size: 1500, Base: "glovebox:base-py", Shared: 1200, Unique: 300},
+		{Name: "glovebox:other-2222222", Role: labels.RoleProject, Project: "/code/other", Size: 300, Unique: 300},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ImagesUsage() returned %d images, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ImagesUsage()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestUsageTotal(t *testing.T) {
+	u := Usage{
+		Images:     []ImageUsage{{Size: 1000, Unique: 1000}, {Size: 1500, Shared: 1000, Unique: 500}},
+		Containers: []ContainerUsage{{Size: 20}},
+		Volumes:    []VolumeUsage{{Size: 5}},
+	}
+	if got := u.Total(); got != 1525 {
+		t.Errorf("Total() = %d, want 1525", got)
+	}
+}
@@ -0,0 +1,90 @@
+// Package labels defines the metadata glovebox records on the containers and
+// images it creates, so they can be found and traced back to their project
+// without relying on naming conventions.
+package labels
+
+import (
+	"sort"
+	"time"
+)
+
+// Label keys
+const (
+	Managed    = "glovebox.managed"     // "true" on everything glovebox creates
+	Version    = "glovebox.version"     // glovebox version that created it
+	Project    = "glovebox.project"     // host project directory
+	Profile    = "glovebox.profile"     // profile it was built or created from
+	Role       = "glovebox.role"        // see the Role constants
+	CreatedAt  = "glovebox.created-at"  // RFC 3339
+	ConfigHash = "glovebox.config-hash" // settings a container was created with
+	ImageID    = "glovebox.image-id"    // image a container was created from
+	Ports      = "glovebox.ports"       // ports a container publishes
+	HostAlias  = "glovebox.host-alias"  // host alias a container was given
+	Parent     = "glovebox.parent"      // image an image was built FROM
+	ParentID   = "glovebox.parent-id"   // ID of that image at build time
+
+	// Recorded on images so they can be shared through a registry and
+	// matched to the profile of whoever pulls them
+	ContentHash      = "glovebox.content-hash"      // hash of the profile it was built from
+	DockerfileDigest = "glovebox.dockerfile-digest" // digest of the Dockerfile it was built from
+	From             = "glovebox.from"              // for base images, the OS image, pinned as image@digest
+)
+
+// Roles
+const (
+	RoleBase      = "base"      // base image
+	RoleProfile   = "profile"   // named profile image
+	RoleProject   = "project"   // project image
+	RoleContainer = "container" // interactive glovebox container
+	RoleService   = "service"   // sidecar service container
+	RoleHelper    = "helper"    // throwaway container doing work for another
+)
+
+// New returns the labels every glovebox resource carries. A zero createdAt
+// is left out.
+func New(version, role string, createdAt time.Time) map[string]string {
+	l := map[string]string{
+		Managed: "true",
+		Version: version,
+		Role:    role,
+	}
+	if !createdAt.IsZero() {
+		l[CreatedAt] = createdAt.UTC().Format(time.RFC3339)
+	}
+	return l
+}
+
+// Selector matches resources glovebox created, optionally narrowed to one role
+func Selector(role string) map[string]string {
+	s := map[string]string{Managed: "true"}
+	if role != "" {
+		s[Role] = role
+	}
+	return s
+}
+
+// Matches reports whether labels carry every key in selector. An empty
+// selector value matches any value.
+func Matches(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		got, ok := labels[k]
+		if !ok || (v != "" && got != v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filters renders a selector as sorted "k=v" (or "k") filter values
+func Filters(selector map[string]string) []string {
+	var filters []string
+	for k, v := range selector {
+		if v == "" {
+			filters = append(filters, k)
+		} else {
+			filters = append(filters, k+"="+v)
+		}
+	}
+	sort.Strings(filters)
+	return filters
+}
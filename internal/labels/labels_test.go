@@ -0,0 +1,59 @@
+package labels
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("EST", -5*3600))
+	l := New("v1.2.0", RoleProject, created)
+
+	want := map[string]string{
+		Managed:   "true",
+		Version:   "v1.2.0",
+		Role:      RoleProject,
+		CreatedAt: "2026-03-01T17:00:00Z",
+	}
+	if !reflect.DeepEqual(l, want) {
+		t.Errorf("New() = %v, want %v", l, want)
+	}
+
+	if _, ok := New("dev", RoleBase, time.Time{})[CreatedAt]; ok {
+		t.Error("expected no created-at label for a zero time")
+	}
+}
+
+func TestMatches(t *testing.T) {
+	l := map[string]string{Managed: "true", Role: RoleService, Project: "/code/app"}
+
+	tests := []struct {
+		name     string
+		selector map[string]string
+		want     bool
+	}{
+		{"any glovebox resource", Selector(""), true},
+		{"matching role", Selector(RoleService), true},
+		{"other role", Selector(RoleContainer), false},
+		{"key present with any value", map[string]string{Project: ""}, true},
+		{"missing key", map[string]string{Profile: ""}, false},
+		{"empty selector", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Matches(l, tt.selector); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilters(t *testing.T) {
+	got := Filters(map[string]string{Role: RoleBase, Managed: "true", Project: ""})
+	want := []string{"glovebox.managed=true", "glovebox.project", "glovebox.role=base"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Filters() = %v, want %v", got, want)
+	}
+}
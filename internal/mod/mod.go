@@ -6,6 +6,8 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -16,18 +18,164 @@ var modFS embed.FS
 
 // Mod represents a composable piece of Dockerfile configuration
 type Mod struct {
-	Name           string            `yaml:"name"`
-	Description    string            `yaml:"description"`
-	Category       string            `yaml:"category"`
-	DockerfileFrom string            `yaml:"dockerfile_from,omitempty"`
-	Provides       []string          `yaml:"provides,omitempty"`
-	Requires       []string          `yaml:"requires,omitempty"`
-	RunAsRoot      string            `yaml:"run_as_root,omitempty"`
-	RunAsUser      string            `yaml:"run_as_user,omitempty"`
-	Env            map[string]string `yaml:"env,omitempty"`
-	UserShell      string            `yaml:"user_shell,omitempty"`
+	Name           string            `yaml:"name" json:"name"`
+	Description    string            `yaml:"description" json:"description"`
+	Category       string            `yaml:"category" json:"category"`
+	DockerfileFrom string            `yaml:"dockerfile_from,omitempty" json:"dockerfile_from,omitempty"`
+	Provides       []string          `yaml:"provides,omitempty" json:"provides,omitempty"`
+	Requires       []string          `yaml:"requires,omitempty" json:"requires,omitempty"`
+	RunAsRoot      string            `yaml:"run_as_root,omitempty" json:"run_as_root,omitempty"`
+	RunAsUser      string            `yaml:"run_as_user,omitempty" json:"run_as_user,omitempty"`
+	Env            map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	UserShell      string            `yaml:"user_shell,omitempty" json:"user_shell,omitempty"`
+	Files          []File            `yaml:"files,omitempty" json:"files,omitempty"`
+	Platforms      []string          `yaml:"platforms,omitempty" json:"platforms,omitempty"` // platforms the mod builds on (e.g. linux/amd64, or amd64); empty for all
+
+	// Build-time resources for run_as_root and run_as_user. None of them end
+	// up in the image.
+	CacheMounts  []string          `yaml:"cache_mounts,omitempty" json:"cache_mounts,omitempty"`   // package caches kept between builds
+	BuildSecrets []BuildSecret     `yaml:"build_secrets,omitempty" json:"build_secrets,omitempty"` // credentials needed while building
+	BuildArgs    map[string]string `yaml:"build_args,omitempty" json:"build_args,omitempty"`       // ARG name → default value
+
+	// Lifecycle hooks run as the dev user in the workspace directory
+	OnCreate string `yaml:"on_create,omitempty" json:"on_create,omitempty"` // once, when a container is first started
+	OnStart  string `yaml:"on_start,omitempty" json:"on_start,omitempty"`   // every time a container starts
+	OnExit   string `yaml:"on_exit,omitempty" json:"on_exit,omitempty"`     // when the container's main shell exits
+
+	// ID is the mod's ID (e.g. "tools/homebrew-ubuntu"), set when it is loaded
+	ID string `yaml:"-" json:"-"`
+
+	// Dir is the directory the mod was loaded from. It is empty for embedded
+	// mods, which cannot reference source files on the host.
+	Dir string `yaml:"-" json:"-"`
 }
 
+// Hook phases, in the order a container goes through them
+const (
+	HookOnCreate = "on_create"
+	HookOnStart  = "on_start"
+	HookOnExit   = "on_exit"
+)
+
+// HookPhases lists every lifecycle hook phase
+var HookPhases = []string{HookOnCreate, HookOnStart, HookOnExit}
+
+// Hook returns the mod's script for a lifecycle phase, or "" if none
+func (m *Mod) Hook(phase string) string {
+	switch phase {
+	case HookOnCreate:
+		return m.OnCreate
+	case HookOnStart:
+		return m.OnStart
+	case HookOnExit:
+		return m.OnExit
+	}
+	return ""
+}
+
+// File describes a file to place in the image. Exactly one of Source or
+// Content must be set. Source paths are relative to the mod's directory.
+type File struct {
+	Dest    string `yaml:"dest" json:"dest"`
+	Source  string `yaml:"source,omitempty" json:"source,omitempty"`
+	Content string `yaml:"content,omitempty" json:"content,omitempty"`
+	Owner   string `yaml:"owner,omitempty" json:"owner,omitempty"` // defaults to dev:dev
+	Mode    string `yaml:"mode,omitempty" json:"mode,omitempty"`   // octal, e.g. "0644"
+}
+
+// DefaultFileOwner is the owner applied to mod files that don't specify one
+const DefaultFileOwner = "dev:dev"
+
+// Validate checks that a file entry is well-formed
+func (f File) Validate() error {
+	if f.Dest == "" {
+		return fmt.Errorf("file is missing dest")
+	}
+	if !strings.HasPrefix(f.Dest, "/") && !strings.HasPrefix(f.Dest, "~/") {
+		return fmt.Errorf("file dest %q must be an absolute path (or start with ~/)", f.Dest)
+	}
+	if (f.Source == "") == (f.Content == "") {
+		return fmt.Errorf("file %s must set exactly one of source or content", f.Dest)
+	}
+	if f.Mode != "" {
+		if _, err := strconv.ParseUint(f.Mode, 8, 32); err != nil {
+			return fmt.Errorf("file %s has invalid mode %q (expected octal, e.g. 0644)", f.Dest, f.Mode)
+		}
+	}
+	return nil
+}
+
+// ContainerDest returns the destination path inside the image, expanding a
+// leading ~/ to the dev user's home directory.
+func (f File) ContainerDest() string {
+	if strings.HasPrefix(f.Dest, "~/") {
+		return "/home/dev/" + strings.TrimPrefix(f.Dest, "~/")
+	}
+	return f.Dest
+}
+
+// EffectiveOwner returns the file's owner, falling back to DefaultFileOwner
+func (f File) EffectiveOwner() string {
+	if f.Owner != "" {
+		return f.Owner
+	}
+	return DefaultFileOwner
+}
+
+// SourcePath resolves a file's source to an absolute host path.
+// Relative sources are resolved against the mod's directory; a leading ~/
+// refers to the user's home directory.
+func (m *Mod) SourcePath(f File) (string, error) {
+	src := f.Source
+	if strings.HasPrefix(src, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("getting home directory: %w", err)
+		}
+		return filepath.Join(home, src[2:]), nil
+	}
+	if filepath.IsAbs(src) {
+		return src, nil
+	}
+	if m.Dir == "" {
+		return "", fmt.Errorf("mod %q: source files are only supported in local mods (use content instead)", m.Name)
+	}
+	return filepath.Join(m.Dir, src), nil
+}
+
+// BuildSecret is a credential a mod's setup needs while the image builds,
+// such as a private registry token. It is readable at /run/secrets/<id>
+// during the mod's RUN steps only.
+type BuildSecret struct {
+	ID       string `yaml:"id" json:"id"`
+	Env      string `yaml:"env,omitempty" json:"env,omitempty"`           // exported under this name during setup, and read from it on the host by default
+	Required bool   `yaml:"required,omitempty" json:"required,omitempty"` // fail the build when no value is provided
+}
+
+// Validate checks that a build secret entry is well-formed
+func (s BuildSecret) Validate() error {
+	if !secretIDPattern.MatchString(s.ID) {
+		return fmt.Errorf("build secret id %q must contain only letters, digits, '.', '_' and '-'", s.ID)
+	}
+	if s.Env != "" && !envNamePattern.MatchString(s.Env) {
+		return fmt.Errorf("build secret %s has invalid env name %q", s.ID, s.Env)
+	}
+	return nil
+}
+
+// ValidateBuildArg checks that a build arg name is a valid ARG name
+func ValidateBuildArg(name string) error {
+	if !envNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid build arg name %q", name)
+	}
+	return nil
+}
+
+var (
+	secretIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+	envNamePattern  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
 // EffectiveProvides returns what this mod provides: explicit provides plus the mod's own name
 func (m *Mod) EffectiveProvides() []string {
 	result := make([]string, 0, len(m.Provides)+1)
@@ -69,6 +217,7 @@ func loadFromFile(path string) (*Mod, error) {
 	if err := yaml.Unmarshal(data, &m); err != nil {
 		return nil, fmt.Errorf("parsing mod: %w", err)
 	}
+	m.Dir = filepath.Dir(path)
 
 	return &m, nil
 }
@@ -99,6 +248,7 @@ func Load(id string) (*Mod, error) {
 	for _, searchPath := range modSearchPaths() {
 		fullPath := filepath.Join(searchPath, filename)
 		if m, err := loadFromFile(fullPath); err == nil {
+			m.ID = id
 			return m, nil
 		}
 	}
@@ -114,6 +264,7 @@ func Load(id string) (*Mod, error) {
 	if err := yaml.Unmarshal(data, &m); err != nil {
 		return nil, fmt.Errorf("parsing mod %s: %w", id, err)
 	}
+	m.ID = id
 
 	return &m, nil
 }
@@ -146,6 +297,40 @@ func LoadRaw(id string) ([]byte, string, error) {
 	return data, "embedded", nil
 }
 
+// Resolved is a mod as glovebox would use it: the copy that wins the load
+// order, what it provides and the mods it pulls in
+type Resolved struct {
+	ID           string   `yaml:"id" json:"id"`
+	Source       string   `yaml:"source" json:"source"` // file path, or "embedded"
+	Provides     []string `yaml:"provides" json:"provides"`
+	Dependencies []string `yaml:"dependencies,omitempty" json:"dependencies,omitempty"` // in install order
+	Mod          *Mod     `yaml:"mod" json:"mod"`
+}
+
+// Resolve loads a mod with its source and transitive dependencies
+func Resolve(id string) (*Resolved, error) {
+	m, err := Load(id)
+	if err != nil {
+		return nil, err
+	}
+	_, source, err := LoadRaw(id)
+	if err != nil {
+		return nil, err
+	}
+	order, err := resolveAllDependencies([]string{id})
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Resolved{ID: id, Source: source, Provides: m.EffectiveProvides(), Mod: m}
+	for _, dep := range order {
+		if dep != id {
+			r.Dependencies = append(r.Dependencies, dep)
+		}
+	}
+	return r, nil
+}
+
 // addModToResult adds a mod ID to the result map, extracting category from path
 func addModToResult(result map[string][]string, seen map[string]bool, id string) {
 	if seen[id] {
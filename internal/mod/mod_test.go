@@ -1,6 +1,9 @@
 package mod
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -689,3 +692,127 @@ func TestDockerfileFrom(t *testing.T) {
 		}
 	})
 }
+
+func TestFileValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    File
+		wantErr bool
+	}{
+		{"inline content", File{Dest: "/etc/motd", Content: "hi"}, false},
+		{"source path", File{Dest: "~/.tmux.conf", Source: "tmux.conf"}, false},
+		{"valid mode", File{Dest: "/usr/local/bin/x", Content: "#!/bin/sh", Mode: "0755"}, false},
+		{"missing dest", File{Content: "hi"}, true},
+		{"relative dest", File{Dest: ".tmux.conf", Content: "hi"}, true},
+		{"neither source nor content", File{Dest: "/etc/motd"}, true},
+		{"both source and content", File{Dest: "/etc/motd", Source: "motd", Content: "hi"}, true},
+		{"non-octal mode", File{Dest: "/etc/motd", Content: "hi", Mode: "rw-r--r--"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.file.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileContainerDest(t *testing.T) {
+	if got := (File{Dest: "~/.config/fish/config.fish"}).ContainerDest(); got != "/home/dev/.config/fish/config.fish" {
+		t.Errorf("expected ~ to expand to /home/dev, got %q", got)
+	}
+	if got := (File{Dest: "/etc/motd"}).ContainerDest(); got != "/etc/motd" {
+		t.Errorf("expected absolute dest unchanged, got %q", got)
+	}
+}
+
+func TestSourcePath(t *testing.T) {
+	t.Run("relative to mod directory", func(t *testing.T) {
+		m := &Mod{Name: "x", Dir: "/home/me/.glovebox/mods/custom"}
+		got, err := m.SourcePath(File{Source: "tmux.conf"})
+		if err != nil {
+			t.Fatalf("SourcePath() error = %v", err)
+		}
+		if got != "/home/me/.glovebox/mods/custom/tmux.conf" {
+			t.Errorf("SourcePath() = %q", got)
+		}
+	})
+
+	t.Run("embedded mods cannot use relative sources", func(t *testing.T) {
+		m := &Mod{Name: "x"}
+		if _, err := m.SourcePath(File{Source: "tmux.conf"}); err == nil {
+			t.Error("expected error for embedded mod with relative source")
+		}
+	})
+
+	t.Run("absolute source is kept", func(t *testing.T) {
+		m := &Mod{Name: "x"}
+		got, err := m.SourcePath(File{Source: "/opt/config/tmux.conf"})
+		if err != nil || got != "/opt/config/tmux.conf" {
+			t.Errorf("SourcePath() = %q, %v", got, err)
+		}
+	})
+}
+
+func TestHook(t *testing.T) {
+	m := &Mod{
+		Name:     "node-project",
+		OnCreate: "npm ci",
+		OnStart:  "echo hi",
+	}
+
+	tests := []struct {
+		phase string
+		want  string
+	}{
+		{HookOnCreate, "npm ci"},
+		{HookOnStart, "echo hi"},
+		{HookOnExit, ""},
+		{"on_reboot", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phase, func(t *testing.T) {
+			if got := m.Hook(tt.phase); got != tt.want {
+				t.Errorf("Hook(%q) = %q, want %q", tt.phase, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("HOME", t.TempDir())
+	modDir := filepath.Join(dir, ".glovebox", "mods", "custom")
+	if err := os.MkdirAll(modDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	modYAML := "name: tool\ndescription: A tool\ncategory: custom\nrequires:\n  - tools/mise\n"
+	if err := os.WriteFile(filepath.Join(modDir, "tool.yaml"), []byte(modYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Resolve("custom/tool")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if r.Source != filepath.Join(modDir, "tool.yaml") {
+		t.Errorf("Source = %q", r.Source)
+	}
+	if !reflect.DeepEqual(r.Provides, []string{"tool"}) {
+		t.Errorf("Provides = %v, want [tool]", r.Provides)
+	}
+	if !reflect.DeepEqual(r.Dependencies, []string{"tools/mise"}) {
+		t.Errorf("Dependencies = %v, want [tools/mise]", r.Dependencies)
+	}
+	if r.Mod.Description != "A tool" {
+		t.Errorf("Mod.Description = %q", r.Mod.Description)
+	}
+
+	if _, err := Resolve("nonexistent/fake"); err == nil {
+		t.Error("expected error for non-existent mod")
+	}
+}
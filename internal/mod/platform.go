@@ -0,0 +1,91 @@
+package mod
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// platformPattern matches an os/arch[/variant] platform such as linux/arm64
+var platformPattern = regexp.MustCompile(`^[a-z0-9]+/[a-z0-9_]+(/[a-z0-9]+)?$`)
+
+// archAliases are the names uname and some vendors use for Docker's
+// architectures
+var archAliases = map[string]string{"x86_64": "amd64", "aarch64": "arm64"}
+
+// ParsePlatforms splits a comma-separated platform list, as given to
+// --platform, and checks each entry. An empty list means the runtime's
+// default platform.
+func ParsePlatforms(list string) ([]string, error) {
+	var platforms []string
+	for _, p := range strings.Split(list, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !platformPattern.MatchString(p) {
+			return nil, fmt.Errorf("invalid platform %q (use os/arch, e.g. linux/amd64)", p)
+		}
+		platforms = append(platforms, p)
+	}
+	return platforms, nil
+}
+
+// SupportsPlatform reports whether the mod can be built for a platform.
+// Mods without platforms support all of them. An entry is a platform
+// (linux/amd64) or just an architecture (amd64).
+func (m *Mod) SupportsPlatform(platform string) bool {
+	if len(m.Platforms) == 0 {
+		return true
+	}
+	for _, p := range m.Platforms {
+		if PlatformMatches(p, platform) {
+			return true
+		}
+	}
+	return false
+}
+
+// PlatformMatches reports whether platform is the one an entry names. An
+// entry without a variant matches every variant.
+func PlatformMatches(entry, platform string) bool {
+	want := strings.Split(entry, "/")
+	got := strings.Split(platform, "/")
+	if len(want) == 1 {
+		return len(got) > 1 && normalizeArch(want[0]) == normalizeArch(got[1])
+	}
+	if len(want) > len(got) {
+		return false
+	}
+	for i := range want {
+		if i == 1 {
+			if normalizeArch(want[i]) != normalizeArch(got[i]) {
+				return false
+			}
+			continue
+		}
+		if want[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeArch(arch string) string {
+	if alias, ok := archAliases[arch]; ok {
+		return alias
+	}
+	return arch
+}
+
+// ValidatePlatforms checks that every mod supports every target platform
+func ValidatePlatforms(mods []*Mod, platforms []string) error {
+	for _, m := range mods {
+		for _, p := range platforms {
+			if !m.SupportsPlatform(p) {
+				return fmt.Errorf("mod %q doesn't support %s (it supports %s)", m.Name, p, strings.Join(m.Platforms, ", "))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package mod
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePlatforms(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		want    []string
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{"one", "linux/amd64", []string{"linux/amd64"}, false},
+		{"several with spaces", "linux/amd64, linux/arm64/v8", []string{"linux/amd64", "linux/arm64/v8"}, false},
+		{"architecture only", "arm64", nil, true},
+		{"too many parts", "linux/arm/v7/x", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePlatforms(tt.list)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePlatforms() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePlatforms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSupportsPlatform(t *testing.T) {
+	tests := []struct {
+		name      string
+		platforms []string
+		platform  string
+		want      bool
+	}{
+		{"unrestricted", nil, "linux/arm64", true},
+		{"same platform", []string{"linux/amd64"}, "linux/amd64", true},
+		{"other platform", []string{"linux/amd64"}, "linux/arm64", false},
+		{"architecture", []string{"arm64"}, "linux/arm64", true},
+		{"architecture alias", []string{"x86_64"}, "linux/amd64", true},
+		{"any variant", []string{"linux/arm64"}, "linux/arm64/v8", true},
+		{"other variant", []string{"linux/arm/v7"}, "linux/arm/v6", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mod{Name: "tool", Platforms: tt.platforms}
+			if got := m.SupportsPlatform(tt.platform); got != tt.want {
+				t.Errorf("SupportsPlatform(%q) = %v, want %v", tt.platform, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePlatforms(t *testing.T) {
+	mods := []*Mod{{Name: "ubuntu"}, {Name: "x86-tool", Platforms: []string{"linux/amd64"}}}
+
+	if err := ValidatePlatforms(mods, []string{"linux/amd64"}); err != nil {
+		t.Errorf("ValidatePlatforms(amd64) error = %v", err)
+	}
+	err := ValidatePlatforms(mods, []string{"linux/amd64", "linux/arm64"})
+	if err == nil || !strings.Contains(err.Error(), `mod "x86-tool" doesn't support linux/arm64`) {
+		t.Errorf("ValidatePlatforms(amd64, arm64) error = %v", err)
+	}
+}
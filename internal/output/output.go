@@ -0,0 +1,86 @@
+// Package output writes glovebox's machine-readable output.
+//
+// Every document is an envelope carrying the schema version and the kind of
+// data it holds:
+//
+//	{"schema_version": 1, "kind": "status", "data": {...}}
+//
+// Kinds and their data (field names are the same in JSON and YAML):
+//
+//	status     {"sections": [{"title", "items": [{"label", "value", "status", "indent", "is_list", "note"}]}]}
+//	           status is "ok", "warning", "info" or omitted
+//	diff       {"container", "total", "categories": [{"name", "changes": [{"type", "path"}]}],
+//	            "noise", "workspace"}; with --raw, "changes" lists every change instead of "categories"
+//	outdated   {"bases": [{"image", "from", "pinned", "current", "outdated", "mods", "images", "update", "error"}]}
+//	ls         {"projects": [{"path", "path_exists", "image", "image_role", "image_size", "image_created",
+//	            "stale_base", "container", "state", "last_used", "uncommitted_changes"}]}
+//	df         {"images": [{"name", "role", "project", "size", "base", "shared", "unique"}],
+//	            "containers": [{"name", "role", "project", "size"}], "volumes": [{"name", "size"}], "total"}
+//	mod-list   {"categories": [{"name", "mods": [{"name", "description", "provides", "supported_os", "error"}]}]}
+//	mod        {"id", "source", "provides", "dependencies", "mod": {the mod's YAML fields}}
+//
+// SchemaVersion changes only when a field is removed or changes meaning;
+// new fields and kinds are added without changing it, so consumers should
+// ignore fields they don't know.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the version of the document schema described above
+const SchemaVersion = 1
+
+// Formats
+const (
+	Text = "text" // the human-readable rendering
+	JSON = "json"
+	YAML = "yaml"
+)
+
+// Formats lists the accepted values of --output
+var Formats = []string{Text, JSON, YAML}
+
+// Document is the envelope every machine-readable output is wrapped in
+type Document struct {
+	SchemaVersion int    `json:"schema_version" yaml:"schema_version"`
+	Kind          string `json:"kind" yaml:"kind"`
+	Data          any    `json:"data" yaml:"data"`
+}
+
+// ValidateFormat checks a --output value
+func ValidateFormat(format string) error {
+	switch format {
+	case Text, JSON, YAML:
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q (want text, json or yaml)", format)
+}
+
+// Structured reports whether format is machine-readable
+func Structured(format string) bool {
+	return format == JSON || format == YAML
+}
+
+// Write writes data of the given kind to w as a JSON or YAML document
+func Write(w io.Writer, format, kind string, data any) error {
+	doc := Document{SchemaVersion: SchemaVersion, Kind: kind, Data: data}
+	switch format {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	case YAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return fmt.Errorf("output format %q is not machine-readable", format)
+}
@@ -0,0 +1,60 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	data := struct {
+		Name string `json:"name" yaml:"name"`
+	}{"app"}
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := Write(&buf, JSON, "status", data); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		var doc struct {
+			SchemaVersion int               `json:"schema_version"`
+			Kind          string            `json:"kind"`
+			Data          map[string]string `json:"data"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+		}
+		if doc.SchemaVersion != SchemaVersion || doc.Kind != "status" || doc.Data["name"] != "app" {
+			t.Errorf("Write() = %s", buf.String())
+		}
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := Write(&buf, YAML, "status", data); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		want := "schema_version: 1\nkind: status\ndata:\n  name: app\n"
+		if buf.String() != want {
+			t.Errorf("Write() = %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("text is not structured", func(t *testing.T) {
+		if err := Write(&bytes.Buffer{}, Text, "status", data); err == nil {
+			t.Error("expected error for text format")
+		}
+	})
+}
+
+func TestValidateFormat(t *testing.T) {
+	for _, f := range Formats {
+		if err := ValidateFormat(f); err != nil {
+			t.Errorf("ValidateFormat(%q) error = %v", f, err)
+		}
+	}
+	if err := ValidateFormat("xml"); err == nil || !strings.Contains(err.Error(), "xml") {
+		t.Errorf("ValidateFormat(xml) error = %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package profile
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HostAlias is the hostname containers use to reach services on the host
+const HostAlias = "host.glovebox.internal"
+
+// defaultPortHostIP keeps published ports reachable only from the host itself
+const defaultPortHostIP = "127.0.0.1"
+
+// Port is a container port published on the host
+type Port struct {
+	HostIP        string
+	HostPort      int
+	ContainerPort int
+	Protocol      string // "tcp" or "udp"
+}
+
+// ParsePort parses a port spec of the form [[host-ip:]host-port:]container-port[/protocol].
+// Ports are published on 127.0.0.1 unless a host IP is given.
+func ParsePort(spec string) (Port, error) {
+	port := Port{HostIP: defaultPortHostIP, Protocol: "tcp"}
+
+	rest := spec
+	if i := strings.LastIndex(rest, "/"); i >= 0 {
+		port.Protocol = rest[i+1:]
+		rest = rest[:i]
+		if port.Protocol != "tcp" && port.Protocol != "udp" {
+			return Port{}, fmt.Errorf("invalid port %q: protocol must be tcp or udp", spec)
+		}
+	}
+
+	parts := strings.Split(rest, ":")
+	if len(parts) > 3 {
+		return Port{}, fmt.Errorf("invalid port %q: use [[host-ip:]host-port:]container-port[/protocol]", spec)
+	}
+	if len(parts) == 3 {
+		port.HostIP = parts[0]
+		parts = parts[1:]
+	}
+
+	var err error
+	if port.ContainerPort, err = parsePortNumber(parts[len(parts)-1]); err != nil {
+		return Port{}, fmt.Errorf("invalid port %q: %w", spec, err)
+	}
+	port.HostPort = port.ContainerPort
+	if len(parts) == 2 {
+		if port.HostPort, err = parsePortNumber(parts[0]); err != nil {
+			return Port{}, fmt.Errorf("invalid port %q: %w", spec, err)
+		}
+	}
+	return port, nil
+}
+
+func parsePortNumber(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > 65535 {
+		return 0, fmt.Errorf("%q is not a port number (1-65535)", s)
+	}
+	return n, nil
+}
+
+// String renders the port in the form runtimes accept for publishing
+func (p Port) String() string {
+	return fmt.Sprintf("%s:%d:%d/%s", p.HostIP, p.HostPort, p.ContainerPort, p.Protocol)
+}
+
+// ChainPorts merges the ports of a profile chain, root first. A later
+// profile's port replaces an earlier one bound to the same host port.
+func ChainPorts(chain []*Profile) ([]Port, error) {
+	var result []Port
+	index := make(map[string]int)
+	for _, p := range chain {
+		for _, spec := range p.Ports {
+			port, err := ParsePort(spec)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", p.Path, err)
+			}
+			key := fmt.Sprintf("%d/%s", port.HostPort, port.Protocol)
+			if i, ok := index[key]; ok {
+				result[i] = port
+				continue
+			}
+			index[key] = len(result)
+			result = append(result, port)
+		}
+	}
+	return result, nil
+}
+
+// ChainHostServices merges the host services of a profile chain; a later
+// profile's entry replaces an earlier one with the same name
+func ChainHostServices(chain []*Profile) (map[string]int, error) {
+	result := make(map[string]int)
+	for _, p := range chain {
+		for name, port := range p.HostServices {
+			if port < 1 || port > 65535 {
+				return nil, fmt.Errorf("%s: host service %q: %d is not a port number (1-65535)", p.Path, name, port)
+			}
+			result[name] = port
+		}
+	}
+	return result, nil
+}
+
+// EffectivePorts returns the published ports from every profile in a
+// project's chain
+func EffectivePorts(projectDir string) ([]Port, error) {
+	chain, err := EffectiveChain(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	return ChainPorts(chain)
+}
+
+// EffectiveHostServices returns the host services from every profile in a
+// project's chain
+func EffectiveHostServices(projectDir string) (map[string]int, error) {
+	chain, err := EffectiveChain(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	return ChainHostServices(chain)
+}
+
+// HostServiceNames returns the names of a set of host services, sorted
+func HostServiceNames(services map[string]int) []string {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -5,8 +5,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/joelhelbling/glovebox/internal/dotfiles"
 	"gopkg.in/yaml.v3"
 )
 
@@ -14,28 +18,71 @@ const (
 	GlobalProfileDir  = ".glovebox"
 	ProfileFileName   = "profile.yaml"
 	ProjectProfileDir = ".glovebox"
+	NamedProfilesDir  = "profiles" // under GlobalProfileDir
+	NamedBasesDir     = "bases"    // under GlobalProfileDir
 )
 
+// BaseImageName is the image built from the global profile
+const BaseImageName = "glovebox:base"
+
+// maxChainDepth guards against runaway extends chains
+const maxChainDepth = 16
+
 // BuildInfo tracks when and how the Dockerfile was generated
 type BuildInfo struct {
 	LastBuiltAt      time.Time `yaml:"last_built_at,omitempty"`
 	DockerfileDigest string    `yaml:"dockerfile_digest,omitempty"`
 	ImageName        string    `yaml:"image_name,omitempty"`
-	BaseDigest       string    `yaml:"base_digest,omitempty"`  // For project profiles, tracks when base changed
-	ContentHash      string    `yaml:"content_hash,omitempty"` // Hash of mods list to detect manual edits
+	BaseDigest       string    `yaml:"base_digest,omitempty"`       // For project profiles, tracks when base changed
+	ContentHash      string    `yaml:"content_hash,omitempty"`      // Hash of mods list to detect manual edits
+	DotfilesRevision string    `yaml:"dotfiles_revision,omitempty"` // Dotfiles content baked into the image
+	From             string    `yaml:"from,omitempty"`              // For base profiles, the OS image (e.g. ubuntu:24.04)
+	FromDigest       string    `yaml:"from_digest,omitempty"`       // Digest From is pinned to, so rebuilds use the same image
 }
 
 // Profile represents a glovebox configuration
 type Profile struct {
-	Version        int       `yaml:"version"`
-	Mods           []string  `yaml:"mods"`
-	PassthroughEnv []string  `yaml:"passthrough_env,omitempty"`
-	Build          BuildInfo `yaml:"build,omitempty"`
+	Version        int                `yaml:"version"`
+	Extends        string             `yaml:"extends,omitempty"` // named profile this one builds on (default: base)
+	Base           string             `yaml:"base,omitempty"`    // named base image to build on (chain root only)
+	Mods           []string           `yaml:"mods"`
+	PassthroughEnv []string           `yaml:"passthrough_env,omitempty"`
+	Dotfiles       *dotfiles.Config   `yaml:"dotfiles,omitempty"`
+	Mounts         []Mount            `yaml:"mounts,omitempty"`
+	Ports          []string           `yaml:"ports,omitempty"`          // container ports published on the host
+	HostServices   map[string]int     `yaml:"host_services,omitempty"`  // host ports reached via HostAlias
+	Services       map[string]Service `yaml:"services,omitempty"`       // project profiles only
+	RuntimeHost    string             `yaml:"runtime_host,omitempty"`   // Docker daemon URL or context name to run on
+	BuildArgs      map[string]string  `yaml:"build_args,omitempty"`     // values for ARGs declared by mods
+	BuildSecrets   map[string]Secret  `yaml:"build_secrets,omitempty"`  // where build secrets requested by mods come from
+	BuildStrategy  string             `yaml:"build_strategy,omitempty"` // how the Dockerfile orders mods: dependency (default) or stable
+	Platform       string             `yaml:"platform,omitempty"`       // platforms to build for, comma-separated (e.g. linux/amd64)
+	Registry       string             `yaml:"registry,omitempty"`       // registry namespace images are pushed to (e.g. ghcr.io/acme)
+	Build          BuildInfo          `yaml:"build,omitempty"`
 
 	// Path is not serialized - it's the location this profile was loaded from
 	Path string `yaml:"-"`
 	// IsGlobal indicates if this is the global (base) profile
 	IsGlobal bool `yaml:"-"`
+	// Name is set for named profiles (~/.glovebox/profiles/<name>/profile.yaml)
+	Name string `yaml:"-"`
+	// BaseName is set for named bases (~/.glovebox/bases/<name>/profile.yaml)
+	BaseName string `yaml:"-"`
+}
+
+// Mount is a host path bind-mounted into containers. Relative sources are
+// resolved against the project directory and "~/" against the home directory.
+type Mount struct {
+	Source   string `yaml:"source"`
+	Target   string `yaml:"target"`
+	ReadOnly bool   `yaml:"readonly,omitempty"`
+}
+
+// Secret is the host source of a build secret: a file (absolute or under
+// "~/") or an environment variable.
+type Secret struct {
+	File string `yaml:"file,omitempty"`
+	Env  string `yaml:"env,omitempty"`
 }
 
 // NewProfile creates a new empty profile
@@ -81,6 +128,15 @@ func Load(path string) (*Profile, error) {
 	globalPath, _ := GlobalPath()
 	p.IsGlobal = (path == globalPath)
 
+	// Determine if this is a named profile or named base
+	parentDir := filepath.Dir(filepath.Dir(path))
+	if namedDir, err := NamedDir(); err == nil && parentDir == namedDir {
+		p.Name = filepath.Base(filepath.Dir(path))
+	}
+	if basesDir, err := BasesDir(); err == nil && parentDir == basesDir {
+		p.BaseName = filepath.Base(filepath.Dir(path))
+	}
+
 	return &p, nil
 }
 
@@ -177,6 +233,39 @@ func (p *Profile) UpdateBuildInfo(digest string) {
 func (p *Profile) ComputeContentHash() string {
 	// Create a stable representation of the content
 	content := fmt.Sprintf("v%d:%v:%v", p.Version, p.Mods, p.PassthroughEnv)
+	if p.Extends != "" {
+		content += ":extends=" + p.Extends
+	}
+	if p.Base != "" {
+		content += ":base=" + p.Base
+	}
+	if p.Dotfiles != nil {
+		content += fmt.Sprintf(":%+v", *p.Dotfiles)
+	}
+	if len(p.Mounts) > 0 {
+		content += fmt.Sprintf(":mounts=%+v", p.Mounts)
+	}
+	if len(p.Ports) > 0 {
+		content += fmt.Sprintf(":ports=%v", p.Ports)
+	}
+	for _, name := range HostServiceNames(p.HostServices) {
+		content += fmt.Sprintf(":host=%s=%d", name, p.HostServices[name])
+	}
+	for _, name := range p.ServiceNames() {
+		content += fmt.Sprintf(":service=%s=%+v", name, p.Services[name])
+	}
+	if p.BuildStrategy != "" {
+		content += ":strategy=" + p.BuildStrategy
+	}
+	if p.Platform != "" {
+		content += ":platform=" + p.Platform
+	}
+	for _, name := range sortedKeys(p.BuildArgs) {
+		content += fmt.Sprintf(":arg=%s=%s", name, p.BuildArgs[name])
+	}
+	for _, id := range sortedKeys(p.BuildSecrets) {
+		content += fmt.Sprintf(":secret=%s=%+v", id, p.BuildSecrets[id])
+	}
 	hash := sha256.Sum256([]byte(content))
 	return fmt.Sprintf("%x", hash)[:12] // Short hash is sufficient
 }
@@ -202,8 +291,12 @@ func (p *Profile) ImageName() string {
 		return p.Build.ImageName
 	}
 
-	if p.IsGlobal {
-		return "glovebox:base"
+	if p.IsBase() {
+		return BaseImageFor(p.BaseName)
+	}
+
+	if p.Name != "" {
+		return NamedImageName(p.Name)
 	}
 
 	// Generate project image name from directory
@@ -240,6 +333,12 @@ func ProjectDir(dir string) string {
 	return filepath.Join(dir, ProjectProfileDir)
 }
 
+// IsBase reports whether this profile builds a base image: the global
+// profile or a named base
+func (p *Profile) IsBase() bool {
+	return p.IsGlobal || p.BaseName != ""
+}
+
 // DockerfilePath returns the path where the Dockerfile should be generated
 func (p *Profile) DockerfilePath() string {
 	if p.IsGlobal {
@@ -250,6 +349,254 @@ func (p *Profile) DockerfilePath() string {
 	return filepath.Join(filepath.Dir(p.Path), "Dockerfile")
 }
 
+// NamedDir returns the directory holding named profiles
+func NamedDir() (string, error) {
+	globalDir, err := GlobalDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(globalDir, NamedProfilesDir), nil
+}
+
+// NamedPath returns the path to a named profile
+func NamedPath(name string) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	namedDir, err := NamedDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(namedDir, name, ProfileFileName), nil
+}
+
+// BasesDir returns the directory holding named bases
+func BasesDir() (string, error) {
+	globalDir, err := GlobalDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(globalDir, NamedBasesDir), nil
+}
+
+// BasePath returns the profile path for a base. The default base ("") is
+// the global profile.
+func BasePath(name string) (string, error) {
+	if name == "" {
+		return GlobalPath()
+	}
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	basesDir, err := BasesDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(basesDir, name, ProfileFileName), nil
+}
+
+// BaseImageFor returns the image built from a base: glovebox:base for the
+// default base, glovebox:base-<name> for named bases
+func BaseImageFor(name string) string {
+	if name == "" {
+		return BaseImageName
+	}
+	return BaseImageName + "-" + name
+}
+
+// IsBaseImage reports whether an image name is that of the default base or
+// of an existing named base. A project image can share the named bases'
+// prefix (glovebox:base-api-<hash> for a project directory named base-api),
+// so the name is matched against the bases themselves.
+func IsBaseImage(imageName string) bool {
+	if imageName == BaseImageName {
+		return true
+	}
+	name, ok := strings.CutPrefix(imageName, BaseImageName+"-")
+	if !ok {
+		return false
+	}
+	bases, err := ListBases()
+	return err == nil && slices.Contains(bases, name)
+}
+
+// LoadBase loads a base profile by name ("" for the global profile).
+// Returns nil if it doesn't exist.
+func LoadBase(name string) (*Profile, error) {
+	path, err := BasePath(name)
+	if err != nil {
+		return nil, err
+	}
+	return Load(path)
+}
+
+// ListBases returns the names of all named bases, sorted. The default base
+// is not included.
+func ListBases() ([]string, error) {
+	basesDir, err := BasesDir()
+	if err != nil {
+		return nil, err
+	}
+	return listProfileDirs(basesDir)
+}
+
+// NamedImageName returns the image built from a named profile
+func NamedImageName(name string) string {
+	return "glovebox:profile-" + name
+}
+
+// ValidateName checks that a profile name is usable in paths and image tags
+func ValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("profile name cannot be empty")
+	}
+	if len(name) > 64 {
+		return fmt.Errorf("profile name %q is too long (max 64 characters)", name)
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case (r == '-' || r == '_' || r == '.') && i > 0:
+		default:
+			return fmt.Errorf("invalid profile name %q: use lowercase letters, digits, '-', '_' or '.'", name)
+		}
+	}
+	return nil
+}
+
+// LoadNamed loads a named profile. Returns nil if it doesn't exist.
+func LoadNamed(name string) (*Profile, error) {
+	path, err := NamedPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return Load(path)
+}
+
+// ListNamed returns the names of all named profiles, sorted
+func ListNamed() ([]string, error) {
+	namedDir, err := NamedDir()
+	if err != nil {
+		return nil, err
+	}
+	return listProfileDirs(namedDir)
+}
+
+// listProfileDirs returns the names of subdirectories of dir holding a profile
+func listProfileDirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading profiles directory: %w", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() || ValidateName(e.Name()) != nil {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, e.Name(), ProfileFileName)); err == nil {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+// Parent loads the profile this one builds on: the named profile it extends,
+// or its base (the global profile unless `base:` names another). Returns nil
+// for base profiles, and for other profiles when the default base doesn't exist.
+func (p *Profile) Parent() (*Profile, error) {
+	if p.IsBase() {
+		if p.Extends != "" || p.Base != "" {
+			return nil, fmt.Errorf("base profile %s cannot extend another profile", p.Path)
+		}
+		return nil, nil
+	}
+
+	if p.Extends == "" {
+		if p.Base == "" {
+			return LoadGlobal()
+		}
+		base, err := LoadBase(p.Base)
+		if err != nil {
+			return nil, fmt.Errorf("loading base %q: %w", p.Base, err)
+		}
+		if base == nil {
+			return nil, fmt.Errorf("base %q not found. Run 'glovebox init --base --name %s' to create it", p.Base, p.Base)
+		}
+		return base, nil
+	}
+
+	if p.Base != "" {
+		return nil, fmt.Errorf("profile %s sets both base and extends; set base on the root of the chain (profile %q or its parents)", p.Path, p.Extends)
+	}
+
+	parent, err := LoadNamed(p.Extends)
+	if err != nil {
+		return nil, fmt.Errorf("loading profile %q: %w", p.Extends, err)
+	}
+	if parent == nil {
+		path, _ := NamedPath(p.Extends)
+		return nil, fmt.Errorf("profile %q not found (expected %s). Run 'glovebox init --profile %s' to create it", p.Extends, path, p.Extends)
+	}
+	return parent, nil
+}
+
+// Ancestors returns the chain of profiles this one builds on, ordered from
+// the global profile down to the immediate parent. It fails on missing
+// named profiles and on cycles.
+func (p *Profile) Ancestors() ([]*Profile, error) {
+	var chain []*Profile
+	seen := map[string]bool{p.Path: true}
+
+	current := p
+	for {
+		parent, err := current.Parent()
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			break
+		}
+		if seen[parent.Path] {
+			return nil, fmt.Errorf("profile %q extends itself (cycle through %s)", p.Extends, parent.Path)
+		}
+		if len(chain) >= maxChainDepth {
+			return nil, fmt.Errorf("profile extends chain is too deep (max %d)", maxChainDepth)
+		}
+		seen[parent.Path] = true
+		chain = append([]*Profile{parent}, chain...)
+		current = parent
+	}
+	return chain, nil
+}
+
+// ParentImageName returns the image this profile's image is built FROM
+func (p *Profile) ParentImageName() string {
+	if p.Extends != "" {
+		return NamedImageName(p.Extends)
+	}
+	return BaseImageFor(p.Base)
+}
+
+// ChainMods returns the union of mods installed by a chain of profiles,
+// in chain order without duplicates.
+func ChainMods(chain []*Profile) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, p := range chain {
+		for _, m := range p.Mods {
+			if !seen[m] {
+				seen[m] = true
+				result = append(result, m)
+			}
+		}
+	}
+	return result
+}
+
 // LoadGlobal loads the global profile (for base image)
 func LoadGlobal() (*Profile, error) {
 	globalPath, err := GlobalPath()
@@ -265,20 +612,45 @@ func LoadProject(dir string) (*Profile, error) {
 	return Load(projectPath)
 }
 
-// EffectivePassthroughEnv returns the combined passthrough env vars from both
-// global and project profiles. Project profile vars are appended to global ones,
-// with duplicates removed (project takes precedence).
-func EffectivePassthroughEnv(projectDir string) ([]string, error) {
-	seen := make(map[string]bool)
-	var result []string
+// EffectiveChain returns the profiles that apply to a project directory,
+// from the chain root down to the project profile. Without a project profile
+// it is just the global profile, and empty when there is no profile at all.
+func EffectiveChain(projectDir string) ([]*Profile, error) {
+	projectProfile, err := LoadProject(projectDir)
+	if err != nil {
+		return nil, fmt.Errorf("loading project profile: %w", err)
+	}
+	if projectProfile == nil {
+		globalProfile, err := LoadGlobal()
+		if err != nil {
+			return nil, fmt.Errorf("loading global profile: %w", err)
+		}
+		if globalProfile == nil {
+			return nil, nil
+		}
+		return []*Profile{globalProfile}, nil
+	}
 
-	// Load global profile first
-	globalProfile, err := LoadGlobal()
+	chain, err := projectProfile.Ancestors()
 	if err != nil {
-		return nil, fmt.Errorf("loading global profile: %w", err)
+		return nil, err
 	}
-	if globalProfile != nil {
-		for _, env := range globalProfile.PassthroughEnv {
+	return append(chain, projectProfile), nil
+}
+
+// EffectivePassthroughEnv returns the combined passthrough env vars from the
+// global profile, any named profiles the project extends, and the project
+// profile, in that order with duplicates removed.
+func EffectivePassthroughEnv(projectDir string) ([]string, error) {
+	chain, err := EffectiveChain(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var result []string
+	for _, p := range chain {
+		for _, env := range p.PassthroughEnv {
 			if !seen[env] {
 				seen[env] = true
 				result = append(result, env)
@@ -286,19 +658,166 @@ func EffectivePassthroughEnv(projectDir string) ([]string, error) {
 		}
 	}
 
-	// Load project profile and add its vars (deduped)
-	projectProfile, err := LoadProject(projectDir)
+	return result, nil
+}
+
+// EffectiveDotfiles returns the dotfiles configuration for a project: the
+// one set closest to the project in its profile chain.
+func EffectiveDotfiles(projectDir string) (*dotfiles.Config, error) {
+	chain, err := EffectiveChain(projectDir)
 	if err != nil {
-		return nil, fmt.Errorf("loading project profile: %w", err)
+		return nil, err
 	}
-	if projectProfile != nil {
-		for _, env := range projectProfile.PassthroughEnv {
-			if !seen[env] {
-				seen[env] = true
-				result = append(result, env)
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].Dotfiles != nil {
+			return chain[i].Dotfiles, nil
+		}
+	}
+	return nil, nil
+}
+
+// EffectiveRuntimeHost returns the Docker daemon a project runs on: the
+// runtime_host set closest to the project in its profile chain, or "" for
+// the default.
+func EffectiveRuntimeHost(projectDir string) (string, error) {
+	chain, err := EffectiveChain(projectDir)
+	if err != nil {
+		return "", err
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].RuntimeHost != "" {
+			return chain[i].RuntimeHost, nil
+		}
+	}
+	return "", nil
+}
+
+// ChainPlatform returns the platforms a profile chain's image is built for:
+// the platform set closest to the image, or "" for the runtime's default.
+func ChainPlatform(chain []*Profile) string {
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].Platform != "" {
+			return chain[i].Platform
+		}
+	}
+	return ""
+}
+
+// ChainRegistry returns the registry namespace a profile chain's image is
+// pushed to and pulled from: the one set closest to the image.
+func ChainRegistry(chain []*Profile) string {
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].Registry != "" {
+			return chain[i].Registry
+		}
+	}
+	return ""
+}
+
+// EffectivePlatform returns the platforms a project's image is built for,
+// see ChainPlatform.
+func EffectivePlatform(projectDir string) (string, error) {
+	chain, err := EffectiveChain(projectDir)
+	if err != nil {
+		return "", err
+	}
+	return ChainPlatform(chain), nil
+}
+
+// ChainBuildArgs merges the build args of a profile chain, root first. A
+// later profile's value replaces an earlier one.
+func ChainBuildArgs(chain []*Profile) map[string]string {
+	result := make(map[string]string)
+	for _, p := range chain {
+		for name, value := range p.BuildArgs {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+// ChainBuildSecrets merges the build secret sources of a profile chain, root
+// first. A later profile's source replaces an earlier one with the same id.
+func ChainBuildSecrets(chain []*Profile) (map[string]Secret, error) {
+	result := make(map[string]Secret)
+	for _, p := range chain {
+		for id, s := range p.BuildSecrets {
+			if (s.File == "") == (s.Env == "") {
+				return nil, fmt.Errorf("build secret %s in %s must set exactly one of file or env", id, p.Path)
+			}
+			if s.File != "" && !filepath.IsAbs(s.File) && !strings.HasPrefix(s.File, "~/") {
+				return nil, fmt.Errorf("build secret %s in %s: file %q must be an absolute path (or start with ~/)", id, p.Path, s.File)
 			}
+			result[id] = s
 		}
 	}
+	return result, nil
+}
+
+// EffectiveMounts returns the bind mounts from every profile in a project's
+// chain, with sources resolved to absolute host paths.
+func EffectiveMounts(projectDir string) ([]Mount, error) {
+	chain, err := EffectiveChain(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	mounts, err := ChainMounts(chain)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range mounts {
+		if mounts[i].Source, err = ResolveMountSource(m.Source, projectDir); err != nil {
+			return nil, err
+		}
+	}
+	return mounts, nil
+}
 
+// ChainMounts merges the mounts of a profile chain, root first. A later
+// profile's mount replaces an earlier one with the same target. Sources are
+// returned as written in the profiles.
+func ChainMounts(chain []*Profile) ([]Mount, error) {
+	var result []Mount
+	index := make(map[string]int)
+	for _, p := range chain {
+		for _, m := range p.Mounts {
+			if m.Source == "" || m.Target == "" {
+				return nil, fmt.Errorf("mount in %s needs both source and target", p.Path)
+			}
+			if i, ok := index[m.Target]; ok {
+				result[i] = m
+				continue
+			}
+			index[m.Target] = len(result)
+			result = append(result, m)
+		}
+	}
 	return result, nil
 }
+
+// ResolveMountSource expands "~/" and makes relative paths absolute against
+// the project directory
+func ResolveMountSource(source, projectDir string) (string, error) {
+	if source == "~" || strings.HasPrefix(source, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("getting home directory: %w", err)
+		}
+		return filepath.Join(home, strings.TrimPrefix(source, "~")), nil
+	}
+	if filepath.IsAbs(source) {
+		return filepath.Clean(source), nil
+	}
+	return filepath.Join(projectDir, source), nil
+}
+
+// sortedKeys returns a map's keys in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
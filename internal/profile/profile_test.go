@@ -600,3 +600,513 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"team-web", false},
+		{"team_data.v2", false},
+		{"py3", false},
+		{"", true},
+		{"Team", true},
+		{"-web", true},
+		{"team/web", true},
+		{"team web", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateName(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// saveNamed writes a named profile under the test's HOME
+func saveNamed(t *testing.T, name, extends string, mods ...string) *Profile {
+	t.Helper()
+	path, err := NamedPath(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewProfile()
+	p.Extends = extends
+	p.Mods = mods
+	if err := p.SaveTo(path); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestNamedProfiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	saveNamed(t, "team-web", "", "languages/nodejs")
+
+	p, err := LoadNamed("team-web")
+	if err != nil {
+		t.Fatalf("LoadNamed() error = %v", err)
+	}
+	if p == nil {
+		t.Fatal("LoadNamed() returned nil")
+	}
+	if p.Name != "team-web" {
+		t.Errorf("Name = %q, want %q", p.Name, "team-web")
+	}
+	if p.IsGlobal {
+		t.Error("named profile should not be global")
+	}
+	if got := p.ImageName(); got != "glovebox:profile-team-web" {
+		t.Errorf("ImageName() = %q, want %q", got, "glovebox:profile-team-web")
+	}
+
+	missing, err := LoadNamed("nope")
+	if err != nil || missing != nil {
+		t.Errorf("LoadNamed(missing) = %v, %v; want nil, nil", missing, err)
+	}
+
+	names, err := ListNamed()
+	if err != nil {
+		t.Fatalf("ListNamed() error = %v", err)
+	}
+	if len(names) != 1 || names[0] != "team-web" {
+		t.Errorf("ListNamed() = %v, want [team-web]", names)
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	global := NewProfile()
+	global.Mods = []string{"os/ubuntu", "shells/bash"}
+	globalPath, _ := GlobalPath()
+	if err := global.SaveTo(globalPath); err != nil {
+		t.Fatal(err)
+	}
+	saveNamed(t, "org", "", "tools/mise")
+	saveNamed(t, "team-web", "org", "languages/nodejs", "tools/mise")
+
+	project := NewProfile()
+	project.Extends = "team-web"
+	if err := project.SaveTo(ProjectPath(filepath.Join(home, "app"))); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("chain is ordered root first", func(t *testing.T) {
+		chain, err := project.Ancestors()
+		if err != nil {
+			t.Fatalf("Ancestors() error = %v", err)
+		}
+		var images []string
+		for _, p := range chain {
+			images = append(images, p.ImageName())
+		}
+		want := []string{"glovebox:base", "glovebox:profile-org", "glovebox:profile-team-web"}
+		if len(images) != len(want) {
+			t.Fatalf("Ancestors() images = %v, want %v", images, want)
+		}
+		for i := range want {
+			if images[i] != want[i] {
+				t.Errorf("Ancestors()[%d] = %q, want %q", i, images[i], want[i])
+			}
+		}
+
+		mods := ChainMods(chain)
+		wantMods := []string{"os/ubuntu", "shells/bash", "tools/mise", "languages/nodejs"}
+		if len(mods) != len(wantMods) {
+			t.Fatalf("ChainMods() = %v, want %v", mods, wantMods)
+		}
+		for i := range wantMods {
+			if mods[i] != wantMods[i] {
+				t.Errorf("ChainMods()[%d] = %q, want %q", i, mods[i], wantMods[i])
+			}
+		}
+	})
+
+	t.Run("parent image", func(t *testing.T) {
+		if got := project.ParentImageName(); got != "glovebox:profile-team-web" {
+			t.Errorf("ParentImageName() = %q", got)
+		}
+		org, _ := LoadNamed("org")
+		if got := org.ParentImageName(); got != "glovebox:base" {
+			t.Errorf("ParentImageName() = %q, want glovebox:base", got)
+		}
+	})
+
+	t.Run("global profile has no ancestors", func(t *testing.T) {
+		g, _ := LoadGlobal()
+		chain, err := g.Ancestors()
+		if err != nil || len(chain) != 0 {
+			t.Errorf("Ancestors() = %v, %v; want empty", chain, err)
+		}
+	})
+
+	t.Run("missing named profile", func(t *testing.T) {
+		p := &Profile{Extends: "ghost", Path: "/tmp/x/.glovebox/profile.yaml"}
+		if _, err := p.Ancestors(); err == nil {
+			t.Error("expected error for missing named profile")
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		saveNamed(t, "a", "b")
+		saveNamed(t, "b", "a")
+		p := &Profile{Extends: "a", Path: "/tmp/x/.glovebox/profile.yaml"}
+		if _, err := p.Ancestors(); err == nil {
+			t.Error("expected error for extends cycle")
+		}
+	})
+}
+
+func TestBaseImageFor(t *testing.T) {
+	if got := BaseImageFor(""); got != "glovebox:base" {
+		t.Errorf("BaseImageFor(\"\") = %q, want glovebox:base", got)
+	}
+	if got := BaseImageFor("py"); got != "glovebox:base-py" {
+		t.Errorf("BaseImageFor(\"py\") = %q, want glovebox:base-py", got)
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	path, err := BasePath("py")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewProfile().SaveTo(path); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		image string
+		want  bool
+	}{
+		{"glovebox:base", true},
+		{"glovebox:base-py", true},
+		{"glovebox:profile-team-web", false},
+		{"glovebox:myproject-abc1234", false},
+		{"glovebox:base-api-abc1234", false}, // project in a directory named base-api
+		{"glovebox:base-go", false},          // no such base
+	}
+	for _, tt := range tests {
+		if got := IsBaseImage(tt.image); got != tt.want {
+			t.Errorf("IsBaseImage(%q) = %v, want %v", tt.image, got, tt.want)
+		}
+	}
+}
+
+func TestNamedBases(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path, err := BasePath("py")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, ".glovebox", "bases", "py", "profile.yaml"); path != want {
+		t.Errorf("BasePath(\"py\") = %q, want %q", path, want)
+	}
+
+	base := NewProfile()
+	base.Mods = []string{"os/alpine"}
+	if err := base.SaveTo(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadBase("py")
+	if err != nil || loaded == nil {
+		t.Fatalf("LoadBase() = %v, %v", loaded, err)
+	}
+	if !loaded.IsBase() || loaded.IsGlobal {
+		t.Errorf("named base: IsBase() = %v, IsGlobal = %v; want true, false", loaded.IsBase(), loaded.IsGlobal)
+	}
+	if got := loaded.ImageName(); got != "glovebox:base-py" {
+		t.Errorf("ImageName() = %q, want glovebox:base-py", got)
+	}
+	if got := loaded.DockerfilePath(); got != filepath.Join(filepath.Dir(path), "Dockerfile") {
+		t.Errorf("DockerfilePath() = %q", got)
+	}
+
+	names, err := ListBases()
+	if err != nil || len(names) != 1 || names[0] != "py" {
+		t.Errorf("ListBases() = %v, %v; want [py]", names, err)
+	}
+
+	t.Run("project chooses base", func(t *testing.T) {
+		project := &Profile{Base: "py", Path: filepath.Join(home, "app", ".glovebox", "profile.yaml")}
+		chain, err := project.Ancestors()
+		if err != nil {
+			t.Fatalf("Ancestors() error = %v", err)
+		}
+		if len(chain) != 1 || chain[0].BaseName != "py" {
+			t.Errorf("Ancestors() = %v, want the py base", chain)
+		}
+		if got := project.ParentImageName(); got != "glovebox:base-py" {
+			t.Errorf("ParentImageName() = %q, want glovebox:base-py", got)
+		}
+	})
+
+	t.Run("named profile chooses base", func(t *testing.T) {
+		saveNamed(t, "data", "")
+		named, _ := LoadNamed("data")
+		named.Base = "py"
+		project := &Profile{Extends: "data", Path: filepath.Join(home, "app", ".glovebox", "profile.yaml")}
+		if err := named.Save(); err != nil {
+			t.Fatal(err)
+		}
+		chain, err := project.Ancestors()
+		if err != nil {
+			t.Fatalf("Ancestors() error = %v", err)
+		}
+		if len(chain) != 2 || chain[0].ImageName() != "glovebox:base-py" {
+			t.Errorf("Ancestors() root = %v, want glovebox:base-py", chain)
+		}
+	})
+
+	t.Run("base only at chain root", func(t *testing.T) {
+		project := &Profile{Base: "py", Extends: "data", Path: "/tmp/x/.glovebox/profile.yaml"}
+		if _, err := project.Ancestors(); err == nil {
+			t.Error("expected error when both base and extends are set")
+		}
+	})
+
+	t.Run("missing base", func(t *testing.T) {
+		project := &Profile{Base: "rust", Path: "/tmp/x/.glovebox/profile.yaml"}
+		if _, err := project.Ancestors(); err == nil {
+			t.Error("expected error for missing base")
+		}
+	})
+}
+
+func TestEffectiveMounts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	global := NewProfile()
+	global.Mods = []string{"os/ubuntu"}
+	global.Mounts = []Mount{
+		{Source: "~/.aws", Target: "/home/dev/.aws", ReadOnly: true},
+		{Source: "/srv/cache", Target: "/cache"},
+	}
+	globalPath, _ := GlobalPath()
+	if err := global.SaveTo(globalPath); err != nil {
+		t.Fatal(err)
+	}
+
+	projectDir := filepath.Join(home, "app")
+	project := NewProfile()
+	project.Mounts = []Mount{
+		{Source: "data", Target: "/data"},
+		{Source: "/other/cache", Target: "/cache"},
+	}
+	if err := project.SaveTo(ProjectPath(projectDir)); err != nil {
+		t.Fatal(err)
+	}
+
+	mounts, err := EffectiveMounts(projectDir)
+	if err != nil {
+		t.Fatalf("EffectiveMounts() error = %v", err)
+	}
+	want := []Mount{
+		{Source: filepath.Join(home, ".aws"), Target: "/home/dev/.aws", ReadOnly: true},
+		{Source: "/other/cache", Target: "/cache"},
+		{Source: filepath.Join(projectDir, "data"), Target: "/data"},
+	}
+	if len(mounts) != len(want) {
+		t.Fatalf("EffectiveMounts() = %v, want %v", mounts, want)
+	}
+	for i := range want {
+		if mounts[i] != want[i] {
+			t.Errorf("mount %d = %+v, want %+v", i, mounts[i], want[i])
+		}
+	}
+
+	t.Run("content hash includes mounts", func(t *testing.T) {
+		before := project.ComputeContentHash()
+		project.Mounts = nil
+		if project.ComputeContentHash() == before {
+			t.Error("ComputeContentHash() should change when mounts change")
+		}
+	})
+}
+
+func TestEffectiveRuntimeHost(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	projectDir := filepath.Join(home, "app")
+
+	host, err := EffectiveRuntimeHost(projectDir)
+	if err != nil || host != "" {
+		t.Fatalf("EffectiveRuntimeHost() without profiles = %q, %v", host, err)
+	}
+
+	global := NewProfile()
+	global.Mods = []string{"os/ubuntu"}
+	global.RuntimeHost = "ssh://me@buildbox"
+	globalPath, _ := GlobalPath()
+	if err := global.SaveTo(globalPath); err != nil {
+		t.Fatal(err)
+	}
+	if host, _ := EffectiveRuntimeHost(projectDir); host != "ssh://me@buildbox" {
+		t.Errorf("EffectiveRuntimeHost() = %q, want the global host", host)
+	}
+
+	project := NewProfile()
+	project.RuntimeHost = "gpu-box"
+	if err := project.SaveTo(ProjectPath(projectDir)); err != nil {
+		t.Fatal(err)
+	}
+	if host, _ := EffectiveRuntimeHost(projectDir); host != "gpu-box" {
+		t.Errorf("EffectiveRuntimeHost() = %q, want the project's host", host)
+	}
+}
+
+func TestServices(t *testing.T) {
+	tests := []struct {
+		name     string
+		services map[string]Service
+		wantErr  bool
+	}{
+		{"valid", map[string]Service{"db": {Image: "postgres:16", Volumes: []string{"/var/lib/postgresql/data"}}, "cache-1": {Image: "redis:7"}}, false},
+		{"missing image", map[string]Service{"db": {}}, true},
+		{"uppercase name", map[string]Service{"DB": {Image: "postgres:16"}}, true},
+		{"leading dash", map[string]Service{"-db": {Image: "postgres:16"}}, true},
+		{"relative volume", map[string]Service{"db": {Image: "postgres:16", Volumes: []string{"data"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Profile{Services: tt.services}
+			err := p.ValidateServices()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateServices() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("names", func(t *testing.T) {
+		p := &Profile{Services: map[string]Service{"redis": {}, "db": {}}}
+		if got := p.ServiceNames(); len(got) != 2 || got[0] != "db" || got[1] != "redis" {
+			t.Errorf("ServiceNames() = %v, want [db redis]", got)
+		}
+		if got := ServiceContainerName("glovebox-app-1234567", "db"); got != "glovebox-app-1234567-db" {
+			t.Errorf("ServiceContainerName() = %q", got)
+		}
+		if got := ServiceVolumeName("glovebox-app-1234567", "db", "/var/lib/postgresql/data/"); got != "glovebox-app-1234567-db-var-lib-postgresql-data" {
+			t.Errorf("ServiceVolumeName() = %q", got)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".glovebox", "profile.yaml")
+		p := NewProfile()
+		p.Services = map[string]Service{"db": {Image: "postgres:16", Env: map[string]string{"POSTGRES_PASSWORD": "dev"}}}
+		if err := p.SaveTo(path); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := loaded.Services["db"]; got.Image != "postgres:16" || got.Env["POSTGRES_PASSWORD"] != "dev" {
+			t.Errorf("Services[db] = %+v", got)
+		}
+	})
+}
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{"3000", "127.0.0.1:3000:3000/tcp", false},
+		{"8080:80", "127.0.0.1:8080:80/tcp", false},
+		{"0.0.0.0:5173:5173", "0.0.0.0:5173:5173/tcp", false},
+		{"5353/udp", "127.0.0.1:5353:5353/udp", false},
+		{"http", "", true},
+		{"70000", "", true},
+		{"3000/sctp", "", true},
+		{"a:b:c:d", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParsePort(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePort(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("ParsePort(%q) = %s, want %s", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChainPorts(t *testing.T) {
+	chain := []*Profile{
+		{Ports: []string{"3000", "5432"}, HostServices: map[string]int{"ollama": 11434}},
+		{Ports: []string{"3000:3001"}, HostServices: map[string]int{"ollama": 11435, "mysql": 3306}},
+	}
+
+	ports, err := ChainPorts(chain)
+	if err != nil {
+		t.Fatalf("ChainPorts() error = %v", err)
+	}
+	if len(ports) != 2 || ports[0].ContainerPort != 3001 || ports[1].HostPort != 5432 {
+		t.Errorf("ChainPorts() = %v, want 3000 remapped to 3001 and 5432", ports)
+	}
+
+	services, err := ChainHostServices(chain)
+	if err != nil {
+		t.Fatalf("ChainHostServices() error = %v", err)
+	}
+	if services["ollama"] != 11435 || services["mysql"] != 3306 {
+		t.Errorf("ChainHostServices() = %v", services)
+	}
+
+	if _, err := ChainHostServices([]*Profile{{HostServices: map[string]int{"bad": 0}}}); err == nil {
+		t.Error("expected error for invalid host service port")
+	}
+}
+
+func TestChainBuildSettings(t *testing.T) {
+	chain := []*Profile{
+		{
+			BuildArgs:    map[string]string{"REGISTRY": "registry.example.com", "TOOL_VERSION": "1.0"},
+			BuildSecrets: map[string]Secret{"npm_token": {Env: "NPM_TOKEN"}},
+		},
+		{
+			BuildArgs:    map[string]string{"TOOL_VERSION": "2.0"},
+			BuildSecrets: map[string]Secret{"npm_token": {File: "~/.npm-token"}},
+		},
+	}
+
+	args := ChainBuildArgs(chain)
+	if len(args) != 2 || args["TOOL_VERSION"] != "2.0" || args["REGISTRY"] != "registry.example.com" {
+		t.Errorf("ChainBuildArgs() = %v", args)
+	}
+
+	secrets, err := ChainBuildSecrets(chain)
+	if err != nil {
+		t.Fatalf("ChainBuildSecrets() error = %v", err)
+	}
+	if secrets["npm_token"] != (Secret{File: "~/.npm-token"}) {
+		t.Errorf("ChainBuildSecrets() = %v", secrets)
+	}
+
+	for _, bad := range []Secret{{}, {File: "token", Env: "TOKEN"}, {File: "relative/token"}} {
+		if _, err := ChainBuildSecrets([]*Profile{{BuildSecrets: map[string]Secret{"token": bad}}}); err == nil {
+			t.Errorf("expected error for build secret %+v", bad)
+		}
+	}
+
+	before := chain[1].ComputeContentHash()
+	chain[1].BuildArgs["TOOL_VERSION"] = "3.0"
+	if chain[1].ComputeContentHash() == before {
+		t.Error("ComputeContentHash() should change when build args change")
+	}
+}
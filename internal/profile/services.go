@@ -0,0 +1,86 @@
+package profile
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Service is a companion container (a database, cache or mock API) started
+// alongside the project's glovebox container on a private network, where it
+// is reachable by its name.
+type Service struct {
+	Image   string            `yaml:"image"`
+	Env     map[string]string `yaml:"env,omitempty"`
+	Volumes []string          `yaml:"volumes,omitempty"` // container paths kept in named volumes
+	Command []string          `yaml:"command,omitempty"` // overrides the image's default command
+}
+
+// ServiceNames returns the profile's service names, sorted
+func (p *Profile) ServiceNames() []string {
+	names := make([]string, 0, len(p.Services))
+	for name := range p.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateServices checks that service names are usable as hostnames and
+// that every service names an image and absolute volume paths
+func (p *Profile) ValidateServices() error {
+	for _, name := range p.ServiceNames() {
+		if err := validateServiceName(name); err != nil {
+			return err
+		}
+		svc := p.Services[name]
+		if svc.Image == "" {
+			return fmt.Errorf("service %q has no image", name)
+		}
+		for _, v := range svc.Volumes {
+			if !path.IsAbs(v) {
+				return fmt.Errorf("service %q: volume %q must be an absolute container path", name, v)
+			}
+		}
+	}
+	return nil
+}
+
+// validateServiceName checks that a service name is a valid DNS label
+func validateServiceName(name string) error {
+	if name == "" || len(name) > 63 {
+		return fmt.Errorf("invalid service name %q: must be 1-63 characters", name)
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case r == '-' && i > 0 && i < len(name)-1:
+		default:
+			return fmt.Errorf("invalid service name %q: use lowercase letters, digits and '-'", name)
+		}
+	}
+	return nil
+}
+
+// ServiceContainerName returns the container name for a project's service,
+// derived from the project's glovebox container name
+func ServiceContainerName(containerName, service string) string {
+	return containerName + "-" + service
+}
+
+// ServiceVolumeName returns the named volume holding a service's data at
+// the given container path
+func ServiceVolumeName(containerName, service, target string) string {
+	slug := strings.Trim(strings.ReplaceAll(path.Clean(target), "/", "-"), "-")
+	if slug == "" {
+		slug = "root"
+	}
+	return ServiceContainerName(containerName, service) + "-" + slug
+}
+
+// NetworkName returns the private network shared by a project's glovebox
+// container and its services
+func NetworkName(containerName string) string {
+	return containerName
+}
@@ -3,9 +3,16 @@ package runtime
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
+	"os"
 	"os/exec"
-	"sort"
+	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
+
+	"github.com/joelhelbling/glovebox/internal/labels"
 )
 
 // Compile-time check that AppleRuntime implements Runtime.
@@ -23,9 +30,33 @@ func NewApple(io Stdio) *AppleRuntime {
 
 func (a *AppleRuntime) Name() string { return "Apple Containers" }
 
-func (a *AppleRuntime) ImageExists(name string) bool {
-	cmd := exec.Command("container", "image", "inspect", name)
-	return cmd.Run() == nil
+func (a *AppleRuntime) ImageExists(name string) (bool, error) {
+	_, err := appleInspect("image", "inspect", name)
+	return exists(err)
+}
+
+// appleInspect runs an inspect command. The CLI exits non-zero for missing
+// images, so failures are ErrNotFound unless the API server is down.
+func appleInspect(args ...string) ([]byte, error) {
+	var stderr strings.Builder
+	cmd := exec.Command("container", args...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err == nil {
+		return output, nil
+	}
+	msg := strings.TrimSpace(stderr.String())
+	if _, ok := err.(*exec.ExitError); !ok || appleDaemonDown(msg) {
+		return nil, fmt.Errorf("%w: %s", ErrDaemonUnavailable, strings.TrimSpace(msg+" "+err.Error()))
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, msg)
+}
+
+// appleDaemonDown reports whether CLI output says the container API server
+// isn't running (`container system start` hasn't been run)
+func appleDaemonDown(msg string) bool {
+	msg = strings.ToLower(msg)
+	return strings.Contains(msg, "xpc") || strings.Contains(msg, "apiserver") || strings.Contains(msg, "system start")
 }
 
 func (a *AppleRuntime) GetImageDigest(name string) (string, error) {
@@ -48,15 +79,36 @@ func (a *AppleRuntime) GetImageDigest(name string) (string, error) {
 	return images[0].Index.Digest, nil
 }
 
-func (a *AppleRuntime) BuildImage(dockerfilePath, contextDir, imageName string) error {
+// buildBuildArgs constructs the argument list for `container build`.
+func (a *AppleRuntime) buildBuildArgs(cfg BuildConfig) []string {
+	args := []string{"build", "-t", cfg.ImageName, "-f", cfg.DockerfilePath}
+	args = append(args, labelArgs(cfg.Labels)...)
+	args = append(args, buildArgArgs(cfg.BuildArgs)...)
+	for _, p := range cfg.Platforms {
+		args = append(args, "--platform", p)
+	}
+	if cfg.Progress != nil {
+		args = append(args, "--progress", "plain")
+	}
+	return append(args, cfg.ContextDir)
+}
+
+func (a *AppleRuntime) BuildImage(cfg BuildConfig) error {
+	if len(cfg.Secrets) > 0 {
+		return fmt.Errorf("build secrets: %w", ErrNotSupported)
+	}
+
 	// Ensure builder is running before building
 	if err := a.ensureBuilder(); err != nil {
 		return fmt.Errorf("failed to start builder: %w", err)
 	}
 
-	cmd := exec.Command("container", "build", "-t", imageName, "-f", dockerfilePath, contextDir)
+	cmd := exec.Command("container", a.buildBuildArgs(cfg)...)
 	cmd.Stdout = a.io.Stdout
 	cmd.Stderr = a.io.Stderr
+	if cfg.Progress != nil {
+		cmd.Stdout, cmd.Stderr = cfg.Progress, cfg.Progress
+	}
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("container build failed: %w", err)
 	}
@@ -72,7 +124,12 @@ type appleImageEntry struct {
 	Reference string `json:"reference"`
 }
 
-func (a *AppleRuntime) ListImages(filterRef string) ([]string, error) {
+func (a *AppleRuntime) ListImages(filter ListFilter) ([]string, error) {
+	// Apple Containers doesn't list untagged images
+	if filter.Dangling {
+		return nil, nil
+	}
+
 	cmd := exec.Command("container", "image", "ls", "--format", "json")
 	output, err := cmd.Output()
 	if err != nil {
@@ -88,40 +145,195 @@ func (a *AppleRuntime) ListImages(filterRef string) ([]string, error) {
 	for _, img := range allImages {
 		// Strip "docker.io/library/" prefix for matching against short names
 		name := stripDockerHubPrefix(img.Reference)
-		if matchesFilter(name, filterRef) {
-			images = append(images, name)
+		if !matchesFilter(name, filter.Name) {
+			continue
+		}
+		// The image list doesn't include labels; inspect only when filtering on them
+		if len(filter.Labels) > 0 && !labels.Matches(a.imageLabels(img.Reference), filter.Labels) {
+			continue
 		}
+		images = append(images, name)
 	}
 	return images, nil
 }
 
-func (a *AppleRuntime) ContainerExists(name string) bool {
-	cmd := exec.Command("container", "inspect", name)
-	output, err := cmd.Output()
+// imageLabels returns the labels of an image, or nil if it can't be inspected
+func (a *AppleRuntime) imageLabels(ref string) map[string]string {
+	info, err := a.InspectImage(ref)
 	if err != nil {
-		return false
+		return nil
+	}
+	return info.Labels
+}
+
+// ResolveImageDigest isn't supported: the container CLI can only inspect
+// images it has pulled
+func (a *AppleRuntime) ResolveImageDigest(ref string) (string, error) {
+	return "", fmt.Errorf("resolving %s: %w", ref, ErrNotSupported)
+}
+
+// SaveImage saves an image to a temporary archive with 'container image
+// save' and returns it, removing it when closed.
+func (a *AppleRuntime) SaveImage(name string) (io.ReadCloser, error) {
+	tmp, err := os.CreateTemp("", "glovebox-image-*.tar")
+	if err != nil {
+		return nil, fmt.Errorf("saving image %s: %w", name, err)
+	}
+	tmp.Close()
+	if err := runQuiet("container", "image", "save", "--output", tmp.Name(), name); err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("saving image %s: %w", name, err)
+	}
+	f, err := os.Open(tmp.Name())
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("saving image %s: %w", name, err)
+	}
+	return &tempArchive{File: f}, nil
+}
+
+// LoadImage copies an archive to a temporary file for 'container image
+// load', which reads only from files.
+func (a *AppleRuntime) LoadImage(archive io.Reader) error {
+	tmp, err := os.CreateTemp("", "glovebox-image-*.tar")
+	if err != nil {
+		return fmt.Errorf("loading image: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, archive)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("loading image: %w", err)
+	}
+	if err := runQuiet("container", "image", "load", "--input", tmp.Name()); err != nil {
+		return fmt.Errorf("loading image: %w", err)
+	}
+	return nil
+}
+
+// TagImage gives an image another name
+func (a *AppleRuntime) TagImage(source, target string) error {
+	if err := runQuiet("container", "image", "tag", source, target); err != nil {
+		return fmt.Errorf("tagging %s as %s: %w", source, target, err)
+	}
+	return nil
+}
+
+// PushImage pushes an image to its registry with the credentials of
+// 'container registry login'.
+func (a *AppleRuntime) PushImage(ref string) error {
+	return a.transfer("push", ref)
+}
+
+// PullImage pulls an image from its registry, see PushImage.
+func (a *AppleRuntime) PullImage(ref string) error {
+	return a.transfer("pull", ref)
+}
+
+func (a *AppleRuntime) transfer(action, ref string) error {
+	cmd := exec.Command("container", "image", action, ref)
+	cmd.Stdout = a.io.Stdout
+	cmd.Stderr = a.io.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("container image %s %s failed: %w", action, ref, err)
+	}
+	return nil
+}
+
+// tempArchive is a temporary file removed once read
+type tempArchive struct {
+	*os.File
+}
+
+func (t *tempArchive) Close() error {
+	err := t.File.Close()
+	os.Remove(t.File.Name())
+	return err
+}
+
+// InspectImage describes an image from its first variant (platform)
+func (a *AppleRuntime) InspectImage(name string) (ImageInfo, error) {
+	output, err := exec.Command("container", "image", "inspect", name).Output()
+	if err != nil {
+		return ImageInfo{}, fmt.Errorf("inspecting image %s: %w", name, err)
+	}
+	return parseAppleImage(name, output)
+}
+
+// parseAppleImage reads `container image inspect` output for one image
+func parseAppleImage(name string, output []byte) (ImageInfo, error) {
+	var images []struct {
+		Index struct {
+			Digest string `json:"digest"`
+		} `json:"index"`
+		Variants []struct {
+			Size     int64 `json:"size"`
+			Platform struct {
+				OS           string `json:"os"`
+				Architecture string `json:"architecture"`
+				Variant      string `json:"variant"`
+			} `json:"platform"`
+			Config struct {
+				Created time.Time `json:"created"`
+				Config  struct {
+					Labels map[string]string `json:"Labels"`
+				} `json:"config"`
+				RootFS struct {
+					DiffIDs []string `json:"diff_ids"`
+				} `json:"rootfs"`
+			} `json:"config"`
+		} `json:"variants"`
+	}
+	if err := json.Unmarshal(output, &images); err != nil {
+		return ImageInfo{}, fmt.Errorf("failed to parse image inspect output: %w", err)
+	}
+	if len(images) == 0 {
+		return ImageInfo{}, fmt.Errorf("no image found for %q", name)
+	}
+
+	info := ImageInfo{Name: stripDockerHubPrefix(name), ID: images[0].Index.Digest}
+	if len(images[0].Variants) > 0 {
+		v := images[0].Variants[0]
+		info.Size = v.Size
+		info.Created = v.Config.Created
+		info.Labels = v.Config.Config.Labels
+		info.Layers = v.Config.RootFS.DiffIDs
+	}
+	for _, v := range images[0].Variants {
+		if platform := formatPlatform(v.Platform.OS, v.Platform.Architecture, v.Platform.Variant); platform != "" {
+			info.Platforms = append(info.Platforms, platform)
+		}
+	}
+	return info, nil
+}
+
+func (a *AppleRuntime) ContainerExists(name string) (bool, error) {
+	output, err := appleInspect("inspect", name)
+	if ok, err := exists(err); !ok {
+		return false, err
 	}
 	// Apple Containers returns [] (empty array) with exit 0 for non-existent containers.
 	var results []json.RawMessage
 	if err := json.Unmarshal(output, &results); err != nil {
-		return false
+		return false, fmt.Errorf("failed to parse container inspect output: %w", err)
 	}
-	return len(results) > 0
+	return len(results) > 0, nil
 }
 
-func (a *AppleRuntime) ContainerRunning(name string) bool {
-	cmd := exec.Command("container", "inspect", name)
-	output, err := cmd.Output()
-	if err != nil {
-		return false
+func (a *AppleRuntime) ContainerRunning(name string) (bool, error) {
+	output, err := appleInspect("inspect", name)
+	if ok, err := exists(err); !ok {
+		return false, err
 	}
 	var containers []struct {
 		Status string `json:"status"`
 	}
 	if err := json.Unmarshal(output, &containers); err != nil {
-		return false
+		return false, fmt.Errorf("failed to parse container inspect output: %w", err)
 	}
-	return len(containers) > 0 && containers[0].Status == "running"
+	return len(containers) > 0 && containers[0].Status == "running", nil
 }
 
 // buildRunArgs constructs the argument list for `container run`.
@@ -134,19 +346,62 @@ func (a *AppleRuntime) buildRunArgs(cfg RunConfig) []string {
 	}
 	// Apple Containers has no --hostname flag; --name implicitly sets hostname.
 
-	keys := make([]string, 0, len(cfg.Env))
-	for k := range cfg.Env {
-		keys = append(keys, k)
+	if cfg.Platform != "" {
+		args = append(args, "--platform", cfg.Platform)
+	}
+
+	if cfg.Network != "" {
+		args = append(args, "--network", cfg.Network)
+	}
+
+	for _, p := range cfg.Ports {
+		args = append(args, "--publish", p.String())
+	}
+
+	args = append(args, labelArgs(cfg.Labels)...)
+
+	for _, m := range cfg.Mounts {
+		spec := fmt.Sprintf("type=bind,source=%s,target=%s", m.Source, m.Target)
+		if m.ReadOnly {
+			spec += ",readonly"
+		}
+		args = append(args, "--mount", spec)
 	}
-	sort.Strings(keys)
-	for _, key := range keys {
-		args = append(args, "-e", fmt.Sprintf("%s=%s", key, cfg.Env[key]))
+
+	// There's no --add-host; the entrypoint maps the alias to the gateway
+	env := cfg.Env
+	if cfg.HostAlias != "" {
+		env = make(map[string]string, len(cfg.Env)+1)
+		for k, v := range cfg.Env {
+			env[k] = v
+		}
+		env["GLOVEBOX_HOST_ALIAS"] = cfg.HostAlias
 	}
+	args = append(args, envArgs(env)...)
 
 	args = append(args, cfg.ImageName)
 	return args
 }
 
+func (a *AppleRuntime) ContainerLabels(name string) (map[string]string, error) {
+	output, err := exec.Command("container", "inspect", name).Output()
+	if err != nil {
+		return nil, fmt.Errorf("inspecting container %s: %w", name, err)
+	}
+	var containers []struct {
+		Configuration struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"configuration"`
+	}
+	if err := json.Unmarshal(output, &containers); err != nil {
+		return nil, fmt.Errorf("failed to parse container inspect output: %w", err)
+	}
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("no container found for %q", name)
+	}
+	return containers[0].Configuration.Labels, nil
+}
+
 func (a *AppleRuntime) RunInteractive(cfg RunConfig) error {
 	args := a.buildRunArgs(cfg)
 	cmd := exec.Command("container", args...)
@@ -156,6 +411,12 @@ func (a *AppleRuntime) RunInteractive(cfg RunConfig) error {
 	return a.normalizeExitError(cmd.Run())
 }
 
+// CreateInteractive isn't needed: Apple Containers always run locally, so
+// the workspace is bind-mounted rather than synced.
+func (a *AppleRuntime) CreateInteractive(cfg RunConfig) error {
+	return ErrNotSupported
+}
+
 func (a *AppleRuntime) StartInteractive(name string) error {
 	cmd := exec.Command("container", "start", "-a", "-i", name)
 	cmd.Stdin = a.io.Stdin
@@ -189,10 +450,12 @@ type appleContainerEntry struct {
 		Image struct {
 			Reference string `json:"reference"`
 		} `json:"image"`
+		Labels map[string]string `json:"labels"`
 	} `json:"configuration"`
+	Status string `json:"status"`
 }
 
-func (a *AppleRuntime) ListContainers(filterName string, all bool) ([]ContainerInfo, error) {
+func (a *AppleRuntime) ListContainers(filter ListFilter, all bool) ([]ContainerInfo, error) {
 	args := []string{"ls", "--format", "json"}
 	if all {
 		args = append(args, "-a")
@@ -212,19 +475,201 @@ func (a *AppleRuntime) ListContainers(filterName string, all bool) ([]ContainerI
 	var containers []ContainerInfo
 	for _, c := range allContainers {
 		name := c.Configuration.ID
-		image := c.Configuration.Image.Reference
-		if filterName == "" || strings.Contains(name, filterName) {
-			containers = append(containers, ContainerInfo{Name: name, Image: image})
+		if filter.Name != "" && !strings.Contains(name, filter.Name) {
+			continue
+		}
+		if !labels.Matches(c.Configuration.Labels, filter.Labels) {
+			continue
 		}
+		containers = append(containers, ContainerInfo{
+			Name:    name,
+			Image:   c.Configuration.Image.Reference,
+			Labels:  c.Configuration.Labels,
+			Running: c.Status == "running",
+		})
 	}
 	return containers, nil
 }
 
+// buildServiceArgs constructs the argument list for `container run` of a
+// detached service container. Apple Containers has no network aliases;
+// services are reachable by container name.
+func (a *AppleRuntime) buildServiceArgs(cfg ServiceConfig) []string {
+	args := []string{"run", "-d", "--name", cfg.ContainerName}
+	if cfg.Network != "" {
+		args = append(args, "--network", cfg.Network)
+	}
+	for _, v := range cfg.Volumes {
+		args = append(args, "-v", fmt.Sprintf("%s:%s", v.Name, v.Target))
+	}
+	args = append(args, labelArgs(cfg.Labels)...)
+	args = append(args, envArgs(cfg.Env)...)
+	args = append(args, cfg.ImageName)
+	return append(args, cfg.Command...)
+}
+
+func (a *AppleRuntime) RunDetached(cfg ServiceConfig) error {
+	for _, v := range cfg.Volumes {
+		// Unlike Docker, Apple Containers doesn't create named volumes on first use
+		if !a.volumeExists(v.Name) {
+			if err := runQuiet("container", "volume", "create", v.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return runQuiet("container", a.buildServiceArgs(cfg)...)
+}
+
+func (a *AppleRuntime) StartContainer(name string) error {
+	return runQuiet("container", "start", name)
+}
+
+func (a *AppleRuntime) StopContainer(name string) error {
+	return runQuiet("container", "stop", name)
+}
+
+func (a *AppleRuntime) NetworkExists(name string) (bool, error) {
+	names, err := a.listNames("network", name)
+	if err != nil {
+		return false, err
+	}
+	return contains(names, name), nil
+}
+
+func (a *AppleRuntime) CreateNetwork(name string) error {
+	return runQuiet("container", "network", "create", name)
+}
+
+func (a *AppleRuntime) RemoveNetwork(name string) error {
+	return runQuiet("container", "network", "delete", name)
+}
+
+func (a *AppleRuntime) ListNetworks(prefix string) ([]string, error) {
+	return a.listNames("network", prefix)
+}
+
+func (a *AppleRuntime) RemoveVolume(name string) error {
+	return runQuiet("container", "volume", "delete", name)
+}
+
+func (a *AppleRuntime) ListVolumes(prefix string) ([]string, error) {
+	return a.listNames("volume", prefix)
+}
+
+// appleDataRoot is where Apple Containers keeps container and volume storage
+func appleDataRoot() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "Library", "Application Support", "com.apple.container")
+}
+
+// DiskUsage measures container and volume storage on disk, since Apple
+// Containers doesn't report it. A container's root filesystem starts as a
+// clone of its image, so its size includes the image.
+func (a *AppleRuntime) DiskUsage() (DiskUsage, error) {
+	containers, err := a.ListContainers(ListFilter{}, true)
+	if err != nil {
+		return DiskUsage{}, fmt.Errorf("listing containers: %w", err)
+	}
+	volumes, err := a.ListVolumes("")
+	if err != nil {
+		return DiskUsage{}, fmt.Errorf("listing volumes: %w", err)
+	}
+
+	root := appleDataRoot()
+	usage := DiskUsage{Containers: make(map[string]int64), Volumes: make(map[string]int64)}
+	for _, c := range containers {
+		usage.Containers[c.Name] = allocatedSize(filepath.Join(root, "containers", c.Name))
+	}
+	for _, v := range volumes {
+		usage.Volumes[v] = allocatedSize(filepath.Join(root, "volumes", v))
+	}
+	return usage, nil
+}
+
+// allocatedSize returns the disk space allocated to the files under path,
+// which for sparse disk images is less than their apparent size
+func allocatedSize(path string) int64 {
+	var total int64
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if st, ok := info.Sys().(*syscall.Stat_t); ok {
+			total += st.Blocks * 512
+		} else {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+func (a *AppleRuntime) volumeExists(name string) bool {
+	names, err := a.listNames("volume", name)
+	return err == nil && contains(names, name)
+}
+
+// appleNamedEntry is an entry from `container network|volume list --format json`.
+// Networks report an id, volumes a name.
+type appleNamedEntry struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// listNames lists networks or volumes whose names start with prefix.
+func (a *AppleRuntime) listNames(kind, prefix string) ([]string, error) {
+	output, err := exec.Command("container", kind, "list", "--format", "json").Output()
+	if err != nil {
+		return nil, err
+	}
+	var entries []appleNamedEntry
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s list: %w", kind, err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		name := e.Name
+		if name == "" {
+			name = e.ID
+		}
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *AppleRuntime) Diff(name string) ([]FileDiff, error) {
 	return nil, ErrNotSupported
 }
 
-func (a *AppleRuntime) Commit(containerName, imageName string) error {
+func (a *AppleRuntime) Commit(containerName, imageName string, imageLabels map[string]string) error {
+	return ErrNotSupported
+}
+
+func (a *AppleRuntime) CopyToContainer(name, dir string, archive io.Reader) error {
+	return ErrNotSupported
+}
+
+func (a *AppleRuntime) CopyFromContainer(name, path string) (io.ReadCloser, error) {
+	return nil, ErrNotSupported
+}
+
+func (a *AppleRuntime) ClearDirectory(name, dir string, helperLabels map[string]string) error {
 	return ErrNotSupported
 }
 
@@ -3,6 +3,7 @@ package runtime
 import (
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestAppleRuntime_Name(t *testing.T) {
@@ -47,6 +48,21 @@ func TestAppleRuntime_buildRunArgs(t *testing.T) {
 		}
 	})
 
+	t.Run("extra mounts", func(t *testing.T) {
+		args := rt.buildRunArgs(RunConfig{
+			ContainerName: "test",
+			ImageName:     "test:latest",
+			HostPath:      "/path",
+			WorkspacePath: "/workspace",
+			Mounts:        []Mount{{Source: "/home/me/dotfiles", Target: "/home/dev/.dotfiles", ReadOnly: true}},
+		})
+
+		argsStr := strings.Join(args, " ")
+		if !strings.Contains(argsStr, "--mount type=bind,source=/home/me/dotfiles,target=/home/dev/.dotfiles,readonly") {
+			t.Errorf("expected read-only bind mount in args, got: %s", argsStr)
+		}
+	})
+
 	t.Run("env vars sorted deterministically", func(t *testing.T) {
 		args := rt.buildRunArgs(RunConfig{
 			ContainerName: "test",
@@ -71,6 +87,31 @@ func TestAppleRuntime_buildRunArgs(t *testing.T) {
 			t.Error("env vars should be sorted: AAA before ZZZ")
 		}
 	})
+	t.Run("ports and host alias", func(t *testing.T) {
+		args := rt.buildRunArgs(RunConfig{
+			ContainerName: "test",
+			ImageName:     "test:latest",
+			HostPath:      "/path",
+			WorkspacePath: "/workspace",
+			Env:           map[string]string{"FOO": "bar"},
+			Ports:         []PortMapping{{HostPort: 5173, ContainerPort: 5173}},
+			HostAlias:     "host.glovebox.internal",
+		})
+
+		argsStr := strings.Join(args, " ")
+		for _, want := range []string{
+			"--publish 5173:5173",
+			"-e FOO=bar",
+			"-e GLOVEBOX_HOST_ALIAS=host.glovebox.internal",
+		} {
+			if !strings.Contains(argsStr, want) {
+				t.Errorf("expected %q in args, got: %s", want, argsStr)
+			}
+		}
+		if strings.Contains(argsStr, "--add-host") {
+			t.Error("Apple Containers has no --add-host flag")
+		}
+	})
 }
 
 func TestAppleRuntime_Capabilities(t *testing.T) {
@@ -98,7 +139,7 @@ func TestAppleRuntime_Diff_returnsErrNotSupported(t *testing.T) {
 
 func TestAppleRuntime_Commit_returnsErrNotSupported(t *testing.T) {
 	rt := NewApple(Stdio{})
-	err := rt.Commit("any-container", "any-image")
+	err := rt.Commit("any-container", "any-image", nil)
 	if err != ErrNotSupported {
 		t.Errorf("expected ErrNotSupported, got %v", err)
 	}
@@ -138,3 +179,56 @@ func TestMatchesFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestAppleRuntime_buildServiceArgs(t *testing.T) {
+	rt := NewApple(Stdio{})
+
+	args := rt.buildServiceArgs(ServiceConfig{
+		ContainerName: "glovebox-app-1234567-redis",
+		ImageName:     "redis:7",
+		Network:       "glovebox-app-1234567",
+		Alias:         "redis",
+		Volumes:       []VolumeMount{{Name: "glovebox-app-1234567-redis-data", Target: "/data"}},
+	})
+
+	argsStr := strings.Join(args, " ")
+	for _, want := range []string{
+		"run -d --name glovebox-app-1234567-redis",
+		"--network glovebox-app-1234567",
+		"-v glovebox-app-1234567-redis-data:/data",
+	} {
+		if !strings.Contains(argsStr, want) {
+			t.Errorf("expected %q in args, got: %s", want, argsStr)
+		}
+	}
+	if strings.Contains(argsStr, "--network-alias") {
+		t.Error("Apple Containers has no --network-alias flag")
+	}
+	if args[len(args)-1] != "redis:7" {
+		t.Errorf("expected image as last arg, got %q", args[len(args)-1])
+	}
+}
+
+func TestParseAppleImage(t *testing.T) {
+	output := []byte(`[{"index": {"digest": "sha256:abc"}, "variants": [
+		{"size": 2048, "platform": {"os": "linux", "architecture": "arm64"}, "config": {"created": "2026-02-01T10:00:00Z", "config": {"Labels": {"glovebox.role": "base"}}}},
+		{"size": 2100, "platform": {"os": "linux", "architecture": "amd64"}, "config": {"created": "2026-02-01T10:00:00Z"}}
+	]}]`)
+
+	img, err := parseAppleImage("docker.io/library/glovebox:base", output)
+	if err != nil {
+		t.Fatalf("parseAppleImage() error = %v", err)
+	}
+	if img.Name != "glovebox:base" || img.ID != "sha256:abc" || img.Size != 2048 {
+		t.Errorf("parseAppleImage() = %+v", img)
+	}
+	if !img.Created.Equal(time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Created = %v", img.Created)
+	}
+	if img.Labels["glovebox.role"] != "base" {
+		t.Errorf("Labels = %v", img.Labels)
+	}
+	if strings.Join(img.Platforms, ",") != "linux/arm64,linux/amd64" {
+		t.Errorf("Platforms = %v", img.Platforms)
+	}
+}
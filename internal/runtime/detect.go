@@ -16,21 +16,30 @@ type DetectResult struct {
 
 // Detect selects the best available container runtime.
 // If override is non-empty, that specific runtime is required.
+// A host (the runtime_host setting) selects a Docker daemon, usually a
+// remote one, and requires Docker.
 // Otherwise, prefers Apple Containers, falls back to Docker.
-func Detect(override string, io Stdio) (DetectResult, error) {
+func Detect(override, host string, io Stdio) (DetectResult, error) {
+	if host != "" {
+		if override != "" && override != "docker" {
+			return DetectResult{}, fmt.Errorf("runtime_host %q requires the docker runtime", host)
+		}
+		override = "docker"
+	}
 	if override != "" {
-		return detectOverride(override, io)
+		return detectOverride(override, host, io)
 	}
 	return detectAuto(io)
 }
 
-func detectOverride(name string, io Stdio) (DetectResult, error) {
+func detectOverride(name, host string, io Stdio) (DetectResult, error) {
 	switch name {
 	case "docker":
-		if !dockerAvailable() {
-			return DetectResult{}, fmt.Errorf("Docker not available: ensure 'docker' is installed and the daemon is running")
+		rt := NewDockerHost(io, host)
+		if err := dockerAvailable(rt); err != nil {
+			return DetectResult{}, fmt.Errorf("Docker not available: %w", err)
 		}
-		return DetectResult{Runtime: NewDocker(io)}, nil
+		return DetectResult{Runtime: rt}, nil
 	case "apple":
 		if !appleAvailable() {
 			return DetectResult{}, fmt.Errorf("Apple Containers not available: install with 'brew install --cask container'")
@@ -51,8 +60,8 @@ func detectAuto(io Stdio) (DetectResult, error) {
 		return DetectResult{Runtime: NewApple(io)}, nil
 	}
 
-	if dockerAvailable() {
-		result := DetectResult{Runtime: NewDocker(io)}
+	if rt := NewDocker(io); dockerAvailable(rt) == nil {
+		result := DetectResult{Runtime: rt}
 		// If we're on macOS, let the user know about Apple Containers
 		if isMacOS() {
 			result.FellBack = true
@@ -81,13 +90,16 @@ func appleAvailable() bool {
 	return strings.Contains(strings.ToLower(string(out)), "container")
 }
 
-// dockerAvailable checks if the docker CLI exists and the daemon is responsive.
-func dockerAvailable() bool {
+// dockerAvailable checks if the docker CLI exists (builds and interactive
+// sessions use it) and the daemon is responsive.
+func dockerAvailable(rt *DockerRuntime) error {
 	if _, err := exec.LookPath("docker"); err != nil {
-		return false
+		return fmt.Errorf("ensure 'docker' is installed")
+	}
+	if err := rt.Ping(); err != nil {
+		return fmt.Errorf("ensure the daemon is running: %w", err)
 	}
-	// Verify daemon is running
-	return exec.Command("docker", "info").Run() == nil
+	return nil
 }
 
 // isMacOS reports whether the current OS is macOS.
@@ -1,12 +1,13 @@
 package runtime
 
 import (
+	"strings"
 	"testing"
 )
 
 func TestDetect_withOverride(t *testing.T) {
 	t.Run("docker override", func(t *testing.T) {
-		result, err := Detect("docker", Stdio{})
+		result, err := Detect("docker", "", Stdio{})
 		if err != nil {
 			t.Skipf("Docker not available: %v", err)
 		}
@@ -19,7 +20,7 @@ func TestDetect_withOverride(t *testing.T) {
 	})
 
 	t.Run("apple override", func(t *testing.T) {
-		result, err := Detect("apple", Stdio{})
+		result, err := Detect("apple", "", Stdio{})
 		if err != nil {
 			t.Skipf("Apple Containers not available: %v", err)
 		}
@@ -31,8 +32,15 @@ func TestDetect_withOverride(t *testing.T) {
 		}
 	})
 
+	t.Run("runtime host requires docker", func(t *testing.T) {
+		_, err := Detect("apple", "ssh://me@buildbox", Stdio{})
+		if err == nil || !strings.Contains(err.Error(), "requires the docker runtime") {
+			t.Errorf("error = %v", err)
+		}
+	})
+
 	t.Run("unknown override errors", func(t *testing.T) {
-		_, err := Detect("nonexistent-runtime", Stdio{})
+		_, err := Detect("nonexistent-runtime", "", Stdio{})
 		if err == nil {
 			t.Error("expected error for unknown runtime")
 		}
@@ -40,7 +48,7 @@ func TestDetect_withOverride(t *testing.T) {
 }
 
 func TestDetect_autoDetection(t *testing.T) {
-	result, err := Detect("", Stdio{})
+	result, err := Detect("", "", Stdio{})
 	if err != nil {
 		t.Skipf("No runtime available in test environment: %v", err)
 	}
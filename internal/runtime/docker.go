@@ -1,45 +1,122 @@
 package runtime
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os/exec"
+	"path"
+	"slices"
 	"sort"
 	"strings"
+	"time"
+
+	"github.com/joelhelbling/glovebox/internal/labels"
 )
 
 // Compile-time check that DockerRuntime implements Runtime.
 var _ Runtime = (*DockerRuntime)(nil)
 
-// DockerRuntime implements Runtime using the Docker CLI.
+// DockerRuntime implements Runtime using the Docker Engine API. Builds and
+// interactive sessions, which stream a terminal, go through the docker CLI.
 type DockerRuntime struct {
-	io Stdio
+	io     Stdio
+	api    *dockerClient
+	flags  []string // docker CLI flags selecting the endpoint
+	remote bool
 }
 
-// NewDocker creates a Docker runtime with the given I/O streams.
+// NewDocker creates a Docker runtime with the given I/O streams, talking to
+// the daemon the docker CLI would use (DOCKER_HOST or the current context).
 func NewDocker(io Stdio) *DockerRuntime {
-	return &DockerRuntime{io: io}
+	return NewDockerHost(io, "")
+}
+
+// NewDockerHost creates a Docker runtime for a configured host: a daemon URL
+// (e.g. ssh://me@buildbox) or a docker context name. DOCKER_HOST and
+// DOCKER_CONTEXT still take precedence; an empty host is NewDocker.
+func NewDockerHost(io Stdio, host string) *DockerRuntime {
+	ep, err := resolveDockerEndpoint(host)
+	if err != nil {
+		return &DockerRuntime{io: io, api: &dockerClient{err: err}}
+	}
+	return &DockerRuntime{io: io, api: newDockerClient(ep), flags: ep.Flags, remote: ep.remote()}
+}
+
+// command returns a docker CLI command against the runtime's daemon
+func (d *DockerRuntime) command(args ...string) *exec.Cmd {
+	return exec.Command("docker", append(slices.Clone(d.flags), args...)...)
 }
 
 func (d *DockerRuntime) Name() string { return "Docker" }
 
-func (d *DockerRuntime) ImageExists(name string) bool {
-	cmd := exec.Command("docker", "image", "inspect", name)
-	return cmd.Run() == nil
+// Ping checks that the daemon is reachable.
+func (d *DockerRuntime) Ping() error {
+	return d.api.do("GET", "/_ping", nil, nil, nil)
+}
+
+// dockerImage is the API's image inspect response
+type dockerImage struct {
+	ID      string    `json:"Id"`
+	Size    int64     `json:"Size"`
+	Created time.Time `json:"Created"`
+	Config  struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+	RootFS struct {
+		Layers []string `json:"Layers"`
+	} `json:"RootFS"`
+	Os           string `json:"Os"`
+	Architecture string `json:"Architecture"`
+	Variant      string `json:"Variant"`
+}
+
+func (d *DockerRuntime) inspectImage(name string) (dockerImage, error) {
+	var img dockerImage
+	if err := d.api.do("GET", "/images/"+name+"/json", nil, nil, &img); err != nil {
+		return dockerImage{}, fmt.Errorf("inspecting image %s: %w", name, err)
+	}
+	return img, nil
+}
+
+func (d *DockerRuntime) ImageExists(name string) (bool, error) {
+	_, err := d.inspectImage(name)
+	return exists(err)
 }
 
 func (d *DockerRuntime) GetImageDigest(name string) (string, error) {
-	cmd := exec.Command("docker", "image", "inspect", "--format", "{{.Id}}", name)
-	output, err := cmd.Output()
+	img, err := d.inspectImage(name)
 	if err != nil {
 		return "", err
 	}
-	return strings.TrimSpace(string(output)), nil
+	return img.ID, nil
 }
 
-func (d *DockerRuntime) BuildImage(dockerfilePath, contextDir, imageName string) error {
-	cmd := exec.Command("docker", "build", "-t", imageName, "-f", dockerfilePath, contextDir)
+// buildBuildArgs constructs the argument list for `docker build`.
+func (d *DockerRuntime) buildBuildArgs(cfg BuildConfig) []string {
+	args := []string{"build", "-t", cfg.ImageName, "-f", cfg.DockerfilePath}
+	args = append(args, labelArgs(cfg.Labels)...)
+	args = append(args, buildArgArgs(cfg.BuildArgs)...)
+	if len(cfg.Platforms) > 0 {
+		args = append(args, "--platform", strings.Join(cfg.Platforms, ","))
+	}
+	for _, s := range cfg.Secrets {
+		args = append(args, "--secret", s.arg())
+	}
+	if cfg.Progress != nil {
+		args = append(args, "--progress=plain")
+	}
+	return append(args, cfg.ContextDir)
+}
+
+func (d *DockerRuntime) BuildImage(cfg BuildConfig) error {
+	cmd := d.command(d.buildBuildArgs(cfg)...)
 	cmd.Stdout = d.io.Stdout
 	cmd.Stderr = d.io.Stderr
+	if cfg.Progress != nil {
+		cmd.Stdout, cmd.Stderr = cfg.Progress, cfg.Progress
+	}
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("docker build failed: %w", err)
 	}
@@ -47,44 +124,199 @@ func (d *DockerRuntime) BuildImage(dockerfilePath, contextDir, imageName string)
 }
 
 func (d *DockerRuntime) RemoveImage(name string) error {
-	return exec.Command("docker", "rmi", name).Run()
+	if err := d.api.do("DELETE", "/images/"+name, nil, nil, nil); err != nil {
+		return fmt.Errorf("removing image %s: %w", name, err)
+	}
+	return nil
 }
 
-func (d *DockerRuntime) ListImages(filterRef string) ([]string, error) {
-	cmd := exec.Command("docker", "images", "--filter", fmt.Sprintf("reference=%s", filterRef), "--format", "{{.Repository}}:{{.Tag}}")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, err
+func (d *DockerRuntime) ListImages(filter ListFilter) ([]string, error) {
+	filters := map[string][]string{}
+	if filter.Name != "" {
+		filters["reference"] = []string{filter.Name}
+	}
+	if len(filter.Labels) > 0 {
+		filters["label"] = labels.Filters(filter.Labels)
+	}
+	if filter.Dangling {
+		filters["dangling"] = []string{"true"}
+	}
+
+	var listed []struct {
+		ID       string   `json:"Id"`
+		RepoTags []string `json:"RepoTags"`
+	}
+	if err := d.api.do("GET", "/images/json", apiFilters(filters), nil, &listed); err != nil {
+		return nil, fmt.Errorf("listing images: %w", err)
 	}
+
 	var images []string
-	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
-		if line != "" {
-			images = append(images, line)
+	for _, img := range listed {
+		if filter.Dangling {
+			images = append(images, img.ID)
+			continue
+		}
+		for _, tag := range img.RepoTags {
+			if tag == "<none>:<none>" {
+				continue
+			}
+			// Images match the reference filter by any tag; list only the matching ones
+			if filter.Name != "" {
+				if ok, _ := path.Match(filter.Name, tag); !ok {
+					continue
+				}
+			}
+			images = append(images, tag)
 		}
 	}
+	sort.Strings(images)
 	return images, nil
 }
 
-func (d *DockerRuntime) ContainerExists(name string) bool {
-	cmd := exec.Command("docker", "container", "inspect", name)
-	return cmd.Run() == nil
+func (d *DockerRuntime) InspectImage(name string) (ImageInfo, error) {
+	img, err := d.inspectImage(name)
+	if err != nil {
+		return ImageInfo{}, err
+	}
+	info := ImageInfo{Name: name, ID: img.ID, Size: img.Size, Created: img.Created, Labels: img.Config.Labels, Layers: img.RootFS.Layers}
+	if platform := formatPlatform(img.Os, img.Architecture, img.Variant); platform != "" {
+		info.Platforms = []string{platform}
+	}
+	return info, nil
+}
+
+// ResolveImageDigest asks the daemon's registry for the manifest a tag
+// points to, with the daemon's registry credentials. For multi-platform
+// images this is the digest of the image index.
+func (d *DockerRuntime) ResolveImageDigest(ref string) (string, error) {
+	var dist struct {
+		Descriptor struct {
+			Digest string `json:"digest"`
+		} `json:"Descriptor"`
+	}
+	if err := d.api.do("GET", "/distribution/"+ref+"/json", nil, nil, &dist); err != nil {
+		return "", fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	if dist.Descriptor.Digest == "" {
+		return "", fmt.Errorf("resolving %s: registry returned no digest", ref)
+	}
+	return dist.Descriptor.Digest, nil
 }
 
-func (d *DockerRuntime) ContainerRunning(name string) bool {
-	cmd := exec.Command("docker", "container", "inspect", "-f", "{{.State.Running}}", name)
-	output, err := cmd.Output()
+// SaveImage returns a tar archive of an image, in the format 'docker save'
+// writes.
+func (d *DockerRuntime) SaveImage(name string) (io.ReadCloser, error) {
+	resp, err := d.api.request("GET", "/images/"+name+"/get", nil, nil)
 	if err != nil {
-		return false
+		return nil, fmt.Errorf("saving image %s: %w", name, err)
 	}
-	return strings.TrimSpace(string(output)) == "true"
+	return resp.Body, nil
+}
+
+// LoadImage loads the images of an archive SaveImage returned, tagged as
+// they were saved.
+func (d *DockerRuntime) LoadImage(archive io.Reader) error {
+	if err := d.api.stream("POST", "/images/load", url.Values{"quiet": {"1"}}, archive); err != nil {
+		return fmt.Errorf("loading image: %w", err)
+	}
+	return nil
+}
+
+// TagImage gives an image another name
+func (d *DockerRuntime) TagImage(source, target string) error {
+	repo, tag := splitImageRef(target)
+	query := url.Values{"repo": {repo}}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	if err := d.api.do("POST", "/images/"+source+"/tag", query, nil, nil); err != nil {
+		return fmt.Errorf("tagging %s as %s: %w", source, target, err)
+	}
+	return nil
+}
+
+// PushImage pushes an image to its registry. Pushes and pulls go through the
+// docker CLI, which has the user's registry credentials and shows progress.
+func (d *DockerRuntime) PushImage(ref string) error {
+	return d.transfer("push", ref)
+}
+
+// PullImage pulls an image from its registry, see PushImage.
+func (d *DockerRuntime) PullImage(ref string) error {
+	return d.transfer("pull", ref)
+}
+
+func (d *DockerRuntime) transfer(action, ref string) error {
+	cmd := d.command(action, ref)
+	cmd.Stdout = d.io.Stdout
+	cmd.Stderr = d.io.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("docker %s %s failed: %w", action, ref, err)
+	}
+	return nil
+}
+
+// dockerContainer is the API's container inspect response
+type dockerContainer struct {
+	Name    string    `json:"Name"`
+	Image   string    `json:"Image"`
+	Created time.Time `json:"Created"`
+	State   struct {
+		Running    bool      `json:"Running"`
+		StartedAt  time.Time `json:"StartedAt"`
+		FinishedAt time.Time `json:"FinishedAt"`
+	} `json:"State"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+}
+
+func (c dockerContainer) info() ContainerInfo {
+	return ContainerInfo{
+		Name:       strings.TrimPrefix(c.Name, "/"),
+		Image:      c.Config.Image,
+		ImageID:    c.Image,
+		Labels:     c.Config.Labels,
+		Running:    c.State.Running,
+		Created:    c.Created,
+		StartedAt:  c.State.StartedAt,
+		FinishedAt: c.State.FinishedAt,
+	}
+}
+
+func (d *DockerRuntime) inspectContainer(name string) (dockerContainer, error) {
+	var c dockerContainer
+	if err := d.api.do("GET", "/containers/"+name+"/json", nil, nil, &c); err != nil {
+		return dockerContainer{}, fmt.Errorf("inspecting container %s: %w", name, err)
+	}
+	return c, nil
+}
+
+func (d *DockerRuntime) ContainerExists(name string) (bool, error) {
+	_, err := d.inspectContainer(name)
+	return exists(err)
+}
+
+func (d *DockerRuntime) ContainerRunning(name string) (bool, error) {
+	c, err := d.inspectContainer(name)
+	if ok, err := exists(err); !ok {
+		return false, err
+	}
+	return c.State.Running, nil
 }
 
 // buildRunArgs constructs the argument list for `docker run`.
 func (d *DockerRuntime) buildRunArgs(cfg RunConfig) []string {
+	workspace := fmt.Sprintf("%s:%s", cfg.HostPath, cfg.WorkspacePath)
+	if cfg.WorkspaceVolume {
+		// An anonymous volume, removed with the container
+		workspace = cfg.WorkspacePath
+	}
 	args := []string{
 		"run", "-it",
 		"--name", cfg.ContainerName,
-		"-v", fmt.Sprintf("%s:%s", cfg.HostPath, cfg.WorkspacePath),
+		"-v", workspace,
 		"-w", cfg.WorkspacePath,
 	}
 
@@ -92,6 +324,32 @@ func (d *DockerRuntime) buildRunArgs(cfg RunConfig) []string {
 		args = append(args, "--hostname", cfg.Hostname)
 	}
 
+	if cfg.Platform != "" {
+		args = append(args, "--platform", cfg.Platform)
+	}
+
+	if cfg.Network != "" {
+		args = append(args, "--network", cfg.Network)
+	}
+
+	if cfg.HostAlias != "" {
+		args = append(args, "--add-host", cfg.HostAlias+":host-gateway")
+	}
+
+	for _, p := range cfg.Ports {
+		args = append(args, "-p", p.String())
+	}
+
+	args = append(args, labelArgs(cfg.Labels)...)
+
+	for _, m := range cfg.Mounts {
+		spec := fmt.Sprintf("%s:%s", m.Source, m.Target)
+		if m.ReadOnly {
+			spec += ":ro"
+		}
+		args = append(args, "-v", spec)
+	}
+
 	keys := make([]string, 0, len(cfg.Env))
 	for k := range cfg.Env {
 		keys = append(keys, k)
@@ -105,17 +363,38 @@ func (d *DockerRuntime) buildRunArgs(cfg RunConfig) []string {
 	return args
 }
 
+func (d *DockerRuntime) ContainerLabels(name string) (map[string]string, error) {
+	c, err := d.inspectContainer(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Config.Labels, nil
+}
+
 func (d *DockerRuntime) RunInteractive(cfg RunConfig) error {
 	args := d.buildRunArgs(cfg)
-	cmd := exec.Command("docker", args...)
+	cmd := d.command(args...)
 	cmd.Stdin = d.io.Stdin
 	cmd.Stdout = d.io.Stdout
 	cmd.Stderr = d.io.Stderr
 	return d.normalizeExitError(cmd.Run())
 }
 
+// CreateInteractive creates the container `docker run` would, without
+// starting it.
+func (d *DockerRuntime) CreateInteractive(cfg RunConfig) error {
+	args := d.buildRunArgs(cfg)
+	args[0] = "create"
+	cmd := d.command(args...)
+	cmd.Stderr = d.io.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("docker create failed: %w", err)
+	}
+	return nil
+}
+
 func (d *DockerRuntime) StartInteractive(name string) error {
-	cmd := exec.Command("docker", "start", "-ai", name)
+	cmd := d.command("start", "-ai", name)
 	cmd.Stdin = d.io.Stdin
 	cmd.Stdout = d.io.Stdout
 	cmd.Stderr = d.io.Stderr
@@ -123,7 +402,7 @@ func (d *DockerRuntime) StartInteractive(name string) error {
 }
 
 func (d *DockerRuntime) Attach(name string) error {
-	cmd := exec.Command("docker", "attach", name)
+	cmd := d.command("attach", name)
 	cmd.Stdin = d.io.Stdin
 	cmd.Stdout = d.io.Stdout
 	cmd.Stderr = d.io.Stderr
@@ -131,64 +410,350 @@ func (d *DockerRuntime) Attach(name string) error {
 }
 
 func (d *DockerRuntime) RemoveContainer(name string) error {
-	return exec.Command("docker", "container", "rm", name).Run()
+	// v removes anonymous volumes, such as a synced workspace
+	if err := d.api.do("DELETE", "/containers/"+name, url.Values{"v": {"1"}}, nil, nil); err != nil {
+		return fmt.Errorf("removing container %s: %w", name, err)
+	}
+	return nil
 }
 
 func (d *DockerRuntime) ForceRemoveContainer(name string) error {
-	return exec.Command("docker", "container", "rm", "-f", name).Run()
+	if err := d.api.do("DELETE", "/containers/"+name, url.Values{"force": {"1"}, "v": {"1"}}, nil, nil); err != nil {
+		return fmt.Errorf("removing container %s: %w", name, err)
+	}
+	return nil
 }
 
-func (d *DockerRuntime) ListContainers(filterName string, all bool) ([]ContainerInfo, error) {
-	args := []string{"container", "ls"}
-	if all {
-		args = append(args, "-a")
+func (d *DockerRuntime) ListContainers(filter ListFilter, all bool) ([]ContainerInfo, error) {
+	filters := map[string][]string{}
+	if filter.Name != "" {
+		filters["name"] = []string{filter.Name}
+	}
+	if len(filter.Labels) > 0 {
+		filters["label"] = labels.Filters(filter.Labels)
 	}
-	if filterName != "" {
-		args = append(args, "--filter", fmt.Sprintf("name=%s", filterName))
+	query := apiFilters(filters)
+	if all {
+		query.Set("all", "1")
 	}
-	args = append(args, "--format", "{{.Names}}\t{{.Image}}")
 
-	cmd := exec.Command("docker", args...)
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, err
+	var listed []struct {
+		ID      string            `json:"Id"`
+		Names   []string          `json:"Names"`
+		Image   string            `json:"Image"`
+		ImageID string            `json:"ImageID"`
+		Created int64             `json:"Created"`
+		State   string            `json:"State"`
+		Labels  map[string]string `json:"Labels"`
+	}
+	if err := d.api.do("GET", "/containers/json", query, nil, &listed); err != nil {
+		return nil, fmt.Errorf("listing containers: %w", err)
 	}
 
 	var containers []ContainerInfo
-	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
-		if line == "" {
+	for _, l := range listed {
+		// The listing gives the image ID instead of the name the container
+		// was created from once that name moved to another image
+		byID := strings.HasPrefix(strings.TrimPrefix(l.ImageID, "sha256:"), strings.TrimPrefix(l.Image, "sha256:"))
+		if !filter.Times && len(l.Names) > 0 && l.State != "" && l.ImageID != "" && !byID {
+			containers = append(containers, ContainerInfo{
+				Name:    strings.TrimPrefix(l.Names[0], "/"),
+				Image:   l.Image,
+				ImageID: l.ImageID,
+				Labels:  l.Labels,
+				Running: l.State == "running",
+				Created: time.Unix(l.Created, 0),
+			})
 			continue
 		}
-		parts := strings.SplitN(line, "\t", 2)
-		if len(parts) == 2 {
-			containers = append(containers, ContainerInfo{Name: parts[0], Image: parts[1]})
+
+		// Inspect for what the listing lacks
+		c, err := d.inspectContainer(l.ID)
+		if ok, err := exists(err); err != nil {
+			return nil, err
+		} else if !ok {
+			continue // removed since listing
 		}
+		containers = append(containers, c.info())
 	}
 	return containers, nil
 }
 
-func (d *DockerRuntime) Diff(name string) ([]FileDiff, error) {
-	cmd := exec.Command("docker", "diff", name)
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, err
+// dockerCreateRequest is the API's container create request body
+type dockerCreateRequest struct {
+	Image      string            `json:"Image"`
+	Cmd        []string          `json:"Cmd,omitempty"`
+	Entrypoint []string          `json:"Entrypoint,omitempty"`
+	User       string            `json:"User,omitempty"`
+	Env        []string          `json:"Env,omitempty"`
+	Labels     map[string]string `json:"Labels,omitempty"`
+	HostConfig struct {
+		Binds       []string `json:"Binds,omitempty"`
+		VolumesFrom []string `json:"VolumesFrom,omitempty"`
+		NetworkMode string   `json:"NetworkMode,omitempty"`
+	} `json:"HostConfig"`
+	NetworkingConfig struct {
+		EndpointsConfig map[string]dockerEndpointSettings `json:"EndpointsConfig,omitempty"`
+	} `json:"NetworkingConfig"`
+}
+
+type dockerEndpointSettings struct {
+	Aliases []string `json:"Aliases,omitempty"`
+}
+
+// serviceCreateRequest builds the create request for a detached service
+// container.
+func serviceCreateRequest(cfg ServiceConfig) dockerCreateRequest {
+	req := dockerCreateRequest{Image: cfg.ImageName, Cmd: cfg.Command, Labels: cfg.Labels}
+
+	keys := make([]string, 0, len(cfg.Env))
+	for k := range cfg.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		req.Env = append(req.Env, fmt.Sprintf("%s=%s", key, cfg.Env[key]))
 	}
 
-	var diffs []FileDiff
-	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
-		if line == "" {
-			continue
+	for _, v := range cfg.Volumes {
+		req.HostConfig.Binds = append(req.HostConfig.Binds, fmt.Sprintf("%s:%s", v.Name, v.Target))
+	}
+	if cfg.Network != "" {
+		req.HostConfig.NetworkMode = cfg.Network
+		if cfg.Alias != "" {
+			req.NetworkingConfig.EndpointsConfig = map[string]dockerEndpointSettings{
+				cfg.Network: {Aliases: []string{cfg.Alias}},
+			}
 		}
-		parts := strings.SplitN(line, " ", 2)
-		if len(parts) == 2 {
-			diffs = append(diffs, FileDiff{ChangeType: parts[0], Path: parts[1]})
+	}
+	return req
+}
+
+func (d *DockerRuntime) RunDetached(cfg ServiceConfig) error {
+	req := serviceCreateRequest(cfg)
+	query := url.Values{"name": {cfg.ContainerName}}
+
+	err := d.api.do("POST", "/containers/create", query, req, nil)
+	if isNotFound(err) {
+		// Like `docker run`, pull images that aren't here yet
+		if err := d.pullImage(cfg.ImageName); err != nil {
+			return err
 		}
+		err = d.api.do("POST", "/containers/create", query, req, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("creating container %s: %w", cfg.ContainerName, err)
+	}
+	return d.StartContainer(cfg.ContainerName)
+}
+
+// pullImage pulls an image from its registry
+func (d *DockerRuntime) pullImage(name string) error {
+	repo, tag := splitImageRef(name)
+	query := url.Values{"fromImage": {repo}}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	if err := d.api.stream("POST", "/images/create", query, nil); err != nil {
+		return fmt.Errorf("pulling image %s: %w", name, err)
+	}
+	return nil
+}
+
+func (d *DockerRuntime) StartContainer(name string) error {
+	if err := d.api.do("POST", "/containers/"+name+"/start", nil, nil, nil); err != nil {
+		return fmt.Errorf("starting container %s: %w", name, err)
+	}
+	return nil
+}
+
+func (d *DockerRuntime) StopContainer(name string) error {
+	if err := d.api.do("POST", "/containers/"+name+"/stop", nil, nil, nil); err != nil {
+		return fmt.Errorf("stopping container %s: %w", name, err)
+	}
+	return nil
+}
+
+func (d *DockerRuntime) NetworkExists(name string) (bool, error) {
+	err := d.api.do("GET", "/networks/"+name, nil, nil, nil)
+	if err != nil {
+		err = fmt.Errorf("inspecting network %s: %w", name, err)
+	}
+	return exists(err)
+}
+
+func (d *DockerRuntime) CreateNetwork(name string) error {
+	body := map[string]string{"Name": name}
+	if err := d.api.do("POST", "/networks/create", nil, body, nil); err != nil {
+		return fmt.Errorf("creating network %s: %w", name, err)
+	}
+	return nil
+}
+
+func (d *DockerRuntime) RemoveNetwork(name string) error {
+	if err := d.api.do("DELETE", "/networks/"+name, nil, nil, nil); err != nil {
+		return fmt.Errorf("removing network %s: %w", name, err)
+	}
+	return nil
+}
+
+func (d *DockerRuntime) ListNetworks(prefix string) ([]string, error) {
+	var listed []struct {
+		Name string `json:"Name"`
+	}
+	query := apiFilters(map[string][]string{"name": {prefix}})
+	if err := d.api.do("GET", "/networks", query, nil, &listed); err != nil {
+		return nil, fmt.Errorf("listing networks: %w", err)
+	}
+	var names []string
+	for _, n := range listed {
+		names = append(names, n.Name)
+	}
+	return withPrefix(names, prefix), nil
+}
+
+func (d *DockerRuntime) RemoveVolume(name string) error {
+	if err := d.api.do("DELETE", "/volumes/"+name, nil, nil, nil); err != nil {
+		return fmt.Errorf("removing volume %s: %w", name, err)
+	}
+	return nil
+}
+
+func (d *DockerRuntime) ListVolumes(prefix string) ([]string, error) {
+	var listed struct {
+		Volumes []struct {
+			Name string `json:"Name"`
+		} `json:"Volumes"`
+	}
+	query := apiFilters(map[string][]string{"name": {prefix}})
+	if err := d.api.do("GET", "/volumes", query, nil, &listed); err != nil {
+		return nil, fmt.Errorf("listing volumes: %w", err)
+	}
+	var names []string
+	for _, v := range listed.Volumes {
+		names = append(names, v.Name)
+	}
+	return withPrefix(names, prefix), nil
+}
+
+func (d *DockerRuntime) DiskUsage() (DiskUsage, error) {
+	var df struct {
+		Containers []struct {
+			Names  []string `json:"Names"`
+			SizeRw int64    `json:"SizeRw"`
+		} `json:"Containers"`
+		Volumes []struct {
+			Name      string `json:"Name"`
+			UsageData struct {
+				Size int64 `json:"Size"` // -1 when not computed
+			} `json:"UsageData"`
+		} `json:"Volumes"`
+	}
+	if err := d.api.do("GET", "/system/df", nil, nil, &df); err != nil {
+		return DiskUsage{}, fmt.Errorf("getting disk usage: %w", err)
+	}
+
+	usage := DiskUsage{Containers: make(map[string]int64), Volumes: make(map[string]int64)}
+	for _, c := range df.Containers {
+		for _, name := range c.Names {
+			usage.Containers[strings.TrimPrefix(name, "/")] = c.SizeRw
+		}
+	}
+	for _, v := range df.Volumes {
+		usage.Volumes[v.Name] = max(v.UsageData.Size, 0)
+	}
+	return usage, nil
+}
+
+// dockerChangeTypes maps the API's change kinds to `docker diff` letters
+var dockerChangeTypes = map[int]string{0: "C", 1: "A", 2: "D"}
+
+func (d *DockerRuntime) Diff(name string) ([]FileDiff, error) {
+	var changes []struct {
+		Path string `json:"Path"`
+		Kind int    `json:"Kind"`
+	}
+	if err := d.api.do("GET", "/containers/"+name+"/changes", nil, nil, &changes); err != nil {
+		return nil, fmt.Errorf("diffing container %s: %w", name, err)
+	}
+
+	var diffs []FileDiff
+	for _, c := range changes {
+		diffs = append(diffs, FileDiff{ChangeType: dockerChangeTypes[c.Kind], Path: c.Path})
 	}
 	return diffs, nil
 }
 
-func (d *DockerRuntime) Commit(containerName, imageName string) error {
-	return exec.Command("docker", "commit", containerName, imageName).Run()
+// Commit tags an image of the container. Docker gives it the container's
+// labels, so those are overridden with the given image labels.
+func (d *DockerRuntime) Commit(containerName, imageName string, imageLabels map[string]string) error {
+	repo, tag := splitImageRef(imageName)
+	query := url.Values{"container": {containerName}, "repo": {repo}, "tag": {tag}}
+	config := struct {
+		Labels map[string]string `json:",omitempty"`
+	}{imageLabels}
+	if err := d.api.do("POST", "/commit", query, config, nil); err != nil {
+		return fmt.Errorf("committing container %s: %w", containerName, err)
+	}
+	return nil
+}
+
+// CopyToContainer extracts a tar archive into a directory of the container,
+// owned by the container's user. The container needn't be running.
+func (d *DockerRuntime) CopyToContainer(name, dir string, archive io.Reader) error {
+	query := url.Values{"path": {dir}, "copyUIDGID": {"1"}}
+	if err := d.api.do("PUT", "/containers/"+name+"/archive", query, archive, nil); err != nil {
+		return fmt.Errorf("copying to container %s: %w", name, err)
+	}
+	return nil
+}
+
+// CopyFromContainer returns a tar archive of a path in the container, its
+// entries named from the path's base name.
+func (d *DockerRuntime) CopyFromContainer(name, path string) (io.ReadCloser, error) {
+	resp, err := d.api.request("GET", "/containers/"+name+"/archive", url.Values{"path": {path}}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("copying from container %s: %w", name, err)
+	}
+	return resp.Body, nil
+}
+
+// ClearDirectory empties a directory of a stopped container, which can't
+// run commands itself: a throwaway container of the same image, sharing its
+// volumes and carrying the given labels, deletes the directory's contents.
+func (d *DockerRuntime) ClearDirectory(name, dir string, helperLabels map[string]string) (err error) {
+	c, err := d.inspectContainer(name)
+	if err != nil {
+		return err
+	}
+	req := dockerCreateRequest{Image: c.Image, Entrypoint: []string{"find", dir, "-mindepth", "1", "-delete"}, User: "0", Labels: helperLabels}
+	req.HostConfig.VolumesFrom = []string{name}
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := d.api.do("POST", "/containers/create", nil, req, &created); err != nil {
+		return fmt.Errorf("clearing %s in container %s: %w", dir, name, err)
+	}
+	defer func() {
+		if rmErr := d.api.do("DELETE", "/containers/"+created.ID, url.Values{"force": {"1"}}, nil, nil); rmErr != nil {
+			err = errors.Join(err, fmt.Errorf("removing helper container %s: %w", created.ID, rmErr))
+		}
+	}()
+
+	var result struct {
+		StatusCode int `json:"StatusCode"`
+	}
+	err = d.api.do("POST", "/containers/"+created.ID+"/start", nil, nil, nil)
+	if err == nil {
+		err = d.api.do("POST", "/containers/"+created.ID+"/wait", nil, nil, &result)
+	}
+	if err == nil && result.StatusCode != 0 {
+		err = fmt.Errorf("exit status %d", result.StatusCode)
+	}
+	if err != nil {
+		return fmt.Errorf("clearing %s in container %s: %w", dir, name, err)
+	}
+	return nil
 }
 
 func (d *DockerRuntime) Capabilities() Capabilities {
@@ -196,6 +761,7 @@ func (d *DockerRuntime) Capabilities() Capabilities {
 		SupportsDiff:   true,
 		SupportsCommit: true,
 		SupportsExport: true,
+		Remote:         d.remote,
 	}
 }
 
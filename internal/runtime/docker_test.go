@@ -1,6 +1,8 @@
 package runtime
 
 import (
+	"io"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -22,6 +24,7 @@ func TestDockerRuntime_buildRunArgs(t *testing.T) {
 			HostPath:      "/home/user/project",
 			WorkspacePath: "/project",
 			Hostname:      "glovebox",
+			Platform:      "linux/amd64",
 		})
 
 		argsStr := strings.Join(args, " ")
@@ -31,6 +34,7 @@ func TestDockerRuntime_buildRunArgs(t *testing.T) {
 			"-v /home/user/project:/project",
 			"-w /project",
 			"--hostname glovebox",
+			"--platform linux/amd64",
 		} {
 			if !strings.Contains(argsStr, want) {
 				t.Errorf("expected %q in args, got: %s", want, argsStr)
@@ -43,6 +47,21 @@ func TestDockerRuntime_buildRunArgs(t *testing.T) {
 		}
 	})
 
+	t.Run("workspace volume", func(t *testing.T) {
+		args := rt.buildRunArgs(RunConfig{
+			ContainerName:   "test",
+			ImageName:       "test:latest",
+			HostPath:        "/home/user/project",
+			WorkspacePath:   "/project",
+			WorkspaceVolume: true,
+		})
+
+		argsStr := strings.Join(args, " ")
+		if strings.Contains(argsStr, "/home/user/project") || !strings.Contains(argsStr, "-v /project -w /project") {
+			t.Errorf("expected an anonymous volume at /project, got: %s", argsStr)
+		}
+	})
+
 	t.Run("env vars", func(t *testing.T) {
 		args := rt.buildRunArgs(RunConfig{
 			ContainerName: "test",
@@ -61,6 +80,27 @@ func TestDockerRuntime_buildRunArgs(t *testing.T) {
 		}
 	})
 
+	t.Run("extra mounts", func(t *testing.T) {
+		args := rt.buildRunArgs(RunConfig{
+			ContainerName: "test",
+			ImageName:     "test:latest",
+			HostPath:      "/path",
+			WorkspacePath: "/workspace",
+			Mounts: []Mount{
+				{Source: "/home/me/dotfiles", Target: "/home/dev/.dotfiles", ReadOnly: true},
+				{Source: "/data", Target: "/data"},
+			},
+		})
+
+		argsStr := strings.Join(args, " ")
+		if !strings.Contains(argsStr, "-v /home/me/dotfiles:/home/dev/.dotfiles:ro") {
+			t.Errorf("expected read-only mount in args, got: %s", argsStr)
+		}
+		if !strings.Contains(argsStr, "-v /data:/data ") {
+			t.Errorf("expected read-write mount in args, got: %s", argsStr)
+		}
+	})
+
 	t.Run("empty hostname omitted", func(t *testing.T) {
 		args := rt.buildRunArgs(RunConfig{
 			ContainerName: "test",
@@ -75,6 +115,29 @@ func TestDockerRuntime_buildRunArgs(t *testing.T) {
 			t.Error("empty hostname should not produce --hostname flag")
 		}
 	})
+
+	t.Run("ports, host alias and labels", func(t *testing.T) {
+		args := rt.buildRunArgs(RunConfig{
+			ContainerName: "test",
+			ImageName:     "test:latest",
+			HostPath:      "/path",
+			WorkspacePath: "/workspace",
+			Ports:         []PortMapping{{HostIP: "127.0.0.1", HostPort: 8080, ContainerPort: 3000, Protocol: "tcp"}},
+			HostAlias:     "host.glovebox.internal",
+			Labels:        map[string]string{"glovebox.ports": "127.0.0.1:8080:3000/tcp"},
+		})
+
+		argsStr := strings.Join(args, " ")
+		for _, want := range []string{
+			"-p 127.0.0.1:8080:3000/tcp",
+			"--add-host host.glovebox.internal:host-gateway",
+			"--label glovebox.ports=127.0.0.1:8080:3000/tcp",
+		} {
+			if !strings.Contains(argsStr, want) {
+				t.Errorf("expected %q in args, got: %s", want, argsStr)
+			}
+		}
+	})
 }
 
 func TestDockerRuntime_Capabilities(t *testing.T) {
@@ -101,3 +164,96 @@ func TestDockerRuntime_normalizeExitError(t *testing.T) {
 		}
 	})
 }
+
+func TestServiceCreateRequest(t *testing.T) {
+	req := serviceCreateRequest(ServiceConfig{
+		ContainerName: "glovebox-app-1234567-db",
+		ImageName:     "postgres:16",
+		Network:       "glovebox-app-1234567",
+		Alias:         "db",
+		Env:           map[string]string{"POSTGRES_PASSWORD": "dev", "A": "1"},
+		Volumes:       []VolumeMount{{Name: "glovebox-app-1234567-db-data", Target: "/var/lib/postgresql/data"}},
+		Command:       []string{"postgres", "-c", "fsync=off"},
+	})
+
+	if req.Image != "postgres:16" || strings.Join(req.Cmd, " ") != "postgres -c fsync=off" {
+		t.Errorf("Image = %q, Cmd = %v", req.Image, req.Cmd)
+	}
+	if strings.Join(req.Env, " ") != "A=1 POSTGRES_PASSWORD=dev" {
+		t.Errorf("Env = %v, want sorted KEY=value pairs", req.Env)
+	}
+	if len(req.HostConfig.Binds) != 1 || req.HostConfig.Binds[0] != "glovebox-app-1234567-db-data:/var/lib/postgresql/data" {
+		t.Errorf("Binds = %v", req.HostConfig.Binds)
+	}
+	if req.HostConfig.NetworkMode != "glovebox-app-1234567" {
+		t.Errorf("NetworkMode = %q", req.HostConfig.NetworkMode)
+	}
+	if aliases := req.NetworkingConfig.EndpointsConfig["glovebox-app-1234567"].Aliases; len(aliases) != 1 || aliases[0] != "db" {
+		t.Errorf("Aliases = %v, want [db]", aliases)
+	}
+
+	t.Run("interactive container joins network", func(t *testing.T) {
+		args := NewDocker(Stdio{}).buildRunArgs(RunConfig{
+			ContainerName: "test",
+			ImageName:     "test:latest",
+			HostPath:      "/path",
+			WorkspacePath: "/workspace",
+			Network:       "glovebox-app-1234567",
+		})
+		if !strings.Contains(strings.Join(args, " "), "--network glovebox-app-1234567") {
+			t.Errorf("expected --network in args, got: %v", args)
+		}
+	})
+}
+
+func TestFilterPrefix(t *testing.T) {
+	got := filterPrefix([]byte("glovebox-app-1\nother-glovebox-app\n\nglovebox-app-2\n"), "glovebox-app")
+	if len(got) != 2 || got[0] != "glovebox-app-1" || got[1] != "glovebox-app-2" {
+		t.Errorf("filterPrefix() = %v, want [glovebox-app-1 glovebox-app-2]", got)
+	}
+}
+
+func TestDockerRuntime_buildBuildArgs(t *testing.T) {
+	rt := NewDocker(Stdio{})
+
+	args := rt.buildBuildArgs(BuildConfig{
+		DockerfilePath: "/p/.glovebox/Dockerfile",
+		ContextDir:     "/p/.glovebox/",
+		ImageName:      "glovebox:p-1234567",
+		Labels:         map[string]string{"glovebox.role": "project", "glovebox.managed": "true"},
+		BuildArgs:      map[string]string{"TOOL_VERSION": "1.2.3"},
+		Secrets: []BuildSecret{
+			{ID: "npm_token", Env: "NPM_TOKEN"},
+			{ID: "pip_conf", File: "/home/me/.pip.conf"},
+		},
+	})
+
+	want := []string{
+		"build", "-t", "glovebox:p-1234567", "-f", "/p/.glovebox/Dockerfile",
+		"--label", "glovebox.managed=true", "--label", "glovebox.role=project",
+		"--build-arg", "TOOL_VERSION=1.2.3",
+		"--secret", "id=npm_token,env=NPM_TOKEN", "--secret", "id=pip_conf,src=/home/me/.pip.conf",
+		"/p/.glovebox/",
+	}
+	if strings.Join(args, " ") != strings.Join(want, " ") {
+		t.Errorf("buildBuildArgs() = %v, want %v", args, want)
+	}
+
+	args = rt.buildBuildArgs(BuildConfig{ImageName: "glovebox:base", DockerfilePath: "Dockerfile", ContextDir: ".", Progress: io.Discard})
+	if !slices.Contains(args, "--progress=plain") {
+		t.Errorf("buildBuildArgs() = %v, want plain progress when following it", args)
+	}
+
+	args = rt.buildBuildArgs(BuildConfig{ImageName: "glovebox:base", DockerfilePath: "Dockerfile", ContextDir: ".", Platforms: []string{"linux/amd64", "linux/arm64"}})
+	if !strings.Contains(strings.Join(args, " "), "--platform linux/amd64,linux/arm64") {
+		t.Errorf("buildBuildArgs() = %v, want the platforms in one --platform flag", args)
+	}
+}
+
+func TestLabelFilterArgs(t *testing.T) {
+	got := strings.Join(labelFilterArgs(map[string]string{"glovebox.managed": "true", "glovebox.project": ""}), " ")
+	want := "--filter label=glovebox.managed=true --filter label=glovebox.project"
+	if got != want {
+		t.Errorf("labelFilterArgs() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,338 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultDockerHost is where the Docker daemon listens unless configured otherwise
+const defaultDockerHost = "unix:///var/run/docker.sock"
+
+// dockerEndpoint is how to reach a Docker daemon
+type dockerEndpoint struct {
+	Host          string // e.g. unix:///var/run/docker.sock, tcp://10.0.0.5:2376
+	TLSDir        string // directory with ca.pem, cert.pem and key.pem; empty for no TLS
+	SkipTLSVerify bool
+
+	// Flags select the endpoint for the docker CLI when it differs from the
+	// CLI's own choice (a runtime_host setting)
+	Flags []string
+}
+
+// remote reports whether the daemon runs on another machine, where host
+// paths can't be bind-mounted. Local sockets and loopback addresses, such as
+// those of Docker Desktop or Colima, share the host's filesystem.
+func (ep dockerEndpoint) remote() bool {
+	u, err := url.Parse(ep.Host)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "ssh":
+		return true
+	case "tcp", "http", "https":
+		host := u.Hostname()
+		if host == "localhost" {
+			return false
+		}
+		ip := net.ParseIP(host)
+		return ip == nil || !ip.IsLoopback()
+	}
+	return false
+}
+
+// resolveDockerEndpoint finds the daemon to use: DOCKER_HOST, then
+// DOCKER_CONTEXT, then the configured host (a URL or context name from the
+// runtime_host setting), then the docker CLI's current context, then the
+// default socket
+func resolveDockerEndpoint(configured string) (dockerEndpoint, error) {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		ep := dockerEndpoint{Host: host}
+		if os.Getenv("DOCKER_TLS_VERIFY") != "" || os.Getenv("DOCKER_CERT_PATH") != "" {
+			ep.TLSDir = os.Getenv("DOCKER_CERT_PATH")
+			if ep.TLSDir == "" {
+				ep.TLSDir = dockerConfigDir()
+			}
+			ep.SkipTLSVerify = os.Getenv("DOCKER_TLS_VERIFY") == ""
+		}
+		return ep, nil
+	}
+
+	name := os.Getenv("DOCKER_CONTEXT")
+	if name == "" && configured != "" {
+		if strings.Contains(configured, "://") {
+			return dockerEndpoint{Host: configured, Flags: []string{"--host", configured}}, nil
+		}
+		ep, err := dockerContextEndpoint(configured)
+		if err != nil {
+			return dockerEndpoint{}, err
+		}
+		ep.Flags = []string{"--context", configured}
+		return ep, nil
+	}
+	if name == "" {
+		var cfg struct {
+			CurrentContext string `json:"currentContext"`
+		}
+		if data, err := os.ReadFile(filepath.Join(dockerConfigDir(), "config.json")); err == nil {
+			if err := json.Unmarshal(data, &cfg); err != nil {
+				return dockerEndpoint{}, fmt.Errorf("reading docker config: %w", err)
+			}
+		}
+		name = cfg.CurrentContext
+	}
+	if name == "" || name == "default" {
+		return dockerEndpoint{Host: defaultDockerHost}, nil
+	}
+	return dockerContextEndpoint(name)
+}
+
+// dockerContextEndpoint reads a named context created with `docker context create`
+func dockerContextEndpoint(name string) (dockerEndpoint, error) {
+	sum := sha256.Sum256([]byte(name))
+	id := hex.EncodeToString(sum[:])
+	data, err := os.ReadFile(filepath.Join(dockerConfigDir(), "contexts", "meta", id, "meta.json"))
+	if err != nil {
+		return dockerEndpoint{}, fmt.Errorf("docker context %q not found: %w", name, err)
+	}
+
+	var meta struct {
+		Endpoints map[string]struct {
+			Host          string `json:"Host"`
+			SkipTLSVerify bool   `json:"SkipTLSVerify"`
+		} `json:"Endpoints"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return dockerEndpoint{}, fmt.Errorf("reading docker context %q: %w", name, err)
+	}
+	docker, ok := meta.Endpoints["docker"]
+	if !ok || docker.Host == "" {
+		return dockerEndpoint{}, fmt.Errorf("docker context %q has no docker endpoint", name)
+	}
+
+	ep := dockerEndpoint{Host: docker.Host, SkipTLSVerify: docker.SkipTLSVerify}
+	tlsDir := filepath.Join(dockerConfigDir(), "contexts", "tls", id, "docker")
+	if _, err := os.Stat(tlsDir); err == nil {
+		ep.TLSDir = tlsDir
+	}
+	return ep, nil
+}
+
+// dockerConfigDir is the docker CLI's configuration directory
+func dockerConfigDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".docker")
+}
+
+// dockerClient calls the Docker Engine API. Paths are unversioned, so the
+// daemon serves its own API version.
+type dockerClient struct {
+	http *http.Client
+	base string // URL the API paths are relative to
+	err  error  // set when the endpoint can't be used; returned by every call
+}
+
+// newDockerClient creates a client for an endpoint. Endpoints it can't
+// reach over HTTP give a client whose calls all fail with the reason.
+func newDockerClient(ep dockerEndpoint) *dockerClient {
+	u, err := url.Parse(ep.Host)
+	if err != nil {
+		return &dockerClient{err: fmt.Errorf("invalid docker host %q: %w", ep.Host, err)}
+	}
+
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	transport := &http.Transport{}
+	c := &dockerClient{http: &http.Client{Transport: transport}}
+
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+		c.base = "http://docker"
+	case "tcp", "http", "https":
+		transport.DialContext = dialer.DialContext
+		scheme := "http"
+		if ep.TLSDir != "" || u.Scheme == "https" {
+			cfg, err := dockerTLSConfig(ep)
+			if err != nil {
+				return &dockerClient{err: err}
+			}
+			transport.TLSClientConfig = cfg
+			scheme = "https"
+		}
+		c.base = scheme + "://" + u.Host
+	case "ssh":
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialSSH(ctx, u)
+		}
+		c.base = "http://docker"
+	default:
+		return &dockerClient{err: fmt.Errorf("docker host %q: %s:// hosts are not supported", ep.Host, u.Scheme)}
+	}
+	return c
+}
+
+// dockerTLSConfig loads the client certificate and CA of a TLS endpoint
+func dockerTLSConfig(ep dockerEndpoint) (*tls.Config, error) {
+	cfg := &tls.Config{InsecureSkipVerify: ep.SkipTLSVerify}
+	if ep.TLSDir == "" {
+		return cfg, nil
+	}
+	if ca, err := os.ReadFile(filepath.Join(ep.TLSDir, "ca.pem")); err == nil {
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(ca)
+		cfg.RootCAs = pool
+	}
+	cert, err := tls.LoadX509KeyPair(filepath.Join(ep.TLSDir, "cert.pem"), filepath.Join(ep.TLSDir, "key.pem"))
+	if err == nil {
+		cfg.Certificates = []tls.Certificate{cert}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("loading docker client certificate: %w", err)
+	}
+	return cfg, nil
+}
+
+// do calls the API and decodes the JSON response into out (when non-nil).
+// A missing resource is ErrNotFound and an unreachable daemon
+// ErrDaemonUnavailable; 304 Not Modified (e.g. starting a running
+// container) is success.
+func (c *dockerClient) do(method, path string, query url.Values, body, out any) error {
+	resp, err := c.request(method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("docker API %s %s: decoding response: %w", method, path, err)
+	}
+	return nil
+}
+
+// stream calls the API and reads a stream of JSON progress messages (image
+// pulls and loads), failing on the first error message
+func (c *dockerClient) stream(method, path string, query url.Values, body any) error {
+	resp, err := c.request(method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var msg struct {
+			Error string `json:"error"`
+		}
+		if err := dec.Decode(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("docker API %s %s: reading progress: %w", method, path, err)
+		}
+		if msg.Error != "" {
+			return fmt.Errorf("docker API %s %s: %s", method, path, msg.Error)
+		}
+	}
+}
+
+// request sends a request, turning error statuses into errors. A body that
+// is an io.Reader is sent as a tar archive, anything else as JSON.
+func (c *dockerClient) request(method, path string, query url.Values, body any) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	var reader io.Reader
+	contentType := "application/json"
+	if archive, ok := body.(io.Reader); ok {
+		reader = archive
+		contentType = "application/x-tar"
+	} else if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("docker API %s %s: encoding request: %w", method, path, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	u := c.base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDaemonUnavailable, err)
+	}
+	if resp.StatusCode < 400 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	data, _ := io.ReadAll(resp.Body)
+	if json.Unmarshal(data, &apiErr) != nil || apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(data))
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, apiErr.Message)
+	case http.StatusServiceUnavailable:
+		return nil, fmt.Errorf("%w: %s", ErrDaemonUnavailable, apiErr.Message)
+	default:
+		return nil, fmt.Errorf("docker API %s %s: %s (%d)", method, path, apiErr.Message, resp.StatusCode)
+	}
+}
+
+// apiFilters encodes list filters the way the API expects them
+func apiFilters(filters map[string][]string) url.Values {
+	q := url.Values{}
+	if len(filters) > 0 {
+		data, _ := json.Marshal(filters)
+		q.Set("filters", string(data))
+	}
+	return q
+}
+
+// splitImageRef splits an image reference into repository and tag for the
+// pull and commit endpoints
+func splitImageRef(ref string) (repo, tag string) {
+	if strings.Contains(ref, "@") {
+		return ref, ""
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, "latest"
+}
@@ -0,0 +1,667 @@
+package runtime
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveDockerEndpoint(t *testing.T) {
+	writeContext := func(t *testing.T, configDir, name, host string) {
+		t.Helper()
+		sum := sha256.Sum256([]byte(name))
+		dir := filepath.Join(configDir, "contexts", "meta", hex.EncodeToString(sum[:]))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		meta := `{"Name": "` + name + `", "Endpoints": {"docker": {"Host": "` + host + `"}}}`
+		if err := os.WriteFile(filepath.Join(dir, "meta.json"), []byte(meta), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		config     string // config.json contents
+		context    string // context written as "remote"
+		configured string // runtime_host setting
+		want       string
+		wantFlags  []string
+		wantErr    bool
+	}{
+		{name: "default socket", want: defaultDockerHost},
+		{name: "DOCKER_HOST", env: map[string]string{"DOCKER_HOST": "tcp://10.0.0.5:2375"}, want: "tcp://10.0.0.5:2375"},
+		{
+			name:    "DOCKER_HOST wins over context",
+			env:     map[string]string{"DOCKER_HOST": "unix:///tmp/docker.sock", "DOCKER_CONTEXT": "remote"},
+			context: "tcp://10.0.0.9:2375",
+			want:    "unix:///tmp/docker.sock",
+		},
+		{name: "DOCKER_CONTEXT", env: map[string]string{"DOCKER_CONTEXT": "remote"}, context: "tcp://10.0.0.9:2375", want: "tcp://10.0.0.9:2375"},
+		{name: "current context", config: `{"currentContext": "remote"}`, context: "unix:///home/me/.colima/docker.sock", want: "unix:///home/me/.colima/docker.sock"},
+		{name: "default context", config: `{"currentContext": "default"}`, want: defaultDockerHost},
+		{name: "missing context", env: map[string]string{"DOCKER_CONTEXT": "nope"}, wantErr: true},
+		{
+			name:       "configured host",
+			config:     `{"currentContext": "remote"}`,
+			context:    "tcp://10.0.0.9:2375",
+			configured: "ssh://me@buildbox",
+			want:       "ssh://me@buildbox",
+			wantFlags:  []string{"--host", "ssh://me@buildbox"},
+		},
+		{name: "configured context", context: "ssh://me@buildbox", configured: "remote", want: "ssh://me@buildbox", wantFlags: []string{"--context", "remote"}},
+		{name: "DOCKER_HOST wins over configured host", env: map[string]string{"DOCKER_HOST": "tcp://10.0.0.5:2375"}, configured: "ssh://me@buildbox", want: "tcp://10.0.0.5:2375"},
+		{name: "missing configured context", configured: "nope", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configDir := t.TempDir()
+			t.Setenv("DOCKER_CONFIG", configDir)
+			for _, key := range []string{"DOCKER_HOST", "DOCKER_CONTEXT", "DOCKER_TLS_VERIFY", "DOCKER_CERT_PATH"} {
+				t.Setenv(key, tt.env[key])
+			}
+			if tt.config != "" {
+				if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(tt.config), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.context != "" {
+				writeContext(t, configDir, "remote", tt.context)
+			}
+
+			ep, err := resolveDockerEndpoint(tt.configured)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveDockerEndpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ep.Host != tt.want {
+				t.Errorf("Host = %q, want %q", ep.Host, tt.want)
+			}
+			if !slices.Equal(ep.Flags, tt.wantFlags) {
+				t.Errorf("Flags = %q, want %q", ep.Flags, tt.wantFlags)
+			}
+		})
+	}
+}
+
+func TestDockerEndpointRemote(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{defaultDockerHost, false},
+		{"unix:///home/me/.colima/docker.sock", false},
+		{"tcp://localhost:2375", false},
+		{"tcp://127.0.0.1:2376", false},
+		{"tcp://[::1]:2375", false},
+		{"tcp://10.0.0.5:2376", true},
+		{"tcp://buildbox.lan:2376", true},
+		{"ssh://me@buildbox", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := (dockerEndpoint{Host: tt.host}).remote(); got != tt.want {
+				t.Errorf("remote() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitImageRef(t *testing.T) {
+	tests := []struct {
+		ref, repo, tag string
+	}{
+		{"glovebox:app-1234567", "glovebox", "app-1234567"},
+		{"postgres", "postgres", "latest"},
+		{"localhost:5000/tools/pg:16", "localhost:5000/tools/pg", "16"},
+		{"localhost:5000/tools/pg", "localhost:5000/tools/pg", "latest"},
+		{"postgres@sha256:abc", "postgres@sha256:abc", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			repo, tag := splitImageRef(tt.ref)
+			if repo != tt.repo || tag != tt.tag {
+				t.Errorf("splitImageRef(%q) = %q, %q, want %q, %q", tt.ref, repo, tag, tt.repo, tt.tag)
+			}
+		})
+	}
+}
+
+// fakeDaemon serves the given routes as a Docker daemon and points a
+// runtime at it
+func fakeDaemon(t *testing.T, routes map[string]http.HandlerFunc) *DockerRuntime {
+	t.Helper()
+	mux := http.NewServeMux()
+	for pattern, handler := range routes {
+		mux.HandleFunc(pattern, handler)
+	}
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	t.Setenv("DOCKER_HOST", "tcp://"+srv.Listener.Addr().String())
+	return NewDocker(Stdio{})
+}
+
+// reply returns a handler answering with a JSON body
+func reply(status int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func TestDockerRuntime_Images(t *testing.T) {
+	var listFilters string
+	rt := fakeDaemon(t, map[string]http.HandlerFunc{
+		"GET /images/glovebox:app-1234567/json": reply(200, `{"Id": "sha256:abc", "Size": 1048576, "Os": "linux", "Architecture": "arm64", "Variant": "v8",
+			"Created": "2026-02-01T10:00:00Z", "Config": {"Labels": {"glovebox.role": "project"}},
+			"RootFS": {"Layers": ["sha256:l1", "sha256:l2"]}}`),
+		"GET /images/missing/json": reply(404, `{"message": "No such image: missing"}`),
+		"GET /distribution/ubuntu:24.04/json": reply(200, `{"Descriptor": {"mediaType": "application/vnd.oci.image.index.v1+json",
+			"digest": "sha256:1234", "size": 6688}}`),
+		"GET /images/json": func(w http.ResponseWriter, r *http.Request) {
+			listFilters = r.URL.Query().Get("filters")
+			reply(200, `[{"Id": "sha256:abc", "RepoTags": ["glovebox:app-1234567", "mirror/app:1"]},
+				{"Id": "sha256:def", "RepoTags": ["glovebox:base"]}]`)(w, r)
+		},
+	})
+
+	if ok, err := rt.ImageExists("glovebox:app-1234567"); !ok || err != nil {
+		t.Errorf("ImageExists(present) = %v, %v, want true, nil", ok, err)
+	}
+	if ok, err := rt.ImageExists("missing"); ok || err != nil {
+		t.Errorf("ImageExists(missing) = %v, %v, want false, nil", ok, err)
+	}
+	if _, err := rt.GetImageDigest("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetImageDigest(missing) error = %v, want ErrNotFound", err)
+	}
+
+	img, err := rt.InspectImage("glovebox:app-1234567")
+	if err != nil {
+		t.Fatalf("InspectImage() error = %v", err)
+	}
+	if img.ID != "sha256:abc" || img.Size != 1048576 || img.Labels["glovebox.role"] != "project" || len(img.Layers) != 2 {
+		t.Errorf("InspectImage() = %+v", img)
+	}
+	if !img.Created.Equal(time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Created = %v", img.Created)
+	}
+	if !slices.Equal(img.Platforms, []string{"linux/arm64/v8"}) {
+		t.Errorf("Platforms = %v", img.Platforms)
+	}
+
+	if d, err := rt.ResolveImageDigest("ubuntu:24.04"); d != "sha256:1234" || err != nil {
+		t.Errorf("ResolveImageDigest() = %q, %v, want sha256:1234", d, err)
+	}
+	if _, err := rt.ResolveImageDigest("ubuntu:99.04"); err == nil {
+		t.Error("ResolveImageDigest(unknown tag) should fail")
+	}
+
+	images, err := rt.ListImages(ListFilter{Name: "glovebox:*", Labels: map[string]string{"glovebox.managed": "true"}})
+	if err != nil {
+		t.Fatalf("ListImages() error = %v", err)
+	}
+	if strings.Join(images, " ") != "glovebox:app-1234567 glovebox:base" {
+		t.Errorf("ListImages() = %v, want only the glovebox tags", images)
+	}
+	var filters map[string][]string
+	if err := json.Unmarshal([]byte(listFilters), &filters); err != nil {
+		t.Fatalf("filters = %q: %v", listFilters, err)
+	}
+	if filters["reference"][0] != "glovebox:*" || filters["label"][0] != "glovebox.managed=true" {
+		t.Errorf("filters = %v", filters)
+	}
+}
+
+func TestDockerRuntime_Containers(t *testing.T) {
+	rt := fakeDaemon(t, map[string]http.HandlerFunc{
+		"GET /containers/json": reply(200, `[{"Id": "c1"}, {"Id": "gone"}]`),
+		"GET /containers/c1/json": reply(200, `{"Name": "/glovebox-app-1234567", "Image": "sha256:abc",
+			"Created": "2026-01-01T00:00:00Z",
+			"State": {"Running": true, "StartedAt": "2026-01-03T00:00:00Z", "FinishedAt": "2026-01-02T00:00:00Z"},
+			"Config": {"Image": "glovebox:app-1234567", "Labels": {"glovebox.project": "/code/a,b"}}}`),
+		"GET /containers/gone/json": reply(404, `{"message": "No such container: gone"}`),
+		"GET /containers/c1/changes": reply(200, `[{"Path": "/etc/hosts", "Kind": 0},
+			{"Path": "/home/dev/file with spaces", "Kind": 1}, {"Path": "/tmp/x", "Kind": 2}]`),
+	})
+
+	containers, err := rt.ListContainers(ListFilter{}, true)
+	if err != nil {
+		t.Fatalf("ListContainers() error = %v", err)
+	}
+	if len(containers) != 1 {
+		t.Fatalf("got %d containers, want 1 (removed containers skipped)", len(containers))
+	}
+	c := containers[0]
+	if c.Name != "glovebox-app-1234567" || c.Image != "glovebox:app-1234567" || c.ImageID != "sha256:abc" || c.Labels["glovebox.project"] != "/code/a,b" {
+		t.Errorf("containers[0] = %+v", c)
+	}
+	if !c.Running || c.LastUsed() != time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("Running = %v, LastUsed() = %v", c.Running, c.LastUsed())
+	}
+
+	if running, err := rt.ContainerRunning("c1"); !running || err != nil {
+		t.Errorf("ContainerRunning(c1) = %v, %v", running, err)
+	}
+	if ok, err := rt.ContainerExists("gone"); ok || err != nil {
+		t.Errorf("ContainerExists(gone) = %v, %v, want false, nil", ok, err)
+	}
+	if _, err := rt.ContainerLabels("gone"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ContainerLabels(gone) error = %v, want ErrNotFound", err)
+	}
+
+	diffs, err := rt.Diff("c1")
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	want := []FileDiff{{"C", "/etc/hosts"}, {"A", "/home/dev/file with spaces"}, {"D", "/tmp/x"}}
+	if len(diffs) != len(want) {
+		t.Fatalf("Diff() = %v, want %v", diffs, want)
+	}
+	for i := range want {
+		if diffs[i] != want[i] {
+			t.Errorf("diffs[%d] = %v, want %v", i, diffs[i], want[i])
+		}
+	}
+}
+
+func TestDockerRuntime_ListContainersInspectsOnlyWhenNeeded(t *testing.T) {
+	var inspected []string
+	inspect := func(w http.ResponseWriter, r *http.Request) {
+		inspected = append(inspected, r.URL.Path)
+		reply(200, `{"Name": "/glovebox-app-1234567", "Image": "sha256:abc",
+			"State": {"Running": false, "StartedAt": "2026-01-03T00:00:00Z", "FinishedAt": "2026-01-04T00:00:00Z"},
+			"Config": {"Image": "glovebox:app-1234567"}}`)(w, r)
+	}
+	listing := `[{"Id": "c1", "Names": ["/glovebox-app-1234567"], "Image": "glovebox:app-1234567", "ImageID": "sha256:abc",
+		"Created": 1767225600, "State": "exited", "Labels": {"glovebox.managed": "true"}}]`
+	rt := fakeDaemon(t, map[string]http.HandlerFunc{
+		"GET /containers/json":    func(w http.ResponseWriter, r *http.Request) { reply(200, listing)(w, r) },
+		"GET /containers/c1/json": inspect,
+	})
+
+	containers, err := rt.ListContainers(ListFilter{}, true)
+	if err != nil || len(containers) != 1 {
+		t.Fatalf("ListContainers() = %v, %v", containers, err)
+	}
+	c := containers[0]
+	if c.Name != "glovebox-app-1234567" || c.Image != "glovebox:app-1234567" || c.ImageID != "sha256:abc" ||
+		c.Running || c.Labels["glovebox.managed"] != "true" || !c.Created.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("containers[0] = %+v", c)
+	}
+	if len(inspected) != 0 {
+		t.Errorf("inspected %v, want the listing used", inspected)
+	}
+
+	containers, err = rt.ListContainers(ListFilter{Times: true}, true)
+	if err != nil || len(containers) != 1 || containers[0].LastUsed() != time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("ListContainers(Times) = %v, %v, want the finish time", containers, err)
+	}
+
+	// The image was rebuilt under the container's image name
+	inspected = nil
+	listing = `[{"Id": "c1", "Names": ["/glovebox-app-1234567"], "Image": "sha256:abc", "ImageID": "sha256:abc", "State": "exited"}]`
+	containers, err = rt.ListContainers(ListFilter{}, true)
+	if err != nil || len(containers) != 1 || containers[0].Image != "glovebox:app-1234567" || len(inspected) != 1 {
+		t.Errorf("ListContainers() = %v, %v after inspecting %v, want the image name inspected", containers, err, inspected)
+	}
+}
+
+func TestDockerRuntime_RunDetachedPullsMissingImage(t *testing.T) {
+	var created, pulled, started int
+	var body dockerCreateRequest
+	rt := fakeDaemon(t, map[string]http.HandlerFunc{
+		"POST /containers/create": func(w http.ResponseWriter, r *http.Request) {
+			created++
+			if pulled == 0 {
+				reply(404, `{"message": "No such image: postgres:16"}`)(w, r)
+				return
+			}
+			if r.URL.Query().Get("name") != "glovebox-app-1234567-db" {
+				t.Errorf("name = %q", r.URL.Query().Get("name"))
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			reply(201, `{"Id": "svc"}`)(w, r)
+		},
+		"POST /images/create": func(w http.ResponseWriter, r *http.Request) {
+			pulled++
+			if q := r.URL.Query(); q.Get("fromImage") != "postgres" || q.Get("tag") != "16" {
+				t.Errorf("pull query = %v", q)
+			}
+			reply(200, `{"status": "Pulling"}`+"\n"+`{"status": "Done"}`)(w, r)
+		},
+		"POST /containers/glovebox-app-1234567-db/start": func(w http.ResponseWriter, r *http.Request) {
+			started++
+			w.WriteHeader(http.StatusNoContent)
+		},
+	})
+
+	err := rt.RunDetached(ServiceConfig{ContainerName: "glovebox-app-1234567-db", ImageName: "postgres:16", Network: "net", Alias: "db"})
+	if err != nil {
+		t.Fatalf("RunDetached() error = %v", err)
+	}
+	if created != 2 || pulled != 1 || started != 1 {
+		t.Errorf("created %d, pulled %d, started %d times; want 2, 1, 1", created, pulled, started)
+	}
+	if body.Image != "postgres:16" || body.HostConfig.NetworkMode != "net" {
+		t.Errorf("create body = %+v", body)
+	}
+
+	t.Run("pull errors are reported", func(t *testing.T) {
+		rt := fakeDaemon(t, map[string]http.HandlerFunc{
+			"POST /containers/create": reply(404, `{"message": "No such image: nope:1"}`),
+			"POST /images/create":     reply(200, `{"error": "pull access denied for nope"}`),
+		})
+		err := rt.RunDetached(ServiceConfig{ContainerName: "x", ImageName: "nope:1"})
+		if err == nil || !strings.Contains(err.Error(), "pull access denied") {
+			t.Errorf("RunDetached() error = %v, want the pull error", err)
+		}
+	})
+}
+
+func TestDockerRuntime_DiskUsage(t *testing.T) {
+	rt := fakeDaemon(t, map[string]http.HandlerFunc{
+		"GET /system/df": reply(200, `{"Images": [], "BuildCache": [],
+			"Containers": [{"Names": ["/glovebox-app-1234567"], "SizeRw": 2000000}],
+			"Volumes": [{"Name": "glovebox-app-1234567-db", "UsageData": {"Size": 40000000}},
+				{"Name": "uncounted", "UsageData": {"Size": -1}}]}`),
+	})
+
+	usage, err := rt.DiskUsage()
+	if err != nil {
+		t.Fatalf("DiskUsage() error = %v", err)
+	}
+	if got := usage.Containers["glovebox-app-1234567"]; got != 2000000 {
+		t.Errorf("container size = %d, want 2000000", got)
+	}
+	if got := usage.Volumes["glovebox-app-1234567-db"]; got != 40000000 {
+		t.Errorf("volume size = %d, want 40000000", got)
+	}
+	if got := usage.Volumes["uncounted"]; got != 0 {
+		t.Errorf("uncomputed volume size = %d, want 0", got)
+	}
+}
+
+func TestDockerRuntime_ClearDirectory(t *testing.T) {
+	var body dockerCreateRequest
+	var calls []string
+	record := func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, r.Method+" "+r.URL.Path)
+			handler(w, r)
+		}
+	}
+	status, removal := 0, http.StatusNoContent
+	rt := fakeDaemon(t, map[string]http.HandlerFunc{
+		"GET /containers/c1/json": reply(200, `{"Name": "/c1", "Image": "sha256:img"}`),
+		"POST /containers/create": record(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			reply(201, `{"Id": "helper"}`)(w, r)
+		}),
+		"POST /containers/helper/start": record(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}),
+		"POST /containers/helper/wait": record(func(w http.ResponseWriter, r *http.Request) {
+			reply(200, fmt.Sprintf(`{"StatusCode": %d}`, status))(w, r)
+		}),
+		"DELETE /containers/helper": record(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(removal)
+		}),
+	})
+
+	helperLabels := map[string]string{"glovebox.role": "helper"}
+	if err := rt.ClearDirectory("c1", "/app", helperLabels); err != nil {
+		t.Fatalf("ClearDirectory() error = %v", err)
+	}
+	if body.Labels["glovebox.role"] != "helper" {
+		t.Errorf("helper labels = %v", body.Labels)
+	}
+	if body.Image != "sha256:img" || !slices.Equal(body.HostConfig.VolumesFrom, []string{"c1"}) ||
+		strings.Join(body.Entrypoint, " ") != "find /app -mindepth 1 -delete" {
+		t.Errorf("create body = %+v", body)
+	}
+	want := "POST /containers/create, POST /containers/helper/start, POST /containers/helper/wait, DELETE /containers/helper"
+	if got := strings.Join(calls, ", "); got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+
+	status = 1
+	if err := rt.ClearDirectory("c1", "/app", helperLabels); err == nil || !strings.Contains(err.Error(), "exit status 1") {
+		t.Errorf("ClearDirectory() error = %v, want the helper's failure", err)
+	}
+
+	status, removal = 0, http.StatusInternalServerError
+	if err := rt.ClearDirectory("c1", "/app", helperLabels); err == nil || !strings.Contains(err.Error(), "removing helper container helper") {
+		t.Errorf("ClearDirectory() error = %v, want the failed removal reported", err)
+	}
+}
+
+func TestDockerRuntime_Archives(t *testing.T) {
+	var uploaded, contentType string
+	var query url.Values
+	rt := fakeDaemon(t, map[string]http.HandlerFunc{
+		"PUT /containers/c1/archive": func(w http.ResponseWriter, r *http.Request) {
+			data, _ := io.ReadAll(r.Body)
+			uploaded, contentType, query = string(data), r.Header.Get("Content-Type"), r.URL.Query()
+		},
+		"GET /containers/c1/archive": func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("path") != "/app" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte("tar data"))
+		},
+	})
+
+	if err := rt.CopyToContainer("c1", "/app", strings.NewReader("tar data")); err != nil {
+		t.Fatalf("CopyToContainer() error = %v", err)
+	}
+	if uploaded != "tar data" || contentType != "application/x-tar" {
+		t.Errorf("uploaded %q as %q", uploaded, contentType)
+	}
+	if query.Get("path") != "/app" || query.Get("copyUIDGID") != "1" {
+		t.Errorf("query = %v, want path /app owned by the container user", query)
+	}
+
+	archive, err := rt.CopyFromContainer("c1", "/app")
+	if err != nil {
+		t.Fatalf("CopyFromContainer() error = %v", err)
+	}
+	data, _ := io.ReadAll(archive)
+	archive.Close()
+	if string(data) != "tar data" {
+		t.Errorf("archive = %q", data)
+	}
+	if _, err := rt.CopyFromContainer("c1", "/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("CopyFromContainer() of a missing path error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDockerRuntime_SaveLoadImage(t *testing.T) {
+	var loaded string
+	rt := fakeDaemon(t, map[string]http.HandlerFunc{
+		"GET /images/glovebox:base/get": func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("image tar"))
+		},
+		"POST /images/load": func(w http.ResponseWriter, r *http.Request) {
+			data, _ := io.ReadAll(r.Body)
+			loaded = string(data)
+			if loaded == "corrupt" {
+				reply(200, `{"errorDetail": {"message": "unexpected EOF"}, "error": "unexpected EOF"}`)(w, r)
+				return
+			}
+			reply(200, `{"stream": "Loaded image: glovebox:base\n"}`)(w, r)
+		},
+	})
+
+	archive, err := rt.SaveImage("glovebox:base")
+	if err != nil {
+		t.Fatalf("SaveImage() error = %v", err)
+	}
+	data, _ := io.ReadAll(archive)
+	archive.Close()
+	if string(data) != "image tar" {
+		t.Errorf("archive = %q", data)
+	}
+	if _, err := rt.SaveImage("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SaveImage(missing) error = %v, want ErrNotFound", err)
+	}
+
+	if err := rt.LoadImage(strings.NewReader("image tar")); err != nil || loaded != "image tar" {
+		t.Errorf("LoadImage() = %v, loaded %q", err, loaded)
+	}
+	if err := rt.LoadImage(strings.NewReader("corrupt")); err == nil || !strings.Contains(err.Error(), "unexpected EOF") {
+		t.Errorf("LoadImage(corrupt) error = %v", err)
+	}
+}
+
+func TestDockerRuntime_TagImage(t *testing.T) {
+	var query url.Values
+	rt := fakeDaemon(t, map[string]http.HandlerFunc{
+		"POST /images/glovebox:base/tag": func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.Query()
+			w.WriteHeader(http.StatusCreated)
+		},
+	})
+
+	if err := rt.TagImage("glovebox:base", "localhost:5000/team/glovebox:base"); err != nil {
+		t.Fatalf("TagImage() error = %v", err)
+	}
+	if query.Get("repo") != "localhost:5000/team/glovebox" || query.Get("tag") != "base" {
+		t.Errorf("query = %v", query)
+	}
+	if err := rt.TagImage("missing", "x:y"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("TagImage(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDockerRuntime_Commit(t *testing.T) {
+	var query url.Values
+	var body struct{ Labels map[string]string }
+	rt := fakeDaemon(t, map[string]http.HandlerFunc{
+		"POST /commit": func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.Query()
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			reply(201, `{"Id": "sha256:new"}`)(w, r)
+		},
+	})
+
+	if err := rt.Commit("c1", "glovebox:app-1234", map[string]string{"glovebox.role": "project"}); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if query.Get("container") != "c1" || query.Get("repo") != "glovebox" || query.Get("tag") != "app-1234" {
+		t.Errorf("query = %v", query)
+	}
+	if body.Labels["glovebox.role"] != "project" {
+		t.Errorf("commit config labels = %v, want the image labels", body.Labels)
+	}
+}
+
+func TestDockerRuntime_Remote(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "")
+	t.Setenv("DOCKER_CONTEXT", "")
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	if NewDocker(Stdio{}).Capabilities().Remote {
+		t.Error("the default socket should be local")
+	}
+	rt := NewDockerHost(Stdio{}, "ssh://me@buildbox:2222")
+	if !rt.Capabilities().Remote {
+		t.Error("an ssh host should be remote")
+	}
+	if args := rt.command("ps").Args; strings.Join(args, " ") != "docker --host ssh://me@buildbox:2222 ps" {
+		t.Errorf("docker CLI args = %q, want the configured host", args)
+	}
+}
+
+func TestCommandConn(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat not available")
+	}
+
+	t.Run("relays stdin and stdout", func(t *testing.T) {
+		conn, err := dialCommand(context.Background(), "cat")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		if _, err := conn.Write([]byte("ping")); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 4)
+		if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "ping" {
+			t.Errorf("read %q, %v", buf, err)
+		}
+	})
+
+	t.Run("reports what the command printed on failure", func(t *testing.T) {
+		conn, err := dialCommand(context.Background(), "sh", "-c", "echo 'Permission denied (publickey)' >&2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		_, err = io.ReadAll(conn)
+		if err == nil || !strings.Contains(err.Error(), "Permission denied") {
+			t.Errorf("error = %v, want the command's stderr", err)
+		}
+	})
+}
+
+func TestDockerRuntime_Errors(t *testing.T) {
+	t.Run("daemon down", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		addr := srv.Listener.Addr().String()
+		srv.Close()
+		t.Setenv("DOCKER_HOST", "tcp://"+addr)
+
+		rt := NewDocker(Stdio{})
+		ok, err := rt.ContainerExists("anything")
+		if ok || !errors.Is(err, ErrDaemonUnavailable) {
+			t.Errorf("ContainerExists() = %v, %v, want false, ErrDaemonUnavailable", ok, err)
+		}
+		if rt.Ping() == nil {
+			t.Error("Ping() should fail")
+		}
+	})
+
+	t.Run("server errors", func(t *testing.T) {
+		rt := fakeDaemon(t, map[string]http.HandlerFunc{
+			"DELETE /images/glovebox:base": reply(409, `{"message": "image is being used by running container c1"}`),
+		})
+		err := rt.RemoveImage("glovebox:base")
+		if err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "being used") {
+			t.Errorf("RemoveImage() error = %v", err)
+		}
+	})
+
+	t.Run("already started", func(t *testing.T) {
+		rt := fakeDaemon(t, map[string]http.HandlerFunc{
+			"POST /containers/c1/start": func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotModified) },
+		})
+		if err := rt.StartContainer("c1"); err != nil {
+			t.Errorf("StartContainer() error = %v, want nil for 304", err)
+		}
+	})
+
+	t.Run("unsupported host", func(t *testing.T) {
+		t.Setenv("DOCKER_HOST", "npipe:////./pipe/docker_engine")
+		if _, err := NewDocker(Stdio{}).ImageExists("x"); err == nil || !strings.Contains(err.Error(), "not supported") {
+			t.Errorf("ImageExists() error = %v", err)
+		}
+	})
+}
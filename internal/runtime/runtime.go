@@ -3,62 +3,261 @@ package runtime
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joelhelbling/glovebox/internal/labels"
 )
 
 // ErrNotSupported is returned when an operation is not supported by the runtime.
 var ErrNotSupported = errors.New("operation not supported by this runtime")
 
+// ErrNotFound is returned when an image, container or network doesn't exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrDaemonUnavailable is returned when the runtime's daemon can't be reached.
+var ErrDaemonUnavailable = errors.New("container runtime is not running")
+
 // Runtime abstracts a container runtime.
+//
+// Existence checks report a missing resource as false with a nil error; an
+// error means the runtime couldn't tell, e.g. ErrDaemonUnavailable.
 type Runtime interface {
 	// Name returns the human-readable runtime name (e.g., "Docker", "Apple Containers").
 	Name() string
 
 	// Image operations
-	ImageExists(name string) bool
+	ImageExists(name string) (bool, error)
 	GetImageDigest(name string) (string, error)
-	BuildImage(dockerfilePath, contextDir, imageName string) error
+	BuildImage(cfg BuildConfig) error
 	RemoveImage(name string) error
-	ListImages(filterRef string) ([]string, error)
+	ListImages(filter ListFilter) ([]string, error)
+	InspectImage(name string) (ImageInfo, error)
+	// ResolveImageDigest looks up the digest a tag points to in its
+	// registry, without pulling the image
+	ResolveImageDigest(ref string) (string, error)
+	// Saving and loading images as tar archives, to move them between
+	// machines without a registry (Capabilities.SupportsExport)
+	SaveImage(name string) (io.ReadCloser, error)
+	LoadImage(archive io.Reader) error
+	// Sharing images through registries, with the runtime's registry
+	// credentials
+	TagImage(source, target string) error
+	PushImage(ref string) error
+	PullImage(ref string) error
 
 	// Container lifecycle
-	ContainerExists(name string) bool
-	ContainerRunning(name string) bool
+	ContainerExists(name string) (bool, error)
+	ContainerRunning(name string) (bool, error)
 	RunInteractive(cfg RunConfig) error
+	CreateInteractive(cfg RunConfig) error // creates without starting; StartInteractive starts it
 	StartInteractive(name string) error
 	Attach(name string) error
 	RemoveContainer(name string) error
 	ForceRemoveContainer(name string) error
-	ListContainers(filterName string, all bool) ([]ContainerInfo, error)
+	ListContainers(filter ListFilter, all bool) ([]ContainerInfo, error)
+	ContainerLabels(name string) (map[string]string, error)
+
+	// Service containers run detached alongside the interactive container
+	RunDetached(cfg ServiceConfig) error
+	StartContainer(name string) error
+	StopContainer(name string) error
+
+	// Networks and volumes
+	NetworkExists(name string) (bool, error)
+	CreateNetwork(name string) error
+	RemoveNetwork(name string) error
+	ListNetworks(prefix string) ([]string, error)
+	RemoveVolume(name string) error
+	ListVolumes(prefix string) ([]string, error)
+
+	// DiskUsage reports the disk space used by all containers and volumes
+	DiskUsage() (DiskUsage, error)
 
 	// Container state inspection
 	Diff(name string) ([]FileDiff, error)
-	Commit(containerName, imageName string) error
+	Commit(containerName, imageName string, imageLabels map[string]string) error // labels replace the container's own
+
+	// Copying files in and out of a container, as tar archives. Used to sync
+	// the workspace with daemons that can't see host paths (Capabilities.Remote).
+	CopyToContainer(name, dir string, archive io.Reader) error
+	CopyFromContainer(name, path string) (io.ReadCloser, error)
+	ClearDirectory(name, dir string, helperLabels map[string]string) error // empties a directory of a stopped container
 
 	// Capabilities reports which optional features this runtime supports.
 	Capabilities() Capabilities
 }
 
+// BuildConfig holds the parameters for building an image.
+type BuildConfig struct {
+	DockerfilePath string
+	ContextDir     string
+	ImageName      string
+	Labels         map[string]string
+	BuildArgs      map[string]string // values for the Dockerfile's ARGs
+	Secrets        []BuildSecret     // mounted by RUN --mount=type=secret steps
+	Platforms      []string          // target platforms (e.g. linux/arm64); empty for the daemon's own
+	// Progress, if set, receives BuildKit's plain progress output in place
+	// of the terminal
+	Progress io.Writer
+}
+
+// BuildSecret is a value a build can read without it being stored in the
+// image. It comes from a host file or environment variable.
+type BuildSecret struct {
+	ID   string
+	File string // host file holding the value
+	Env  string // host environment variable holding the value
+}
+
+// arg renders the secret as a --secret flag value
+func (s BuildSecret) arg() string {
+	if s.File != "" {
+		return fmt.Sprintf("id=%s,src=%s", s.ID, s.File)
+	}
+	return fmt.Sprintf("id=%s,env=%s", s.ID, s.Env)
+}
+
+// formatPlatform renders a platform as os/arch[/variant]
+func formatPlatform(os, arch, variant string) string {
+	if os == "" || arch == "" {
+		return ""
+	}
+	if variant != "" {
+		return os + "/" + arch + "/" + variant
+	}
+	return os + "/" + arch
+}
+
+// ListFilter selects the images or containers to list. Zero fields match
+// everything.
+type ListFilter struct {
+	Name   string            // Images: reference pattern (e.g. "glovebox:*"). Containers: name substring.
+	Labels map[string]string // Labels to match; an empty value matches any value
+
+	// Dangling lists untagged images, such as those superseded by a rebuild,
+	// by ID instead of name. Images only.
+	Dangling bool
+
+	// Times reports when containers last started and finished, which Docker
+	// only tells by inspecting each one. Containers only.
+	Times bool
+}
+
 // RunConfig holds the parameters for creating and running a new container.
 type RunConfig struct {
+	ContainerName   string
+	ImageName       string
+	HostPath        string
+	WorkspacePath   string
+	WorkspaceVolume bool              // Keep the workspace in a volume instead of bind-mounting HostPath (remote daemons)
+	Env             map[string]string // Pre-resolved key=value pairs
+	Hostname        string            // Docker: --hostname flag. Apple Containers: ignored (--name sets hostname).
+	Mounts          []Mount           // Additional bind mounts beyond the workspace
+	Network         string            // Network to join (default: the runtime's default network)
+	Ports           []PortMapping     // Container ports published on the host
+	HostAlias       string            // Hostname resolving to the host. Apple Containers: added by the entrypoint.
+	Labels          map[string]string // Recorded on the container, see ContainerLabels
+	Platform        string            // Platform of the image to run, e.g. linux/amd64 (default: the daemon's own)
+}
+
+// PortMapping publishes a container port on the host.
+type PortMapping struct {
+	HostIP        string
+	HostPort      int
+	ContainerPort int
+	Protocol      string // "tcp" or "udp"
+}
+
+// String renders the mapping in the --publish syntax both runtimes accept.
+func (p PortMapping) String() string {
+	spec := fmt.Sprintf("%d:%d", p.HostPort, p.ContainerPort)
+	if p.HostIP != "" {
+		spec = p.HostIP + ":" + spec
+	}
+	if p.Protocol != "" {
+		spec += "/" + p.Protocol
+	}
+	return spec
+}
+
+// ServiceConfig holds the parameters for a detached service container.
+type ServiceConfig struct {
 	ContainerName string
 	ImageName     string
-	HostPath      string
-	WorkspacePath string
-	Env           map[string]string // Pre-resolved key=value pairs
-	Hostname      string            // Docker: --hostname flag. Apple Containers: ignored (--name sets hostname).
+	Network       string
+	Alias         string // Name other containers on the network reach it by. Apple Containers: ignored.
+	Env           map[string]string
+	Volumes       []VolumeMount
+	Command       []string // Overrides the image's default command when set
+	Labels        map[string]string
+}
+
+// VolumeMount is a named volume mounted into a container. Runtimes create
+// missing volumes on first use.
+type VolumeMount struct {
+	Name   string
+	Target string
+}
+
+// Mount is a host directory bind-mounted into a container.
+type Mount struct {
+	Source   string // host path
+	Target   string // container path
+	ReadOnly bool
 }
 
 // ContainerInfo represents a container returned by list operations.
 type ContainerInfo struct {
-	Name  string
-	Image string
+	Name       string
+	Image      string
+	ImageID    string // Apple Containers: not reported
+	Labels     map[string]string
+	Running    bool
+	Created    time.Time // Apple Containers: not reported
+	StartedAt  time.Time // listed with ListFilter.Times; Apple Containers: not reported
+	FinishedAt time.Time // listed with ListFilter.Times; Apple Containers: not reported
+}
+
+// LastUsed returns when the container last started or stopped, or zero if
+// the runtime doesn't report it.
+func (c ContainerInfo) LastUsed() time.Time {
+	last := c.Created
+	for _, t := range []time.Time{c.StartedAt, c.FinishedAt} {
+		if t.After(last) {
+			last = t
+		}
+	}
+	return last
+}
+
+// ImageInfo describes an image.
+type ImageInfo struct {
+	Name    string
+	ID      string
+	Size    int64 // bytes
+	Created time.Time
+	Labels  map[string]string
+	Layers  []string // layer digests, base layers first
+	// Platforms the image is built for, as os/arch[/variant]. Docker
+	// reports the one it would run.
+	Platforms []string
+}
+
+// DiskUsage is the disk space taken by containers and volumes, in bytes.
+type DiskUsage struct {
+	Containers map[string]int64 // by name; the writable layer
+	Volumes    map[string]int64 // by name
 }
 
 // FileDiff represents a single filesystem change in a container.
 type FileDiff struct {
-	ChangeType string // "A" (added), "C" (changed), "D" (deleted)
-	Path       string
+	ChangeType string `json:"type" yaml:"type"` // "A" (added), "C" (changed), "D" (deleted)
+	Path       string `json:"path" yaml:"path"`
 }
 
 // Capabilities describes which optional features a runtime supports.
@@ -66,6 +265,10 @@ type Capabilities struct {
 	SupportsDiff   bool
 	SupportsCommit bool
 	SupportsExport bool
+
+	// Remote reports that the daemon runs on another machine, so host paths
+	// can't be bind-mounted
+	Remote bool
 }
 
 // Stdio holds the I/O streams for interactive container operations.
@@ -74,3 +277,97 @@ type Stdio struct {
 	Stdout io.Writer
 	Stderr io.Writer
 }
+
+// envArgs renders env vars as sorted -e flags.
+func envArgs(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var args []string
+	for _, key := range keys {
+		args = append(args, "-e", fmt.Sprintf("%s=%s", key, env[key]))
+	}
+	return args
+}
+
+// labelArgs renders labels as sorted --label flags.
+func labelArgs(labels map[string]string) []string {
+	return keyValueArgs("--label", labels)
+}
+
+// buildArgArgs renders build args as sorted --build-arg flags.
+func buildArgArgs(buildArgs map[string]string) []string {
+	return keyValueArgs("--build-arg", buildArgs)
+}
+
+// keyValueArgs renders a map as a flag repeated for each key=value, sorted
+// by key.
+func keyValueArgs(flag string, values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var args []string
+	for _, key := range keys {
+		args = append(args, flag, fmt.Sprintf("%s=%s", key, values[key]))
+	}
+	return args
+}
+
+// labelFilterArgs renders a label selector as sorted --filter flags.
+func labelFilterArgs(selector map[string]string) []string {
+	var args []string
+	for _, f := range labels.Filters(selector) {
+		args = append(args, "--filter", "label="+f)
+	}
+	return args
+}
+
+// runQuiet runs a runtime command, folding its stderr into the error so
+// failures are explained without cluttering successful runs.
+func runQuiet(name string, args ...string) error {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%s %s: %s", name, args[0], msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// filterPrefix splits line-oriented command output, keeping names that
+// start with prefix. Name filters in runtimes match substrings.
+func filterPrefix(output []byte, prefix string) []string {
+	return withPrefix(strings.Split(strings.TrimSpace(string(output)), "\n"), prefix)
+}
+
+// withPrefix keeps the non-empty names that start with prefix.
+func withPrefix(names []string, prefix string) []string {
+	var kept []string
+	for _, name := range names {
+		if name != "" && strings.HasPrefix(name, prefix) {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// exists turns the error of an inspect call into the result of an
+// existence check: ErrNotFound is false, other errors are passed on.
+func exists(err error) (bool, error) {
+	if isNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// isNotFound reports whether err means the resource doesn't exist.
+func isNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
@@ -0,0 +1,904 @@
+// Package runtimetest provides an in-memory container runtime for testing
+// commands without Docker or Apple Containers.
+package runtimetest
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joelhelbling/glovebox/internal/runtime"
+)
+
+// Compile-time check that FakeRuntime implements runtime.Runtime.
+var _ runtime.Runtime = (*FakeRuntime)(nil)
+
+// Image is an image held by a FakeRuntime.
+type Image struct {
+	Name      string // empty once a rebuild or commit took its name (dangling)
+	ID        string
+	Size      int64
+	Created   time.Time
+	Labels    map[string]string
+	Layers    []string
+	Platforms []string
+}
+
+// Container is a container held by a FakeRuntime.
+type Container struct {
+	Name       string
+	Image      string
+	ImageID    string
+	Labels     map[string]string
+	Running    bool
+	Created    time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Size       int64                  // writable layer, reported by DiskUsage
+	Diffs      []runtime.FileDiff     // changes made in its sessions, reported by Diff
+	Workspace  map[string]string      // files copied in with CopyToContainer, by path relative to the workspace
+	Run        *runtime.RunConfig     // set for interactive containers
+	Service    *runtime.ServiceConfig // set for service containers
+
+	copied map[string]copiedFile // workspace files as copied in, to keep the times of unchanged ones
+}
+
+// copiedFile is a file as CopyToContainer received it
+type copiedFile struct {
+	hdr     *tar.Header
+	content string
+}
+
+// Commit records a commit of a container to an image.
+type Commit struct {
+	Container string
+	Image     string
+	Diffs     []runtime.FileDiff // the container's changes at the time
+}
+
+// FakeRuntime is an in-memory runtime.Runtime. Interactive sessions return
+// immediately, applying the changes scripted with ScriptSession; failures
+// are injected with FailOn.
+type FakeRuntime struct {
+	Caps  runtime.Capabilities
+	Clock func() time.Time // defaults to time.Now
+
+	// OnSession, when set, is called in every interactive session, e.g. to
+	// edit a synced workspace
+	OnSession func(c *Container)
+
+	// BuildOutput is the progress output BuildImage writes, even when it fails
+	BuildOutput string
+
+	// Registry maps tags to the digests ResolveImageDigest reports, standing
+	// in for image registries. Other tags are not found.
+	Registry map[string]string
+
+	// Remote holds the images pushed to registries, by reference, for
+	// PullImage to pull
+	Remote map[string]Image
+
+	Builds  []runtime.BuildConfig // every BuildImage call, in order
+	Commits []Commit              // every Commit call, in order
+	Calls   []string              // every call as "Method arg", in order
+
+	images     []*Image
+	containers map[string]*Container
+	networks   map[string]bool
+	volumes    map[string]int64
+	sessions   [][]runtime.FileDiff
+	failures   map[string]error
+	nextID     int
+}
+
+// New creates an empty fake runtime supporting diff, commit and export.
+func New() *FakeRuntime {
+	return &FakeRuntime{
+		Caps:       runtime.Capabilities{SupportsDiff: true, SupportsCommit: true, SupportsExport: true},
+		Clock:      time.Now,
+		containers: make(map[string]*Container),
+		networks:   make(map[string]bool),
+		volumes:    make(map[string]int64),
+		failures:   make(map[string]error),
+		Registry:   make(map[string]string),
+		Remote:     make(map[string]Image),
+	}
+}
+
+// AddImage adds an image, taking its name from any image that has it. A
+// missing ID or creation time is filled in.
+func (f *FakeRuntime) AddImage(img Image) *Image {
+	if img.ID == "" {
+		img.ID = f.newID()
+	}
+	if img.Created.IsZero() {
+		img.Created = f.Clock()
+	}
+	if old := f.Image(img.Name); old != nil && img.Name != "" {
+		old.Name = ""
+	}
+	f.images = append(f.images, &img)
+	return &img
+}
+
+// AddContainer adds a container. Its image ID is filled in from the image
+// when not set.
+func (f *FakeRuntime) AddContainer(c Container) *Container {
+	if c.ImageID == "" {
+		if img := f.Image(c.Image); img != nil {
+			c.ImageID = img.ID
+		}
+	}
+	if c.Created.IsZero() {
+		c.Created = f.Clock()
+	}
+	f.containers[c.Name] = &c
+	return &c
+}
+
+// AddVolume adds a volume of the given size.
+func (f *FakeRuntime) AddVolume(name string, size int64) {
+	f.volumes[name] = size
+}
+
+// Image returns the image with the given name or ID, or nil.
+func (f *FakeRuntime) Image(name string) *Image {
+	for _, img := range f.images {
+		if name != "" && (img.Name == name || img.ID == name) {
+			return img
+		}
+	}
+	return nil
+}
+
+// Images returns all images, dangling ones included.
+func (f *FakeRuntime) Images() []*Image {
+	return f.images
+}
+
+// Container returns the named container, or nil.
+func (f *FakeRuntime) Container(name string) *Container {
+	return f.containers[name]
+}
+
+// HasNetwork reports whether the named network exists.
+func (f *FakeRuntime) HasNetwork(name string) bool {
+	return f.networks[name]
+}
+
+// HasVolume reports whether the named volume exists.
+func (f *FakeRuntime) HasVolume(name string) bool {
+	_, ok := f.volumes[name]
+	return ok
+}
+
+// ScriptSession queues the changes the next interactive session (run,
+// start or attach) makes to its container's filesystem. Sessions without a
+// script make no changes.
+func (f *FakeRuntime) ScriptSession(diffs ...runtime.FileDiff) {
+	f.sessions = append(f.sessions, diffs)
+}
+
+// FailOn makes every call to the named method (e.g. "BuildImage") return
+// err, until cleared with a nil err. "*" fails every method.
+func (f *FakeRuntime) FailOn(method string, err error) {
+	if err == nil {
+		delete(f.failures, method)
+		return
+	}
+	f.failures[method] = err
+}
+
+// Called reports whether a call was made, e.g. "RemoveContainer glovebox-app-1234567".
+func (f *FakeRuntime) Called(call string) bool {
+	for _, c := range f.Calls {
+		if c == call {
+			return true
+		}
+	}
+	return false
+}
+
+// call records a call and returns the failure injected for it
+func (f *FakeRuntime) call(method string, args ...string) error {
+	f.Calls = append(f.Calls, strings.TrimSpace(method+" "+strings.Join(args, " ")))
+	if err, ok := f.failures[method]; ok {
+		return err
+	}
+	return f.failures["*"]
+}
+
+func (f *FakeRuntime) newID() string {
+	f.nextID++
+	sum := sha256.Sum256([]byte(fmt.Sprint(f.nextID)))
+	return fmt.Sprintf("sha256:%x", sum)
+}
+
+func (f *FakeRuntime) Name() string { return "Fake" }
+
+func (f *FakeRuntime) ImageExists(name string) (bool, error) {
+	if err := f.call("ImageExists", name); err != nil {
+		return false, err
+	}
+	return f.Image(name) != nil, nil
+}
+
+func (f *FakeRuntime) GetImageDigest(name string) (string, error) {
+	if err := f.call("GetImageDigest", name); err != nil {
+		return "", err
+	}
+	img := f.Image(name)
+	if img == nil {
+		return "", fmt.Errorf("image %s: %w", name, runtime.ErrNotFound)
+	}
+	return img.ID, nil
+}
+
+// BuildImage records the build and tags a new image with the build's labels.
+func (f *FakeRuntime) BuildImage(cfg runtime.BuildConfig) error {
+	if cfg.Progress != nil {
+		_, _ = io.WriteString(cfg.Progress, f.BuildOutput)
+	}
+	if err := f.call("BuildImage", cfg.ImageName); err != nil {
+		return err
+	}
+	f.Builds = append(f.Builds, cfg)
+	id := f.newID()
+	f.AddImage(Image{Name: cfg.ImageName, ID: id, Labels: maps.Clone(cfg.Labels), Layers: []string{id}, Platforms: slices.Clone(cfg.Platforms)})
+	return nil
+}
+
+func (f *FakeRuntime) RemoveImage(name string) error {
+	if err := f.call("RemoveImage", name); err != nil {
+		return err
+	}
+	img := f.Image(name)
+	if img == nil {
+		return fmt.Errorf("image %s: %w", name, runtime.ErrNotFound)
+	}
+	// Removing one of several tags of an image only untags it
+	tags := 0
+	for _, candidate := range f.images {
+		if candidate.ID == img.ID {
+			tags++
+		}
+	}
+	for _, c := range f.sortedContainers() {
+		if c.ImageID == img.ID && tags == 1 {
+			return fmt.Errorf("image %s is in use by container %s", name, c.Name)
+		}
+	}
+	for i, candidate := range f.images {
+		if candidate == img {
+			f.images = append(f.images[:i], f.images[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (f *FakeRuntime) ListImages(filter runtime.ListFilter) ([]string, error) {
+	if err := f.call("ListImages", filter.Name); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, img := range f.images {
+		if filter.Dangling != (img.Name == "") || !matchLabels(img.Labels, filter.Labels) {
+			continue
+		}
+		if filter.Dangling {
+			names = append(names, img.ID)
+			continue
+		}
+		if filter.Name != "" {
+			if ok, _ := path.Match(filter.Name, img.Name); !ok {
+				continue
+			}
+		}
+		names = append(names, img.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (f *FakeRuntime) InspectImage(name string) (runtime.ImageInfo, error) {
+	if err := f.call("InspectImage", name); err != nil {
+		return runtime.ImageInfo{}, err
+	}
+	img := f.Image(name)
+	if img == nil {
+		return runtime.ImageInfo{}, fmt.Errorf("image %s: %w", name, runtime.ErrNotFound)
+	}
+	return runtime.ImageInfo{Name: name, ID: img.ID, Size: img.Size, Created: img.Created, Labels: img.Labels, Layers: img.Layers, Platforms: img.Platforms}, nil
+}
+
+func (f *FakeRuntime) ResolveImageDigest(ref string) (string, error) {
+	if err := f.call("ResolveImageDigest", ref); err != nil {
+		return "", err
+	}
+	d, ok := f.Registry[ref]
+	if !ok {
+		return "", fmt.Errorf("resolving %s: %w", ref, runtime.ErrNotFound)
+	}
+	return d, nil
+}
+
+// SaveImage archives an image as JSON, which LoadImage reads back.
+func (f *FakeRuntime) SaveImage(name string) (io.ReadCloser, error) {
+	if err := f.call("SaveImage", name); err != nil {
+		return nil, err
+	}
+	if !f.Caps.SupportsExport {
+		return nil, runtime.ErrNotSupported
+	}
+	img := f.Image(name)
+	if img == nil {
+		return nil, fmt.Errorf("image %s: %w", name, runtime.ErrNotFound)
+	}
+	data, err := json.Marshal(img)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// LoadImage adds the image of an archive SaveImage returned, under its
+// name and ID.
+func (f *FakeRuntime) LoadImage(archive io.Reader) error {
+	if err := f.call("LoadImage"); err != nil {
+		return err
+	}
+	if !f.Caps.SupportsExport {
+		return runtime.ErrNotSupported
+	}
+	var img Image
+	if err := json.NewDecoder(archive).Decode(&img); err != nil {
+		return fmt.Errorf("loading image: %w", err)
+	}
+	if old := f.Image(img.ID); old != nil {
+		// Already here: only the tag moves
+		if tagged := f.Image(img.Name); tagged != nil && tagged != old {
+			tagged.Name = ""
+		}
+		old.Name = img.Name
+		return nil
+	}
+	f.AddImage(img)
+	return nil
+}
+
+// TagImage names an image again. The fake holds each tag as a copy of the
+// image with the same ID.
+func (f *FakeRuntime) TagImage(source, target string) error {
+	if err := f.call("TagImage", source, target); err != nil {
+		return err
+	}
+	img := f.Image(source)
+	if img == nil {
+		return fmt.Errorf("image %s: %w", source, runtime.ErrNotFound)
+	}
+	tagged := *img
+	tagged.Name = target
+	tagged.Labels = maps.Clone(img.Labels)
+	f.AddImage(tagged)
+	return nil
+}
+
+// PushImage copies an image to Remote and records its digest in Registry.
+func (f *FakeRuntime) PushImage(ref string) error {
+	if err := f.call("PushImage", ref); err != nil {
+		return err
+	}
+	img := f.Image(ref)
+	if img == nil {
+		return fmt.Errorf("image %s: %w", ref, runtime.ErrNotFound)
+	}
+	pushed := *img
+	pushed.Labels = maps.Clone(img.Labels)
+	f.Remote[ref] = pushed
+	f.Registry[ref] = img.ID
+	return nil
+}
+
+// PullImage adds the image pushed as ref to Remote.
+func (f *FakeRuntime) PullImage(ref string) error {
+	if err := f.call("PullImage", ref); err != nil {
+		return err
+	}
+	img, ok := f.Remote[ref]
+	if !ok {
+		return fmt.Errorf("pulling %s: %w", ref, runtime.ErrNotFound)
+	}
+	img.Name = ref
+	img.Labels = maps.Clone(img.Labels)
+	f.AddImage(img)
+	return nil
+}
+
+func (f *FakeRuntime) ContainerExists(name string) (bool, error) {
+	if err := f.call("ContainerExists", name); err != nil {
+		return false, err
+	}
+	return f.containers[name] != nil, nil
+}
+
+func (f *FakeRuntime) ContainerRunning(name string) (bool, error) {
+	if err := f.call("ContainerRunning", name); err != nil {
+		return false, err
+	}
+	c := f.containers[name]
+	return c != nil && c.Running, nil
+}
+
+// RunInteractive creates the container and runs a session in it, which
+// exits leaving the container stopped.
+func (f *FakeRuntime) RunInteractive(cfg runtime.RunConfig) error {
+	if err := f.call("RunInteractive", cfg.ContainerName); err != nil {
+		return err
+	}
+	if f.containers[cfg.ContainerName] != nil {
+		return fmt.Errorf("container name %s is already in use", cfg.ContainerName)
+	}
+	if f.Image(cfg.ImageName) == nil {
+		return fmt.Errorf("image %s: %w", cfg.ImageName, runtime.ErrNotFound)
+	}
+	run := cfg
+	c := f.AddContainer(Container{Name: cfg.ContainerName, Image: cfg.ImageName, Labels: maps.Clone(cfg.Labels), Run: &run})
+	f.session(c, true)
+	return nil
+}
+
+// CreateInteractive creates the container without starting it.
+func (f *FakeRuntime) CreateInteractive(cfg runtime.RunConfig) error {
+	if err := f.call("CreateInteractive", cfg.ContainerName); err != nil {
+		return err
+	}
+	if f.containers[cfg.ContainerName] != nil {
+		return fmt.Errorf("container name %s is already in use", cfg.ContainerName)
+	}
+	if f.Image(cfg.ImageName) == nil {
+		return fmt.Errorf("image %s: %w", cfg.ImageName, runtime.ErrNotFound)
+	}
+	run := cfg
+	f.AddContainer(Container{Name: cfg.ContainerName, Image: cfg.ImageName, Labels: maps.Clone(cfg.Labels), Run: &run})
+	return nil
+}
+
+// StartInteractive starts a stopped container and runs a session in it.
+func (f *FakeRuntime) StartInteractive(name string) error {
+	if err := f.call("StartInteractive", name); err != nil {
+		return err
+	}
+	c := f.containers[name]
+	if c == nil {
+		return fmt.Errorf("container %s: %w", name, runtime.ErrNotFound)
+	}
+	f.session(c, true)
+	return nil
+}
+
+// Attach runs a session in a running container, which keeps running.
+func (f *FakeRuntime) Attach(name string) error {
+	if err := f.call("Attach", name); err != nil {
+		return err
+	}
+	c := f.containers[name]
+	if c == nil || !c.Running {
+		return fmt.Errorf("container %s is not running", name)
+	}
+	f.session(c, false)
+	return nil
+}
+
+// session applies the next scripted session to a container
+func (f *FakeRuntime) session(c *Container, exits bool) {
+	c.Running = true
+	c.StartedAt = f.Clock()
+	if len(f.sessions) > 0 {
+		c.Diffs = append(c.Diffs, f.sessions[0]...)
+		f.sessions = f.sessions[1:]
+	}
+	if f.OnSession != nil {
+		f.OnSession(c)
+	}
+	if exits {
+		c.Running = false
+		c.FinishedAt = f.Clock()
+	}
+}
+
+func (f *FakeRuntime) RemoveContainer(name string) error {
+	if err := f.call("RemoveContainer", name); err != nil {
+		return err
+	}
+	c := f.containers[name]
+	if c == nil {
+		return fmt.Errorf("container %s: %w", name, runtime.ErrNotFound)
+	}
+	if c.Running {
+		return fmt.Errorf("cannot remove running container %s", name)
+	}
+	delete(f.containers, name)
+	return nil
+}
+
+func (f *FakeRuntime) ForceRemoveContainer(name string) error {
+	if err := f.call("ForceRemoveContainer", name); err != nil {
+		return err
+	}
+	if f.containers[name] == nil {
+		return fmt.Errorf("container %s: %w", name, runtime.ErrNotFound)
+	}
+	delete(f.containers, name)
+	return nil
+}
+
+func (f *FakeRuntime) ListContainers(filter runtime.ListFilter, all bool) ([]runtime.ContainerInfo, error) {
+	if err := f.call("ListContainers", filter.Name); err != nil {
+		return nil, err
+	}
+	var infos []runtime.ContainerInfo
+	for _, c := range f.sortedContainers() {
+		if (!all && !c.Running) || !strings.Contains(c.Name, filter.Name) || !matchLabels(c.Labels, filter.Labels) {
+			continue
+		}
+		info := runtime.ContainerInfo{
+			Name:    c.Name,
+			Image:   c.Image,
+			ImageID: c.ImageID,
+			Labels:  c.Labels,
+			Running: c.Running,
+			Created: c.Created,
+		}
+		// Like Docker, only when asked for
+		if filter.Times {
+			info.StartedAt, info.FinishedAt = c.StartedAt, c.FinishedAt
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (f *FakeRuntime) ContainerLabels(name string) (map[string]string, error) {
+	if err := f.call("ContainerLabels", name); err != nil {
+		return nil, err
+	}
+	c := f.containers[name]
+	if c == nil {
+		return nil, fmt.Errorf("container %s: %w", name, runtime.ErrNotFound)
+	}
+	return c.Labels, nil
+}
+
+// RunDetached starts a service container, pulling its image when missing.
+func (f *FakeRuntime) RunDetached(cfg runtime.ServiceConfig) error {
+	if err := f.call("RunDetached", cfg.ContainerName); err != nil {
+		return err
+	}
+	if f.containers[cfg.ContainerName] != nil {
+		return fmt.Errorf("container name %s is already in use", cfg.ContainerName)
+	}
+	if f.Image(cfg.ImageName) == nil {
+		f.AddImage(Image{Name: cfg.ImageName})
+	}
+	for _, v := range cfg.Volumes {
+		if _, ok := f.volumes[v.Name]; !ok {
+			f.volumes[v.Name] = 0
+		}
+	}
+	svc := cfg
+	c := f.AddContainer(Container{Name: cfg.ContainerName, Image: cfg.ImageName, Labels: maps.Clone(cfg.Labels), Service: &svc})
+	c.Running = true
+	c.StartedAt = f.Clock()
+	return nil
+}
+
+func (f *FakeRuntime) StartContainer(name string) error {
+	if err := f.call("StartContainer", name); err != nil {
+		return err
+	}
+	c := f.containers[name]
+	if c == nil {
+		return fmt.Errorf("container %s: %w", name, runtime.ErrNotFound)
+	}
+	c.Running = true
+	c.StartedAt = f.Clock()
+	return nil
+}
+
+func (f *FakeRuntime) StopContainer(name string) error {
+	if err := f.call("StopContainer", name); err != nil {
+		return err
+	}
+	c := f.containers[name]
+	if c == nil {
+		return fmt.Errorf("container %s: %w", name, runtime.ErrNotFound)
+	}
+	c.Running = false
+	c.FinishedAt = f.Clock()
+	return nil
+}
+
+func (f *FakeRuntime) NetworkExists(name string) (bool, error) {
+	if err := f.call("NetworkExists", name); err != nil {
+		return false, err
+	}
+	return f.networks[name], nil
+}
+
+func (f *FakeRuntime) CreateNetwork(name string) error {
+	if err := f.call("CreateNetwork", name); err != nil {
+		return err
+	}
+	f.networks[name] = true
+	return nil
+}
+
+func (f *FakeRuntime) RemoveNetwork(name string) error {
+	if err := f.call("RemoveNetwork", name); err != nil {
+		return err
+	}
+	if !f.networks[name] {
+		return fmt.Errorf("network %s: %w", name, runtime.ErrNotFound)
+	}
+	delete(f.networks, name)
+	return nil
+}
+
+func (f *FakeRuntime) ListNetworks(prefix string) ([]string, error) {
+	if err := f.call("ListNetworks", prefix); err != nil {
+		return nil, err
+	}
+	return withPrefix(f.networks, prefix), nil
+}
+
+func (f *FakeRuntime) RemoveVolume(name string) error {
+	if err := f.call("RemoveVolume", name); err != nil {
+		return err
+	}
+	if _, ok := f.volumes[name]; !ok {
+		return fmt.Errorf("volume %s: %w", name, runtime.ErrNotFound)
+	}
+	delete(f.volumes, name)
+	return nil
+}
+
+func (f *FakeRuntime) ListVolumes(prefix string) ([]string, error) {
+	if err := f.call("ListVolumes", prefix); err != nil {
+		return nil, err
+	}
+	return withPrefix(f.volumes, prefix), nil
+}
+
+func (f *FakeRuntime) DiskUsage() (runtime.DiskUsage, error) {
+	if err := f.call("DiskUsage"); err != nil {
+		return runtime.DiskUsage{}, err
+	}
+	usage := runtime.DiskUsage{Containers: make(map[string]int64), Volumes: maps.Clone(f.volumes)}
+	for name, c := range f.containers {
+		usage.Containers[name] = c.Size
+	}
+	return usage, nil
+}
+
+func (f *FakeRuntime) Diff(name string) ([]runtime.FileDiff, error) {
+	if err := f.call("Diff", name); err != nil {
+		return nil, err
+	}
+	if !f.Caps.SupportsDiff {
+		return nil, runtime.ErrNotSupported
+	}
+	c := f.containers[name]
+	if c == nil {
+		return nil, fmt.Errorf("container %s: %w", name, runtime.ErrNotFound)
+	}
+	return append([]runtime.FileDiff(nil), c.Diffs...), nil
+}
+
+// Commit tags a new image holding the container's changes. Like Docker, it
+// labels the image with the container's labels, overridden by imageLabels.
+func (f *FakeRuntime) Commit(containerName, imageName string, imageLabels map[string]string) error {
+	if err := f.call("Commit", containerName, imageName); err != nil {
+		return err
+	}
+	if !f.Caps.SupportsCommit {
+		return runtime.ErrNotSupported
+	}
+	c := f.containers[containerName]
+	if c == nil {
+		return fmt.Errorf("container %s: %w", containerName, runtime.ErrNotFound)
+	}
+	img := Image{Name: imageName, ID: f.newID(), Labels: maps.Clone(c.Labels)}
+	if img.Labels == nil {
+		img.Labels = map[string]string{}
+	}
+	maps.Copy(img.Labels, imageLabels)
+	if from := f.Image(c.ImageID); from != nil {
+		img.Layers = append(append([]string(nil), from.Layers...), img.ID)
+	}
+	f.AddImage(img)
+	f.Commits = append(f.Commits, Commit{Container: containerName, Image: imageName, Diffs: append([]runtime.FileDiff(nil), c.Diffs...)})
+	return nil
+}
+
+// CopyToContainer extracts the regular files of an archive into the
+// container's Workspace; dir must be its workspace path.
+func (f *FakeRuntime) CopyToContainer(name, dir string, archive io.Reader) error {
+	if err := f.call("CopyToContainer", name, dir); err != nil {
+		return err
+	}
+	c := f.containers[name]
+	if c == nil {
+		return fmt.Errorf("container %s: %w", name, runtime.ErrNotFound)
+	}
+	if c.Run == nil || c.Run.WorkspacePath != dir {
+		return fmt.Errorf("%s is not the workspace of container %s", dir, name)
+	}
+	if c.Workspace == nil {
+		c.Workspace = make(map[string]string)
+	}
+	tr := tar.NewReader(archive)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		file := path.Clean(hdr.Name)
+		c.Workspace[file] = string(data)
+		if c.copied == nil {
+			c.copied = make(map[string]copiedFile)
+		}
+		c.copied[file] = copiedFile{hdr: hdr, content: string(data)}
+	}
+}
+
+// CopyFromContainer archives the container's Workspace the way the Docker
+// API does, under the workspace directory's base name; path must be its
+// workspace path. Files changed since they were copied in are dated at the
+// fake's clock.
+func (f *FakeRuntime) CopyFromContainer(name, p string) (io.ReadCloser, error) {
+	if err := f.call("CopyFromContainer", name, p); err != nil {
+		return nil, err
+	}
+	c := f.containers[name]
+	if c == nil {
+		return nil, fmt.Errorf("container %s: %w", name, runtime.ErrNotFound)
+	}
+	if c.Run == nil || c.Run.WorkspacePath != p {
+		return nil, fmt.Errorf("%s is not the workspace of container %s", p, name)
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	base := path.Base(p)
+	if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: base + "/", Mode: 0o755}); err != nil {
+		return nil, err
+	}
+	for _, file := range slices.Sorted(maps.Keys(c.Workspace)) {
+		content := c.Workspace[file]
+		hdr := &tar.Header{Typeflag: tar.TypeReg, Name: base + "/" + file, Mode: 0o644, Size: int64(len(content)), ModTime: f.Clock()}
+		if in, ok := c.copied[file]; ok && in.content == content {
+			hdr.Mode, hdr.ModTime = in.hdr.Mode, in.hdr.ModTime
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(tw, content); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(&buf), nil
+}
+
+// ClearDirectory empties the container's Workspace; dir must be its
+// workspace path.
+func (f *FakeRuntime) ClearDirectory(name, dir string, helperLabels map[string]string) error {
+	if err := f.call("ClearDirectory", name, dir); err != nil {
+		return err
+	}
+	c := f.containers[name]
+	if c == nil {
+		return fmt.Errorf("container %s: %w", name, runtime.ErrNotFound)
+	}
+	if c.Run == nil || c.Run.WorkspacePath != dir {
+		return fmt.Errorf("%s is not the workspace of container %s", dir, name)
+	}
+	c.Workspace, c.copied = nil, nil
+	return nil
+}
+
+func (f *FakeRuntime) Capabilities() runtime.Capabilities {
+	return f.Caps
+}
+
+func (f *FakeRuntime) sortedContainers() []*Container {
+	names := make([]string, 0, len(f.containers))
+	for name := range f.containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	containers := make([]*Container, 0, len(names))
+	for _, name := range names {
+		containers = append(containers, f.containers[name])
+	}
+	return containers
+}
+
+// matchLabels reports whether labels satisfy a selector; an empty selector
+// value matches any value
+func matchLabels(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		got, ok := labels[k]
+		if !ok || (v != "" && got != v) {
+			return false
+		}
+	}
+	return true
+}
+
+func withPrefix[V any](set map[string]V, prefix string) []string {
+	var names []string
+	for name := range set {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseDiff reads changes in `docker diff` format, one "A|C|D path" per
+// line. Blank lines and lines starting with # are skipped.
+func ParseDiff(text string) ([]runtime.FileDiff, error) {
+	var diffs []runtime.FileDiff
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		kind, p, ok := strings.Cut(entry, " ")
+		if !ok || (kind != "A" && kind != "C" && kind != "D") {
+			return nil, fmt.Errorf("line %d: want \"A|C|D path\", got %q", line, entry)
+		}
+		diffs = append(diffs, runtime.FileDiff{ChangeType: kind, Path: p})
+	}
+	return diffs, scanner.Err()
+}
+
+// LoadDiff reads a diff fixture file in ParseDiff's format.
+func LoadDiff(file string) ([]runtime.FileDiff, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	diffs, err := ParseDiff(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return diffs, nil
+}
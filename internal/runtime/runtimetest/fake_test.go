@@ -0,0 +1,79 @@
+package runtimetest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/joelhelbling/glovebox/internal/runtime"
+)
+
+func TestParseDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []runtime.FileDiff
+		wantErr bool
+	}{
+		{
+			name: "entries, comments and blank lines",
+			text: "# brew install\nA /usr/local/bin/rg\n\nC /home/dev/.bashrc\nD /tmp/old\n",
+			want: []runtime.FileDiff{
+				{ChangeType: "A", Path: "/usr/local/bin/rg"},
+				{ChangeType: "C", Path: "/home/dev/.bashrc"},
+				{ChangeType: "D", Path: "/tmp/old"},
+			},
+		},
+		{name: "empty", text: ""},
+		{name: "unknown kind", text: "X /etc/hosts", wantErr: true},
+		{name: "missing path", text: "A", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDiff(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFakeRuntime(t *testing.T) {
+	t.Run("scripted sessions change the container", func(t *testing.T) {
+		f := New()
+		f.AddImage(Image{Name: "img"})
+		f.ScriptSession(runtime.FileDiff{ChangeType: "A", Path: "/a"})
+		if err := f.RunInteractive(runtime.RunConfig{ContainerName: "c", ImageName: "img"}); err != nil {
+			t.Fatal(err)
+		}
+		diffs, err := f.Diff("c")
+		if err != nil || len(diffs) != 1 || diffs[0].Path != "/a" {
+			t.Errorf("diffs = %+v, %v", diffs, err)
+		}
+	})
+
+	t.Run("failure injection", func(t *testing.T) {
+		f := New()
+		boom := errors.New("boom")
+		f.FailOn("ImageExists", boom)
+		if _, err := f.ImageExists("img"); !errors.Is(err, boom) {
+			t.Errorf("error = %v, want boom", err)
+		}
+		f.FailOn("ImageExists", nil)
+		if _, err := f.ImageExists("img"); err != nil {
+			t.Errorf("error = %v after clearing", err)
+		}
+	})
+
+	t.Run("images in use can't be removed", func(t *testing.T) {
+		f := New()
+		f.AddImage(Image{Name: "img"})
+		f.AddContainer(Container{Name: "c", Image: "img"})
+		if err := f.RemoveImage("img"); err == nil {
+			t.Error("removed an image a container uses")
+		}
+	})
+}
@@ -15,6 +15,9 @@ type BannerInfo struct {
 	ContainerStatus string // "new", "existing", "running"
 	OS              string // base OS name (ubuntu, fedora, alpine)
 	PassthroughEnv  []string
+	Services        []string // e.g. "db (postgres:16)"
+	Ports           []string // e.g. "localhost:8080 → 3000"
+	HostServices    []string // e.g. "ollama (host.glovebox.internal:11434)"
 }
 
 // Banner renders the glovebox startup banner
@@ -92,6 +95,19 @@ func (b *Banner) Render(info BannerInfo) string {
 		line(labelValue("Env", strings.Join(info.PassthroughEnv, ", ")))
 	}
 
+	// Sidecar services (if any)
+	if len(info.Services) > 0 {
+		line(labelValue("Services", strings.Join(info.Services, ", ")))
+	}
+
+	// Published ports and host services (if any)
+	if len(info.Ports) > 0 {
+		line(labelValue("Ports", strings.Join(info.Ports, ", ")))
+	}
+	if len(info.HostServices) > 0 {
+		line(labelValue("Host", strings.Join(info.HostServices, ", ")))
+	}
+
 	sb.WriteString("\n")
 
 	return sb.String()
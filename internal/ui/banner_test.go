@@ -61,6 +61,29 @@ func TestBannerRenderWithoutEnv(t *testing.T) {
 	}
 }
 
+func TestBannerRenderServices(t *testing.T) {
+	banner := NewBanner()
+
+	info := BannerInfo{
+		Workspace:       "~/code/myproject",
+		Image:           "glovebox:myproject-abc123",
+		Container:       "glovebox-myproject-abc123",
+		ContainerStatus: "new",
+		Services:        []string{"db (postgres:16)", "cache (redis:7)"},
+	}
+
+	output := banner.Render(info)
+
+	if !strings.Contains(output, "db (postgres:16)") || !strings.Contains(output, "cache (redis:7)") {
+		t.Error("expected banner to contain services")
+	}
+
+	info.Services = nil
+	if strings.Contains(banner.Render(info), "Services") {
+		t.Error("expected banner to not contain 'Services' line when there are no services")
+	}
+}
+
 func TestTerminalVerticalBar(t *testing.T) {
 	term := NewTerminal()
 
@@ -9,17 +9,17 @@ import (
 
 // ModInfo represents a mod for display
 type ModInfo struct {
-	Name         string   // just the mod name without category prefix (base name for OS variants)
-	Description  string   // human-readable description (consolidated, without OS suffix)
-	Provides     []string // what this mod provides (for display)
-	SupportedOSs []string // OS variants available for this mod (empty if OS-agnostic)
-	Error        bool     // true if there was an error loading this mod
+	Name         string   `json:"name" yaml:"name"`                                     // just the mod name without category prefix (base name for OS variants)
+	Description  string   `json:"description,omitempty" yaml:"description,omitempty"`   // human-readable description (consolidated, without OS suffix)
+	Provides     []string `json:"provides,omitempty" yaml:"provides,omitempty"`         // what this mod provides (for display)
+	SupportedOSs []string `json:"supported_os,omitempty" yaml:"supported_os,omitempty"` // OS variants available for this mod (empty if OS-agnostic)
+	Error        bool     `json:"error,omitempty" yaml:"error,omitempty"`               // true if there was an error loading this mod
 }
 
 // ModCategory represents a category of mods
 type ModCategory struct {
-	Name string // category name (e.g., "ai", "editors")
-	Mods []ModInfo
+	Name string    `json:"name" yaml:"name"` // category name (e.g., "ai", "editors")
+	Mods []ModInfo `json:"mods" yaml:"mods"`
 }
 
 // ModList renders the mod list output
@@ -9,18 +9,18 @@ import (
 
 // StatusSection represents a section in the status output
 type StatusSection struct {
-	Title string
-	Items []StatusItem
+	Title string       `json:"title" yaml:"title"`
+	Items []StatusItem `json:"items" yaml:"items"`
 }
 
 // StatusItem represents a single item in a status section
 type StatusItem struct {
-	Label  string
-	Value  string
-	Status ItemStatus
-	Indent int  // 0 = normal, 1 = sub-item, 2 = sub-sub-item
-	IsList bool // true for list items (mods, etc.)
-	Note   string
+	Label  string     `json:"label,omitempty" yaml:"label,omitempty"`
+	Value  string     `json:"value,omitempty" yaml:"value,omitempty"`
+	Status ItemStatus `json:"status,omitempty" yaml:"status,omitempty"`
+	Indent int        `json:"indent,omitempty" yaml:"indent,omitempty"`   // 0 = normal, 1 = sub-item, 2 = sub-sub-item
+	IsList bool       `json:"is_list,omitempty" yaml:"is_list,omitempty"` // true for list items (mods, etc.)
+	Note   string     `json:"note,omitempty" yaml:"note,omitempty"`
 }
 
 // ItemStatus indicates the state of a status item
@@ -33,6 +33,20 @@ const (
 	StatusInfo
 )
 
+// MarshalText names the status in machine-readable output
+func (s ItemStatus) MarshalText() ([]byte, error) {
+	switch s {
+	case StatusOK:
+		return []byte("ok"), nil
+	case StatusWarning:
+		return []byte("warning"), nil
+	case StatusInfo:
+		return []byte("info"), nil
+	default:
+		return []byte(""), nil
+	}
+}
+
 // Status renders the glovebox status output
 type Status struct {
 	term *Terminal
@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestStatusItemEncoding(t *testing.T) {
+	items := []StatusItem{
+		{Label: "Image", Value: "glovebox:base", Status: StatusWarning, Note: "Run 'glovebox build --base' to build."},
+		{Value: "os/ubuntu", IsList: true, Indent: 1},
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `[{"label":"Image","value":"glovebox:base","status":"warning","note":"Run 'glovebox build --base' to build."},` +
+		`{"value":"os/ubuntu","indent":1,"is_list":true}]`
+	if string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+
+	data, err = yaml.Marshal(StatusItem{Label: "Status", Value: "Running", Status: StatusOK})
+	if err != nil {
+		t.Fatalf("yaml.Marshal() error = %v", err)
+	}
+	if string(data) != "label: Status\nvalue: Running\nstatus: ok\n" {
+		t.Errorf("yaml.Marshal() = %q", data)
+	}
+}
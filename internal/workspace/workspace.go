@@ -0,0 +1,359 @@
+// Package workspace syncs a project directory with a container that can't
+// bind-mount it, such as one on a remote Docker host. The workspace travels
+// as tar archives in the format of the Docker API's archive endpoint.
+package workspace
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ConflictSuffix is appended to the name of a file changed both in the
+// project and in the container, for the container's version
+const ConflictSuffix = ".glovebox-conflict"
+
+// Stats counts what Unpack changed in the project
+type Stats struct {
+	Updated   int      // files and links written
+	Removed   int      // files and links deleted in the container
+	Conflicts []string // files changed in both, left as the project has them
+}
+
+// File is a packed file or symlink as the project had it
+type File struct {
+	Size    int64     `json:"size,omitempty"`
+	ModTime time.Time `json:"mod_time,omitzero"` // whole seconds; zero for links
+	Link    string    `json:"link,omitempty"`
+}
+
+// Snapshot is the files an archive was packed from, by slash-separated
+// path relative to the project. Unpack tells from it which side of the
+// sync changed a file.
+type Snapshot map[string]File
+
+// LoadSnapshot reads a snapshot saved with Save. A missing file is an
+// empty snapshot.
+func LoadSnapshot(file string) (Snapshot, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", file, err)
+	}
+	return s, nil
+}
+
+// Save writes the snapshot to file
+func (s Snapshot) Save(file string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0o644)
+}
+
+// Pack writes a tar archive of dir's contents to w, entries named relative
+// to dir, and returns a snapshot of the files and symlinks it contains.
+// Other special files (sockets, devices) are skipped.
+func Pack(dir string, w io.Writer) (Snapshot, error) {
+	tw := tar.NewWriter(w)
+	files := make(Snapshot)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var link string
+		switch {
+		case info.Mode().IsRegular(), info.IsDir():
+		case info.Mode()&fs.ModeSymlink != 0:
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		// The receiving side sets ownership; whole seconds round-trip exactly
+		hdr.Name = name
+		hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+		hdr.ModTime = info.ModTime().Truncate(time.Second)
+		if info.IsDir() {
+			hdr.Name += "/"
+		} else {
+			files[name] = fileOf(hdr)
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			return copyFile(tw, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("packing %s: %w", dir, err)
+	}
+	return files, tw.Close()
+}
+
+func copyFile(w io.Writer, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// Unpack extracts an archive of a container directory, its entries under
+// the directory's base name, into dir. pushed is the snapshot of what was
+// packed into the container; a file is only written or removed when the
+// container changed it and the project didn't. A file changed in both is
+// left alone, the container's version written beside it with
+// ConflictSuffix, and reported in Stats.Conflicts.
+//
+// Entries can't reach outside dir, through ".." or symlinks: the container
+// is untrusted.
+func Unpack(r io.Reader, dir string, pushed Snapshot) (Stats, error) {
+	var stats Stats
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return stats, err
+	}
+	defer root.Close()
+
+	seen := make(map[string]bool)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return stats, fmt.Errorf("reading workspace archive: %w", err)
+		}
+
+		entry := path.Clean(hdr.Name)
+		if !filepath.IsLocal(filepath.FromSlash(entry)) {
+			return stats, fmt.Errorf("workspace archive entry %q is outside the workspace", hdr.Name)
+		}
+		// Strip the directory's own name
+		_, slashed, _ := strings.Cut(entry, "/")
+		if slashed == "" {
+			continue
+		}
+		name := filepath.FromSlash(slashed)
+		seen[slashed] = true
+
+		if hdr.Typeflag == tar.TypeReg || hdr.Typeflag == tar.TypeSymlink {
+			base, wasPushed := pushed[slashed]
+			if wasPushed && base.same(fileOf(hdr)) {
+				// Unchanged in the container: the project's copy stands
+				continue
+			}
+			current, err := stat(root, name)
+			if err != nil {
+				return stats, fmt.Errorf("extracting %s: %w", name, err)
+			}
+			if current != nil && current.same(fileOf(hdr)) {
+				continue
+			}
+			if projectChanged(current, base, wasPushed) {
+				stats.Conflicts = append(stats.Conflicts, slashed)
+				name += ConflictSuffix
+			}
+		}
+
+		changed, err := extract(root, name, hdr, tr)
+		if err != nil {
+			return stats, fmt.Errorf("extracting %s: %w", name, err)
+		}
+		if changed {
+			stats.Updated++
+		}
+	}
+
+	for _, slashed := range slices.Sorted(maps.Keys(pushed)) {
+		if seen[slashed] {
+			continue
+		}
+		name := filepath.FromSlash(slashed)
+		current, err := stat(root, name)
+		if err != nil {
+			return stats, fmt.Errorf("removing %s: %w", name, err)
+		}
+		if current == nil {
+			continue
+		}
+		if !current.same(pushed[slashed]) {
+			stats.Conflicts = append(stats.Conflicts, slashed)
+			continue
+		}
+		if err := root.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return stats, fmt.Errorf("removing %s: %w", name, err)
+		}
+		stats.Removed++
+	}
+	return stats, nil
+}
+
+// projectChanged reports whether the project's copy of a file (nil when
+// missing) changed since it was pushed. A file that wasn't pushed changed
+// when the project has one now.
+func projectChanged(current *File, base File, wasPushed bool) bool {
+	if !wasPushed {
+		return current != nil
+	}
+	return current == nil || !current.same(base)
+}
+
+func (f File) same(other File) bool {
+	return f.Size == other.Size && f.ModTime.Equal(other.ModTime) && f.Link == other.Link
+}
+
+// fileOf describes an archive entry as a snapshot does
+func fileOf(hdr *tar.Header) File {
+	if hdr.Typeflag == tar.TypeSymlink {
+		return File{Link: hdr.Linkname}
+	}
+	return File{Size: hdr.Size, ModTime: hdr.ModTime.Truncate(time.Second).UTC()}
+}
+
+// stat describes a file or symlink in the project as a snapshot does,
+// returning nil when there's none. Anything else, such as a directory, is
+// an empty File, unlike any packed one.
+func stat(root *os.Root, name string) (*File, error) {
+	info, err := root.Lstat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case info.Mode().IsRegular():
+		return &File{Size: info.Size(), ModTime: info.ModTime().Truncate(time.Second).UTC()}, nil
+	case info.Mode()&fs.ModeSymlink != 0:
+		link, err := root.Readlink(name)
+		if err != nil {
+			return nil, err
+		}
+		return &File{Link: link}, nil
+	}
+	return &File{}, nil
+}
+
+// extract writes one archive entry, reporting whether it changed anything
+func extract(root *os.Root, name string, hdr *tar.Header, r io.Reader) (bool, error) {
+	existing, err := root.Lstat(name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+	mode := fs.FileMode(hdr.Mode).Perm()
+	if dir := filepath.Dir(name); dir != "." {
+		if err := root.MkdirAll(dir, 0o755); err != nil {
+			return false, err
+		}
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if existing != nil && existing.IsDir() {
+			return false, nil
+		}
+		if err := replace(root, name, existing); err != nil {
+			return false, err
+		}
+		return true, root.Mkdir(name, mode|0o700)
+
+	case tar.TypeReg:
+		if existing != nil && existing.Mode().IsRegular() && existing.Size() == hdr.Size &&
+			existing.ModTime().Truncate(time.Second).Equal(hdr.ModTime.Truncate(time.Second)) {
+			return false, nil
+		}
+		if existing != nil && existing.IsDir() {
+			if err := replace(root, name, existing); err != nil {
+				return false, err
+			}
+		}
+		// Write beside the file and rename over it, which replaces a
+		// symlink rather than writing through it
+		tmp := filepath.Join(filepath.Dir(name), ".glovebox-sync-"+filepath.Base(name))
+		f, err := root.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+		if err != nil {
+			return false, err
+		}
+		_, err = io.Copy(f, r)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = root.Chmod(tmp, mode)
+		}
+		if err == nil {
+			err = root.Chtimes(tmp, hdr.ModTime, hdr.ModTime)
+		}
+		if err == nil {
+			err = root.Rename(tmp, name)
+		}
+		if err != nil {
+			_ = root.Remove(tmp)
+			return false, err
+		}
+		return true, nil
+
+	case tar.TypeSymlink:
+		if existing != nil && existing.Mode()&fs.ModeSymlink != 0 {
+			if target, err := root.Readlink(name); err == nil && target == hdr.Linkname {
+				return false, nil
+			}
+		}
+		if err := replace(root, name, existing); err != nil {
+			return false, err
+		}
+		return true, root.Symlink(hdr.Linkname, name)
+	}
+	return false, nil
+}
+
+// replace clears the way for an entry of another type
+func replace(root *os.Root, name string, existing fs.FileInfo) error {
+	if existing == nil {
+		return nil
+	}
+	return root.RemoveAll(name)
+}
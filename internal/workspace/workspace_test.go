@@ -0,0 +1,284 @@
+package workspace
+
+import (
+	"archive/tar"
+	"bytes"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFiles creates files (name → content) under dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// asContainer turns a Pack archive into what the Docker API returns for the
+// workspace directory: the same entries under the directory's base name.
+// edit changes or drops entries; added are files created in the container.
+func asContainer(t *testing.T, archive []byte, base string, edit func(hdr *tar.Header, content []byte) ([]byte, bool), added ...*tar.Header) *bytes.Buffer {
+	t.Helper()
+	var out bytes.Buffer
+	tw := tar.NewWriter(&out)
+	if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: base + "/", Mode: 0o755}); err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(bytes.NewReader(archive))
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		var content bytes.Buffer
+		_, _ = content.ReadFrom(tr)
+		data := content.Bytes()
+		if edit != nil {
+			var keep bool
+			if data, keep = edit(hdr, data); !keep {
+				continue
+			}
+		}
+		hdr.Name = base + "/" + hdr.Name
+		hdr.Size = int64(len(data))
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = tw.Write(data)
+	}
+	for _, hdr := range added {
+		hdr.Name = base + "/" + hdr.Name
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = tw.Write(bytes.Repeat([]byte("x"), int(hdr.Size)))
+	}
+	_ = tw.Close()
+	return &out
+}
+
+func pack(t *testing.T, dir string) ([]byte, Snapshot) {
+	t.Helper()
+	var buf bytes.Buffer
+	files, err := Pack(dir, &buf)
+	if err != nil {
+		t.Fatalf("Pack() error = %v", err)
+	}
+	return buf.Bytes(), files
+}
+
+func TestPack(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"main.go": "package main", "src/lib.go": "package src"})
+	if err := os.Symlink("src/lib.go", filepath.Join(dir, "lib.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, files := pack(t, dir)
+	if want := []string{"lib.go", "main.go", "src/lib.go"}; !slices.Equal(slices.Sorted(maps.Keys(files)), want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+	if files["lib.go"] != (File{Link: "src/lib.go"}) || files["main.go"].Size != 12 {
+		t.Errorf("files = %+v", files)
+	}
+
+	var names []string
+	tr := tar.NewReader(bytes.NewReader(archive))
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, hdr.Name)
+		if hdr.Name == "lib.go" && (hdr.Typeflag != tar.TypeSymlink || hdr.Linkname != "src/lib.go") {
+			t.Errorf("lib.go = %+v, want a symlink", hdr)
+		}
+		if hdr.Uid != 0 || hdr.Uname != "" {
+			t.Errorf("%s keeps host ownership", hdr.Name)
+		}
+	}
+	if want := "./ lib.go main.go src/ src/lib.go"; strings.Join(names, " ") != want {
+		t.Errorf("entries = %q, want %q", strings.Join(names, " "), want)
+	}
+}
+
+func TestUnpack(t *testing.T) {
+	t.Run("unchanged round trip", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"main.go": "package main", "src/lib.go": "package src"})
+		archive, files := pack(t, dir)
+
+		stats, err := Unpack(asContainer(t, archive, "app", nil), dir, files)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(stats, Stats{}) {
+			t.Errorf("stats = %+v, want nothing changed", stats)
+		}
+	})
+
+	t.Run("changes, additions and deletions", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"main.go": "package main", "old.txt": "bye", "keep.txt": "same"})
+		archive, files := pack(t, dir)
+
+		later := time.Now().Add(time.Hour)
+		edited := asContainer(t, archive, "app", func(hdr *tar.Header, content []byte) ([]byte, bool) {
+			switch hdr.Name {
+			case "old.txt":
+				return nil, false
+			case "main.go":
+				hdr.ModTime = later
+				return []byte("package main // edited"), true
+			}
+			return content, true
+		}, &tar.Header{Typeflag: tar.TypeReg, Name: "gen/new.txt", Mode: 0o600, Size: 3, ModTime: later})
+
+		stats, err := Unpack(edited, dir, files)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(stats, Stats{Updated: 2, Removed: 1}) {
+			t.Errorf("stats = %+v, want 2 updated and 1 removed", stats)
+		}
+		if data, _ := os.ReadFile(filepath.Join(dir, "main.go")); string(data) != "package main // edited" {
+			t.Errorf("main.go = %q", data)
+		}
+		if info, err := os.Stat(filepath.Join(dir, "gen", "new.txt")); err != nil || info.Mode().Perm() != 0o600 {
+			t.Errorf("gen/new.txt = %v, %v", info, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "old.txt")); !os.IsNotExist(err) {
+			t.Error("old.txt should be removed")
+		}
+	})
+
+	t.Run("files that were never sent are kept", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"created-meanwhile.txt": "hi"})
+		if _, err := Unpack(asContainer(t, nil, "app", nil), dir, nil); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "created-meanwhile.txt")); err != nil {
+			t.Error("a file the container never had should be kept")
+		}
+	})
+
+	t.Run("files changed in the project meanwhile aren't overwritten", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"both.txt": "v1", "host.txt": "v1", "gone.txt": "v1"})
+		archive, files := pack(t, dir)
+
+		later := time.Now().Add(time.Hour)
+		edited := asContainer(t, archive, "app", func(hdr *tar.Header, content []byte) ([]byte, bool) {
+			switch hdr.Name {
+			case "gone.txt":
+				return nil, false
+			case "both.txt":
+				hdr.ModTime = later
+				return []byte("container"), true
+			}
+			return content, true
+		}, &tar.Header{Typeflag: tar.TypeReg, Name: "new.txt", Mode: 0o644, Size: 3, ModTime: later})
+		writeFiles(t, dir, map[string]string{"both.txt": "host edit", "host.txt": "host edit", "gone.txt": "host edit", "new.txt": "host"})
+
+		stats, err := Unpack(edited, dir, files)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"both.txt", "new.txt", "gone.txt"}; !slices.Equal(stats.Conflicts, want) {
+			t.Errorf("conflicts = %v, want %v", stats.Conflicts, want)
+		}
+		for _, name := range []string{"both.txt", "host.txt", "gone.txt"} {
+			if data, _ := os.ReadFile(filepath.Join(dir, name)); string(data) != "host edit" {
+				t.Errorf("%s = %q, want the project's edit kept", name, data)
+			}
+		}
+		if data, _ := os.ReadFile(filepath.Join(dir, "both.txt"+ConflictSuffix)); string(data) != "container" {
+			t.Errorf("conflict copy of both.txt = %q, want the container's version", data)
+		}
+		if data, _ := os.ReadFile(filepath.Join(dir, "new.txt")); string(data) != "host" {
+			t.Errorf("new.txt = %q, want the project's file kept", data)
+		}
+	})
+
+	t.Run("a file replaces a symlink instead of writing through it", func(t *testing.T) {
+		dir := t.TempDir()
+		target := filepath.Join(dir, "target.txt")
+		writeFiles(t, dir, map[string]string{"target.txt": "original"})
+		if err := os.Symlink("target.txt", filepath.Join(dir, "link.txt")); err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		_ = tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "app/link.txt", Mode: 0o644, Size: 5})
+		_, _ = tw.Write([]byte("plain"))
+		_ = tw.Close()
+
+		if _, err := Unpack(&buf, dir, Snapshot{"link.txt": {Link: "target.txt"}}); err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := os.ReadFile(target); string(data) != "original" {
+			t.Errorf("target.txt = %q, written through the link", data)
+		}
+		if info, _ := os.Lstat(filepath.Join(dir, "link.txt")); info == nil || !info.Mode().IsRegular() {
+			t.Error("link.txt should be a regular file")
+		}
+	})
+
+	t.Run("entries can't escape the workspace", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			entries []tar.Header
+		}{
+			{"dot dot", []tar.Header{{Typeflag: tar.TypeReg, Name: "app/../../outside.txt"}}},
+			{"through a symlink", []tar.Header{
+				{Typeflag: tar.TypeSymlink, Name: "app/escape", Linkname: "{outside}"},
+				{Typeflag: tar.TypeReg, Name: "app/escape/outside.txt"},
+			}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				parent := t.TempDir()
+				dir := filepath.Join(parent, "ws", "app")
+				outside := filepath.Join(parent, "outside")
+				for _, d := range []string{dir, outside} {
+					if err := os.MkdirAll(d, 0o755); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				var buf bytes.Buffer
+				tw := tar.NewWriter(&buf)
+				for _, hdr := range tt.entries {
+					hdr.Linkname = strings.ReplaceAll(hdr.Linkname, "{outside}", outside)
+					hdr.Mode = 0o644
+					_ = tw.WriteHeader(&hdr)
+				}
+				_ = tw.Close()
+
+				if _, err := Unpack(&buf, dir, nil); err == nil {
+					t.Error("Unpack() should fail")
+				}
+				for _, p := range []string{filepath.Join(parent, "outside.txt"), filepath.Join(parent, "ws", "outside.txt"), filepath.Join(outside, "outside.txt")} {
+					if _, err := os.Stat(p); err == nil {
+						t.Errorf("%s was written", p)
+					}
+				}
+			})
+		}
+	})
+}